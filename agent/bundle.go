@@ -0,0 +1,103 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"time"
+)
+
+// RunBundleVersion is the bundle format written by ExportRunBundle. Bundles
+// with any other version are rejected by ImportRunBundle.
+const RunBundleVersion = 1
+
+// RunBundle is a self-contained, portable snapshot of one run: its state, the
+// full event history in publish order, the tools it was offered and free-form
+// metadata describing the exporting environment.
+type RunBundle struct {
+	Version    int               `json:"version"`
+	ExportedAt time.Time         `json:"exported_at"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	State      RunState          `json:"state"`
+	Events     []Event           `json:"events"`
+	Tools      []ToolDefinition  `json:"tools,omitempty"`
+}
+
+// ExportRunBundle builds a validated bundle from deep copies of its inputs.
+// Every event must belong to state's run.
+func ExportRunBundle(state RunState, events []Event, tools []ToolDefinition, metadata map[string]string) (RunBundle, error) {
+	bundle := RunBundle{
+		Version:    RunBundleVersion,
+		ExportedAt: time.Now().UTC(),
+		Metadata:   maps.Clone(metadata),
+		State:      CloneRunState(state),
+		Events:     make([]Event, len(events)),
+		Tools:      CloneToolDefinitions(tools),
+	}
+	for i := range events {
+		bundle.Events[i] = CloneEvent(events[i])
+	}
+	if err := ValidateRunBundle(bundle); err != nil {
+		return RunBundle{}, err
+	}
+	return bundle, nil
+}
+
+// ImportRunBundle decodes a single JSON bundle from r and validates it.
+func ImportRunBundle(r io.Reader) (RunBundle, error) {
+	var bundle RunBundle
+	decoder := json.NewDecoder(r)
+	if err := decoder.Decode(&bundle); err != nil {
+		return RunBundle{}, fmt.Errorf("%w: reason=invalid_json: %w", ErrRunBundleInvalid, err)
+	}
+	if decoder.More() {
+		return RunBundle{}, fmt.Errorf("%w: reason=trailing_data", ErrRunBundleInvalid)
+	}
+	if err := ValidateRunBundle(bundle); err != nil {
+		return RunBundle{}, err
+	}
+	return bundle, nil
+}
+
+// ValidateRunBundle checks the bundle version, the run state with
+// ValidateRunState, every event with ValidateEvent, and that events and tool
+// definitions are consistent with the run.
+func ValidateRunBundle(bundle RunBundle) error {
+	if bundle.Version != RunBundleVersion {
+		return fmt.Errorf(
+			"%w: field=version reason=unsupported value=%d supported=%d",
+			ErrRunBundleInvalid,
+			bundle.Version,
+			RunBundleVersion,
+		)
+	}
+	if err := ValidateRunState(bundle.State); err != nil {
+		return fmt.Errorf("%w: field=state: %w", ErrRunBundleInvalid, err)
+	}
+	for i, event := range bundle.Events {
+		if err := ValidateEvent(event); err != nil {
+			return fmt.Errorf("%w: field=events[%d]: %w", ErrRunBundleInvalid, i, err)
+		}
+		if event.RunID != bundle.State.ID {
+			return fmt.Errorf(
+				"%w: field=events[%d].run_id reason=mismatch value=%q run_id=%q",
+				ErrRunBundleInvalid,
+				i,
+				event.RunID,
+				bundle.State.ID,
+			)
+		}
+	}
+	seen := make(map[string]struct{}, len(bundle.Tools))
+	for i, tool := range bundle.Tools {
+		if tool.Name == "" {
+			return fmt.Errorf("%w: field=tools[%d].name reason=empty", ErrRunBundleInvalid, i)
+		}
+		if _, exists := seen[tool.Name]; exists {
+			return fmt.Errorf("%w: field=tools[%d].name reason=duplicate value=%q", ErrRunBundleInvalid, i, tool.Name)
+		}
+		seen[tool.Name] = struct{}{}
+	}
+	return nil
+}
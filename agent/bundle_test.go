@@ -0,0 +1,153 @@
+package agent_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Gurpartap/agentframe/agent"
+)
+
+func bundleFixture() (agent.RunState, []agent.Event, []agent.ToolDefinition) {
+	state := agent.RunState{
+		ID:      "run-bundle-1",
+		Version: 4,
+		Step:    1,
+		Status:  agent.RunStatusCompleted,
+		Output:  "done",
+		Messages: []agent.Message{
+			{Role: agent.RoleUser, Content: "hello"},
+			{Role: agent.RoleAssistant, Content: "done"},
+		},
+	}
+	events := []agent.Event{
+		{RunID: state.ID, Type: agent.EventTypeRunStarted},
+		{RunID: state.ID, Step: 1, Type: agent.EventTypeAssistantMessage, Message: &agent.Message{Role: agent.RoleAssistant, Content: "done"}},
+		{RunID: state.ID, Step: 1, Type: agent.EventTypeRunCompleted},
+	}
+	tools := []agent.ToolDefinition{{Name: "read", InputSchema: map[string]any{"type": "object"}}}
+	return state, events, tools
+}
+
+func TestRunBundleRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	state, events, tools := bundleFixture()
+	exported, err := agent.ExportRunBundle(state, events, tools, map[string]string{"source": "test"})
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if exported.Version != agent.RunBundleVersion {
+		t.Fatalf("version mismatch: got=%d want=%d", exported.Version, agent.RunBundleVersion)
+	}
+	if exported.ExportedAt.IsZero() {
+		t.Fatalf("exported_at must be set")
+	}
+
+	events[1].Message.Content = "mutated"
+	tools[0].InputSchema["type"] = "mutated"
+	if exported.Events[1].Message.Content != "done" || exported.Tools[0].InputSchema["type"] != "object" {
+		t.Fatalf("export must deep copy its inputs")
+	}
+
+	payload, err := json.Marshal(exported)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	imported, err := agent.ImportRunBundle(bytes.NewReader(payload))
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if imported.State.ID != state.ID || imported.State.Output != "done" || len(imported.State.Messages) != 2 {
+		t.Fatalf("state mismatch: got=%+v", imported.State)
+	}
+	if len(imported.Events) != 3 || imported.Events[2].Type != agent.EventTypeRunCompleted {
+		t.Fatalf("events mismatch: got=%+v", imported.Events)
+	}
+	if len(imported.Tools) != 1 || imported.Tools[0].Name != "read" {
+		t.Fatalf("tools mismatch: got=%+v", imported.Tools)
+	}
+	if imported.Metadata["source"] != "test" {
+		t.Fatalf("metadata mismatch: got=%v", imported.Metadata)
+	}
+}
+
+func TestExportRunBundleRejectsForeignEvents(t *testing.T) {
+	t.Parallel()
+
+	state, events, tools := bundleFixture()
+	events[2].RunID = "run-other"
+	_, err := agent.ExportRunBundle(state, events, tools, nil)
+	if !errors.Is(err, agent.ErrRunBundleInvalid) {
+		t.Fatalf("expected ErrRunBundleInvalid, got %v", err)
+	}
+}
+
+func TestImportRunBundleRejectsInvalidBundles(t *testing.T) {
+	t.Parallel()
+
+	state, events, tools := bundleFixture()
+	valid, err := agent.ExportRunBundle(state, events, tools, nil)
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+
+	testCases := []struct {
+		name      string
+		mutate    func(*agent.RunBundle)
+		wantCause error
+	}{
+		{
+			name:   "unsupported version",
+			mutate: func(b *agent.RunBundle) { b.Version = agent.RunBundleVersion + 1 },
+		},
+		{
+			name:      "invalid state",
+			mutate:    func(b *agent.RunBundle) { b.State.Status = "bogus" },
+			wantCause: agent.ErrRunStateInvalid,
+		},
+		{
+			name:      "invalid event",
+			mutate:    func(b *agent.RunBundle) { b.Events[1].Message = nil },
+			wantCause: agent.ErrEventInvalid,
+		},
+		{
+			name:   "duplicate tool",
+			mutate: func(b *agent.RunBundle) { b.Tools = append(b.Tools, agent.ToolDefinition{Name: "read"}) },
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			payload, err := json.Marshal(valid)
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			var bundle agent.RunBundle
+			if err := json.Unmarshal(payload, &bundle); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			tc.mutate(&bundle)
+			payload, err = json.Marshal(bundle)
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+
+			_, err = agent.ImportRunBundle(bytes.NewReader(payload))
+			if !errors.Is(err, agent.ErrRunBundleInvalid) {
+				t.Fatalf("expected ErrRunBundleInvalid, got %v", err)
+			}
+			if tc.wantCause != nil && !errors.Is(err, tc.wantCause) {
+				t.Fatalf("cause mismatch: got=%v want=%v", err, tc.wantCause)
+			}
+		})
+	}
+
+	if _, err := agent.ImportRunBundle(strings.NewReader("{")); !errors.Is(err, agent.ErrRunBundleInvalid) {
+		t.Fatalf("expected ErrRunBundleInvalid for malformed JSON, got %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package agent_test
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/Gurpartap/agentframe/agent"
@@ -253,6 +254,69 @@ func TestCloneRunState_CopiesPendingRequirementOrigin(t *testing.T) {
 	}
 }
 
+func TestCloneRunState_CopiesEngineState(t *testing.T) {
+	t.Parallel()
+
+	original := agent.RunState{
+		ID:          "run-clone-engine-state",
+		Status:      agent.RunStatusRunning,
+		EngineState: json.RawMessage(`{"node":"plan"}`),
+	}
+
+	cloned := agent.CloneRunState(original)
+	cloned.EngineState[2] = 'X'
+	if string(original.EngineState) != `{"node":"plan"}` {
+		t.Fatalf("clone mutation leaked into original engine state: %s", original.EngineState)
+	}
+}
+
+func TestCloneMessage_DeepCopiesContentParts(t *testing.T) {
+	t.Parallel()
+
+	original := agent.Message{
+		Role: agent.RoleUser,
+		Parts: []agent.ContentPart{
+			agent.ImagePart("image/png", []byte{1, 2, 3}),
+			agent.JSONPart(json.RawMessage(`{"a":1}`)),
+		},
+	}
+
+	cloned := agent.CloneMessage(original)
+	cloned.Parts[0].Data[0] = 9
+	cloned.Parts[1].JSON[2] = 'X'
+	cloned.Parts[0].MIMEType = "image/jpeg"
+	if original.Parts[0].Data[0] != 1 || original.Parts[0].MIMEType != "image/png" {
+		t.Fatalf("clone mutation leaked into original image part: %+v", original.Parts[0])
+	}
+	if string(original.Parts[1].JSON) != `{"a":1}` {
+		t.Fatalf("clone mutation leaked into original json part: %s", original.Parts[1].JSON)
+	}
+
+	result := agent.ToolResult{CallID: "call-1", Name: "screenshot", Parts: original.Parts}
+	message := agent.ToolResultMessage(result)
+	message.Parts[0].Data[1] = 9
+	if original.Parts[0].Data[1] != 2 {
+		t.Fatalf("tool result message shares part data with the result")
+	}
+}
+
+func TestToolResultMessage_AppendsStructuredPayload(t *testing.T) {
+	t.Parallel()
+
+	message := agent.ToolResultMessage(agent.ToolResult{
+		CallID:     "call-1",
+		Name:       "lookup",
+		Content:    "2 matches",
+		Structured: json.RawMessage(`{"matches":2}`),
+	})
+	if len(message.Parts) != 1 || message.Parts[0].Type != agent.ContentPartTypeJSON {
+		t.Fatalf("structured payload must be appended as a json part: got=%+v", message.Parts)
+	}
+	if string(message.Parts[0].JSON) != `{"matches":2}` {
+		t.Fatalf("json part mismatch: got=%s", message.Parts[0].JSON)
+	}
+}
+
 func mustMap(t *testing.T, value any) map[string]any {
 	t.Helper()
 
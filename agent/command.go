@@ -32,6 +32,7 @@ type ContinueCommand struct {
 	MaxSteps   int
 	Tools      []ToolDefinition
 	Resolution *Resolution
+	ToolChoice ToolChoice
 }
 
 func (ContinueCommand) Kind() CommandKind {
@@ -63,6 +64,7 @@ type FollowUpCommand struct {
 	UserPrompt string
 	MaxSteps   int
 	Tools      []ToolDefinition
+	ToolChoice ToolChoice
 }
 
 func (FollowUpCommand) Kind() CommandKind {
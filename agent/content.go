@@ -0,0 +1,127 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// ContentPartType identifies the payload carried by a ContentPart.
+type ContentPartType string
+
+const (
+	ContentPartTypeText  ContentPartType = "text"
+	ContentPartTypeImage ContentPartType = "image"
+	ContentPartTypeFile  ContentPartType = "file"
+	ContentPartTypeJSON  ContentPartType = "json"
+)
+
+// ContentPart is one block of multi-part message or tool result content.
+//
+// Text parts carry Text. Image parts carry MIMEType and exactly one of Data or
+// URI. File parts reference a file by URI with optional Name and MIMEType. JSON
+// parts carry a structured JSON value.
+type ContentPart struct {
+	Type     ContentPartType `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	MIMEType string          `json:"mime_type,omitempty"`
+	Data     []byte          `json:"data,omitempty"`
+	URI      string          `json:"uri,omitempty"`
+	Name     string          `json:"name,omitempty"`
+	JSON     json.RawMessage `json:"json,omitempty"`
+}
+
+// TextPart returns a text content part.
+func TextPart(text string) ContentPart {
+	return ContentPart{Type: ContentPartTypeText, Text: text}
+}
+
+// ImagePart returns an inline image content part.
+func ImagePart(mimeType string, data []byte) ContentPart {
+	return ContentPart{Type: ContentPartTypeImage, MIMEType: mimeType, Data: data}
+}
+
+// FilePart returns a file reference content part.
+func FilePart(name, uri, mimeType string) ContentPart {
+	return ContentPart{Type: ContentPartTypeFile, Name: name, URI: uri, MIMEType: mimeType}
+}
+
+// JSONPart returns a structured JSON content part.
+func JSONPart(value json.RawMessage) ContentPart {
+	return ContentPart{Type: ContentPartTypeJSON, JSON: value}
+}
+
+// CloneContentParts returns deep copies of all content parts.
+func CloneContentParts(in []ContentPart) []ContentPart {
+	if in == nil {
+		return nil
+	}
+	out := make([]ContentPart, len(in))
+	for i := range in {
+		out[i] = in[i]
+		if in[i].Data != nil {
+			out[i].Data = bytes.Clone(in[i].Data)
+		}
+		if in[i].JSON != nil {
+			out[i].JSON = bytes.Clone(in[i].JSON)
+		}
+	}
+	return out
+}
+
+// contentPartsViolation reports the first invalid part as a field path relative
+// to the parts list and a machine-readable reason. It returns empty strings when
+// all parts are valid.
+func contentPartsViolation(parts []ContentPart) (field string, reason string) {
+	for i, part := range parts {
+		if field, reason := contentPartViolation(part); field != "" {
+			return "parts[" + strconv.Itoa(i) + "]" + field, reason
+		}
+	}
+	return "", ""
+}
+
+func contentPartViolation(part ContentPart) (field string, reason string) {
+	switch part.Type {
+	case ContentPartTypeText:
+		if part.Text == "" {
+			return ".text", "empty"
+		}
+		if len(part.Data) > 0 || part.URI != "" || len(part.JSON) > 0 {
+			return ".type", "unexpected_payload"
+		}
+	case ContentPartTypeImage:
+		if !strings.HasPrefix(part.MIMEType, "image/") {
+			return ".mime_type", "not_image"
+		}
+		if (len(part.Data) > 0) == (part.URI != "") {
+			return ".data", "requires_data_or_uri"
+		}
+		if part.Text != "" || len(part.JSON) > 0 {
+			return ".type", "unexpected_payload"
+		}
+	case ContentPartTypeFile:
+		if part.URI == "" {
+			return ".uri", "empty"
+		}
+		if len(part.Data) > 0 || part.Text != "" || len(part.JSON) > 0 {
+			return ".type", "unexpected_payload"
+		}
+	case ContentPartTypeJSON:
+		if len(part.JSON) == 0 {
+			return ".json", "empty"
+		}
+		if !json.Valid(part.JSON) {
+			return ".json", "invalid_json"
+		}
+		if len(part.Data) > 0 || part.Text != "" || part.URI != "" {
+			return ".type", "unexpected_payload"
+		}
+	case "":
+		return ".type", "empty"
+	default:
+		return ".type", "unknown"
+	}
+	return "", ""
+}
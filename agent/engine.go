@@ -13,4 +13,8 @@ type EngineInput struct {
 	Tools               []ToolDefinition
 	Resolution          *Resolution
 	ResolvedRequirement *PendingRequirement
+	// OutputSchema constrains the final answer when set; see RunInput.OutputSchema.
+	OutputSchema map[string]any
+	// ToolChoice constrains tool use for model calls in this slice.
+	ToolChoice ToolChoice
 }
@@ -39,10 +39,14 @@ var (
 	ErrEventPublish = errors.New("event publish failed")
 	// ErrEventInvalid is returned when an event payload violates required runtime contracts.
 	ErrEventInvalid = errors.New("event is invalid")
+	// ErrRunBundleInvalid is returned when a run export bundle has an unsupported version or invalid contents.
+	ErrRunBundleInvalid = errors.New("run bundle is invalid")
 	// ErrEngineOutputContractViolation is returned when engine output violates runtime state invariants.
 	ErrEngineOutputContractViolation = errors.New("engine output contract violation")
 	// ErrToolDefinitionsInvalid is returned when command tool definitions violate runtime input constraints.
 	ErrToolDefinitionsInvalid = errors.New("tool definitions are invalid")
+	// ErrToolChoiceInvalid is returned when a command tool choice is unknown or names an undefined tool.
+	ErrToolChoiceInvalid = errors.New("tool choice is invalid")
 	// ErrMissingIDGenerator is returned when NewRunner is called without an ID generator dependency.
 	ErrMissingIDGenerator = errors.New("missing id generator")
 	// ErrMissingRunStore is returned when NewRunner is called without a run store dependency.
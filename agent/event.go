@@ -1,11 +1,14 @@
 package agent
 
+import "slices"
+
 // EventType is emitted by the runtime and loop for observability and streaming.
 type EventType string
 
 const (
 	EventTypeCommandApplied   EventType = "command_applied"
 	EventTypeRunStarted       EventType = "run_started"
+	EventTypeUserMessage      EventType = "user_message"
 	EventTypeAssistantMessage EventType = "assistant_message"
 	EventTypeToolResult       EventType = "tool_result"
 	EventTypeRunCompleted     EventType = "run_completed"
@@ -13,6 +16,7 @@ const (
 	EventTypeRunSuspended     EventType = "run_suspended"
 	EventTypeRunCancelled     EventType = "run_cancelled"
 	EventTypeRunCheckpoint    EventType = "run_checkpoint"
+	EventTypePlanUpdated      EventType = "plan_updated"
 )
 
 // Event is intentionally compact so adapters can map it to logs, metrics, or streams.
@@ -24,4 +28,27 @@ type Event struct {
 	Message     *Message    `json:"message,omitempty"`
 	ToolResult  *ToolResult `json:"tool_result,omitempty"`
 	Description string      `json:"description,omitempty"`
+	Plan        *Plan       `json:"plan,omitempty"`
+	// SelectedTools lists the tool names offered to the model for an
+	// assistant_message step when the engine narrows tools per step.
+	SelectedTools []string `json:"selected_tools,omitempty"`
+	// ToolsSelected reports that the engine narrowed tools for the step, so
+	// an empty SelectedTools means no tools were offered rather than all.
+	ToolsSelected bool `json:"tools_selected,omitempty"`
+}
+
+// CloneEvent returns a deep copy suitable for isolation across component boundaries.
+func CloneEvent(in Event) Event {
+	out := in
+	if in.Message != nil {
+		message := CloneMessage(*in.Message)
+		out.Message = &message
+	}
+	if in.ToolResult != nil {
+		result := CloneToolResult(*in.ToolResult)
+		out.ToolResult = &result
+	}
+	out.Plan = ClonePlan(in.Plan)
+	out.SelectedTools = slices.Clone(in.SelectedTools)
+	return out
 }
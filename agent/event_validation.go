@@ -1,6 +1,9 @@
 package agent
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+)
 
 // ValidateEvent checks event payload invariants before publish boundaries.
 func ValidateEvent(event Event) error {
@@ -30,6 +33,49 @@ func ValidateEvent(event Event) error {
 		)
 	}
 
+	if event.Plan != nil && event.Type != EventTypePlanUpdated {
+		return fmt.Errorf(
+			"%w: field=plan reason=forbidden type=%s run_id=%q step=%d",
+			ErrEventInvalid,
+			event.Type,
+			event.RunID,
+			event.Step,
+		)
+	}
+
+	if len(event.SelectedTools) > 0 || event.ToolsSelected {
+		if event.Type != EventTypeAssistantMessage {
+			return fmt.Errorf(
+				"%w: field=selected_tools reason=forbidden type=%s run_id=%q step=%d",
+				ErrEventInvalid,
+				event.Type,
+				event.RunID,
+				event.Step,
+			)
+		}
+		if !event.ToolsSelected {
+			return fmt.Errorf(
+				"%w: field=tools_selected reason=required_with_selected_tools type=%s run_id=%q step=%d",
+				ErrEventInvalid,
+				event.Type,
+				event.RunID,
+				event.Step,
+			)
+		}
+		for i, name := range event.SelectedTools {
+			if name == "" {
+				return fmt.Errorf(
+					"%w: field=selected_tools[%d] reason=empty type=%s run_id=%q step=%d",
+					ErrEventInvalid,
+					i,
+					event.Type,
+					event.RunID,
+					event.Step,
+				)
+			}
+		}
+	}
+
 	switch event.Type {
 	case EventTypeCommandApplied:
 		if event.CommandKind == "" {
@@ -59,7 +105,7 @@ func ValidateEvent(event Event) error {
 				event.Step,
 			)
 		}
-	case EventTypeAssistantMessage:
+	case EventTypeUserMessage, EventTypeAssistantMessage:
 		if event.Message == nil {
 			return fmt.Errorf(
 				"%w: field=message reason=nil type=%s run_id=%q step=%d",
@@ -88,6 +134,9 @@ func ValidateEvent(event Event) error {
 				event.Step,
 			)
 		}
+		if err := validateEventContentParts(event, "message", event.Message.Parts); err != nil {
+			return err
+		}
 	case EventTypeToolResult:
 		if event.CommandKind != "" {
 			return fmt.Errorf(
@@ -135,6 +184,50 @@ func ValidateEvent(event Event) error {
 				event.Step,
 			)
 		}
+		if len(event.ToolResult.Structured) > 0 && !json.Valid(event.ToolResult.Structured) {
+			return fmt.Errorf(
+				"%w: field=tool_result.structured reason=invalid_json type=%s run_id=%q step=%d",
+				ErrEventInvalid,
+				event.Type,
+				event.RunID,
+				event.Step,
+			)
+		}
+		if err := validateEventContentParts(event, "tool_result", event.ToolResult.Parts); err != nil {
+			return err
+		}
+	case EventTypePlanUpdated:
+		if event.CommandKind != "" {
+			return fmt.Errorf(
+				"%w: field=command_kind reason=forbidden value=%q type=%s run_id=%q step=%d",
+				ErrEventInvalid,
+				event.CommandKind,
+				event.Type,
+				event.RunID,
+				event.Step,
+			)
+		}
+		if event.Message != nil {
+			return fmt.Errorf(
+				"%w: field=message reason=forbidden type=%s run_id=%q step=%d",
+				ErrEventInvalid,
+				event.Type,
+				event.RunID,
+				event.Step,
+			)
+		}
+		if event.ToolResult != nil {
+			return fmt.Errorf(
+				"%w: field=tool_result reason=forbidden type=%s run_id=%q step=%d",
+				ErrEventInvalid,
+				event.Type,
+				event.RunID,
+				event.Step,
+			)
+		}
+		if err := validateEventPlan(event); err != nil {
+			return err
+		}
 	case EventTypeRunStarted,
 		EventTypeRunCompleted,
 		EventTypeRunFailed,
@@ -178,15 +271,79 @@ func isKnownEventType(eventType EventType) bool {
 	switch eventType {
 	case EventTypeCommandApplied,
 		EventTypeRunStarted,
+		EventTypeUserMessage,
 		EventTypeAssistantMessage,
 		EventTypeToolResult,
 		EventTypeRunCompleted,
 		EventTypeRunFailed,
 		EventTypeRunSuspended,
 		EventTypeRunCancelled,
-		EventTypeRunCheckpoint:
+		EventTypeRunCheckpoint,
+		EventTypePlanUpdated:
 		return true
 	default:
 		return false
 	}
 }
+
+func validateEventPlan(event Event) error {
+	if event.Plan == nil {
+		return fmt.Errorf(
+			"%w: field=plan reason=nil type=%s run_id=%q step=%d",
+			ErrEventInvalid,
+			event.Type,
+			event.RunID,
+			event.Step,
+		)
+	}
+	if len(event.Plan.Steps) == 0 {
+		return fmt.Errorf(
+			"%w: field=plan.steps reason=empty type=%s run_id=%q step=%d",
+			ErrEventInvalid,
+			event.Type,
+			event.RunID,
+			event.Step,
+		)
+	}
+	for i, step := range event.Plan.Steps {
+		if step.Title == "" {
+			return fmt.Errorf(
+				"%w: field=plan.steps[%d].title reason=empty type=%s run_id=%q step=%d",
+				ErrEventInvalid,
+				i,
+				event.Type,
+				event.RunID,
+				event.Step,
+			)
+		}
+		if !isKnownPlanStepStatus(step.Status) {
+			return fmt.Errorf(
+				"%w: field=plan.steps[%d].status reason=unknown value=%q type=%s run_id=%q step=%d",
+				ErrEventInvalid,
+				i,
+				step.Status,
+				event.Type,
+				event.RunID,
+				event.Step,
+			)
+		}
+	}
+	return nil
+}
+
+func validateEventContentParts(event Event, field string, parts []ContentPart) error {
+	partField, reason := contentPartsViolation(parts)
+	if partField == "" {
+		return nil
+	}
+	return fmt.Errorf(
+		"%w: field=%s.%s reason=%s type=%s run_id=%q step=%d",
+		ErrEventInvalid,
+		field,
+		partField,
+		reason,
+		event.Type,
+		event.RunID,
+		event.Step,
+	)
+}
@@ -51,6 +51,27 @@ func TestValidateEventMatrix(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "valid user message",
+			event: Event{
+				RunID: "run-1",
+				Step:  1,
+				Type:  EventTypeUserMessage,
+				Message: &Message{
+					Role:    RoleUser,
+					Content: "answer again",
+				},
+			},
+		},
+		{
+			name: "user message requires message",
+			event: Event{
+				RunID: "run-1",
+				Step:  1,
+				Type:  EventTypeUserMessage,
+			},
+			wantErr: "event is invalid: field=message reason=nil type=user_message run_id=\"run-1\" step=1",
+		},
 		{
 			name: "valid tool result",
 			event: Event{
@@ -277,6 +298,167 @@ func TestValidateEventMatrix(t *testing.T) {
 			},
 			wantErr: "event is invalid: field=tool_result reason=forbidden type=run_checkpoint run_id=\"run-1\" step=3",
 		},
+		{
+			name: "valid plan updated",
+			event: Event{
+				RunID: "run-1",
+				Step:  1,
+				Type:  EventTypePlanUpdated,
+				Plan: &Plan{
+					Steps: []PlanStep{{Title: "write tests", Status: PlanStepStatusPending}},
+				},
+			},
+		},
+		{
+			name: "plan updated requires plan",
+			event: Event{
+				RunID: "run-1",
+				Step:  1,
+				Type:  EventTypePlanUpdated,
+			},
+			wantErr: "event is invalid: field=plan reason=nil type=plan_updated run_id=\"run-1\" step=1",
+		},
+		{
+			name: "plan updated rejects unknown step status",
+			event: Event{
+				RunID: "run-1",
+				Step:  1,
+				Type:  EventTypePlanUpdated,
+				Plan: &Plan{
+					Steps: []PlanStep{{Title: "write tests", Status: "done"}},
+				},
+			},
+			wantErr: "event is invalid: field=plan.steps[0].status reason=unknown value=\"done\" type=plan_updated run_id=\"run-1\" step=1",
+		},
+		{
+			name: "run checkpoint forbids plan",
+			event: Event{
+				RunID: "run-1",
+				Step:  1,
+				Type:  EventTypeRunCheckpoint,
+				Plan: &Plan{
+					Steps: []PlanStep{{Title: "write tests", Status: PlanStepStatusPending}},
+				},
+			},
+			wantErr: "event is invalid: field=plan reason=forbidden type=run_checkpoint run_id=\"run-1\" step=1",
+		},
+		{
+			name: "tool result forbids selected tools",
+			event: Event{
+				RunID:         "run-1",
+				Step:          1,
+				Type:          EventTypeToolResult,
+				ToolResult:    &ToolResult{CallID: "call-1", Name: "read"},
+				SelectedTools: []string{"read"},
+			},
+			wantErr: "event is invalid: field=selected_tools reason=forbidden type=tool_result run_id=\"run-1\" step=1",
+		},
+		{
+			name: "assistant message rejects empty selected tool name",
+			event: Event{
+				RunID:         "run-1",
+				Step:          1,
+				Type:          EventTypeAssistantMessage,
+				Message:       &Message{Role: RoleAssistant, Content: "hi"},
+				SelectedTools: []string{"read", ""},
+				ToolsSelected: true,
+			},
+			wantErr: "event is invalid: field=selected_tools[1] reason=empty type=assistant_message run_id=\"run-1\" step=1",
+		},
+		{
+			name: "assistant message requires tools selected flag with selected tools",
+			event: Event{
+				RunID:         "run-1",
+				Step:          1,
+				Type:          EventTypeAssistantMessage,
+				Message:       &Message{Role: RoleAssistant, Content: "hi"},
+				SelectedTools: []string{"read"},
+			},
+			wantErr: "event is invalid: field=tools_selected reason=required_with_selected_tools type=assistant_message run_id=\"run-1\" step=1",
+		},
+		{
+			name: "tool result forbids tools selected flag",
+			event: Event{
+				RunID:         "run-1",
+				Step:          1,
+				Type:          EventTypeToolResult,
+				ToolResult:    &ToolResult{CallID: "call-1", Name: "read"},
+				ToolsSelected: true,
+			},
+			wantErr: "event is invalid: field=selected_tools reason=forbidden type=tool_result run_id=\"run-1\" step=1",
+		},
+		{
+			name: "valid multi-part assistant message",
+			event: Event{
+				RunID: "run-1",
+				Step:  1,
+				Type:  EventTypeAssistantMessage,
+				Message: &Message{
+					Role: RoleAssistant,
+					Parts: []ContentPart{
+						TextPart("see attached"),
+						ImagePart("image/png", []byte{0x89, 0x50}),
+						FilePart("report.pdf", "file:///tmp/report.pdf", "application/pdf"),
+						JSONPart([]byte(`{"ok":true}`)),
+					},
+				},
+			},
+		},
+		{
+			name: "assistant message rejects image without mime type",
+			event: Event{
+				RunID: "run-1",
+				Step:  1,
+				Type:  EventTypeAssistantMessage,
+				Message: &Message{
+					Role:  RoleAssistant,
+					Parts: []ContentPart{{Type: ContentPartTypeImage, Data: []byte{1}}},
+				},
+			},
+			wantErr: "event is invalid: field=message.parts[0].mime_type reason=not_image type=assistant_message run_id=\"run-1\" step=1",
+		},
+		{
+			name: "tool result rejects invalid json part",
+			event: Event{
+				RunID: "run-1",
+				Step:  1,
+				Type:  EventTypeToolResult,
+				ToolResult: &ToolResult{
+					CallID: "call-1",
+					Name:   "lookup",
+					Parts:  []ContentPart{TextPart("ok"), JSONPart([]byte(`{"ok":`))},
+				},
+			},
+			wantErr: "event is invalid: field=tool_result.parts[1].json reason=invalid_json type=tool_result run_id=\"run-1\" step=1",
+		},
+		{
+			name: "tool result rejects invalid structured payload",
+			event: Event{
+				RunID: "run-1",
+				Step:  1,
+				Type:  EventTypeToolResult,
+				ToolResult: &ToolResult{
+					CallID:     "call-1",
+					Name:       "lookup",
+					Structured: []byte(`{"matches":`),
+				},
+			},
+			wantErr: "event is invalid: field=tool_result.structured reason=invalid_json type=tool_result run_id=\"run-1\" step=1",
+		},
+		{
+			name: "tool result rejects unknown part type",
+			event: Event{
+				RunID: "run-1",
+				Step:  1,
+				Type:  EventTypeToolResult,
+				ToolResult: &ToolResult{
+					CallID: "call-1",
+					Name:   "lookup",
+					Parts:  []ContentPart{{Type: "audio"}},
+				},
+			},
+			wantErr: "event is invalid: field=tool_result.parts[0].type reason=unknown type=tool_result run_id=\"run-1\" step=1",
+		},
 		{
 			name: "run suspended forbids command kind",
 			event: Event{
@@ -11,9 +11,13 @@ const (
 )
 
 // Message is the shared transport object passed between runtime, model, and tools.
+//
+// Content holds plain text. Parts, when present, carries multi-part content;
+// a non-empty Content is then treated as a leading text part.
 type Message struct {
 	Role        Role                `json:"role"`
 	Content     string              `json:"content,omitempty"`
+	Parts       []ContentPart       `json:"parts,omitempty"`
 	Name        string              `json:"name,omitempty"`
 	ToolCallID  string              `json:"tool_call_id,omitempty"`
 	ToolCalls   []ToolCall          `json:"tool_calls,omitempty"`
@@ -23,6 +27,7 @@ type Message struct {
 // CloneMessage returns a deep copy suitable for isolation across component boundaries.
 func CloneMessage(in Message) Message {
 	out := in
+	out.Parts = CloneContentParts(in.Parts)
 	if len(in.ToolCalls) > 0 {
 		out.ToolCalls = make([]ToolCall, len(in.ToolCalls))
 		for i := range in.ToolCalls {
@@ -0,0 +1,49 @@
+package agent
+
+// PlanStepStatus tracks the progress of one step in an engine-managed plan.
+type PlanStepStatus string
+
+const (
+	PlanStepStatusPending    PlanStepStatus = "pending"
+	PlanStepStatusInProgress PlanStepStatus = "in_progress"
+	PlanStepStatusCompleted  PlanStepStatus = "completed"
+	PlanStepStatusFailed     PlanStepStatus = "failed"
+)
+
+// PlanStep is one checklist item of a plan.
+type PlanStep struct {
+	Title  string         `json:"title"`
+	Status PlanStepStatus `json:"status"`
+}
+
+// Plan is the checklist an engine works through. Revision increases every time
+// the plan is replaced after a failure.
+type Plan struct {
+	Revision int        `json:"revision"`
+	Steps    []PlanStep `json:"steps"`
+}
+
+// ClonePlan returns a deep copy suitable for isolation across component boundaries.
+func ClonePlan(in *Plan) *Plan {
+	if in == nil {
+		return nil
+	}
+	out := *in
+	if in.Steps != nil {
+		out.Steps = make([]PlanStep, len(in.Steps))
+		copy(out.Steps, in.Steps)
+	}
+	return &out
+}
+
+func isKnownPlanStepStatus(status PlanStepStatus) bool {
+	switch status {
+	case PlanStepStatusPending,
+		PlanStepStatusInProgress,
+		PlanStepStatusCompleted,
+		PlanStepStatusFailed:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,145 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Redactor replaces secrets in text with placeholder tokens before the text is
+// persisted, published or sent to a model. Implementations must be safe for
+// concurrent use and leave already redacted text unchanged.
+type Redactor interface {
+	Redact(text string) string
+}
+
+// RedactorFunc adapts a function to Redactor.
+type RedactorFunc func(text string) string
+
+func (f RedactorFunc) Redact(text string) string {
+	return f(text)
+}
+
+// RedactToolResult returns a copy of result with its content, text and JSON
+// parts and structured payload redacted. JSON values keep their shape; only
+// string values inside them are rewritten.
+func RedactToolResult(redactor Redactor, result ToolResult) ToolResult {
+	out := CloneToolResult(result)
+	if redactor == nil {
+		return out
+	}
+	out.Content = redactor.Redact(out.Content)
+	out.Parts = redactContentParts(redactor, out.Parts)
+	if len(out.Structured) > 0 {
+		out.Structured = redactRawJSON(redactor, out.Structured)
+	}
+	return out
+}
+
+// RedactMessage returns a copy of message with its content, parts, tool call
+// arguments and requirement prompt redacted.
+func RedactMessage(redactor Redactor, message Message) Message {
+	out := CloneMessage(message)
+	if redactor == nil {
+		return out
+	}
+	out.Content = redactor.Redact(out.Content)
+	out.Parts = redactContentParts(redactor, out.Parts)
+	for i := range out.ToolCalls {
+		for key, value := range out.ToolCalls[i].Arguments {
+			out.ToolCalls[i].Arguments[key] = redactJSONValue(redactor, value)
+		}
+	}
+	if out.Requirement != nil {
+		out.Requirement.Prompt = redactor.Redact(out.Requirement.Prompt)
+	}
+	return out
+}
+
+// RedactEvent returns a copy of event with every free-text field redacted:
+// the message, tool result, description and plan step titles.
+func RedactEvent(redactor Redactor, event Event) Event {
+	out := CloneEvent(event)
+	if redactor == nil {
+		return out
+	}
+	if out.Message != nil {
+		message := RedactMessage(redactor, *out.Message)
+		out.Message = &message
+	}
+	if out.ToolResult != nil {
+		result := RedactToolResult(redactor, *out.ToolResult)
+		out.ToolResult = &result
+	}
+	out.Description = redactor.Redact(out.Description)
+	if out.Plan != nil {
+		for i := range out.Plan.Steps {
+			out.Plan.Steps[i].Title = redactor.Redact(out.Plan.Steps[i].Title)
+		}
+	}
+	return out
+}
+
+func redactContentParts(redactor Redactor, parts []ContentPart) []ContentPart {
+	for i := range parts {
+		switch parts[i].Type {
+		case ContentPartTypeText:
+			parts[i].Text = redactor.Redact(parts[i].Text)
+		case ContentPartTypeJSON:
+			if len(parts[i].JSON) > 0 {
+				parts[i].JSON = redactRawJSON(redactor, parts[i].JSON)
+			}
+		}
+	}
+	return parts
+}
+
+// redactRawJSON redacts string values inside raw, returning raw untouched
+// when none changed. Input that is not valid JSON is redacted as plain text.
+func redactRawJSON(redactor Redactor, raw json.RawMessage) json.RawMessage {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return json.RawMessage(redactor.Redact(string(raw)))
+	}
+	changed := false
+	value = redactJSONStrings(redactor, value, &changed)
+	if !changed {
+		return raw
+	}
+	var encoded bytes.Buffer
+	encoder := json.NewEncoder(&encoded)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return json.RawMessage(redactor.Redact(string(raw)))
+	}
+	return bytes.TrimSuffix(encoded.Bytes(), []byte("\n"))
+}
+
+func redactJSONValue(redactor Redactor, value any) any {
+	var changed bool
+	return redactJSONStrings(redactor, value, &changed)
+}
+
+func redactJSONStrings(redactor Redactor, value any, changed *bool) any {
+	switch typed := value.(type) {
+	case string:
+		redacted := redactor.Redact(typed)
+		if redacted != typed {
+			*changed = true
+		}
+		return redacted
+	case map[string]any:
+		for key, nested := range typed {
+			typed[key] = redactJSONStrings(redactor, nested, changed)
+		}
+		return typed
+	case []any:
+		for i := range typed {
+			typed[i] = redactJSONStrings(redactor, typed[i], changed)
+		}
+		return typed
+	default:
+		return value
+	}
+}
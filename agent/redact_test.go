@@ -0,0 +1,50 @@
+package agent_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Gurpartap/agentframe/agent"
+)
+
+func TestRedactEventCopiesAndRedactsEveryTextField(t *testing.T) {
+	t.Parallel()
+
+	redactor := agent.RedactorFunc(func(text string) string {
+		return strings.ReplaceAll(text, "s3cr3t", "[REDACTED]")
+	})
+	event := agent.Event{
+		RunID:       "run-1",
+		Step:        1,
+		Type:        agent.EventTypeToolResult,
+		Description: "failed with s3cr3t",
+		ToolResult: &agent.ToolResult{
+			CallID:     "call-1",
+			Name:       "read",
+			Content:    "value=s3cr3t",
+			Parts:      []agent.ContentPart{agent.TextPart("s3cr3t"), agent.JSONPart(json.RawMessage(`{"k":["s3cr3t",1]}`))},
+			Structured: json.RawMessage(`{"nested":{"v":"s3cr3t"},"n":1.50}`),
+		},
+	}
+
+	got := agent.RedactEvent(redactor, event)
+	if got.Description != "failed with [REDACTED]" || got.ToolResult.Content != "value=[REDACTED]" {
+		t.Fatalf("text redaction mismatch: description=%q content=%q", got.Description, got.ToolResult.Content)
+	}
+	if got.ToolResult.Parts[0].Text != "[REDACTED]" || string(got.ToolResult.Parts[1].JSON) != `{"k":["[REDACTED]",1]}` {
+		t.Fatalf("parts redaction mismatch: got=%+v", got.ToolResult.Parts)
+	}
+	if string(got.ToolResult.Structured) != `{"n":1.50,"nested":{"v":"[REDACTED]"}}` {
+		t.Fatalf("structured redaction mismatch: got=%s", got.ToolResult.Structured)
+	}
+	if event.ToolResult.Content != "value=s3cr3t" || event.ToolResult.Parts[0].Text != "s3cr3t" {
+		t.Fatalf("redaction must not mutate its input: %+v", event.ToolResult)
+	}
+
+	unchanged := json.RawMessage(`{ "clean": true }`)
+	result := agent.RedactToolResult(redactor, agent.ToolResult{CallID: "c", Name: "n", Structured: unchanged})
+	if string(result.Structured) != string(unchanged) {
+		t.Fatalf("clean JSON must keep its formatting: got=%s want=%s", result.Structured, unchanged)
+	}
+}
@@ -1,5 +1,7 @@
 package agent
 
+import "encoding/json"
+
 // RunID is the stable identifier for a runtime execution.
 type RunID string
 
@@ -68,6 +70,12 @@ type RunInput struct {
 	UserPrompt   string
 	MaxSteps     int
 	Tools        []ToolDefinition
+	// OutputSchema, when set, constrains the final answer to a JSON object
+	// matching the schema. It is persisted on RunState for later commands.
+	OutputSchema map[string]any
+	// ToolChoice constrains tool use for this command only; a named tool must
+	// be one of Tools.
+	ToolChoice ToolChoice
 }
 
 // RunState is the durable runtime state.
@@ -80,6 +88,13 @@ type RunState struct {
 	Output             string              `json:"output,omitempty"`
 	Error              string              `json:"error,omitempty"`
 	Messages           []Message           `json:"messages,omitempty"`
+	// OutputSchema is the final answer contract copied from RunInput.OutputSchema.
+	OutputSchema map[string]any `json:"output_schema,omitempty"`
+	// StructuredOutput is the parsed final answer of a run with an OutputSchema.
+	StructuredOutput json.RawMessage `json:"structured_output,omitempty"`
+	// EngineState is opaque JSON owned by the engine that executes the run, used
+	// to persist engine-specific progress such as a workflow cursor.
+	EngineState json.RawMessage `json:"engine_state,omitempty"`
 }
 
 // CloneRunState returns a deep copy safe for in-memory stores.
@@ -90,6 +105,13 @@ func CloneRunState(in RunState) RunState {
 		out.PendingRequirement = &requirementCopy
 	}
 	out.Messages = CloneMessages(in.Messages)
+	if in.StructuredOutput != nil {
+		out.StructuredOutput = append(json.RawMessage(nil), in.StructuredOutput...)
+	}
+	out.OutputSchema = cloneJSONLikeMap(in.OutputSchema)
+	if in.EngineState != nil {
+		out.EngineState = append(json.RawMessage(nil), in.EngineState...)
+	}
 	return out
 }
 
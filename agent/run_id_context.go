@@ -0,0 +1,25 @@
+package agent
+
+import "context"
+
+type runIDContextKey struct{}
+
+// WithRunID attaches the identifier of the run being executed to context.
+func WithRunID(ctx context.Context, runID RunID) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, runIDContextKey{}, runID)
+}
+
+// RunIDFromContext reads the identifier of the run being executed from context.
+func RunIDFromContext(ctx context.Context) (RunID, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	runID, ok := ctx.Value(runIDContextKey{}).(RunID)
+	if !ok || runID == "" {
+		return "", false
+	}
+	return runID, true
+}
@@ -0,0 +1,65 @@
+package agent_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Gurpartap/agentframe/agent"
+	eventinginmem "github.com/Gurpartap/agentframe/eventing/inmem"
+	runstoreinmem "github.com/Gurpartap/agentframe/runstore/inmem"
+)
+
+func TestRunIDContextRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	ctx := agent.WithRunID(nil, "run-1")
+	got, ok := agent.RunIDFromContext(ctx)
+	if !ok {
+		t.Fatalf("expected run id in context")
+	}
+	if got != "run-1" {
+		t.Fatalf("unexpected run id: got=%q want=%q", got, "run-1")
+	}
+
+	if got, ok := agent.RunIDFromContext(context.Background()); ok {
+		t.Fatalf("expected no run id, got=%q", got)
+	}
+}
+
+func TestRunnerAttachesRunIDToEngineContext(t *testing.T) {
+	t.Parallel()
+
+	store := runstoreinmem.New()
+	var seen []agent.RunID
+	engine := &engineSpy{
+		executeFn: func(ctx context.Context, state agent.RunState, _ agent.EngineInput) (agent.RunState, error) {
+			runID, _ := agent.RunIDFromContext(ctx)
+			seen = append(seen, runID)
+
+			next := state
+			next.Step++
+			next.Status = agent.RunStatusRunning
+			return next, nil
+		},
+	}
+	runner := newDispatchRunnerWithEngine(t, store, eventinginmem.New(), engine)
+
+	if _, err := runner.Run(context.Background(), agent.RunInput{RunID: "ctx-run", UserPrompt: "start"}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if _, err := runner.Continue(context.Background(), "ctx-run", 1, nil, nil); err != nil {
+		t.Fatalf("continue: %v", err)
+	}
+	if _, err := runner.FollowUp(context.Background(), "ctx-run", "more", 1, nil); err != nil {
+		t.Fatalf("follow up: %v", err)
+	}
+
+	if len(seen) != 3 {
+		t.Fatalf("engine call count mismatch: got=%d want=3", len(seen))
+	}
+	for i, runID := range seen {
+		if runID != "ctx-run" {
+			t.Fatalf("engine call %d run id mismatch: got=%q want=%q", i, runID, "ctx-run")
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 )
@@ -37,6 +38,20 @@ func ValidateRunState(state RunState) error {
 			state.ID,
 		)
 	}
+	if len(state.EngineState) > 0 && !json.Valid(state.EngineState) {
+		return fmt.Errorf(
+			"%w: field=engine_state reason=invalid_json run_id=%q",
+			ErrRunStateInvalid,
+			state.ID,
+		)
+	}
+	if len(state.StructuredOutput) > 0 && !json.Valid(state.StructuredOutput) {
+		return fmt.Errorf(
+			"%w: field=structured_output reason=invalid_json run_id=%q",
+			ErrRunStateInvalid,
+			state.ID,
+		)
+	}
 	if err := validateSuspensionInvariant(state); err != nil {
 		return err
 	}
@@ -1,6 +1,7 @@
 package agent_test
 
 import (
+	"encoding/json"
 	"errors"
 	"testing"
 
@@ -34,6 +35,23 @@ func TestValidateRunStateMatrix(t *testing.T) {
 				Status:  agent.RunStatusCompleted,
 			},
 		},
+		{
+			name: "valid engine state",
+			state: agent.RunState{
+				ID:          "run-valid-engine-state",
+				Status:      agent.RunStatusRunning,
+				EngineState: json.RawMessage(`{"node":"plan"}`),
+			},
+		},
+		{
+			name: "invalid engine state json",
+			state: agent.RunState{
+				ID:          "run-invalid-engine-state",
+				Status:      agent.RunStatusRunning,
+				EngineState: json.RawMessage(`{"node":`),
+			},
+			wantErr: true,
+		},
 		{
 			name: "valid suspended with pending requirement",
 			state: agent.RunState{
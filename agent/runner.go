@@ -236,6 +236,13 @@ func validateEngineOutput(prev RunState, next RunState) error {
 			prev.ID,
 		)
 	}
+	if !reflect.DeepEqual(next.OutputSchema, prev.OutputSchema) {
+		return fmt.Errorf(
+			"%w: invariant=output_schema run_id=%q",
+			ErrEngineOutputContractViolation,
+			prev.ID,
+		)
+	}
 	if err := validateSuspendedRequirementProvenance(prev, next); err != nil {
 		return err
 	}
@@ -375,6 +382,43 @@ func validateToolDefinitions(command CommandKind, tools []ToolDefinition) error
 	return nil
 }
 
+func validateToolChoice(command CommandKind, choice ToolChoice, tools []ToolDefinition) error {
+	switch choice.Mode {
+	case "", ToolChoiceAuto, ToolChoiceRequired, ToolChoiceNone:
+		if choice.Mode == ToolChoiceRequired && len(tools) == 0 {
+			return fmt.Errorf("%w: command=%s mode=%q reason=no_tools", ErrToolChoiceInvalid, command, choice.Mode)
+		}
+		if choice.Name != "" {
+			return fmt.Errorf(
+				"%w: command=%s mode=%q reason=unexpected_name",
+				ErrToolChoiceInvalid,
+				command,
+				choice.Mode,
+			)
+		}
+		return nil
+	case ToolChoiceTool:
+		for i := range tools {
+			if tools[i].Name == choice.Name {
+				return nil
+			}
+		}
+		return fmt.Errorf(
+			"%w: command=%s name=%q reason=undefined_tool",
+			ErrToolChoiceInvalid,
+			command,
+			choice.Name,
+		)
+	default:
+		return fmt.Errorf(
+			"%w: command=%s mode=%q reason=unknown_mode",
+			ErrToolChoiceInvalid,
+			command,
+			choice.Mode,
+		)
+	}
+}
+
 func normalizeCommandSaveError(command CommandKind, err error) error {
 	if !errors.Is(err, ErrRunVersionConflict) {
 		return err
@@ -502,6 +546,9 @@ func (r *Runner) dispatchStart(ctx context.Context, cmd StartCommand) (RunResult
 	if err := validateToolDefinitions(CommandKindStart, input.Tools); err != nil {
 		return RunResult{}, err
 	}
+	if err := validateToolChoice(CommandKindStart, input.ToolChoice, input.Tools); err != nil {
+		return RunResult{}, err
+	}
 	runID := input.RunID
 	if runID == "" {
 		generated, err := r.idGen.NewRunID(ctx)
@@ -515,7 +562,8 @@ func (r *Runner) dispatchStart(ctx context.Context, cmd StartCommand) (RunResult
 	}
 
 	state := RunState{
-		ID: runID,
+		ID:           runID,
+		OutputSchema: cloneJSONLikeMap(input.OutputSchema),
 	}
 	if err := TransitionRunStatus(&state, RunStatusPending); err != nil {
 		return RunResult{}, err
@@ -547,10 +595,12 @@ func (r *Runner) dispatchStart(ctx context.Context, cmd StartCommand) (RunResult
 		Description: "run persisted and ready for execution",
 	}))
 
-	finalState, runErr := r.engine.Execute(ctx, state, EngineInput{
-		MaxSteps:   input.MaxSteps,
-		Tools:      CloneToolDefinitions(input.Tools),
-		Resolution: nil,
+	finalState, runErr := r.engine.Execute(WithRunID(ctx, runID), state, EngineInput{
+		MaxSteps:     input.MaxSteps,
+		Tools:        CloneToolDefinitions(input.Tools),
+		Resolution:   nil,
+		OutputSchema: cloneJSONLikeMap(state.OutputSchema),
+		ToolChoice:   input.ToolChoice,
 	})
 	if contractErr := validateEngineOutput(state, finalState); contractErr != nil {
 		return RunResult{}, errors.Join(contractErr, eventErr)
@@ -623,6 +673,9 @@ func (r *Runner) dispatchContinue(ctx context.Context, cmd ContinueCommand) (Run
 	if err := validateToolDefinitions(CommandKindContinue, cmd.Tools); err != nil {
 		return RunResult{}, err
 	}
+	if err := validateToolChoice(CommandKindContinue, cmd.ToolChoice, cmd.Tools); err != nil {
+		return RunResult{}, err
+	}
 	sideEffectCtx := func() context.Context { return sideEffectContext(ctx) }
 	state, err := r.store.Load(sideEffectCtx(), runID)
 	if err != nil {
@@ -645,7 +698,7 @@ func (r *Runner) dispatchContinue(ctx context.Context, cmd ContinueCommand) (Run
 			return RunResult{State: state}, err
 		}
 	}
-	continueCtx := ctx
+	continueCtx := WithRunID(ctx, state.ID)
 	if override, ok := approvedToolCallReplayOverrideForContinue(cmd.Resolution, resolvedRequirement); ok {
 		continueCtx = WithApprovedToolCallReplayOverride(continueCtx, override)
 	}
@@ -654,6 +707,8 @@ func (r *Runner) dispatchContinue(ctx context.Context, cmd ContinueCommand) (Run
 		Tools:               CloneToolDefinitions(cmd.Tools),
 		Resolution:          cmd.Resolution,
 		ResolvedRequirement: resolvedRequirement,
+		OutputSchema:        cloneJSONLikeMap(state.OutputSchema),
+		ToolChoice:          cmd.ToolChoice,
 	})
 	var eventErr error
 	if contractErr := validateEngineOutput(state, finalState); contractErr != nil {
@@ -817,6 +872,9 @@ func (r *Runner) dispatchFollowUp(ctx context.Context, cmd FollowUpCommand) (Run
 	if err := validateToolDefinitions(CommandKindFollowUp, cmd.Tools); err != nil {
 		return RunResult{}, err
 	}
+	if err := validateToolChoice(CommandKindFollowUp, cmd.ToolChoice, cmd.Tools); err != nil {
+		return RunResult{}, err
+	}
 	sideEffectCtx := func() context.Context { return sideEffectContext(ctx) }
 	state, err := r.store.Load(sideEffectCtx(), cmd.RunID)
 	if err != nil {
@@ -838,10 +896,12 @@ func (r *Runner) dispatchFollowUp(ctx context.Context, cmd FollowUpCommand) (Run
 		Role:    RoleUser,
 		Content: cmd.UserPrompt,
 	})
-	finalState, runErr := r.engine.Execute(ctx, state, EngineInput{
-		MaxSteps:   cmd.MaxSteps,
-		Tools:      CloneToolDefinitions(cmd.Tools),
-		Resolution: nil,
+	finalState, runErr := r.engine.Execute(WithRunID(ctx, state.ID), state, EngineInput{
+		MaxSteps:     cmd.MaxSteps,
+		Tools:        CloneToolDefinitions(cmd.Tools),
+		Resolution:   nil,
+		OutputSchema: cloneJSONLikeMap(state.OutputSchema),
+		ToolChoice:   cmd.ToolChoice,
 	})
 	var eventErr error
 	if contractErr := validateEngineOutput(state, finalState); contractErr != nil {
@@ -690,6 +690,46 @@ func TestRunnerDispatch_InvalidInputMatrix(t *testing.T) {
 				})
 			},
 		},
+		{
+			name:    "undefined_tool_choice_start",
+			wantErr: agent.ErrToolChoiceInvalid,
+			call: func(runner *agent.Runner) (agent.RunResult, error) {
+				return runner.Dispatch(context.Background(), agent.StartCommand{
+					Input: agent.RunInput{
+						RunID:      startRunID,
+						UserPrompt: "start",
+						MaxSteps:   3,
+						Tools:      []agent.ToolDefinition{{Name: "lookup"}},
+						ToolChoice: agent.ToolChoice{Mode: agent.ToolChoiceTool, Name: "deploy"},
+					},
+				})
+			},
+			checkAbsent: startRunID,
+		},
+		{
+			name:    "unknown_tool_choice_mode_continue",
+			wantErr: agent.ErrToolChoiceInvalid,
+			call: func(runner *agent.Runner) (agent.RunResult, error) {
+				return runner.Dispatch(context.Background(), agent.ContinueCommand{
+					RunID:      existingRunID,
+					MaxSteps:   3,
+					Tools:      []agent.ToolDefinition{{Name: "lookup"}},
+					ToolChoice: agent.ToolChoice{Mode: "any"},
+				})
+			},
+		},
+		{
+			name:    "required_tool_choice_without_tools_follow_up",
+			wantErr: agent.ErrToolChoiceInvalid,
+			call: func(runner *agent.Runner) (agent.RunResult, error) {
+				return runner.Dispatch(context.Background(), agent.FollowUpCommand{
+					RunID:      existingRunID,
+					UserPrompt: "follow up",
+					MaxSteps:   3,
+					ToolChoice: agent.ToolChoice{Mode: agent.ToolChoiceRequired},
+				})
+			},
+		},
 	}
 
 	for _, tc := range cases {
@@ -0,0 +1,25 @@
+package agent
+
+import "context"
+
+type stepContextKey struct{}
+
+// WithStep attaches the engine step whose tool calls are executing to context.
+func WithStep(ctx context.Context, step int) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, stepContextKey{}, step)
+}
+
+// StepFromContext reads the executing engine step from context.
+func StepFromContext(ctx context.Context) (int, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+	step, ok := ctx.Value(stepContextKey{}).(int)
+	if !ok || step <= 0 {
+		return 0, false
+	}
+	return step, true
+}
@@ -0,0 +1,20 @@
+package agent_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Gurpartap/agentframe/agent"
+)
+
+func TestStepContextRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	got, ok := agent.StepFromContext(agent.WithStep(nil, 3))
+	if !ok || got != 3 {
+		t.Fatalf("step mismatch: got=%d ok=%v want=3", got, ok)
+	}
+	if got, ok := agent.StepFromContext(context.Background()); ok {
+		t.Fatalf("expected no step, got=%d", got)
+	}
+}
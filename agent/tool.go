@@ -1,5 +1,10 @@
 package agent
 
+import (
+	"bytes"
+	"encoding/json"
+)
+
 // ToolDefinition declares a callable capability exposed to the model.
 type ToolDefinition struct {
 	Name        string         `json:"name"`
@@ -7,6 +12,32 @@ type ToolDefinition struct {
 	InputSchema map[string]any `json:"input_schema,omitempty"`
 }
 
+// ToolChoiceMode controls whether and which tools the model may call.
+type ToolChoiceMode string
+
+const (
+	// ToolChoiceAuto lets the model decide between answering and calling tools.
+	ToolChoiceAuto ToolChoiceMode = "auto"
+	// ToolChoiceRequired requires the model to call at least one tool.
+	ToolChoiceRequired ToolChoiceMode = "required"
+	// ToolChoiceNone forbids tool calls; the model must answer.
+	ToolChoiceNone ToolChoiceMode = "none"
+	// ToolChoiceTool requires the model to call the tool named by ToolChoice.Name.
+	ToolChoiceTool ToolChoiceMode = "tool"
+)
+
+// ToolChoice constrains tool use for a model call. The zero value is
+// equivalent to ToolChoiceAuto.
+type ToolChoice struct {
+	Mode ToolChoiceMode `json:"mode,omitempty"`
+	Name string         `json:"name,omitempty"`
+}
+
+// IsZero reports whether the choice leaves tool use to the model.
+func (c ToolChoice) IsZero() bool {
+	return c.Mode == "" && c.Name == ""
+}
+
 // ToolCall is requested by the assistant message and executed by ToolExecutor.
 type ToolCall struct {
 	ID        string         `json:"id"`
@@ -15,10 +46,16 @@ type ToolCall struct {
 }
 
 // ToolResult is the normalized output produced by a tool execution.
+//
+// Parts carries optional multi-part content such as screenshots or JSON
+// blocks alongside the plain-text Content. Structured carries an optional
+// machine-readable JSON payload for consumers that should not re-parse Content.
 type ToolResult struct {
 	CallID        string            `json:"call_id"`
 	Name          string            `json:"name"`
 	Content       string            `json:"content"`
+	Parts         []ContentPart     `json:"parts,omitempty"`
+	Structured    json.RawMessage   `json:"structured,omitempty"`
 	IsError       bool              `json:"is_error,omitempty"`
 	FailureReason ToolFailureReason `json:"failure_reason,omitempty"`
 }
@@ -26,15 +63,16 @@ type ToolResult struct {
 // CloneToolDefinition returns a deep copy of a tool definition.
 func CloneToolDefinition(in ToolDefinition) ToolDefinition {
 	out := in
-	if in.InputSchema != nil {
-		out.InputSchema = make(map[string]any, len(in.InputSchema))
-		for key, value := range in.InputSchema {
-			out.InputSchema[key] = cloneJSONLikeValue(value)
-		}
-	}
+	out.InputSchema = cloneJSONLikeMap(in.InputSchema)
 	return out
 }
 
+// CloneSchema returns a deep copy of a JSON schema such as
+// ToolDefinition.InputSchema or RunInput.OutputSchema.
+func CloneSchema(in map[string]any) map[string]any {
+	return cloneJSONLikeMap(in)
+}
+
 // CloneToolDefinitions returns deep copies of all tool definitions.
 func CloneToolDefinitions(in []ToolDefinition) []ToolDefinition {
 	out := make([]ToolDefinition, len(in))
@@ -52,16 +90,33 @@ const (
 	ToolFailureReasonInvalidArguments ToolFailureReason = "invalid_arguments"
 	ToolFailureReasonExecutorError    ToolFailureReason = "executor_error"
 	ToolFailureReasonSuspended        ToolFailureReason = "suspended"
+	ToolFailureReasonTimeout          ToolFailureReason = "timeout"
 )
 
-// ToolResultMessage converts a tool result to a transcript message.
+// ToolResultMessage converts a tool result to a transcript message. A
+// structured payload is appended as a trailing JSON content part.
 func ToolResultMessage(result ToolResult) Message {
-	return Message{
+	message := Message{
 		Role:       RoleTool,
 		Name:       result.Name,
 		ToolCallID: result.CallID,
 		Content:    result.Content,
+		Parts:      CloneContentParts(result.Parts),
+	}
+	if len(result.Structured) > 0 {
+		message.Parts = append(message.Parts, JSONPart(bytes.Clone(result.Structured)))
+	}
+	return message
+}
+
+// CloneToolResult returns a deep copy of a tool result.
+func CloneToolResult(in ToolResult) ToolResult {
+	out := in
+	out.Parts = CloneContentParts(in.Parts)
+	if in.Structured != nil {
+		out.Structured = bytes.Clone(in.Structured)
 	}
+	return out
 }
 
 // CloneToolCall returns a deep copy of a tool call.
@@ -76,6 +131,17 @@ func CloneToolCall(in ToolCall) ToolCall {
 	return out
 }
 
+func cloneJSONLikeMap(in map[string]any) map[string]any {
+	if in == nil {
+		return nil
+	}
+	out := make(map[string]any, len(in))
+	for key, value := range in {
+		out[key] = cloneJSONLikeValue(value)
+	}
+	return out
+}
+
 func cloneJSONLikeValue(in any) any {
 	switch typed := in.(type) {
 	case map[string]any:
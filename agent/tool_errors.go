@@ -0,0 +1,57 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ToolTimeoutError reports that a tool handler exceeded its configured timeout.
+// It deliberately does not wrap context.DeadlineExceeded so engines classify it
+// as a tool failure rather than a cancellation of the run.
+type ToolTimeoutError struct {
+	Tool    string
+	Timeout time.Duration
+}
+
+func (e *ToolTimeoutError) Error() string {
+	if e == nil {
+		return "tool timed out"
+	}
+	return fmt.Sprintf("tool %q timed out after %s", e.Tool, e.Timeout)
+}
+
+// ToolPanicError reports a panic recovered from a tool handler.
+type ToolPanicError struct {
+	Tool  string
+	Value any
+	Stack string
+}
+
+func (e *ToolPanicError) Error() string {
+	if e == nil {
+		return "tool panicked"
+	}
+	return fmt.Sprintf("tool %q panicked: %v", e.Tool, e.Value)
+}
+
+// ToolErrorFailureReason classifies a tool execution error that is neither a
+// cancellation nor a suspend request.
+func ToolErrorFailureReason(err error) ToolFailureReason {
+	var timeoutErr *ToolTimeoutError
+	if errors.As(err, &timeoutErr) {
+		return ToolFailureReasonTimeout
+	}
+	return ToolFailureReasonExecutorError
+}
+
+// ToolErrorEventDescription returns diagnostics for the tool_result event of a
+// failed execution. Recovered panics include their stack so operators can debug
+// them without exposing the stack to the model.
+func ToolErrorEventDescription(err error) string {
+	var panicErr *ToolPanicError
+	if errors.As(err, &panicErr) {
+		return fmt.Sprintf("%s\n%s", panicErr.Error(), panicErr.Stack)
+	}
+	return ""
+}
@@ -0,0 +1,164 @@
+package agentconformance
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Gurpartap/agentframe/agent"
+	runstoreinmem "github.com/Gurpartap/agentframe/runstore/inmem"
+)
+
+// EngineFactory returns a fresh engine for one subtest. The engine must be able
+// to make progress on a single user prompt without further input.
+type EngineFactory func(t *testing.T) agent.Engine
+
+// EngineSuite verifies the agent.Engine contract enforced by agent.Runner: run
+// identity, monotonic steps, append-only transcripts, suspension provenance,
+// cancellation handling, and isolation of caller-owned input.
+func EngineSuite(t *testing.T, newEngine EngineFactory) {
+	t.Helper()
+
+	t.Run("output_preserves_run_contract", func(t *testing.T) {
+		engine := newEngine(t)
+		state := engineSeedState("conf-engine-contract")
+		snapshot := agent.CloneRunState(state)
+		tools := engineTools()
+		toolsSnapshot := agent.CloneToolDefinitions(tools)
+
+		next, _ := engine.Execute(context.Background(), state, agent.EngineInput{
+			MaxSteps: 4,
+			Tools:    tools,
+		})
+		assertEngineTransition(t, snapshot, next)
+		if next.Status == agent.RunStatusPending {
+			t.Fatalf("engine must leave pending status after execution")
+		}
+		if !reflect.DeepEqual(state.Messages, snapshot.Messages) {
+			t.Fatalf("engine mutated caller-owned input messages")
+		}
+		if !reflect.DeepEqual(tools, toolsSnapshot) {
+			t.Fatalf("engine mutated caller-owned tool definitions")
+		}
+	})
+
+	t.Run("runner_accepts_engine_output", func(t *testing.T) {
+		engine := newEngine(t)
+		store := runstoreinmem.New()
+		runner, err := agent.NewRunner(agent.Dependencies{
+			IDGenerator: fixedIDGenerator{runID: "conf-engine-runner"},
+			RunStore:    store,
+			Engine:      engine,
+		})
+		if err != nil {
+			t.Fatalf("new runner: %v", err)
+		}
+		result, err := runner.Run(context.Background(), agent.RunInput{
+			SystemPrompt: "conformance",
+			UserPrompt:   "hello",
+			MaxSteps:     4,
+			Tools:        engineTools(),
+		})
+		if errors.Is(err, agent.ErrEngineOutputContractViolation) {
+			t.Fatalf("runner rejected engine output: %v", err)
+		}
+		persisted, loadErr := store.Load(context.Background(), "conf-engine-runner")
+		if loadErr != nil {
+			t.Fatalf("load persisted state: %v", loadErr)
+		}
+		if !reflect.DeepEqual(persisted, result.State) {
+			t.Fatalf("persisted state mismatch:\n got=%+v\nwant=%+v", persisted, result.State)
+		}
+	})
+
+	t.Run("nil_context_is_rejected", func(t *testing.T) {
+		engine := newEngine(t)
+		_, err := engine.Execute(nil, engineSeedState("conf-engine-nil-ctx"), agent.EngineInput{MaxSteps: 1})
+		if !errors.Is(err, agent.ErrContextNil) {
+			t.Fatalf("expected ErrContextNil, got %v", err)
+		}
+	})
+
+	t.Run("suspended_state_requires_resolution", func(t *testing.T) {
+		engine := newEngine(t)
+		state := engineSeedState("conf-engine-suspended")
+		state.Status = agent.RunStatusSuspended
+		state.PendingRequirement = &agent.PendingRequirement{
+			ID:     "req-conformance",
+			Kind:   agent.RequirementKindApproval,
+			Origin: agent.RequirementOriginModel,
+		}
+		snapshot := agent.CloneRunState(state)
+		next, err := engine.Execute(context.Background(), state, agent.EngineInput{MaxSteps: 1})
+		if !errors.Is(err, agent.ErrResolutionRequired) {
+			t.Fatalf("expected ErrResolutionRequired, got %v", err)
+		}
+		if !reflect.DeepEqual(next, snapshot) {
+			t.Fatalf("rejected execution must return input state unchanged:\n got=%+v\nwant=%+v", next, snapshot)
+		}
+	})
+
+	t.Run("done_context_cancels_run", func(t *testing.T) {
+		engine := newEngine(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		state := engineSeedState("conf-engine-cancelled")
+		snapshot := agent.CloneRunState(state)
+		next, err := engine.Execute(ctx, state, agent.EngineInput{MaxSteps: 4})
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+		assertEngineTransition(t, snapshot, next)
+		if next.Status != agent.RunStatusCancelled {
+			t.Fatalf("expected cancelled status, got %s", next.Status)
+		}
+	})
+}
+
+func assertEngineTransition(t *testing.T, prev agent.RunState, next agent.RunState) {
+	t.Helper()
+
+	if next.ID != prev.ID {
+		t.Fatalf("engine changed run id: got=%q want=%q", next.ID, prev.ID)
+	}
+	if next.Step < prev.Step {
+		t.Fatalf("engine decreased step: got=%d want>=%d", next.Step, prev.Step)
+	}
+	if len(next.Messages) < len(prev.Messages) {
+		t.Fatalf("engine truncated transcript: got=%d want>=%d", len(next.Messages), len(prev.Messages))
+	}
+	if !reflect.DeepEqual(next.Messages[:len(prev.Messages)], prev.Messages) {
+		t.Fatalf("engine rewrote transcript prefix")
+	}
+	if err := agent.ValidateRunState(next); err != nil {
+		t.Fatalf("engine produced invalid run state: %v", err)
+	}
+}
+
+func engineSeedState(runID agent.RunID) agent.RunState {
+	return agent.RunState{
+		ID:      runID,
+		Version: 1,
+		Status:  agent.RunStatusPending,
+		Messages: []agent.Message{
+			{Role: agent.RoleSystem, Content: "conformance"},
+			{Role: agent.RoleUser, Content: "hello"},
+		},
+	}
+}
+
+func engineTools() []agent.ToolDefinition {
+	return []agent.ToolDefinition{
+		{
+			Name:        "lookup",
+			Description: "Look up information",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"q": map[string]any{"type": "string"},
+				},
+			},
+		},
+	}
+}
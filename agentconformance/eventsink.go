@@ -0,0 +1,190 @@
+package agentconformance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/Gurpartap/agentframe/agent"
+	runstoreinmem "github.com/Gurpartap/agentframe/runstore/inmem"
+)
+
+// EventSinkHarness exposes a sink under test together with a read-back view of its events.
+type EventSinkHarness struct {
+	Sink agent.EventSink
+	// Events returns the events accepted for runID in delivery order.
+	Events func(runID agent.RunID) ([]agent.Event, error)
+}
+
+// EventSinkFactory returns a fresh, empty sink harness for one subtest.
+type EventSinkFactory func(t *testing.T) EventSinkHarness
+
+// EventSinkSuite verifies the agent.EventSink contract: per-run ordering,
+// validation before side effects, context handling, and payload isolation.
+func EventSinkSuite(t *testing.T, newSink EventSinkFactory) {
+	t.Helper()
+
+	t.Run("preserves_per_run_publish_order", func(t *testing.T) {
+		harness := newSink(t)
+		ctx := context.Background()
+		published := map[agent.RunID][]agent.Event{}
+		for step := 0; step < 3; step++ {
+			for _, runID := range []agent.RunID{"conf-sink-a", "conf-sink-b"} {
+				event := agent.Event{
+					RunID:       runID,
+					Step:        step,
+					Type:        agent.EventTypeRunCheckpoint,
+					Description: fmt.Sprintf("checkpoint %d", step),
+				}
+				if err := harness.Sink.Publish(ctx, event); err != nil {
+					t.Fatalf("publish run=%s step=%d: %v", runID, step, err)
+				}
+				published[runID] = append(published[runID], event)
+			}
+		}
+		for runID, want := range published {
+			got := mustReadEvents(t, harness, runID)
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("run %s events mismatch:%s\nwant:%s", runID, describeEvents(got), describeEvents(want))
+			}
+		}
+	})
+
+	t.Run("rejects_invalid_events_without_side_effects", func(t *testing.T) {
+		harness := newSink(t)
+		invalid := []agent.Event{
+			{RunID: "conf-sink-invalid", Type: ""},
+			{RunID: "conf-sink-invalid", Type: "unknown_type"},
+			{RunID: "conf-sink-invalid", Type: agent.EventTypeAssistantMessage},
+			{RunID: "conf-sink-invalid", Type: agent.EventTypeToolResult, ToolResult: &agent.ToolResult{Name: "lookup"}},
+			{RunID: "conf-sink-invalid", Type: agent.EventTypeCommandApplied},
+			{RunID: "conf-sink-invalid", Step: -1, Type: agent.EventTypeRunCheckpoint},
+		}
+		for i, event := range invalid {
+			if err := harness.Sink.Publish(context.Background(), event); !errors.Is(err, agent.ErrEventInvalid) {
+				t.Fatalf("invalid event %d: expected ErrEventInvalid, got %v", i, err)
+			}
+		}
+		if got := mustReadEvents(t, harness, "conf-sink-invalid"); len(got) != 0 {
+			t.Fatalf("rejected events must not be stored:%s", describeEvents(got))
+		}
+	})
+
+	t.Run("done_context_is_rejected", func(t *testing.T) {
+		harness := newSink(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := harness.Sink.Publish(ctx, agent.Event{
+			RunID: "conf-sink-done",
+			Type:  agent.EventTypeRunStarted,
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+		if got := mustReadEvents(t, harness, "conf-sink-done"); len(got) != 0 {
+			t.Fatalf("cancelled publish must not be stored:%s", describeEvents(got))
+		}
+	})
+
+	t.Run("published_payloads_are_isolated_from_callers", func(t *testing.T) {
+		harness := newSink(t)
+		message := agent.Message{
+			Role:    agent.RoleAssistant,
+			Content: "original",
+			ToolCalls: []agent.ToolCall{
+				{ID: "call-1", Name: "lookup", Arguments: map[string]any{"q": "original"}},
+			},
+		}
+		if err := harness.Sink.Publish(context.Background(), agent.Event{
+			RunID:   "conf-sink-isolation",
+			Step:    1,
+			Type:    agent.EventTypeAssistantMessage,
+			Message: &message,
+		}); err != nil {
+			t.Fatalf("publish: %v", err)
+		}
+		message.Content = "mutated"
+		message.ToolCalls[0].Arguments["q"] = "mutated"
+
+		got := mustReadEvents(t, harness, "conf-sink-isolation")
+		if len(got) != 1 || got[0].Message == nil {
+			t.Fatalf("unexpected events:%s", describeEvents(got))
+		}
+		if got[0].Message.Content != "original" || got[0].Message.ToolCalls[0].Arguments["q"] != "original" {
+			t.Fatalf("sink retained caller-owned memory: %+v", *got[0].Message)
+		}
+	})
+
+	t.Run("receives_runner_lifecycle_in_order", func(t *testing.T) {
+		harness := newSink(t)
+		runner, err := agent.NewRunner(agent.Dependencies{
+			IDGenerator: fixedIDGenerator{runID: "conf-sink-runner"},
+			RunStore:    runstoreinmem.New(),
+			Engine:      completingEngine{},
+			EventSink:   harness.Sink,
+		})
+		if err != nil {
+			t.Fatalf("new runner: %v", err)
+		}
+		if _, err := runner.Run(context.Background(), agent.RunInput{UserPrompt: "hello"}); err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		got := mustReadEvents(t, harness, "conf-sink-runner")
+		wantTypes := []agent.EventType{
+			agent.EventTypeRunStarted,
+			agent.EventTypeRunCheckpoint,
+			agent.EventTypeCommandApplied,
+		}
+		if len(got) != len(wantTypes) {
+			t.Fatalf("unexpected runner events:%s", describeEvents(got))
+		}
+		for i := range wantTypes {
+			if got[i].Type != wantTypes[i] {
+				t.Fatalf("event[%d] type mismatch: got=%s want=%s", i, got[i].Type, wantTypes[i])
+			}
+		}
+	})
+}
+
+func mustReadEvents(t *testing.T, harness EventSinkHarness, runID agent.RunID) []agent.Event {
+	t.Helper()
+
+	events, err := harness.Events(runID)
+	if err != nil {
+		t.Fatalf("read events for run %s: %v", runID, err)
+	}
+	return events
+}
+
+func describeEvents(events []agent.Event) string {
+	out := ""
+	for i, event := range events {
+		out += fmt.Sprintf("\n  [%d] type=%s step=%d run_id=%s", i, event.Type, event.Step, event.RunID)
+	}
+	return out
+}
+
+type fixedIDGenerator struct {
+	runID agent.RunID
+}
+
+func (g fixedIDGenerator) NewRunID(context.Context) (agent.RunID, error) {
+	return g.runID, nil
+}
+
+type completingEngine struct{}
+
+func (completingEngine) Execute(_ context.Context, state agent.RunState, _ agent.EngineInput) (agent.RunState, error) {
+	if err := agent.TransitionRunStatus(&state, agent.RunStatusRunning); err != nil {
+		return state, err
+	}
+	state.Step++
+	state.Messages = append(state.Messages, agent.Message{Role: agent.RoleAssistant, Content: "done"})
+	if err := agent.TransitionRunStatus(&state, agent.RunStatusCompleted); err != nil {
+		return state, err
+	}
+	state.Output = "done"
+	return state, nil
+}
@@ -0,0 +1,219 @@
+package agentconformance
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/Gurpartap/agentframe/agent"
+)
+
+// RunStoreFactory returns a fresh, empty store for one subtest.
+type RunStoreFactory func(t *testing.T) agent.RunStore
+
+// RunStoreSuite verifies the agent.RunStore contract: optimistic versioning,
+// lookup errors, structural validation, and isolation of persisted state.
+func RunStoreSuite(t *testing.T, newStore RunStoreFactory) {
+	t.Helper()
+
+	t.Run("create_then_load_sets_version_one", func(t *testing.T) {
+		store := newStore(t)
+		ctx := context.Background()
+		state := seedRunState("conf-store-create")
+		if err := store.Save(ctx, state); err != nil {
+			t.Fatalf("save: %v", err)
+		}
+		loaded, err := store.Load(ctx, state.ID)
+		if err != nil {
+			t.Fatalf("load: %v", err)
+		}
+		if loaded.Version != 1 {
+			t.Fatalf("version mismatch after create: got=%d want=1", loaded.Version)
+		}
+		loaded.Version = state.Version
+		if !reflect.DeepEqual(loaded, state) {
+			t.Fatalf("loaded state mismatch:\n got=%+v\nwant=%+v", loaded, state)
+		}
+	})
+
+	t.Run("create_with_nonzero_version_conflicts", func(t *testing.T) {
+		store := newStore(t)
+		state := seedRunState("conf-store-create-nonzero")
+		state.Version = 3
+		err := store.Save(context.Background(), state)
+		if !errors.Is(err, agent.ErrRunVersionConflict) {
+			t.Fatalf("expected ErrRunVersionConflict, got %v", err)
+		}
+		if _, err := store.Load(context.Background(), state.ID); !errors.Is(err, agent.ErrRunNotFound) {
+			t.Fatalf("rejected create must not persist state, got %v", err)
+		}
+	})
+
+	t.Run("save_bumps_version_and_rejects_stale_writes", func(t *testing.T) {
+		store := newStore(t)
+		ctx := context.Background()
+		state := seedRunState("conf-store-stale")
+		if err := store.Save(ctx, state); err != nil {
+			t.Fatalf("save create: %v", err)
+		}
+		current, err := store.Load(ctx, state.ID)
+		if err != nil {
+			t.Fatalf("load: %v", err)
+		}
+		updated := current
+		updated.Step = 1
+		if err := store.Save(ctx, updated); err != nil {
+			t.Fatalf("save update: %v", err)
+		}
+		latest, err := store.Load(ctx, state.ID)
+		if err != nil {
+			t.Fatalf("load latest: %v", err)
+		}
+		if latest.Version != current.Version+1 || latest.Step != 1 {
+			t.Fatalf("update mismatch: version=%d step=%d", latest.Version, latest.Step)
+		}
+
+		stale := current
+		stale.Step = 2
+		if err := store.Save(ctx, stale); !errors.Is(err, agent.ErrRunVersionConflict) {
+			t.Fatalf("expected ErrRunVersionConflict for stale save, got %v", err)
+		}
+		afterConflict, err := store.Load(ctx, state.ID)
+		if err != nil {
+			t.Fatalf("load after conflict: %v", err)
+		}
+		if !reflect.DeepEqual(afterConflict, latest) {
+			t.Fatalf("stale save must not mutate state:\n got=%+v\nwant=%+v", afterConflict, latest)
+		}
+	})
+
+	t.Run("concurrent_saves_at_same_version_admit_one_writer", func(t *testing.T) {
+		store := newStore(t)
+		ctx := context.Background()
+		state := seedRunState("conf-store-race")
+		if err := store.Save(ctx, state); err != nil {
+			t.Fatalf("save create: %v", err)
+		}
+		base, err := store.Load(ctx, state.ID)
+		if err != nil {
+			t.Fatalf("load: %v", err)
+		}
+
+		const writers = 8
+		var wg sync.WaitGroup
+		errs := make([]error, writers)
+		for i := 0; i < writers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				next := agent.CloneRunState(base)
+				next.Step = i + 1
+				errs[i] = store.Save(ctx, next)
+			}(i)
+		}
+		wg.Wait()
+
+		succeeded := 0
+		for i, err := range errs {
+			switch {
+			case err == nil:
+				succeeded++
+			case errors.Is(err, agent.ErrRunVersionConflict):
+			default:
+				t.Fatalf("writer %d returned unexpected error: %v", i, err)
+			}
+		}
+		if succeeded != 1 {
+			t.Fatalf("expected exactly one successful writer, got %d", succeeded)
+		}
+	})
+
+	t.Run("load_unknown_run_returns_not_found", func(t *testing.T) {
+		store := newStore(t)
+		if _, err := store.Load(context.Background(), "conf-store-missing"); !errors.Is(err, agent.ErrRunNotFound) {
+			t.Fatalf("expected ErrRunNotFound, got %v", err)
+		}
+	})
+
+	t.Run("empty_run_id_is_rejected", func(t *testing.T) {
+		store := newStore(t)
+		if _, err := store.Load(context.Background(), ""); !errors.Is(err, agent.ErrInvalidRunID) {
+			t.Fatalf("expected ErrInvalidRunID on load, got %v", err)
+		}
+		if err := store.Save(context.Background(), agent.RunState{Status: agent.RunStatusPending}); !errors.Is(err, agent.ErrInvalidRunID) {
+			t.Fatalf("expected ErrInvalidRunID on save, got %v", err)
+		}
+	})
+
+	t.Run("structurally_invalid_state_is_rejected", func(t *testing.T) {
+		store := newStore(t)
+		state := seedRunState("conf-store-invalid")
+		state.Status = agent.RunStatusSuspended
+		err := store.Save(context.Background(), state)
+		if !errors.Is(err, agent.ErrRunStateInvalid) {
+			t.Fatalf("expected ErrRunStateInvalid, got %v", err)
+		}
+		if _, err := store.Load(context.Background(), state.ID); !errors.Is(err, agent.ErrRunNotFound) {
+			t.Fatalf("rejected state must not persist, got %v", err)
+		}
+	})
+
+	t.Run("done_context_is_rejected", func(t *testing.T) {
+		store := newStore(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		state := seedRunState("conf-store-done")
+		if err := store.Save(ctx, state); !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled on save, got %v", err)
+		}
+		if _, err := store.Load(context.Background(), state.ID); !errors.Is(err, agent.ErrRunNotFound) {
+			t.Fatalf("cancelled save must not persist, got %v", err)
+		}
+	})
+
+	t.Run("persisted_state_is_isolated_from_callers", func(t *testing.T) {
+		store := newStore(t)
+		ctx := context.Background()
+		state := seedRunState("conf-store-isolation")
+		if err := store.Save(ctx, state); err != nil {
+			t.Fatalf("save: %v", err)
+		}
+		state.Messages[0].Content = "mutated after save"
+		state.Messages[1].ToolCalls[0].Arguments["q"] = "mutated after save"
+
+		loaded, err := store.Load(ctx, state.ID)
+		if err != nil {
+			t.Fatalf("load: %v", err)
+		}
+		if loaded.Messages[0].Content != "seed" || loaded.Messages[1].ToolCalls[0].Arguments["q"] != "go" {
+			t.Fatalf("store retained caller-owned memory after save: %+v", loaded.Messages)
+		}
+		loaded.Messages[0].Content = "mutated after load"
+
+		reloaded, err := store.Load(ctx, state.ID)
+		if err != nil {
+			t.Fatalf("reload: %v", err)
+		}
+		if reloaded.Messages[0].Content != "seed" {
+			t.Fatalf("store returned shared memory on load: %q", reloaded.Messages[0].Content)
+		}
+	})
+}
+
+func seedRunState(runID agent.RunID) agent.RunState {
+	return agent.RunState{
+		ID:     runID,
+		Status: agent.RunStatusPending,
+		Messages: []agent.Message{
+			{Role: agent.RoleUser, Content: "seed"},
+			{
+				Role: agent.RoleAssistant,
+				ToolCalls: []agent.ToolCall{
+					{ID: "call-1", Name: "lookup", Arguments: map[string]any{"q": "go"}},
+				},
+			},
+		},
+	}
+}
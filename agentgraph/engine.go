@@ -0,0 +1,534 @@
+// Package agentgraph implements agent.Engine as a workflow graph whose nodes
+// are model calls, tool calls, or conditional branches over run state.
+package agentgraph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/Gurpartap/agentframe/agent"
+	"github.com/Gurpartap/agentframe/agentreact"
+	"github.com/Gurpartap/agentframe/internal/enginekit"
+)
+
+// DefaultMaxSteps bounds node executions when EngineInput.MaxSteps is unset.
+const DefaultMaxSteps = 16
+
+// Engine executes a Graph one node per step. The node cursor is persisted in
+// RunState.EngineState so a suspended run continues at the node it left.
+type Engine struct {
+	graph    Graph
+	nodes    map[string]Node
+	model    agentreact.Model
+	tools    agentreact.ToolExecutor
+	events   agent.EventSink
+	redactor agent.Redactor
+}
+
+// Option customizes an Engine.
+type Option func(*Engine)
+
+// WithRedactor redacts every tool result before it is appended to the
+// transcript and published, as agentreact.WithRedactor does for ReactLoop.
+func WithRedactor(redactor agent.Redactor) Option {
+	return func(e *Engine) {
+		e.redactor = redactor
+	}
+}
+
+// stepResult reports how one node execution ended. An empty next runs the
+// current node again.
+type stepResult struct {
+	next      string
+	suspended bool
+	// repaired reports that the model was asked to fix a final answer that
+	// did not match the output schema.
+	repaired bool
+	eventErr error
+}
+
+// cursor is the engine state persisted between executions.
+type cursor struct {
+	Node string `json:"node"`
+	// Entered records that the current node's prompt was already appended.
+	Entered bool `json:"entered,omitempty"`
+	// Repairs counts output schema repairs requested at the current node.
+	Repairs int `json:"repairs,omitempty"`
+}
+
+func New(
+	graph Graph,
+	model agentreact.Model,
+	tools agentreact.ToolExecutor,
+	events agent.EventSink,
+	options ...Option,
+) (*Engine, error) {
+	if model == nil {
+		return nil, fmt.Errorf("new graph engine: %w", ErrMissingModel)
+	}
+	if tools == nil {
+		return nil, fmt.Errorf("new graph engine: %w", ErrMissingToolExecutor)
+	}
+	nodes, err := indexGraph(graph)
+	if err != nil {
+		return nil, fmt.Errorf("new graph engine: %w", err)
+	}
+	if events == nil {
+		events = enginekit.NoopEventSink{}
+	}
+	engine := &Engine{
+		graph:  graph,
+		nodes:  nodes,
+		model:  model,
+		tools:  tools,
+		events: events,
+	}
+	for _, option := range options {
+		if option != nil {
+			option(engine)
+		}
+	}
+	return engine, nil
+}
+
+func (e *Engine) Execute(ctx context.Context, state agent.RunState, input agent.EngineInput) (agent.RunState, error) {
+	if ctx == nil {
+		return state, agent.ErrContextNil
+	}
+	if err := agent.ValidateRunState(state); err != nil {
+		return state, err
+	}
+	if state.Status == agent.RunStatusSuspended {
+		return state, fmt.Errorf(
+			"%w: run_id=%q reason=continue_requires_resolution",
+			agent.ErrResolutionRequired,
+			state.ID,
+		)
+	}
+	position, err := decodeCursor(state, e.graph.Start)
+	if err != nil {
+		return state, err
+	}
+
+	maxSteps := input.MaxSteps
+	if maxSteps <= 0 {
+		maxSteps = DefaultMaxSteps
+	}
+	var eventErr error
+
+	if err := agent.TransitionRunStatus(&state, agent.RunStatusRunning); err != nil {
+		return state, errors.Join(err, eventErr)
+	}
+	if err := setCursor(&state, position); err != nil {
+		return enginekit.FailRun(ctx, e.events, state, err, eventErr)
+	}
+	if input.Resolution != nil {
+		state.Messages = append(state.Messages, enginekit.ResolutionMessage(input.Resolution))
+	}
+	if input.ResolvedRequirement != nil {
+		replayCall, replay, replayErr := enginekit.ApprovedToolReplayCall(ctx, &state, input)
+		if replayErr != nil {
+			return enginekit.FailRun(ctx, e.events, state, replayErr, eventErr)
+		}
+		if replay {
+			result, execErr := enginekit.ExecuteApprovedToolReplay(agent.WithStep(ctx, state.Step), e.tools.Execute, replayCall)
+			if execErr != nil {
+				if cancellationErr := enginekit.ContextCancellationError(ctx, execErr); cancellationErr != nil {
+					return enginekit.CancelRun(ctx, e.events, state, cancellationErr, eventErr)
+				}
+				return enginekit.FailRun(ctx, e.events, state, execErr, eventErr)
+			}
+			eventErr = errors.Join(eventErr, e.appendToolResult(ctx, &state, result))
+		}
+		// A suspended tool node is finished once its requirement is resolved,
+		// whatever the outcome; model nodes run again with the resolution.
+		if node, ok := e.nodes[position.Node]; ok && node.Kind == NodeKindTool {
+			position, eventErr = e.advance(ctx, &state, node.Next, eventErr)
+			if err := setCursor(&state, position); err != nil {
+				return enginekit.FailRun(ctx, e.events, state, err, eventErr)
+			}
+		}
+	}
+
+	for position.Node != End {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return enginekit.CancelRun(ctx, e.events, state, ctxErr, eventErr)
+		}
+		if state.Step >= maxSteps {
+			return enginekit.ExceedMaxSteps(ctx, e.events, state, eventErr)
+		}
+		node, ok := e.nodes[position.Node]
+		if !ok {
+			return enginekit.FailRun(ctx, e.events, state, fmt.Errorf("%w: value=%q", ErrUnknownNode, position.Node), eventErr)
+		}
+
+		state.Step++
+
+		var outcome stepResult
+		var runErr error
+		switch node.Kind {
+		case NodeKindModel:
+			if !position.Entered && node.Prompt != "" {
+				state.Messages = append(state.Messages, agent.Message{Role: agent.RoleUser, Content: node.Prompt})
+			}
+			position.Entered = true
+			if err := setCursor(&state, position); err != nil {
+				return enginekit.FailRun(ctx, e.events, state, err, eventErr)
+			}
+			outcome, runErr = e.executeModelNode(ctx, &state, node, input, position.Repairs)
+		case NodeKindTool:
+			outcome, runErr = e.executeToolNode(ctx, &state, node)
+		case NodeKindBranch:
+			outcome, runErr = e.executeBranchNode(state, node)
+		}
+		eventErr = errors.Join(eventErr, outcome.eventErr)
+		if runErr != nil {
+			if cancellationErr := enginekit.ContextCancellationError(ctx, runErr); cancellationErr != nil {
+				return enginekit.CancelRun(ctx, e.events, state, cancellationErr, eventErr)
+			}
+			return enginekit.FailRun(ctx, e.events, state, runErr, eventErr)
+		}
+		if outcome.suspended {
+			if err := agent.TransitionRunStatus(&state, agent.RunStatusSuspended); err != nil {
+				state.PendingRequirement = nil
+				return enginekit.FailRun(ctx, e.events, state, err, eventErr)
+			}
+			return state, eventErr
+		}
+		if outcome.repaired {
+			position.Repairs++
+			if err := setCursor(&state, position); err != nil {
+				return enginekit.FailRun(ctx, e.events, state, err, eventErr)
+			}
+		}
+		if outcome.next != "" {
+			position, eventErr = e.advance(ctx, &state, outcome.next, eventErr)
+			if err := setCursor(&state, position); err != nil {
+				return enginekit.FailRun(ctx, e.events, state, err, eventErr)
+			}
+		}
+	}
+
+	output := finalOutput(state.Messages)
+	if len(input.OutputSchema) > 0 {
+		structured, err := enginekit.ParseStructuredOutput(output, input.OutputSchema)
+		if err != nil {
+			return enginekit.FailRun(ctx, e.events, state, fmt.Errorf("%w: %w", agentreact.ErrOutputSchemaViolation, err), eventErr)
+		}
+		state.StructuredOutput = structured
+	}
+	if err := agent.TransitionRunStatus(&state, agent.RunStatusCompleted); err != nil {
+		return state, errors.Join(err, eventErr)
+	}
+	state.Output = output
+	eventErr = errors.Join(eventErr, enginekit.PublishEvent(ctx, e.events, agent.Event{
+		RunID:       state.ID,
+		Step:        state.Step,
+		Type:        agent.EventTypeRunCompleted,
+		Description: "graph reached end node",
+	}))
+	return state, eventErr
+}
+
+// executeModelNode performs one model call and executes any tool calls the
+// model returned. The node finishes when the model answers without tool calls.
+// A node leading to End must answer with input.OutputSchema when it is set;
+// other answers are repaired up to agentreact.DefaultMaxOutputRepairs times.
+func (e *Engine) executeModelNode(
+	ctx context.Context,
+	state *agent.RunState,
+	node Node,
+	input agent.EngineInput,
+	repairs int,
+) (stepResult, error) {
+	var outcome stepResult
+	offered := selectTools(input.Tools, node.Tools)
+	toolChoice, err := nodeToolChoice(node, input.ToolChoice, offered)
+	if err != nil {
+		return outcome, err
+	}
+	var outputSchema map[string]any
+	if node.Next == End {
+		outputSchema = input.OutputSchema
+	}
+	assistant, err := e.model.Generate(ctx, agentreact.ModelRequest{
+		Messages:     agent.CloneMessages(state.Messages),
+		Tools:        agent.CloneToolDefinitions(offered),
+		Resolution:   enginekit.CloneResolution(input.Resolution),
+		OutputSchema: outputSchema,
+		ToolChoice:   toolChoice,
+	})
+	if err != nil {
+		return outcome, err
+	}
+	if assistant.Role == "" {
+		assistant.Role = agent.RoleAssistant
+	}
+	state.Messages = append(state.Messages, agent.CloneMessage(assistant))
+	outcome.eventErr = enginekit.PublishEvent(ctx, e.events, agent.Event{
+		RunID:   state.ID,
+		Step:    state.Step,
+		Type:    agent.EventTypeAssistantMessage,
+		Message: &assistant,
+	})
+	if assistant.Requirement != nil {
+		if len(assistant.ToolCalls) > 0 {
+			return outcome, errors.New("assistant response cannot include both requirement and tool calls")
+		}
+		requirement := *assistant.Requirement
+		if err := enginekit.ValidateModelRequirement(state, &requirement); err != nil {
+			return outcome, err
+		}
+		state.PendingRequirement = &requirement
+		outcome.suspended = true
+		return outcome, nil
+	}
+	if len(assistant.ToolCalls) == 0 {
+		if len(outputSchema) > 0 {
+			if _, outputErr := enginekit.ParseStructuredOutput(assistant.Content, outputSchema); outputErr != nil {
+				if repairs >= agentreact.DefaultMaxOutputRepairs {
+					return outcome, fmt.Errorf("%w: repairs=%d: %w", agentreact.ErrOutputSchemaViolation, repairs, outputErr)
+				}
+				repair := enginekit.OutputRepairMessage(outputSchema, outputErr)
+				state.Messages = append(state.Messages, agent.CloneMessage(repair))
+				outcome.eventErr = errors.Join(outcome.eventErr, enginekit.PublishEvent(ctx, e.events, agent.Event{
+					RunID:   state.ID,
+					Step:    state.Step,
+					Type:    agent.EventTypeUserMessage,
+					Message: &repair,
+				}))
+				outcome.repaired = true
+				return outcome, nil
+			}
+		}
+		outcome.next = node.Next
+		return outcome, nil
+	}
+	if err := enginekit.ValidateToolCallShape(assistant.ToolCalls, ErrToolCallInvalid); err != nil {
+		return outcome, err
+	}
+
+	defined := make(map[string]agent.ToolDefinition, len(offered))
+	for _, definition := range offered {
+		defined[definition.Name] = definition
+	}
+	for _, call := range assistant.ToolCalls {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return outcome, ctxErr
+		}
+		definition, ok := defined[call.Name]
+		if !ok {
+			outcome.eventErr = errors.Join(outcome.eventErr, e.appendToolResult(ctx, state, enginekit.ToolErrorResult(
+				call,
+				agent.ToolFailureReasonUnknownTool,
+				fmt.Errorf("tool %q is not offered by node %q", call.Name, node.Name),
+			)))
+			continue
+		}
+		if validationErr := enginekit.ValidateToolArguments(definition.InputSchema, call.Arguments); validationErr != nil {
+			outcome.eventErr = errors.Join(outcome.eventErr, e.appendToolResult(ctx, state, enginekit.ToolErrorResult(
+				call,
+				agent.ToolFailureReasonInvalidArguments,
+				validationErr,
+			)))
+			continue
+		}
+		callOutcome, err := e.executeToolCall(ctx, state, call)
+		outcome.eventErr = errors.Join(outcome.eventErr, callOutcome.eventErr)
+		if err != nil || callOutcome.suspended {
+			outcome.suspended = callOutcome.suspended
+			return outcome, err
+		}
+	}
+	return outcome, nil
+}
+
+func (e *Engine) executeToolNode(ctx context.Context, state *agent.RunState, node Node) (stepResult, error) {
+	var outcome stepResult
+	call, err := node.Call(agent.CloneRunState(*state))
+	if err != nil {
+		return outcome, fmt.Errorf("build tool call for node %q: %w", node.Name, err)
+	}
+	if call.ID == "" {
+		call.ID = fmt.Sprintf("%s-%d", node.Name, state.Step)
+	}
+	if call.Name == "" {
+		return outcome, fmt.Errorf("%w: node=%q reason=empty_name", ErrToolCallInvalid, node.Name)
+	}
+	assistant := agent.Message{
+		Role:      agent.RoleAssistant,
+		ToolCalls: []agent.ToolCall{agent.CloneToolCall(call)},
+	}
+	state.Messages = append(state.Messages, agent.CloneMessage(assistant))
+	outcome.eventErr = enginekit.PublishEvent(ctx, e.events, agent.Event{
+		RunID:   state.ID,
+		Step:    state.Step,
+		Type:    agent.EventTypeAssistantMessage,
+		Message: &assistant,
+	})
+	callOutcome, err := e.executeToolCall(ctx, state, call)
+	outcome.eventErr = errors.Join(outcome.eventErr, callOutcome.eventErr)
+	if err != nil {
+		return outcome, err
+	}
+	if callOutcome.suspended {
+		outcome.suspended = true
+		return outcome, nil
+	}
+	outcome.next = node.Next
+	return outcome, nil
+}
+
+func (e *Engine) executeBranchNode(state agent.RunState, node Node) (stepResult, error) {
+	next, err := node.Branch(agent.CloneRunState(state))
+	if err != nil {
+		return stepResult{}, fmt.Errorf("branch node %q: %w", node.Name, err)
+	}
+	if !isKnownTarget(e.nodes, next) {
+		return stepResult{}, fmt.Errorf("%w: branch=%q value=%q", ErrUnknownNode, node.Name, next)
+	}
+	return stepResult{next: next}, nil
+}
+
+// executeToolCall runs one call and appends its observation. When the executor
+// requests suspension the pending requirement is set on state.
+func (e *Engine) executeToolCall(ctx context.Context, state *agent.RunState, call agent.ToolCall) (stepResult, error) {
+	executed, toolErr := e.tools.Execute(agent.WithStep(agent.WithoutApprovedToolCallReplayOverride(ctx), state.Step), call)
+	if toolErr == nil {
+		if identityErr := enginekit.ValidateToolResultIdentity(call, executed); identityErr != nil {
+			executed = enginekit.ToolErrorResult(call, agent.ToolFailureReasonExecutorError, identityErr)
+		}
+		return stepResult{eventErr: e.appendToolResult(ctx, state, executed)}, nil
+	}
+	if cancellationErr := enginekit.ContextCancellationError(ctx, toolErr); cancellationErr != nil {
+		return stepResult{}, cancellationErr
+	}
+	var suspendRequestErr *agent.SuspendRequestError
+	if !errors.As(toolErr, &suspendRequestErr) {
+		result := enginekit.ToolErrorResult(call, agent.ToolErrorFailureReason(toolErr), toolErr)
+		return stepResult{eventErr: e.appendToolResultWithDescription(ctx, state, result, agent.ToolErrorEventDescription(toolErr))}, nil
+	}
+	requirement, invalidErr := enginekit.ValidateToolSuspendRequest(state, call, suspendRequestErr)
+	if invalidErr != nil {
+		result := enginekit.ToolErrorResult(call, agent.ToolFailureReasonExecutorError, invalidErr)
+		return stepResult{eventErr: e.appendToolResult(ctx, state, result)}, invalidErr
+	}
+	result := enginekit.ToolErrorResult(call, agent.ToolFailureReasonSuspended, toolErr)
+	outcome := stepResult{suspended: true, eventErr: e.appendToolResult(ctx, state, result)}
+	state.PendingRequirement = requirement
+	return outcome, nil
+}
+
+func (e *Engine) appendToolResult(ctx context.Context, state *agent.RunState, result agent.ToolResult) error {
+	return e.appendToolResultWithDescription(ctx, state, result, "")
+}
+
+func (e *Engine) appendToolResultWithDescription(
+	ctx context.Context,
+	state *agent.RunState,
+	result agent.ToolResult,
+	description string,
+) error {
+	if e.redactor != nil {
+		result = agent.RedactToolResult(e.redactor, result)
+	}
+	state.Messages = append(state.Messages, agent.ToolResultMessage(result))
+	resultCopy := agent.CloneToolResult(result)
+	return enginekit.PublishEvent(ctx, e.events, agent.Event{
+		RunID:       state.ID,
+		Step:        state.Step,
+		Type:        agent.EventTypeToolResult,
+		Description: description,
+		ToolResult:  &resultCopy,
+	})
+}
+
+// advance moves the cursor to next and publishes a checkpoint for the transition.
+func (e *Engine) advance(ctx context.Context, state *agent.RunState, next string, eventErr error) (cursor, error) {
+	eventErr = errors.Join(eventErr, enginekit.PublishEvent(ctx, e.events, agent.Event{
+		RunID:       state.ID,
+		Step:        state.Step,
+		Type:        agent.EventTypeRunCheckpoint,
+		Description: fmt.Sprintf("graph entered node %q", next),
+	}))
+	return cursor{Node: next}, eventErr
+}
+
+func decodeCursor(state agent.RunState, start string) (cursor, error) {
+	if len(state.EngineState) == 0 {
+		return cursor{Node: start}, nil
+	}
+	var position cursor
+	if err := json.Unmarshal(state.EngineState, &position); err != nil {
+		return cursor{}, fmt.Errorf("%w: field=engine_state reason=invalid_cursor run_id=%q: %w", agent.ErrRunStateInvalid, state.ID, err)
+	}
+	if position.Node == "" {
+		return cursor{}, fmt.Errorf("%w: field=engine_state.node reason=empty run_id=%q", agent.ErrRunStateInvalid, state.ID)
+	}
+	return position, nil
+}
+
+func setCursor(state *agent.RunState, position cursor) error {
+	encoded, err := json.Marshal(position)
+	if err != nil {
+		return fmt.Errorf("encode graph cursor: %w", err)
+	}
+	state.EngineState = encoded
+	return nil
+}
+
+// nodeToolChoice returns the tool choice sent to the model by a model node.
+// Nodes offering no tools send none; a named tool must be offered by the node.
+func nodeToolChoice(node Node, choice agent.ToolChoice, offered []agent.ToolDefinition) (agent.ToolChoice, error) {
+	if len(offered) == 0 {
+		return agent.ToolChoice{}, nil
+	}
+	if choice.Mode == agent.ToolChoiceTool && !slices.ContainsFunc(offered, func(definition agent.ToolDefinition) bool {
+		return definition.Name == choice.Name
+	}) {
+		return agent.ToolChoice{}, fmt.Errorf(
+			"%w: field=tool_choice reason=not_offered value=%q node=%q",
+			agent.ErrToolChoiceInvalid,
+			choice.Name,
+			node.Name,
+		)
+	}
+	return choice, nil
+}
+
+func selectTools(available []agent.ToolDefinition, names []string) []agent.ToolDefinition {
+	if len(names) == 0 {
+		return nil
+	}
+	wanted := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		wanted[name] = struct{}{}
+	}
+	var selected []agent.ToolDefinition
+	for _, definition := range available {
+		if _, ok := wanted[definition.Name]; ok {
+			selected = append(selected, definition)
+		}
+	}
+	return selected
+}
+
+// finalOutput is the last assistant answer, or the last tool observation when
+// the graph ends on a tool node.
+func finalOutput(messages []agent.Message) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		message := messages[i]
+		switch message.Role {
+		case agent.RoleAssistant:
+			if len(message.ToolCalls) == 0 {
+				return message.Content
+			}
+		case agent.RoleTool:
+			return message.Content
+		}
+	}
+	return ""
+}
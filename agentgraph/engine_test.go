@@ -0,0 +1,422 @@
+package agentgraph_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Gurpartap/agentframe/agent"
+	"github.com/Gurpartap/agentframe/agentconformance"
+	"github.com/Gurpartap/agentframe/agentgraph"
+	"github.com/Gurpartap/agentframe/agentreact"
+	eventinginmem "github.com/Gurpartap/agentframe/eventing/inmem"
+	runstoreinmem "github.com/Gurpartap/agentframe/runstore/inmem"
+	toolingregistry "github.com/Gurpartap/agentframe/tooling/registry"
+)
+
+type scriptedModel struct {
+	mu        sync.Mutex
+	index     int
+	responses []agent.Message
+	requests  []agentreact.ModelRequest
+}
+
+func (m *scriptedModel) Generate(_ context.Context, request agentreact.ModelRequest) (agent.Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests = append(m.requests, request)
+	if m.index >= len(m.responses) {
+		return agent.Message{}, fmt.Errorf("script exhausted at step %d", m.index+1)
+	}
+	msg := agent.CloneMessage(m.responses[m.index])
+	m.index++
+	return msg, nil
+}
+
+type staticIDGenerator struct{}
+
+func (staticIDGenerator) NewRunID(context.Context) (agent.RunID, error) {
+	return "run-graph", nil
+}
+
+func newRunner(t *testing.T, engine agent.Engine, store agent.RunStore, events agent.EventSink) *agent.Runner {
+	t.Helper()
+
+	runner, err := agent.NewRunner(agent.Dependencies{
+		IDGenerator: staticIDGenerator{},
+		RunStore:    store,
+		Engine:      engine,
+		EventSink:   events,
+	})
+	if err != nil {
+		t.Fatalf("new runner: %v", err)
+	}
+	return runner
+}
+
+func testPipeline() agentgraph.Graph {
+	return agentgraph.Graph{
+		Start: "plan",
+		Nodes: []agentgraph.Node{
+			{Name: "plan", Kind: agentgraph.NodeKindModel, Prompt: "Write a plan.", Next: "test"},
+			{
+				Name: "test",
+				Kind: agentgraph.NodeKindTool,
+				Call: func(agent.RunState) (agent.ToolCall, error) {
+					return agent.ToolCall{Name: "run_tests", Arguments: map[string]any{}}, nil
+				},
+				Next: "check",
+			},
+			{
+				Name: "check",
+				Kind: agentgraph.NodeKindBranch,
+				Branch: func(state agent.RunState) (string, error) {
+					last := state.Messages[len(state.Messages)-1]
+					if strings.Contains(last.Content, "PASS") {
+						return "review", nil
+					}
+					return agentgraph.End, nil
+				},
+			},
+			{Name: "review", Kind: agentgraph.NodeKindModel, Prompt: "Review the change.", Next: agentgraph.End},
+		},
+	}
+}
+
+func decodeCursorNode(t *testing.T, state agent.RunState) string {
+	t.Helper()
+
+	var cursor struct {
+		Node string `json:"node"`
+	}
+	if err := json.Unmarshal(state.EngineState, &cursor); err != nil {
+		t.Fatalf("decode engine state %q: %v", state.EngineState, err)
+	}
+	return cursor.Node
+}
+
+func TestEngine_RunsPipelineToEnd(t *testing.T) {
+	t.Parallel()
+
+	tools, err := toolingregistry.New(map[string]toolingregistry.Handler{
+		"run_tests": func(context.Context, map[string]any) (string, error) {
+			return "PASS", nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("new registry: %v", err)
+	}
+	model := &scriptedModel{
+		responses: []agent.Message{
+			{Role: agent.RoleAssistant, Content: "1. change code"},
+			{Role: agent.RoleAssistant, Content: "looks good"},
+		},
+	}
+	events := eventinginmem.New()
+	engine, err := agentgraph.New(testPipeline(), model, tools, events)
+	if err != nil {
+		t.Fatalf("new engine: %v", err)
+	}
+
+	result, err := newRunner(t, engine, runstoreinmem.New(), events).Run(context.Background(), agent.RunInput{
+		UserPrompt: "fix the bug",
+		MaxSteps:   8,
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if result.State.Status != agent.RunStatusCompleted || result.State.Output != "looks good" {
+		t.Fatalf("final state mismatch: status=%s output=%q", result.State.Status, result.State.Output)
+	}
+	if result.State.Step != 4 {
+		t.Fatalf("step mismatch: got=%d want=4", result.State.Step)
+	}
+	if node := decodeCursorNode(t, result.State); node != agentgraph.End {
+		t.Fatalf("cursor mismatch: got=%q want=%q", node, agentgraph.End)
+	}
+	if len(model.requests) != 2 || len(model.requests[0].Tools) != 0 {
+		t.Fatalf("unexpected model requests: %+v", model.requests)
+	}
+	lastPrompt := model.requests[1].Messages[len(model.requests[1].Messages)-1]
+	if lastPrompt.Role != agent.RoleUser || lastPrompt.Content != "Review the change." {
+		t.Fatalf("review prompt mismatch: got=%+v", lastPrompt)
+	}
+
+	checkpoints := 0
+	for _, event := range events.Events() {
+		if event.Type == agent.EventTypeRunCheckpoint && strings.HasPrefix(event.Description, "graph entered node") {
+			checkpoints++
+		}
+	}
+	if checkpoints != 4 {
+		t.Fatalf("node checkpoint count mismatch: got=%d want=4", checkpoints)
+	}
+}
+
+func TestEngine_SuspendsAndContinuesMidGraph(t *testing.T) {
+	t.Parallel()
+
+	executions := 0
+	tools, err := toolingregistry.New(map[string]toolingregistry.Handler{
+		"run_tests": func(ctx context.Context, _ map[string]any) (string, error) {
+			if _, approved := agent.ApprovedToolCallReplayOverrideFromContext(ctx); !approved {
+				return "", &agent.SuspendRequestError{
+					Requirement: &agent.PendingRequirement{
+						ID:          "req-tests",
+						Kind:        agent.RequirementKindApproval,
+						Origin:      agent.RequirementOriginTool,
+						Fingerprint: "fp-tests",
+					},
+					Err: errors.New("tests need approval"),
+				}
+			}
+			executions++
+			return "PASS", nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("new registry: %v", err)
+	}
+	model := &scriptedModel{
+		responses: []agent.Message{
+			{Role: agent.RoleAssistant, Content: "1. change code"},
+			{Role: agent.RoleAssistant, Content: "approved and reviewed"},
+		},
+	}
+	events := eventinginmem.New()
+	engine, err := agentgraph.New(testPipeline(), model, tools, events)
+	if err != nil {
+		t.Fatalf("new engine: %v", err)
+	}
+	store := runstoreinmem.New()
+	runner := newRunner(t, engine, store, events)
+
+	suspended, err := runner.Run(context.Background(), agent.RunInput{UserPrompt: "fix the bug", MaxSteps: 8})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if suspended.State.Status != agent.RunStatusSuspended {
+		t.Fatalf("status mismatch: got=%s want=%s", suspended.State.Status, agent.RunStatusSuspended)
+	}
+	persisted, err := store.Load(context.Background(), "run-graph")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if node := decodeCursorNode(t, persisted); node != "test" {
+		t.Fatalf("persisted cursor mismatch: got=%q want=%q", node, "test")
+	}
+
+	resumed, err := runner.Continue(context.Background(), "run-graph", 8, nil, &agent.Resolution{
+		RequirementID: "req-tests",
+		Kind:          agent.RequirementKindApproval,
+		Outcome:       agent.ResolutionOutcomeApproved,
+	})
+	if err != nil {
+		t.Fatalf("continue: %v", err)
+	}
+	if resumed.State.Status != agent.RunStatusCompleted || resumed.State.Output != "approved and reviewed" {
+		t.Fatalf("resumed state mismatch: status=%s output=%q", resumed.State.Status, resumed.State.Output)
+	}
+	if executions != 1 {
+		t.Fatalf("approved tool execution count mismatch: got=%d want=1", executions)
+	}
+	if len(model.requests) != 2 {
+		t.Fatalf("plan node must not rerun after resume: model calls=%d", len(model.requests))
+	}
+}
+
+func TestNew_RejectsInvalidGraph(t *testing.T) {
+	t.Parallel()
+
+	model := &scriptedModel{}
+	tools, err := toolingregistry.New(nil)
+	if err != nil {
+		t.Fatalf("new registry: %v", err)
+	}
+	graphs := map[string]agentgraph.Graph{
+		"missing_start":     {Nodes: []agentgraph.Node{{Name: "a", Kind: agentgraph.NodeKindModel, Next: agentgraph.End}}},
+		"unknown_next":      {Start: "a", Nodes: []agentgraph.Node{{Name: "a", Kind: agentgraph.NodeKindModel, Next: "b"}}},
+		"reserved_name":     {Start: agentgraph.End, Nodes: []agentgraph.Node{{Name: agentgraph.End, Kind: agentgraph.NodeKindModel}}},
+		"tool_without_call": {Start: "a", Nodes: []agentgraph.Node{{Name: "a", Kind: agentgraph.NodeKindTool, Next: agentgraph.End}}},
+	}
+	for name, graph := range graphs {
+		if _, err := agentgraph.New(graph, model, tools, nil); !errors.Is(err, agentgraph.ErrGraphInvalid) {
+			t.Fatalf("%s: expected ErrGraphInvalid, got %v", name, err)
+		}
+	}
+}
+
+func TestConformance_EngineSuite(t *testing.T) {
+	t.Parallel()
+
+	agentconformance.EngineSuite(t, func(t *testing.T) agent.Engine {
+		tools, err := toolingregistry.New(nil)
+		if err != nil {
+			t.Fatalf("new registry: %v", err)
+		}
+		engine, err := agentgraph.New(agentgraph.Graph{
+			Start: "answer",
+			Nodes: []agentgraph.Node{{Name: "answer", Kind: agentgraph.NodeKindModel, Next: agentgraph.End}},
+		}, &scriptedModel{
+			responses: []agent.Message{{Role: agent.RoleAssistant, Content: "done"}},
+		}, tools, nil)
+		if err != nil {
+			t.Fatalf("new engine: %v", err)
+		}
+		return engine
+	})
+}
+
+func TestEngine_HonorsOutputSchemaToolChoiceAndArguments(t *testing.T) {
+	t.Parallel()
+
+	tools, err := toolingregistry.New(map[string]toolingregistry.Handler{
+		"lookup": func(context.Context, map[string]any) (string, error) {
+			return "42", nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("new registry: %v", err)
+	}
+	model := &scriptedModel{
+		responses: []agent.Message{
+			{Role: agent.RoleAssistant, ToolCalls: []agent.ToolCall{{ID: "call-1", Name: "lookup", Arguments: map[string]any{"query": 7}}}},
+			{Role: agent.RoleAssistant, Content: "the answer is 42"},
+			{Role: agent.RoleAssistant, Content: `{"answer": "42"}`},
+		},
+	}
+	events := eventinginmem.New()
+	engine, err := agentgraph.New(agentgraph.Graph{
+		Start: "answer",
+		Nodes: []agentgraph.Node{{Name: "answer", Kind: agentgraph.NodeKindModel, Tools: []string{"lookup"}, Next: agentgraph.End}},
+	}, model, tools, events)
+	if err != nil {
+		t.Fatalf("new engine: %v", err)
+	}
+	schema := map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"answer": map[string]any{"type": "string"}},
+		"required":   []any{"answer"},
+	}
+	choice := agent.ToolChoice{Mode: agent.ToolChoiceRequired}
+
+	result, err := newRunner(t, engine, runstoreinmem.New(), events).Run(context.Background(), agent.RunInput{
+		UserPrompt: "what is the answer?",
+		MaxSteps:   8,
+		Tools: []agent.ToolDefinition{{
+			Name: "lookup",
+			InputSchema: map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"query": map[string]any{"type": "string"}},
+			},
+		}},
+		OutputSchema: schema,
+		ToolChoice:   choice,
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if result.State.Status != agent.RunStatusCompleted || string(result.State.StructuredOutput) != `{"answer":"42"}` {
+		t.Fatalf("final state mismatch: status=%s structured_output=%s", result.State.Status, result.State.StructuredOutput)
+	}
+	if len(model.requests) != 3 {
+		t.Fatalf("model call count mismatch: got=%d want=3", len(model.requests))
+	}
+	for i, request := range model.requests {
+		if request.ToolChoice != choice || len(request.OutputSchema) == 0 {
+			t.Fatalf("request %d mismatch: tool_choice=%+v output_schema=%v", i+1, request.ToolChoice, request.OutputSchema)
+		}
+	}
+	repair := model.requests[2].Messages[len(model.requests[2].Messages)-1]
+	if repair.Role != agent.RoleUser || !strings.HasPrefix(repair.Content, "[output_validation]") {
+		t.Fatalf("repair prompt mismatch: got=%+v", repair)
+	}
+
+	var invalid, repairPublished bool
+	for _, event := range events.Events() {
+		if event.Type == agent.EventTypeToolResult && event.ToolResult.FailureReason == agent.ToolFailureReasonInvalidArguments {
+			invalid = true
+		}
+		if event.Type == agent.EventTypeUserMessage && event.Message.Content == repair.Content {
+			repairPublished = true
+		}
+	}
+	if !repairPublished {
+		t.Fatalf("repair prompt must be published as a user_message event")
+	}
+	if !invalid {
+		t.Fatalf("tool call with invalid arguments must not execute")
+	}
+}
+
+func TestEngine_NamedToolChoiceMustBeOfferedByNode(t *testing.T) {
+	t.Parallel()
+
+	tools, err := toolingregistry.New(map[string]toolingregistry.Handler{})
+	if err != nil {
+		t.Fatalf("new registry: %v", err)
+	}
+	model := &scriptedModel{responses: []agent.Message{{Role: agent.RoleAssistant, Content: "done"}}}
+	engine, err := agentgraph.New(agentgraph.Graph{
+		Start: "answer",
+		Nodes: []agentgraph.Node{{Name: "answer", Kind: agentgraph.NodeKindModel, Tools: []string{"read"}, Next: agentgraph.End}},
+	}, model, tools, nil)
+	if err != nil {
+		t.Fatalf("new engine: %v", err)
+	}
+
+	state, runErr := engine.Execute(context.Background(), agent.RunState{ID: "run-graph", Status: agent.RunStatusPending}, agent.EngineInput{
+		MaxSteps:   4,
+		Tools:      []agent.ToolDefinition{{Name: "read"}, {Name: "write"}},
+		ToolChoice: agent.ToolChoice{Mode: agent.ToolChoiceTool, Name: "write"},
+	})
+	if !errors.Is(runErr, agent.ErrToolChoiceInvalid) {
+		t.Fatalf("expected ErrToolChoiceInvalid, got %v", runErr)
+	}
+	if state.Status != agent.RunStatusFailed || len(model.requests) != 0 {
+		t.Fatalf("run mismatch: status=%s model_calls=%d", state.Status, len(model.requests))
+	}
+}
+
+func TestEngine_RedactsToolResults(t *testing.T) {
+	t.Parallel()
+
+	tools, err := toolingregistry.New(map[string]toolingregistry.Handler{
+		"run_tests": func(context.Context, map[string]any) (string, error) {
+			return "FAIL token=s3cret", nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("new registry: %v", err)
+	}
+	redactor := agent.RedactorFunc(func(text string) string {
+		return strings.ReplaceAll(text, "s3cret", "[REDACTED]")
+	})
+	model := &scriptedModel{responses: []agent.Message{{Role: agent.RoleAssistant, Content: "1. change code"}}}
+	events := eventinginmem.New()
+	engine, err := agentgraph.New(testPipeline(), model, tools, events, agentgraph.WithRedactor(redactor))
+	if err != nil {
+		t.Fatalf("new engine: %v", err)
+	}
+
+	result, err := newRunner(t, engine, runstoreinmem.New(), events).Run(context.Background(), agent.RunInput{
+		UserPrompt: "fix the bug",
+		MaxSteps:   8,
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if result.State.Output != "FAIL token=[REDACTED]" {
+		t.Fatalf("output mismatch: got=%q", result.State.Output)
+	}
+	for _, event := range events.Events() {
+		if event.ToolResult != nil && strings.Contains(event.ToolResult.Content, "s3cret") {
+			t.Fatalf("tool_result event leaks the secret: %q", event.ToolResult.Content)
+		}
+	}
+}
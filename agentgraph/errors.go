@@ -0,0 +1,16 @@
+package agentgraph
+
+import "errors"
+
+var (
+	// ErrMissingModel is returned when New is called without a model dependency.
+	ErrMissingModel = errors.New("missing model")
+	// ErrMissingToolExecutor is returned when New is called without a tool executor dependency.
+	ErrMissingToolExecutor = errors.New("missing tool executor")
+	// ErrGraphInvalid is returned when a graph definition is structurally invalid.
+	ErrGraphInvalid = errors.New("graph is invalid")
+	// ErrUnknownNode is returned when execution reaches a node name the graph does not define.
+	ErrUnknownNode = errors.New("graph node is unknown")
+	// ErrToolCallInvalid is returned when a node produces an invalid tool call shape.
+	ErrToolCallInvalid = errors.New("tool call is invalid")
+)
@@ -0,0 +1,98 @@
+package agentgraph
+
+import (
+	"fmt"
+
+	"github.com/Gurpartap/agentframe/agent"
+)
+
+// End is the reserved node name that finishes a run when entered.
+const End = "end"
+
+// NodeKind classifies what a node does when the cursor reaches it.
+type NodeKind string
+
+const (
+	// NodeKindModel asks the model for the next assistant message. Tool calls
+	// returned by the model are executed and the node runs again, until the
+	// model answers without tool calls.
+	NodeKindModel NodeKind = "model"
+	// NodeKindTool issues exactly one tool call built from run state.
+	NodeKindTool NodeKind = "tool"
+	// NodeKindBranch selects the next node from run state without side effects.
+	NodeKindBranch NodeKind = "branch"
+)
+
+// Node is one vertex of a workflow graph.
+type Node struct {
+	Name string
+	Kind NodeKind
+	// Next is the node entered after a model or tool node finishes.
+	Next string
+	// Prompt is appended as a user message when a model node is entered.
+	Prompt string
+	// Tools names the run tool definitions offered to the model by a model node.
+	Tools []string
+	// Call builds the tool call issued by a tool node.
+	Call func(state agent.RunState) (agent.ToolCall, error)
+	// Branch returns the name of the next node for a branch node.
+	Branch func(state agent.RunState) (string, error)
+}
+
+// Graph is a named set of nodes with a start node.
+type Graph struct {
+	Start string
+	Nodes []Node
+}
+
+func indexGraph(graph Graph) (map[string]Node, error) {
+	if graph.Start == "" {
+		return nil, fmt.Errorf("%w: field=start reason=empty", ErrGraphInvalid)
+	}
+	nodes := make(map[string]Node, len(graph.Nodes))
+	for i, node := range graph.Nodes {
+		if node.Name == "" {
+			return nil, fmt.Errorf("%w: field=nodes[%d].name reason=empty", ErrGraphInvalid, i)
+		}
+		if node.Name == End {
+			return nil, fmt.Errorf("%w: field=nodes[%d].name reason=reserved value=%q", ErrGraphInvalid, i, node.Name)
+		}
+		if _, exists := nodes[node.Name]; exists {
+			return nil, fmt.Errorf("%w: field=nodes[%d].name reason=duplicate value=%q", ErrGraphInvalid, i, node.Name)
+		}
+		switch node.Kind {
+		case NodeKindModel:
+		case NodeKindTool:
+			if node.Call == nil {
+				return nil, fmt.Errorf("%w: field=nodes[%d].call reason=nil node=%q", ErrGraphInvalid, i, node.Name)
+			}
+		case NodeKindBranch:
+			if node.Branch == nil {
+				return nil, fmt.Errorf("%w: field=nodes[%d].branch reason=nil node=%q", ErrGraphInvalid, i, node.Name)
+			}
+		default:
+			return nil, fmt.Errorf("%w: field=nodes[%d].kind reason=unknown value=%q", ErrGraphInvalid, i, node.Kind)
+		}
+		nodes[node.Name] = node
+	}
+	if _, ok := nodes[graph.Start]; !ok {
+		return nil, fmt.Errorf("%w: field=start reason=unknown_node value=%q", ErrGraphInvalid, graph.Start)
+	}
+	for _, node := range nodes {
+		if node.Kind == NodeKindBranch {
+			continue
+		}
+		if !isKnownTarget(nodes, node.Next) {
+			return nil, fmt.Errorf("%w: field=next reason=unknown_node node=%q value=%q", ErrGraphInvalid, node.Name, node.Next)
+		}
+	}
+	return nodes, nil
+}
+
+func isKnownTarget(nodes map[string]Node, name string) bool {
+	if name == End {
+		return true
+	}
+	_, ok := nodes[name]
+	return ok
+}
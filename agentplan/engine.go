@@ -0,0 +1,328 @@
+// Package agentplan implements a plan-and-execute agent.Engine: the model first
+// writes a plan, each plan step then runs as a bounded inner ReAct loop, and a
+// failed step triggers a revised plan for the remaining work.
+package agentplan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Gurpartap/agentframe/agent"
+	"github.com/Gurpartap/agentframe/agentreact"
+	"github.com/Gurpartap/agentframe/internal/enginekit"
+)
+
+const (
+	// DefaultMaxSteps bounds model calls across the whole run when EngineInput.MaxSteps is unset.
+	DefaultMaxSteps = 32
+	// DefaultStepIterations bounds model calls of the inner ReAct loop per plan step.
+	DefaultStepIterations = 4
+	// DefaultMaxReplans bounds plan revisions after failed steps.
+	DefaultMaxReplans = 2
+)
+
+// Config tunes planning and step execution. Zero values use the defaults.
+type Config struct {
+	StepIterations int
+	MaxReplans     int
+	// PlanningPrompt replaces the instruction that asks the model for the initial plan.
+	PlanningPrompt string
+}
+
+// Engine plans a run, then executes each plan step with an inner ReactLoop.
+// The plan and its progress are persisted in RunState.EngineState and
+// published as plan_updated events.
+type Engine struct {
+	model          agentreact.Model
+	loop           *agentreact.ReactLoop
+	events         agent.EventSink
+	stepIterations int
+	maxReplans     int
+	planningPrompt string
+}
+
+// New builds a plan engine. loopOptions configure the inner ReactLoop that
+// executes each plan step, for example agentreact.WithToolSelector or
+// agentreact.WithRedactor.
+func New(
+	cfg Config,
+	model agentreact.Model,
+	tools agentreact.ToolExecutor,
+	events agent.EventSink,
+	loopOptions ...agentreact.Option,
+) (*Engine, error) {
+	if model == nil {
+		return nil, fmt.Errorf("new plan engine: %w", ErrMissingModel)
+	}
+	if tools == nil {
+		return nil, fmt.Errorf("new plan engine: %w", ErrMissingToolExecutor)
+	}
+	if events == nil {
+		events = enginekit.NoopEventSink{}
+	}
+	loop, err := agentreact.New(model, tools, stepEventSink{next: events}, loopOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("new plan engine: %w", err)
+	}
+	engine := &Engine{
+		model:          model,
+		loop:           loop,
+		events:         events,
+		stepIterations: cfg.StepIterations,
+		maxReplans:     cfg.MaxReplans,
+		planningPrompt: strings.TrimSpace(cfg.PlanningPrompt),
+	}
+	if engine.stepIterations <= 0 {
+		engine.stepIterations = DefaultStepIterations
+	}
+	if engine.maxReplans <= 0 {
+		engine.maxReplans = DefaultMaxReplans
+	}
+	if engine.planningPrompt == "" {
+		engine.planningPrompt = defaultPlanningPrompt
+	}
+	return engine, nil
+}
+
+func (e *Engine) Execute(ctx context.Context, state agent.RunState, input agent.EngineInput) (agent.RunState, error) {
+	if ctx == nil {
+		return state, agent.ErrContextNil
+	}
+	if err := agent.ValidateRunState(state); err != nil {
+		return state, err
+	}
+	if state.Status == agent.RunStatusSuspended {
+		return state, fmt.Errorf(
+			"%w: run_id=%q reason=continue_requires_resolution",
+			agent.ErrResolutionRequired,
+			state.ID,
+		)
+	}
+	plan, err := decodePlanState(state)
+	if err != nil {
+		return state, err
+	}
+
+	maxSteps := input.MaxSteps
+	if maxSteps <= 0 {
+		maxSteps = DefaultMaxSteps
+	}
+	var eventErr error
+
+	if err := agent.TransitionRunStatus(&state, agent.RunStatusRunning); err != nil {
+		return state, errors.Join(err, eventErr)
+	}
+
+	if !plan.planned() {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return enginekit.CancelRun(ctx, e.events, state, ctxErr, eventErr)
+		}
+		if state.Step >= maxSteps {
+			return enginekit.ExceedMaxSteps(ctx, e.events, state, eventErr)
+		}
+		titles, err := e.requestPlan(ctx, &state, e.planningPrompt, &eventErr)
+		if err != nil {
+			return e.abortRun(ctx, state, err, eventErr)
+		}
+		plan.Plan = agent.Plan{Revision: 1, Steps: pendingSteps(titles)}
+		eventErr = errors.Join(eventErr, e.updatePlan(ctx, &state, plan))
+	}
+
+	resolution := input.Resolution
+	resolvedRequirement := input.ResolvedRequirement
+	for plan.Current < len(plan.Plan.Steps) {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return enginekit.CancelRun(ctx, e.events, state, ctxErr, eventErr)
+		}
+		step := &plan.Plan.Steps[plan.Current]
+		// The last step answers for the whole run, so it carries the output
+		// schema; earlier steps answer with free-form summaries.
+		var outputSchema map[string]any
+		if plan.Current == len(plan.Plan.Steps)-1 {
+			outputSchema = input.OutputSchema
+		}
+		if step.Status == agent.PlanStepStatusPending {
+			step.Status = agent.PlanStepStatusInProgress
+			plan.StepStartedAt = state.Step
+			prompt := fmt.Sprintf(stepPromptFormat, plan.Current+1, len(plan.Plan.Steps), step.Title, FailureMarker)
+			if len(outputSchema) > 0 {
+				prompt = fmt.Sprintf(finalStepPromptFormat, plan.Current+1, len(plan.Plan.Steps), step.Title)
+			}
+			state.Messages = append(state.Messages, agent.Message{
+				Role:    agent.RoleUser,
+				Content: prompt,
+			})
+			eventErr = errors.Join(eventErr, e.updatePlan(ctx, &state, plan))
+		}
+		if state.Step >= maxSteps {
+			return enginekit.ExceedMaxSteps(ctx, e.events, state, eventErr)
+		}
+
+		next, innerErr := e.loop.Execute(ctx, state, agent.EngineInput{
+			MaxSteps:            min(plan.StepStartedAt+e.stepIterations, maxSteps),
+			Tools:               agent.CloneToolDefinitions(input.Tools),
+			Resolution:          resolution,
+			ResolvedRequirement: resolvedRequirement,
+			OutputSchema:        agent.CloneSchema(outputSchema),
+			ToolChoice:          input.ToolChoice,
+		})
+		resolution, resolvedRequirement = nil, nil
+		state.Messages = next.Messages
+		state.Step = next.Step
+
+		var failureReason string
+		switch next.Status {
+		case agent.RunStatusCompleted:
+			eventErr = errors.Join(eventErr, innerErr)
+			answer := strings.TrimSpace(next.Output)
+			if reason, failed := strings.CutPrefix(answer, FailureMarker); failed {
+				failureReason = strings.TrimSpace(reason)
+				break
+			}
+			step.Status = agent.PlanStepStatusCompleted
+			plan.LastOutput = next.Output
+			state.StructuredOutput = next.StructuredOutput
+			plan.Current++
+			eventErr = errors.Join(eventErr, e.updatePlan(ctx, &state, plan))
+			continue
+		case agent.RunStatusSuspended:
+			eventErr = errors.Join(eventErr, innerErr)
+			state.PendingRequirement = next.PendingRequirement
+			if err := agent.TransitionRunStatus(&state, agent.RunStatusSuspended); err != nil {
+				state.PendingRequirement = nil
+				return enginekit.FailRun(ctx, e.events, state, err, eventErr)
+			}
+			return state, eventErr
+		case agent.RunStatusMaxStepsExceeded:
+			if state.Step >= maxSteps {
+				return enginekit.ExceedMaxSteps(ctx, e.events, state, eventErr)
+			}
+			failureReason = fmt.Sprintf("step used its budget of %d model calls", e.stepIterations)
+		case agent.RunStatusCancelled:
+			return enginekit.CancelRun(ctx, e.events, state, cancellationCause(ctx, innerErr), eventErr)
+		default:
+			return enginekit.FailRun(ctx, e.events, state, innerErr, eventErr)
+		}
+
+		step.Status = agent.PlanStepStatusFailed
+		eventErr = errors.Join(eventErr, e.updatePlan(ctx, &state, plan))
+		if plan.Replans >= e.maxReplans {
+			return enginekit.FailRun(ctx, e.events, state, fmt.Errorf(
+				"%w: step=%q replans=%d reason=%q",
+				ErrReplanLimitExceeded,
+				step.Title,
+				plan.Replans,
+				failureReason,
+			), eventErr)
+		}
+		if state.Step >= maxSteps {
+			return enginekit.ExceedMaxSteps(ctx, e.events, state, eventErr)
+		}
+		titles, err := e.requestPlan(ctx, &state, fmt.Sprintf(defaultReplanningPrompt, step.Title, failureReason), &eventErr)
+		if err != nil {
+			return e.abortRun(ctx, state, err, eventErr)
+		}
+		plan.Replans++
+		plan.Plan.Revision++
+		plan.Current++
+		plan.Plan.Steps = append(plan.Plan.Steps[:plan.Current], pendingSteps(titles)...)
+		eventErr = errors.Join(eventErr, e.updatePlan(ctx, &state, plan))
+	}
+
+	if err := agent.TransitionRunStatus(&state, agent.RunStatusCompleted); err != nil {
+		return state, errors.Join(err, eventErr)
+	}
+	state.Output = plan.LastOutput
+	eventErr = errors.Join(eventErr, enginekit.PublishEvent(ctx, e.events, agent.Event{
+		RunID:       state.ID,
+		Step:        state.Step,
+		Type:        agent.EventTypeRunCompleted,
+		Description: "all plan steps completed",
+	}))
+	return state, eventErr
+}
+
+// requestPlan asks the model for a list of step titles. It consumes one run
+// step and joins publish failures into eventErr.
+func (e *Engine) requestPlan(ctx context.Context, state *agent.RunState, prompt string, eventErr *error) ([]string, error) {
+	state.Step++
+	state.Messages = append(state.Messages, agent.Message{Role: agent.RoleUser, Content: prompt})
+	assistant, err := e.model.Generate(ctx, agentreact.ModelRequest{
+		Messages: agent.CloneMessages(state.Messages),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if assistant.Role == "" {
+		assistant.Role = agent.RoleAssistant
+	}
+	state.Messages = append(state.Messages, agent.CloneMessage(assistant))
+	*eventErr = errors.Join(*eventErr, enginekit.PublishEvent(ctx, e.events, agent.Event{
+		RunID:   state.ID,
+		Step:    state.Step,
+		Type:    agent.EventTypeAssistantMessage,
+		Message: &assistant,
+	}))
+	if len(assistant.ToolCalls) > 0 || assistant.Requirement != nil {
+		return nil, fmt.Errorf("%w: reason=planning_response_must_be_text", ErrPlanInvalid)
+	}
+	return parsePlan(assistant.Content)
+}
+
+// updatePlan persists plan progress on state and publishes it.
+func (e *Engine) updatePlan(ctx context.Context, state *agent.RunState, plan planState) error {
+	if err := encodePlanState(state, plan); err != nil {
+		return err
+	}
+	completed := 0
+	for _, step := range plan.Plan.Steps {
+		if step.Status == agent.PlanStepStatusCompleted {
+			completed++
+		}
+	}
+	return enginekit.PublishEvent(ctx, e.events, agent.Event{
+		RunID:       state.ID,
+		Step:        state.Step,
+		Type:        agent.EventTypePlanUpdated,
+		Description: fmt.Sprintf("plan revision %d: %d of %d steps completed", plan.Plan.Revision, completed, len(plan.Plan.Steps)),
+		Plan:        agent.ClonePlan(&plan.Plan),
+	})
+}
+
+func (e *Engine) abortRun(ctx context.Context, state agent.RunState, runErr error, eventErr error) (agent.RunState, error) {
+	if cancellationErr := enginekit.ContextCancellationError(ctx, runErr); cancellationErr != nil {
+		return enginekit.CancelRun(ctx, e.events, state, cancellationErr, eventErr)
+	}
+	return enginekit.FailRun(ctx, e.events, state, runErr, eventErr)
+}
+
+func pendingSteps(titles []string) []agent.PlanStep {
+	steps := make([]agent.PlanStep, len(titles))
+	for i, title := range titles {
+		steps[i] = agent.PlanStep{Title: title, Status: agent.PlanStepStatusPending}
+	}
+	return steps
+}
+
+// stepEventSink forwards inner loop events except terminal run events, which
+// only describe the end of one plan step rather than the run.
+type stepEventSink struct {
+	next agent.EventSink
+}
+
+func (s stepEventSink) Publish(ctx context.Context, event agent.Event) error {
+	switch event.Type {
+	case agent.EventTypeRunCompleted, agent.EventTypeRunFailed, agent.EventTypeRunCancelled:
+		return nil
+	}
+	return s.next.Publish(ctx, event)
+}
+
+func cancellationCause(ctx context.Context, err error) error {
+	if cancellationErr := enginekit.ContextCancellationError(ctx, err); cancellationErr != nil {
+		return cancellationErr
+	}
+	return err
+}
@@ -0,0 +1,432 @@
+package agentplan_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/Gurpartap/agentframe/agent"
+	"github.com/Gurpartap/agentframe/agentconformance"
+	"github.com/Gurpartap/agentframe/agentplan"
+	"github.com/Gurpartap/agentframe/agentreact"
+	eventinginmem "github.com/Gurpartap/agentframe/eventing/inmem"
+	runstoreinmem "github.com/Gurpartap/agentframe/runstore/inmem"
+	toolingregistry "github.com/Gurpartap/agentframe/tooling/registry"
+)
+
+type scriptedModel struct {
+	mu        sync.Mutex
+	index     int
+	responses []agent.Message
+}
+
+func (m *scriptedModel) Generate(_ context.Context, _ agentreact.ModelRequest) (agent.Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.index >= len(m.responses) {
+		return agent.Message{}, fmt.Errorf("script exhausted at step %d", m.index+1)
+	}
+	msg := agent.CloneMessage(m.responses[m.index])
+	m.index++
+	return msg, nil
+}
+
+type requestRecordingModel struct {
+	mu       sync.Mutex
+	next     agentreact.Model
+	requests []agentreact.ModelRequest
+}
+
+func (m *requestRecordingModel) Generate(ctx context.Context, request agentreact.ModelRequest) (agent.Message, error) {
+	m.mu.Lock()
+	m.requests = append(m.requests, request)
+	m.mu.Unlock()
+	return m.next.Generate(ctx, request)
+}
+
+type staticIDGenerator struct{}
+
+func (staticIDGenerator) NewRunID(context.Context) (agent.RunID, error) {
+	return "run-plan", nil
+}
+
+func answer(content string) agent.Message {
+	return agent.Message{Role: agent.RoleAssistant, Content: content}
+}
+
+func newRunner(
+	t *testing.T,
+	cfg agentplan.Config,
+	model agentreact.Model,
+	tools agentreact.ToolExecutor,
+	loopOptions ...agentreact.Option,
+) (*agent.Runner, *eventinginmem.Sink) {
+	t.Helper()
+
+	events := eventinginmem.New()
+	engine, err := agentplan.New(cfg, model, tools, events, loopOptions...)
+	if err != nil {
+		t.Fatalf("new engine: %v", err)
+	}
+	runner, err := agent.NewRunner(agent.Dependencies{
+		IDGenerator: staticIDGenerator{},
+		RunStore:    runstoreinmem.New(),
+		Engine:      engine,
+		EventSink:   events,
+	})
+	if err != nil {
+		t.Fatalf("new runner: %v", err)
+	}
+	return runner, events
+}
+
+func noTools(t *testing.T) agentreact.ToolExecutor {
+	t.Helper()
+
+	registry, err := toolingregistry.New(nil)
+	if err != nil {
+		t.Fatalf("new registry: %v", err)
+	}
+	return registry
+}
+
+func planSnapshots(events []agent.Event) []agent.Plan {
+	var plans []agent.Plan
+	for _, event := range events {
+		if event.Type == agent.EventTypePlanUpdated {
+			plans = append(plans, *event.Plan)
+		}
+	}
+	return plans
+}
+
+func stepStatuses(plan agent.Plan) []agent.PlanStepStatus {
+	statuses := make([]agent.PlanStepStatus, len(plan.Steps))
+	for i, step := range plan.Steps {
+		statuses[i] = step.Status
+	}
+	return statuses
+}
+
+func TestEngine_ExecutesPlanStepsInOrder(t *testing.T) {
+	t.Parallel()
+
+	tools, err := toolingregistry.New(map[string]toolingregistry.Handler{
+		"lookup": func(context.Context, map[string]any) (string, error) {
+			return "found", nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("new registry: %v", err)
+	}
+	model := &scriptedModel{
+		responses: []agent.Message{
+			answer(`{"steps":["research","summarize"]}`),
+			{
+				Role:      agent.RoleAssistant,
+				ToolCalls: []agent.ToolCall{{ID: "call-1", Name: "lookup", Arguments: map[string]any{}}},
+			},
+			answer("research done"),
+			answer("final summary"),
+		},
+	}
+	runner, events := newRunner(t, agentplan.Config{}, model, tools)
+
+	result, err := runner.Run(context.Background(), agent.RunInput{
+		UserPrompt: "explain Go",
+		MaxSteps:   10,
+		Tools:      []agent.ToolDefinition{{Name: "lookup"}},
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if result.State.Status != agent.RunStatusCompleted || result.State.Output != "final summary" {
+		t.Fatalf("final state mismatch: status=%s output=%q", result.State.Status, result.State.Output)
+	}
+
+	plans := planSnapshots(events.Events())
+	want := [][]agent.PlanStepStatus{
+		{agent.PlanStepStatusPending, agent.PlanStepStatusPending},
+		{agent.PlanStepStatusInProgress, agent.PlanStepStatusPending},
+		{agent.PlanStepStatusCompleted, agent.PlanStepStatusPending},
+		{agent.PlanStepStatusCompleted, agent.PlanStepStatusInProgress},
+		{agent.PlanStepStatusCompleted, agent.PlanStepStatusCompleted},
+	}
+	if len(plans) != len(want) {
+		t.Fatalf("plan update count mismatch: got=%d want=%d", len(plans), len(want))
+	}
+	for i := range want {
+		if got := stepStatuses(plans[i]); !reflect.DeepEqual(got, want[i]) {
+			t.Fatalf("plan update %d statuses mismatch: got=%v want=%v", i, got, want[i])
+		}
+	}
+
+	completedEvents := 0
+	for _, event := range events.Events() {
+		if event.Type == agent.EventTypeRunCompleted {
+			completedEvents++
+		}
+	}
+	if completedEvents != 1 {
+		t.Fatalf("run_completed must be published once for the run, got %d", completedEvents)
+	}
+}
+
+func TestEngine_LastStepProducesStructuredOutput(t *testing.T) {
+	t.Parallel()
+
+	model := &requestRecordingModel{next: &scriptedModel{
+		responses: []agent.Message{
+			answer(`{"steps":["research","summarize"]}`),
+			answer("research done"),
+			answer(`{"summary":"Go is simple"}`),
+		},
+	}}
+	runner, _ := newRunner(t, agentplan.Config{}, model, noTools(t))
+	schema := map[string]any{
+		"required":   []any{"summary"},
+		"properties": map[string]any{"summary": map[string]any{"type": "string"}},
+	}
+
+	result, err := runner.Run(context.Background(), agent.RunInput{
+		UserPrompt:   "explain Go",
+		MaxSteps:     10,
+		OutputSchema: schema,
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if got := string(result.State.StructuredOutput); got != `{"summary":"Go is simple"}` {
+		t.Fatalf("structured output mismatch: got=%s", got)
+	}
+
+	wantSchema := []bool{false, false, true}
+	if len(model.requests) != len(wantSchema) {
+		t.Fatalf("model call count mismatch: got=%d want=%d", len(model.requests), len(wantSchema))
+	}
+	for i, request := range model.requests {
+		if got := len(request.OutputSchema) > 0; got != wantSchema[i] {
+			t.Fatalf("output schema at call %d mismatch: got=%v want=%v", i+1, got, wantSchema[i])
+		}
+	}
+}
+
+func TestEngine_PassesLoopOptionsToStepLoop(t *testing.T) {
+	t.Parallel()
+
+	var selections int
+	selector := agentreact.ToolSelectorFunc(func(context.Context, agentreact.ToolSelectionRequest) ([]string, error) {
+		selections++
+		return []string{}, nil
+	})
+	model := &scriptedModel{
+		responses: []agent.Message{
+			answer(`{"steps":["research"]}`),
+			answer("research done"),
+		},
+	}
+	runner, _ := newRunner(t, agentplan.Config{}, model, noTools(t), agentreact.WithToolSelector(selector))
+
+	result, err := runner.Run(context.Background(), agent.RunInput{
+		UserPrompt: "explain Go",
+		MaxSteps:   10,
+		Tools:      []agent.ToolDefinition{{Name: "lookup"}},
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if result.State.Status != agent.RunStatusCompleted {
+		t.Fatalf("status mismatch: got=%s want=%s", result.State.Status, agent.RunStatusCompleted)
+	}
+	if selections != 1 {
+		t.Fatalf("tool selector call count mismatch: got=%d want=1", selections)
+	}
+}
+
+func TestEngine_PassesToolChoiceToSteps(t *testing.T) {
+	t.Parallel()
+
+	model := &requestRecordingModel{next: &scriptedModel{
+		responses: []agent.Message{
+			answer(`{"steps":["research"]}`),
+			answer("research done"),
+		},
+	}}
+	runner, _ := newRunner(t, agentplan.Config{}, model, noTools(t))
+	choice := agent.ToolChoice{Mode: agent.ToolChoiceTool, Name: "lookup"}
+
+	result, err := runner.Run(context.Background(), agent.RunInput{
+		UserPrompt: "explain Go",
+		MaxSteps:   10,
+		Tools:      []agent.ToolDefinition{{Name: "lookup"}},
+		ToolChoice: choice,
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if result.State.Status != agent.RunStatusCompleted {
+		t.Fatalf("status mismatch: got=%s want=%s", result.State.Status, agent.RunStatusCompleted)
+	}
+	if len(model.requests) != 2 {
+		t.Fatalf("model call count mismatch: got=%d want=2", len(model.requests))
+	}
+	if got := model.requests[1].ToolChoice; got != choice {
+		t.Fatalf("step tool choice mismatch: got=%+v want=%+v", got, choice)
+	}
+}
+
+func TestEngine_ReplansAfterFailedStep(t *testing.T) {
+	t.Parallel()
+
+	model := &scriptedModel{
+		responses: []agent.Message{
+			answer(`{"steps":["build"]}`),
+			answer(agentplan.FailureMarker + " missing dependency"),
+			answer("```json\n{\"steps\":[{\"title\":\"install dependency\"},{\"title\":\"build\"}]}\n```"),
+			answer("installed"),
+			answer("built"),
+		},
+	}
+	runner, events := newRunner(t, agentplan.Config{}, model, noTools(t))
+
+	result, err := runner.Run(context.Background(), agent.RunInput{UserPrompt: "build it", MaxSteps: 10})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if result.State.Status != agent.RunStatusCompleted || result.State.Output != "built" {
+		t.Fatalf("final state mismatch: status=%s output=%q", result.State.Status, result.State.Output)
+	}
+
+	plans := planSnapshots(events.Events())
+	last := plans[len(plans)-1]
+	if last.Revision != 2 {
+		t.Fatalf("plan revision mismatch: got=%d want=2", last.Revision)
+	}
+	wantTitles := []string{"build", "install dependency", "build"}
+	wantStatuses := []agent.PlanStepStatus{agent.PlanStepStatusFailed, agent.PlanStepStatusCompleted, agent.PlanStepStatusCompleted}
+	for i, step := range last.Steps {
+		if step.Title != wantTitles[i] || step.Status != wantStatuses[i] {
+			t.Fatalf("step %d mismatch: got=%+v want title=%q status=%s", i, step, wantTitles[i], wantStatuses[i])
+		}
+	}
+}
+
+func TestEngine_FailsWhenReplanLimitExceeded(t *testing.T) {
+	t.Parallel()
+
+	model := &scriptedModel{
+		responses: []agent.Message{
+			answer(`{"steps":["build"]}`),
+			answer(agentplan.FailureMarker + " broken"),
+			answer(`{"steps":["build again"]}`),
+			answer(agentplan.FailureMarker + " still broken"),
+		},
+	}
+	runner, _ := newRunner(t, agentplan.Config{MaxReplans: 1}, model, noTools(t))
+
+	result, err := runner.Run(context.Background(), agent.RunInput{UserPrompt: "build it", MaxSteps: 10})
+	if !errors.Is(err, agentplan.ErrReplanLimitExceeded) {
+		t.Fatalf("expected ErrReplanLimitExceeded, got %v", err)
+	}
+	if result.State.Status != agent.RunStatusFailed {
+		t.Fatalf("status mismatch: got=%s want=%s", result.State.Status, agent.RunStatusFailed)
+	}
+}
+
+func TestEngine_ResumesStepAfterApproval(t *testing.T) {
+	t.Parallel()
+
+	executions := 0
+	tools, err := toolingregistry.New(map[string]toolingregistry.Handler{
+		"deploy": func(ctx context.Context, _ map[string]any) (string, error) {
+			if _, approved := agent.ApprovedToolCallReplayOverrideFromContext(ctx); !approved {
+				return "", &agent.SuspendRequestError{
+					Requirement: &agent.PendingRequirement{
+						ID:          "req-deploy",
+						Kind:        agent.RequirementKindApproval,
+						Origin:      agent.RequirementOriginTool,
+						Fingerprint: "fp-deploy",
+					},
+					Err: errors.New("deploy requires approval"),
+				}
+			}
+			executions++
+			return "deployed", nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("new registry: %v", err)
+	}
+	model := &scriptedModel{
+		responses: []agent.Message{
+			answer(`{"steps":["deploy"]}`),
+			{
+				Role:      agent.RoleAssistant,
+				ToolCalls: []agent.ToolCall{{ID: "call-1", Name: "deploy", Arguments: map[string]any{}}},
+			},
+			answer("deployment complete"),
+		},
+	}
+	runner, _ := newRunner(t, agentplan.Config{}, model, tools)
+	definitions := []agent.ToolDefinition{{Name: "deploy"}}
+
+	suspended, err := runner.Run(context.Background(), agent.RunInput{UserPrompt: "ship", MaxSteps: 10, Tools: definitions})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if suspended.State.Status != agent.RunStatusSuspended {
+		t.Fatalf("status mismatch: got=%s want=%s", suspended.State.Status, agent.RunStatusSuspended)
+	}
+
+	resumed, err := runner.Continue(context.Background(), "run-plan", 10, definitions, &agent.Resolution{
+		RequirementID: "req-deploy",
+		Kind:          agent.RequirementKindApproval,
+		Outcome:       agent.ResolutionOutcomeApproved,
+	})
+	if err != nil {
+		t.Fatalf("continue: %v", err)
+	}
+	if resumed.State.Status != agent.RunStatusCompleted || resumed.State.Output != "deployment complete" {
+		t.Fatalf("resumed state mismatch: status=%s output=%q", resumed.State.Status, resumed.State.Output)
+	}
+	if executions != 1 {
+		t.Fatalf("approved tool execution count mismatch: got=%d want=1", executions)
+	}
+}
+
+func TestEngine_RejectsUnparseablePlan(t *testing.T) {
+	t.Parallel()
+
+	runner, _ := newRunner(t, agentplan.Config{}, &scriptedModel{
+		responses: []agent.Message{answer("I will just do it")},
+	}, noTools(t))
+
+	result, err := runner.Run(context.Background(), agent.RunInput{UserPrompt: "build it"})
+	if !errors.Is(err, agentplan.ErrPlanInvalid) {
+		t.Fatalf("expected ErrPlanInvalid, got %v", err)
+	}
+	if result.State.Status != agent.RunStatusFailed {
+		t.Fatalf("status mismatch: got=%s want=%s", result.State.Status, agent.RunStatusFailed)
+	}
+}
+
+func TestConformance_EngineSuite(t *testing.T) {
+	t.Parallel()
+
+	agentconformance.EngineSuite(t, func(t *testing.T) agent.Engine {
+		engine, err := agentplan.New(agentplan.Config{}, &scriptedModel{
+			responses: []agent.Message{
+				answer(`{"steps":["answer"]}`),
+				answer("done"),
+			},
+		}, noTools(t), nil)
+		if err != nil {
+			t.Fatalf("new engine: %v", err)
+		}
+		return engine
+	})
+}
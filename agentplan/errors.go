@@ -0,0 +1,14 @@
+package agentplan
+
+import "errors"
+
+var (
+	// ErrMissingModel is returned when New is called without a model dependency.
+	ErrMissingModel = errors.New("missing model")
+	// ErrMissingToolExecutor is returned when New is called without a tool executor dependency.
+	ErrMissingToolExecutor = errors.New("missing tool executor")
+	// ErrPlanInvalid is returned when the model response cannot be parsed into a plan.
+	ErrPlanInvalid = errors.New("plan is invalid")
+	// ErrReplanLimitExceeded is returned when a step fails after all replans are used.
+	ErrReplanLimitExceeded = errors.New("replan limit exceeded")
+)
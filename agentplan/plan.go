@@ -0,0 +1,112 @@
+package agentplan
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Gurpartap/agentframe/agent"
+)
+
+const (
+	defaultPlanningPrompt = "Before doing any work, break the task into a short ordered list of concrete steps. " +
+		`Respond with JSON only, in the form {"steps":["first step","second step"]}.`
+
+	defaultReplanningPrompt = "Plan step %q failed: %s\n" +
+		"Produce a revised plan for the remaining work only. " +
+		`Respond with JSON only, in the form {"steps":["next step","following step"]}.`
+
+	stepPromptFormat = "Execute plan step %d of %d: %s\n" +
+		"Reply with a short summary once the step is done. " +
+		"If the step cannot be completed, start your reply with %q followed by the reason."
+
+	finalStepPromptFormat = "Execute plan step %d of %d: %s\n" +
+		"This is the last step: reply with the final answer for the whole task, " +
+		"as a JSON object matching the requested output schema."
+
+	// FailureMarker prefixes a step answer that reports the step as failed.
+	FailureMarker = "FAILED:"
+)
+
+// planState is the engine state persisted in RunState.EngineState.
+type planState struct {
+	Plan agent.Plan `json:"plan"`
+	// Current indexes the step being executed; it equals len(Plan.Steps) once done.
+	Current int `json:"current"`
+	// StepStartedAt is the run step at which the current step began executing.
+	StepStartedAt int `json:"step_started_at"`
+	Replans       int `json:"replans"`
+	// LastOutput is the final answer of the most recently completed step.
+	LastOutput string `json:"last_output,omitempty"`
+}
+
+func (s planState) planned() bool {
+	return len(s.Plan.Steps) > 0
+}
+
+// parsePlan extracts step titles from a JSON object of the form
+// {"steps":[...]}, tolerating prose or code fences around the object.
+func parsePlan(content string) ([]string, error) {
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("%w: reason=no_json_object", ErrPlanInvalid)
+	}
+	var payload struct {
+		Steps []json.RawMessage `json:"steps"`
+	}
+	if err := json.Unmarshal([]byte(content[start:end+1]), &payload); err != nil {
+		return nil, fmt.Errorf("%w: reason=invalid_json: %w", ErrPlanInvalid, err)
+	}
+	titles := make([]string, 0, len(payload.Steps))
+	for i, raw := range payload.Steps {
+		var title string
+		if err := json.Unmarshal(raw, &title); err != nil {
+			var object struct {
+				Title string `json:"title"`
+			}
+			if err := json.Unmarshal(raw, &object); err != nil {
+				return nil, fmt.Errorf("%w: field=steps[%d] reason=not_a_string_or_object", ErrPlanInvalid, i)
+			}
+			title = object.Title
+		}
+		title = strings.TrimSpace(title)
+		if title == "" {
+			return nil, fmt.Errorf("%w: field=steps[%d] reason=empty", ErrPlanInvalid, i)
+		}
+		titles = append(titles, title)
+	}
+	if len(titles) == 0 {
+		return nil, fmt.Errorf("%w: field=steps reason=empty", ErrPlanInvalid)
+	}
+	return titles, nil
+}
+
+func decodePlanState(state agent.RunState) (planState, error) {
+	if len(state.EngineState) == 0 {
+		return planState{}, nil
+	}
+	var decoded planState
+	if err := json.Unmarshal(state.EngineState, &decoded); err != nil {
+		return planState{}, fmt.Errorf("%w: field=engine_state reason=invalid_plan run_id=%q: %w", agent.ErrRunStateInvalid, state.ID, err)
+	}
+	if decoded.Current < 0 || decoded.Current > len(decoded.Plan.Steps) {
+		return planState{}, fmt.Errorf(
+			"%w: field=engine_state.current reason=out_of_range value=%d steps=%d run_id=%q",
+			agent.ErrRunStateInvalid,
+			decoded.Current,
+			len(decoded.Plan.Steps),
+			state.ID,
+		)
+	}
+	return decoded, nil
+}
+
+func encodePlanState(state *agent.RunState, plan planState) error {
+	encoded, err := json.Marshal(plan)
+	if err != nil {
+		return fmt.Errorf("encode plan state: %w", err)
+	}
+	state.EngineState = encoded
+	return nil
+}
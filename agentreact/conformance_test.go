@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/Gurpartap/agentframe/agent"
+	"github.com/Gurpartap/agentframe/agentconformance"
 	"github.com/Gurpartap/agentframe/agentreact"
 )
 
@@ -2091,3 +2092,18 @@ func countEventType(events []agent.Event, want agent.EventType) int {
 	}
 	return count
 }
+
+func TestConformance_EngineSuite(t *testing.T) {
+	t.Parallel()
+
+	agentconformance.EngineSuite(t, func(t *testing.T) agent.Engine {
+		model := newScriptedModel(response{
+			Message: agent.Message{Role: agent.RoleAssistant, Content: "conformance answer"},
+		})
+		loop, err := agentreact.New(model, newRegistry(nil), newEventSink())
+		if err != nil {
+			t.Fatalf("new loop: %v", err)
+		}
+		return loop
+	})
+}
@@ -11,6 +11,14 @@ type ModelRequest struct {
 	Messages   []agent.Message
 	Tools      []agent.ToolDefinition
 	Resolution *agent.Resolution
+	// OutputSchema is set when the final answer must be a JSON object matching
+	// the schema, allowing models to request structured output natively.
+	OutputSchema map[string]any
+	// ToolChoice constrains tool use for this call. Unless the caller asked
+	// for a required or named tool, ReactLoop sets it to agent.ToolChoiceNone
+	// on the final allowed step so the run ends with an answer instead of
+	// exceeding its step budget.
+	ToolChoice agent.ToolChoice
 }
 
 // Model produces assistant messages that may include tool calls.
@@ -9,4 +9,8 @@ var (
 	ErrMissingToolExecutor = errors.New("missing tool executor")
 	// ErrToolCallInvalid is returned when the model produces an invalid tool call shape.
 	ErrToolCallInvalid = errors.New("tool call is invalid")
+	// ErrOutputSchemaViolation is returned when the final answer still violates the output schema after all repairs.
+	ErrOutputSchemaViolation = errors.New("output schema violation")
+	// ErrToolSelectionInvalid is returned when a ToolSelector chooses tools the run does not define.
+	ErrToolSelectionInvalid = errors.New("tool selection is invalid")
 )
@@ -0,0 +1,6 @@
+package agentreact
+
+// DefaultMaxOutputRepairs bounds how many times the model is asked to repair a
+// final answer that does not match EngineInput.OutputSchema. Each repair
+// prompt is appended as a user message and published as a user_message event.
+const DefaultMaxOutputRepairs = 2
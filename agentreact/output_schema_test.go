@@ -0,0 +1,133 @@
+package agentreact_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Gurpartap/agentframe/agent"
+	"github.com/Gurpartap/agentframe/agentreact"
+)
+
+func verdictSchema() map[string]any {
+	return map[string]any{
+		"type":     "object",
+		"required": []any{"verdict", "score"},
+		"properties": map[string]any{
+			"verdict": map[string]any{"type": "string"},
+			"score":   map[string]any{"type": "integer"},
+		},
+		"additionalProperties": false,
+	}
+}
+
+func TestOutputSchema_StoresStructuredOutput(t *testing.T) {
+	t.Parallel()
+
+	runner, store, _ := newExampleRuntime(t, "output-schema", []response{
+		{Message: agent.Message{Content: "```json\n{\"verdict\": \"pass\", \"score\": 9}\n```"}},
+	}, nil)
+
+	result, err := runner.Run(context.Background(), agent.RunInput{
+		UserPrompt:   "grade it",
+		MaxSteps:     2,
+		OutputSchema: verdictSchema(),
+	})
+	if err != nil {
+		t.Fatalf("run returned error: %v", err)
+	}
+	if result.State.Status != agent.RunStatusCompleted {
+		t.Fatalf("status mismatch: got=%s want=%s", result.State.Status, agent.RunStatusCompleted)
+	}
+	if string(result.State.StructuredOutput) != `{"verdict":"pass","score":9}` {
+		t.Fatalf("structured output mismatch: got=%s", result.State.StructuredOutput)
+	}
+
+	persisted, err := store.Load(context.Background(), result.State.ID)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	var decoded struct {
+		Verdict string `json:"verdict"`
+		Score   int    `json:"score"`
+	}
+	if err := json.Unmarshal(persisted.StructuredOutput, &decoded); err != nil {
+		t.Fatalf("decode persisted structured output: %v", err)
+	}
+	if decoded.Verdict != "pass" || decoded.Score != 9 {
+		t.Fatalf("persisted structured output mismatch: got=%+v", decoded)
+	}
+	if persisted.OutputSchema == nil {
+		t.Fatalf("output schema must be persisted on run state")
+	}
+}
+
+func TestOutputSchema_RepairsInvalidAnswer(t *testing.T) {
+	t.Parallel()
+
+	runner, _, events := newExampleRuntime(t, "output-repair", []response{
+		{Message: agent.Message{Content: "It passed with a nine."}},
+		{Message: agent.Message{Content: `{"verdict":"pass"}`}},
+		{Message: agent.Message{Content: `{"verdict":"pass","score":9}`}},
+	}, nil)
+
+	result, err := runner.Run(context.Background(), agent.RunInput{
+		UserPrompt:   "grade it",
+		MaxSteps:     5,
+		OutputSchema: verdictSchema(),
+	})
+	if err != nil {
+		t.Fatalf("run returned error: %v", err)
+	}
+	if result.State.Status != agent.RunStatusCompleted || result.State.Step != 3 {
+		t.Fatalf("final state mismatch: status=%s step=%d", result.State.Status, result.State.Step)
+	}
+
+	var repairs []agent.Message
+	for _, message := range result.State.Messages {
+		if message.Role == agent.RoleUser && strings.HasPrefix(message.Content, "[output_validation]") {
+			repairs = append(repairs, message)
+		}
+	}
+	if len(repairs) != 2 {
+		t.Fatalf("repair prompt count mismatch: got=%d want=2", len(repairs))
+	}
+
+	var published []agent.Message
+	for _, event := range events.Events() {
+		if event.Type == agent.EventTypeUserMessage {
+			published = append(published, *event.Message)
+		}
+	}
+	if !reflect.DeepEqual(published, repairs) {
+		t.Fatalf("user_message events mismatch: got=%+v want=%+v", published, repairs)
+	}
+}
+
+func TestOutputSchema_FailsAfterRepairBudget(t *testing.T) {
+	t.Parallel()
+
+	responses := make([]response, agentreact.DefaultMaxOutputRepairs+1)
+	for i := range responses {
+		responses[i] = response{Message: agent.Message{Content: `{"verdict":"pass","score":"high"}`}}
+	}
+	runner, _, _ := newExampleRuntime(t, "output-fail", responses, nil)
+
+	result, err := runner.Run(context.Background(), agent.RunInput{
+		UserPrompt:   "grade it",
+		MaxSteps:     8,
+		OutputSchema: verdictSchema(),
+	})
+	if !errors.Is(err, agentreact.ErrOutputSchemaViolation) {
+		t.Fatalf("expected ErrOutputSchemaViolation, got %v", err)
+	}
+	if result.State.Status != agent.RunStatusFailed {
+		t.Fatalf("status mismatch: got=%s want=%s", result.State.Status, agent.RunStatusFailed)
+	}
+	if len(result.State.StructuredOutput) != 0 {
+		t.Fatalf("structured output must stay empty on failure: got=%s", result.State.StructuredOutput)
+	}
+}
@@ -0,0 +1,20 @@
+package agentreact
+
+import "github.com/Gurpartap/agentframe/agent"
+
+// WithRedactor redacts every tool result before it is appended to the
+// transcript and published, so secrets in tool output never reach run state,
+// events or later model requests. Assistant messages are not rewritten.
+func WithRedactor(redactor agent.Redactor) Option {
+	return func(l *ReactLoop) {
+		l.redactor = redactor
+	}
+}
+
+// redactToolResult applies the configured redactor, if any.
+func (l *ReactLoop) redactToolResult(result agent.ToolResult) agent.ToolResult {
+	if l.redactor == nil {
+		return result
+	}
+	return agent.RedactToolResult(l.redactor, result)
+}
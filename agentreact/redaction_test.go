@@ -0,0 +1,67 @@
+package agentreact_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Gurpartap/agentframe/agent"
+	"github.com/Gurpartap/agentframe/agentreact"
+)
+
+func TestWithRedactor_RedactsToolResultsBeforeStateEventsAndModel(t *testing.T) {
+	t.Parallel()
+
+	const secret = "hunter2-secret"
+	model := &requestRecordingModel{next: newScriptedModel(
+		response{Message: agent.Message{ToolCalls: []agent.ToolCall{{ID: "call-1", Name: "env"}}}},
+		response{Message: agent.Message{Content: "done"}},
+	)}
+	tools := map[string]handler{
+		"env": func(context.Context, map[string]any) (string, error) { return "PASSWORD=" + secret, nil },
+	}
+	redactor := agent.RedactorFunc(func(text string) string {
+		return strings.ReplaceAll(text, secret, "[REDACTED:password]")
+	})
+
+	events := newEventSink()
+	loop, err := agentreact.New(model, newRegistry(tools), events, agentreact.WithRedactor(redactor))
+	if err != nil {
+		t.Fatalf("new loop: %v", err)
+	}
+	runner, err := agent.NewRunner(agent.Dependencies{
+		IDGenerator: newCounterIDGenerator("redact"),
+		RunStore:    newRunStore(),
+		Engine:      loop,
+		EventSink:   events,
+	})
+	if err != nil {
+		t.Fatalf("new runner: %v", err)
+	}
+	result, runErr := runner.Run(context.Background(), agent.RunInput{
+		UserPrompt: "print the environment",
+		MaxSteps:   3,
+		Tools:      []agent.ToolDefinition{{Name: "env"}},
+	})
+	if runErr != nil {
+		t.Fatalf("run returned error: %v", runErr)
+	}
+
+	for _, message := range result.State.Messages {
+		if strings.Contains(message.Content, secret) {
+			t.Fatalf("state message leaks secret: %+v", message)
+		}
+	}
+	if len(model.requests) != 2 {
+		t.Fatalf("model request count mismatch: got=%d want=2", len(model.requests))
+	}
+	for _, message := range model.requests[1].Messages {
+		if strings.Contains(message.Content, secret) {
+			t.Fatalf("model request leaks secret: %+v", message)
+		}
+	}
+	toolResult := mustToolResultEvent(t, events.Events())
+	if toolResult.Content != "PASSWORD=[REDACTED:password]" {
+		t.Fatalf("tool result content mismatch: got=%q want=%q", toolResult.Content, "PASSWORD=[REDACTED:password]")
+	}
+}
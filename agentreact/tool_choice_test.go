@@ -0,0 +1,135 @@
+package agentreact_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Gurpartap/agentframe/agent"
+	"github.com/Gurpartap/agentframe/agentreact"
+)
+
+func runWithToolChoice(
+	t *testing.T,
+	model agentreact.Model,
+	maxSteps int,
+	choice agent.ToolChoice,
+) (agent.RunResult, error) {
+	t.Helper()
+
+	events := newEventSink()
+	loop, err := agentreact.New(model, newRegistry(map[string]handler{
+		"lookup": func(context.Context, map[string]any) (string, error) { return "found", nil },
+	}), events)
+	if err != nil {
+		t.Fatalf("new loop: %v", err)
+	}
+	runner, err := agent.NewRunner(agent.Dependencies{
+		IDGenerator: newCounterIDGenerator("choice"),
+		RunStore:    newRunStore(),
+		Engine:      loop,
+		EventSink:   events,
+	})
+	if err != nil {
+		t.Fatalf("new runner: %v", err)
+	}
+	return runner.Run(context.Background(), agent.RunInput{
+		UserPrompt: "look it up",
+		MaxSteps:   maxSteps,
+		Tools:      []agent.ToolDefinition{{Name: "lookup"}},
+		ToolChoice: choice,
+	})
+}
+
+func TestReactLoop_DisablesToolsOnFinalStep(t *testing.T) {
+	t.Parallel()
+
+	model := &requestRecordingModel{next: newScriptedModel(
+		response{Message: agent.Message{ToolCalls: []agent.ToolCall{{ID: "call-1", Name: "lookup"}}}},
+		response{Message: agent.Message{ToolCalls: []agent.ToolCall{{ID: "call-2", Name: "lookup"}}}},
+		response{Message: agent.Message{Content: "answer"}},
+	)}
+
+	result, runErr := runWithToolChoice(t, model, 3, agent.ToolChoice{Mode: agent.ToolChoiceAuto})
+	if runErr != nil {
+		t.Fatalf("run returned error: %v", runErr)
+	}
+	if result.State.Status != agent.RunStatusCompleted || result.State.Output != "answer" {
+		t.Fatalf("run mismatch: status=%s output=%q", result.State.Status, result.State.Output)
+	}
+
+	want := []agent.ToolChoiceMode{agent.ToolChoiceAuto, agent.ToolChoiceAuto, agent.ToolChoiceNone}
+	if len(model.requests) != len(want) {
+		t.Fatalf("model call count mismatch: got=%d want=%d", len(model.requests), len(want))
+	}
+	for i, request := range model.requests {
+		if request.ToolChoice.Mode != want[i] {
+			t.Fatalf("tool choice mismatch at step %d: got=%q want=%q", i+1, request.ToolChoice.Mode, want[i])
+		}
+		if len(request.Tools) != 1 {
+			t.Fatalf("tools must stay offered at step %d: got=%v", i+1, toolNames(request.Tools))
+		}
+	}
+}
+
+func TestReactLoop_KeepsExplicitToolChoiceOnFinalStep(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		choice agent.ToolChoice
+	}{
+		{name: "required", choice: agent.ToolChoice{Mode: agent.ToolChoiceRequired}},
+		{name: "named tool", choice: agent.ToolChoice{Mode: agent.ToolChoiceTool, Name: "lookup"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			model := &requestRecordingModel{next: newScriptedModel(
+				response{Message: agent.Message{ToolCalls: []agent.ToolCall{{ID: "call-1", Name: "lookup"}}}},
+				response{Message: agent.Message{Content: "answer"}},
+			)}
+			if _, runErr := runWithToolChoice(t, model, 2, tc.choice); runErr != nil {
+				t.Fatalf("run returned error: %v", runErr)
+			}
+			if len(model.requests) != 2 {
+				t.Fatalf("model call count mismatch: got=%d want=2", len(model.requests))
+			}
+			for i, request := range model.requests {
+				if request.ToolChoice != tc.choice {
+					t.Fatalf("tool choice mismatch at step %d: got=%+v want=%+v", i+1, request.ToolChoice, tc.choice)
+				}
+			}
+		})
+	}
+}
+
+func TestReactLoop_NamedToolChoiceMustBeOffered(t *testing.T) {
+	t.Parallel()
+
+	model := newScriptedModel(response{Message: agent.Message{Content: "done"}})
+	events := newEventSink()
+	loop, err := agentreact.New(
+		model,
+		newRegistry(nil),
+		events,
+		agentreact.WithToolSelector(agentreact.ToolSelectorFunc(func(context.Context, agentreact.ToolSelectionRequest) ([]string, error) {
+			return []string{"read"}, nil
+		})),
+	)
+	if err != nil {
+		t.Fatalf("new loop: %v", err)
+	}
+	state, runErr := loop.Execute(context.Background(), agent.RunState{ID: "choice-run", Status: agent.RunStatusPending}, agent.EngineInput{
+		MaxSteps:   3,
+		Tools:      []agent.ToolDefinition{{Name: "read"}, {Name: "write"}},
+		ToolChoice: agent.ToolChoice{Mode: agent.ToolChoiceTool, Name: "write"},
+	})
+	if !errors.Is(runErr, agentreact.ErrToolSelectionInvalid) {
+		t.Fatalf("expected ErrToolSelectionInvalid, got %v", runErr)
+	}
+	if state.Status != agent.RunStatusFailed {
+		t.Fatalf("status mismatch: got=%s want=%s", state.Status, agent.RunStatusFailed)
+	}
+}
@@ -3,9 +3,11 @@ package agentreact_test
 import (
 	"context"
 	"errors"
+	"math"
 	"strings"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/Gurpartap/agentframe/agent"
 	"github.com/Gurpartap/agentframe/agentreact"
@@ -121,6 +123,52 @@ func TestToolFailure_InvalidArguments_NoExecutorCall(t *testing.T) {
 	}
 }
 
+func TestToolFailure_IntegerArguments(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		value       any
+		wantInvalid bool
+	}{
+		{name: "int", value: 3},
+		{name: "integral float", value: float64(3)},
+		{name: "fractional float", value: 3.5, wantInvalid: true},
+		{name: "positive infinity", value: math.Inf(1), wantInvalid: true},
+		{name: "negative infinity", value: math.Inf(-1), wantInvalid: true},
+		{name: "nan", value: math.NaN(), wantInvalid: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			model := newScriptedModel(
+				response{Message: agent.Message{
+					Role:      agent.RoleAssistant,
+					ToolCalls: []agent.ToolCall{{ID: "call-1", Name: "lookup", Arguments: map[string]any{"n": tc.value}}},
+				}},
+				response{Message: agent.Message{Role: agent.RoleAssistant, Content: "done"}},
+			)
+			registry := newRegistry(map[string]handler{
+				"lookup": func(context.Context, map[string]any) (string, error) { return "found", nil },
+			})
+			tools := []agent.ToolDefinition{{
+				Name: "lookup",
+				InputSchema: map[string]any{
+					"properties": map[string]any{"n": map[string]any{"type": "integer"}},
+				},
+			}}
+
+			_, events := runToolTest(t, model, registry, tools)
+
+			toolResult := mustToolResultEvent(t, events.Events())
+			if got := toolResult.FailureReason == agent.ToolFailureReasonInvalidArguments; got != tc.wantInvalid {
+				t.Fatalf("invalid arguments mismatch: got=%v want=%v reason=%q", got, tc.wantInvalid, toolResult.FailureReason)
+			}
+		})
+	}
+}
+
 func TestToolFailure_ExecutorError(t *testing.T) {
 	t.Parallel()
 
@@ -185,6 +233,74 @@ func TestToolFailure_ExecutorError(t *testing.T) {
 	}
 }
 
+func TestToolFailure_TimeoutHasDistinctReason(t *testing.T) {
+	t.Parallel()
+
+	model := newScriptedModel(
+		response{
+			Message: agent.Message{
+				Role:      agent.RoleAssistant,
+				ToolCalls: []agent.ToolCall{{ID: "call-1", Name: "lookup", Arguments: map[string]any{}}},
+			},
+		},
+		response{
+			Message: agent.Message{Role: agent.RoleAssistant, Content: "done"},
+		},
+	)
+	executor := toolExecutorFunc(func(context.Context, agent.ToolCall) (agent.ToolResult, error) {
+		return agent.ToolResult{}, &agent.ToolTimeoutError{Tool: "lookup", Timeout: time.Second}
+	})
+
+	result, events := runToolTest(t, model, executor, []agent.ToolDefinition{{Name: "lookup"}})
+
+	toolResult := mustToolResultEvent(t, events.Events())
+	if !toolResult.IsError || toolResult.FailureReason != agent.ToolFailureReasonTimeout {
+		t.Fatalf("tool result mismatch: is_error=%t reason=%s", toolResult.IsError, toolResult.FailureReason)
+	}
+	if !strings.Contains(result.State.Messages[2].Content, string(agent.ToolFailureReasonTimeout)) {
+		t.Fatalf("unexpected transcript tool message: %+v", result.State.Messages[2])
+	}
+}
+
+func TestToolFailure_PanicStackStaysInEvent(t *testing.T) {
+	t.Parallel()
+
+	model := newScriptedModel(
+		response{
+			Message: agent.Message{
+				Role:      agent.RoleAssistant,
+				ToolCalls: []agent.ToolCall{{ID: "call-1", Name: "lookup", Arguments: map[string]any{}}},
+			},
+		},
+		response{
+			Message: agent.Message{Role: agent.RoleAssistant, Content: "done"},
+		},
+	)
+	registry := newRegistry(map[string]handler{
+		"lookup": func(context.Context, map[string]any) (string, error) {
+			panic("nil map write")
+		},
+	})
+
+	result, events := runToolTest(t, model, registry, []agent.ToolDefinition{{Name: "lookup"}})
+
+	var toolEvent agent.Event
+	for _, event := range events.Events() {
+		if event.Type == agent.EventTypeToolResult {
+			toolEvent = event
+		}
+	}
+	if toolEvent.ToolResult == nil || toolEvent.ToolResult.FailureReason != agent.ToolFailureReasonExecutorError {
+		t.Fatalf("tool result event mismatch: %+v", toolEvent)
+	}
+	if !strings.Contains(toolEvent.Description, "nil map write") || !strings.Contains(toolEvent.Description, "goroutine") {
+		t.Fatalf("panic stack missing from event description: %q", toolEvent.Description)
+	}
+	if strings.Contains(result.State.Messages[2].Content, "goroutine") {
+		t.Fatalf("panic stack leaked into transcript: %q", result.State.Messages[2].Content)
+	}
+}
+
 func TestToolFailure_ExecutorResultCallIDMismatch(t *testing.T) {
 	t.Parallel()
 
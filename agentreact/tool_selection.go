@@ -0,0 +1,121 @@
+package agentreact
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Gurpartap/agentframe/agent"
+)
+
+// Option customizes a ReactLoop.
+type Option func(*ReactLoop)
+
+// WithToolSelector narrows the tool definitions offered to the model at each
+// step. The selected names are recorded on the assistant_message event, with
+// ToolsSelected set so an empty selection stays distinguishable.
+func WithToolSelector(selector ToolSelector) Option {
+	return func(l *ReactLoop) {
+		l.toolSelector = selector
+	}
+}
+
+// ToolSelectionRequest describes the step a ToolSelector chooses tools for.
+type ToolSelectionRequest struct {
+	// State is a copy of the run state before the model call; State.Step is
+	// the step being started.
+	State agent.RunState
+	// Tools are all definitions available to the run.
+	Tools []agent.ToolDefinition
+}
+
+// ToolSelector chooses which of the run's tools the model may use for one
+// step, for example by retrieving tools relevant to the transcript or by
+// gating tools on run progress. It returns tool names; calls to tools that
+// were not selected are answered with an unknown_tool result.
+type ToolSelector interface {
+	SelectTools(ctx context.Context, request ToolSelectionRequest) ([]string, error)
+}
+
+// ToolSelectorFunc adapts a function to ToolSelector.
+type ToolSelectorFunc func(ctx context.Context, request ToolSelectionRequest) ([]string, error)
+
+func (f ToolSelectorFunc) SelectTools(ctx context.Context, request ToolSelectionRequest) ([]string, error) {
+	return f(ctx, request)
+}
+
+// selectStepTools returns the definitions offered for the current step and the
+// selected names to record, which are non-nil even when empty. Without a
+// selector every tool is offered and no names are recorded.
+func (l *ReactLoop) selectStepTools(
+	ctx context.Context,
+	state agent.RunState,
+	tools []agent.ToolDefinition,
+	available map[string]agent.ToolDefinition,
+) ([]agent.ToolDefinition, []string, error) {
+	if l.toolSelector == nil {
+		return tools, nil, nil
+	}
+	names, err := l.toolSelector.SelectTools(ctx, ToolSelectionRequest{
+		State: agent.CloneRunState(state),
+		Tools: agent.CloneToolDefinitions(tools),
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("select tools step=%d: %w", state.Step, err)
+	}
+	selected := make([]agent.ToolDefinition, 0, len(names))
+	seen := make(map[string]struct{}, len(names))
+	for i, name := range names {
+		definition, ok := available[name]
+		if !ok {
+			return nil, nil, fmt.Errorf(
+				"%w: field=selected_tools[%d] reason=unknown_tool value=%q step=%d",
+				ErrToolSelectionInvalid,
+				i,
+				name,
+				state.Step,
+			)
+		}
+		if _, duplicate := seen[name]; duplicate {
+			return nil, nil, fmt.Errorf(
+				"%w: field=selected_tools[%d] reason=duplicate value=%q step=%d",
+				ErrToolSelectionInvalid,
+				i,
+				name,
+				state.Step,
+			)
+		}
+		seen[name] = struct{}{}
+		selected = append(selected, definition)
+	}
+	return selected, append([]string{}, names...), nil
+}
+
+// stepToolChoice returns the tool choice sent to the model for one step. When
+// the caller left tool use to the model, the final allowed step forbids tool
+// calls so the model answers instead of exhausting the step budget; an
+// explicit required or named choice is kept. A named tool must be offered at
+// the step.
+func stepToolChoice(
+	step int,
+	choice agent.ToolChoice,
+	offered map[string]agent.ToolDefinition,
+	finalStep bool,
+) (agent.ToolChoice, error) {
+	if len(offered) == 0 {
+		return agent.ToolChoice{}, nil
+	}
+	if finalStep && (choice.Mode == "" || choice.Mode == agent.ToolChoiceAuto) {
+		return agent.ToolChoice{Mode: agent.ToolChoiceNone}, nil
+	}
+	if choice.Mode == agent.ToolChoiceTool {
+		if _, ok := offered[choice.Name]; !ok {
+			return agent.ToolChoice{}, fmt.Errorf(
+				"%w: field=tool_choice reason=unselected_tool value=%q step=%d",
+				ErrToolSelectionInvalid,
+				choice.Name,
+				step,
+			)
+		}
+	}
+	return choice, nil
+}
@@ -0,0 +1,167 @@
+package agentreact_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Gurpartap/agentframe/agent"
+	"github.com/Gurpartap/agentframe/agentreact"
+)
+
+type requestRecordingModel struct {
+	next *scriptedModel
+
+	mu       sync.Mutex
+	requests []agentreact.ModelRequest
+}
+
+func (m *requestRecordingModel) Generate(ctx context.Context, request agentreact.ModelRequest) (agent.Message, error) {
+	m.mu.Lock()
+	m.requests = append(m.requests, request)
+	m.mu.Unlock()
+	return m.next.Generate(ctx, request)
+}
+
+func toolNames(definitions []agent.ToolDefinition) []string {
+	names := make([]string, 0, len(definitions))
+	for _, definition := range definitions {
+		names = append(names, definition.Name)
+	}
+	return names
+}
+
+func runWithSelector(
+	t *testing.T,
+	model agentreact.Model,
+	selector agentreact.ToolSelector,
+	tools map[string]handler,
+	definitions []agent.ToolDefinition,
+) (agent.RunResult, error, *eventSink) {
+	t.Helper()
+
+	events := newEventSink()
+	loop, err := agentreact.New(model, newRegistry(tools), events, agentreact.WithToolSelector(selector))
+	if err != nil {
+		t.Fatalf("new loop: %v", err)
+	}
+	runner, err := agent.NewRunner(agent.Dependencies{
+		IDGenerator: newCounterIDGenerator("select"),
+		RunStore:    newRunStore(),
+		Engine:      loop,
+		EventSink:   events,
+	})
+	if err != nil {
+		t.Fatalf("new runner: %v", err)
+	}
+	result, runErr := runner.Run(context.Background(), agent.RunInput{
+		UserPrompt: "update the file",
+		MaxSteps:   5,
+		Tools:      definitions,
+	})
+	return result, runErr, events
+}
+
+func TestToolSelector_GatesWriteUntilRead(t *testing.T) {
+	t.Parallel()
+
+	model := &requestRecordingModel{next: newScriptedModel(
+		response{Message: agent.Message{ToolCalls: []agent.ToolCall{{ID: "call-1", Name: "write"}}}},
+		response{Message: agent.Message{ToolCalls: []agent.ToolCall{{ID: "call-2", Name: "read"}}}},
+		response{Message: agent.Message{ToolCalls: []agent.ToolCall{{ID: "call-3", Name: "write"}}}},
+		response{Message: agent.Message{Content: "done"}},
+	)}
+	var writes int
+	tools := map[string]handler{
+		"read": func(context.Context, map[string]any) (string, error) { return "contents", nil },
+		"write": func(context.Context, map[string]any) (string, error) {
+			writes++
+			return "written", nil
+		},
+	}
+	readFirst := agentreact.ToolSelectorFunc(func(_ context.Context, request agentreact.ToolSelectionRequest) ([]string, error) {
+		for _, message := range request.State.Messages {
+			if message.Role == agent.RoleTool && message.Name == "read" {
+				return []string{"read", "write"}, nil
+			}
+		}
+		return []string{"read"}, nil
+	})
+
+	result, runErr, events := runWithSelector(t, model, readFirst, tools, []agent.ToolDefinition{{Name: "read"}, {Name: "write"}})
+	if runErr != nil {
+		t.Fatalf("run returned error: %v", runErr)
+	}
+	if result.State.Status != agent.RunStatusCompleted || writes != 1 {
+		t.Fatalf("run mismatch: status=%s writes=%d", result.State.Status, writes)
+	}
+
+	wantOffered := [][]string{{"read"}, {"read"}, {"read", "write"}, {"read", "write"}}
+	for i, request := range model.requests {
+		if got := toolNames(request.Tools); !slices.Equal(got, wantOffered[i]) {
+			t.Fatalf("offered tools mismatch at step %d: got=%v want=%v", i+1, got, wantOffered[i])
+		}
+	}
+	step := 0
+	for _, event := range events.Events() {
+		if event.Type != agent.EventTypeAssistantMessage {
+			continue
+		}
+		if !event.ToolsSelected || !slices.Equal(event.SelectedTools, wantOffered[step]) {
+			t.Fatalf("selected tools mismatch at step %d: got=%v want=%v", step+1, event.SelectedTools, wantOffered[step])
+		}
+		step++
+	}
+
+	gated := mustToolResultEvent(t, events.Events())
+	if gated.FailureReason != agent.ToolFailureReasonUnknownTool || !strings.Contains(gated.Content, "not available at this step") {
+		t.Fatalf("gated call result mismatch: %+v", gated)
+	}
+}
+
+func TestToolSelector_RecordsEmptySelection(t *testing.T) {
+	t.Parallel()
+
+	model := &requestRecordingModel{next: newScriptedModel(response{Message: agent.Message{Content: "done"}})}
+	none := agentreact.ToolSelectorFunc(func(context.Context, agentreact.ToolSelectionRequest) ([]string, error) {
+		return nil, nil
+	})
+
+	_, runErr, events := runWithSelector(t, model, none, nil, []agent.ToolDefinition{{Name: "read"}})
+	if runErr != nil {
+		t.Fatalf("run returned error: %v", runErr)
+	}
+	if len(model.requests) != 1 || len(model.requests[0].Tools) != 0 {
+		t.Fatalf("empty selection must offer no tools: requests=%d", len(model.requests))
+	}
+	for _, event := range events.Events() {
+		if event.Type != agent.EventTypeAssistantMessage {
+			continue
+		}
+		if !event.ToolsSelected || event.SelectedTools == nil || len(event.SelectedTools) != 0 {
+			t.Fatalf("empty selection must be recorded: tools_selected=%v selected_tools=%#v", event.ToolsSelected, event.SelectedTools)
+		}
+		return
+	}
+	t.Fatalf("assistant_message event not published")
+}
+
+func TestToolSelector_RejectsUnknownSelection(t *testing.T) {
+	t.Parallel()
+
+	model := newScriptedModel(response{Message: agent.Message{Content: "done"}})
+	selector := agentreact.ToolSelectorFunc(func(context.Context, agentreact.ToolSelectionRequest) ([]string, error) {
+		return []string{"deploy"}, nil
+	})
+
+	result, runErr, _ := runWithSelector(t, model, selector, nil, []agent.ToolDefinition{{Name: "read"}})
+	if !errors.Is(runErr, agentreact.ErrToolSelectionInvalid) {
+		t.Fatalf("expected ErrToolSelectionInvalid, got %v", runErr)
+	}
+	if result.State.Status != agent.RunStatusFailed {
+		t.Fatalf("status mismatch: got=%s want=%s", result.State.Status, agent.RunStatusFailed)
+	}
+}
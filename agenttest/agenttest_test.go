@@ -0,0 +1,320 @@
+package agenttest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Gurpartap/agentframe/agent"
+	"github.com/Gurpartap/agentframe/agentreact"
+	"github.com/Gurpartap/agentframe/agenttest"
+	eventinginmem "github.com/Gurpartap/agentframe/eventing/inmem"
+	runstoreinmem "github.com/Gurpartap/agentframe/runstore/inmem"
+	toolingregistry "github.com/Gurpartap/agentframe/tooling/registry"
+)
+
+type scriptedModel struct {
+	mu        sync.Mutex
+	index     int
+	responses []agent.Message
+}
+
+func (m *scriptedModel) Generate(_ context.Context, _ agentreact.ModelRequest) (agent.Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.index >= len(m.responses) {
+		return agent.Message{}, fmt.Errorf("script exhausted at step %d", m.index+1)
+	}
+	msg := agent.CloneMessage(m.responses[m.index])
+	m.index++
+	return msg, nil
+}
+
+type staticIDGenerator struct{}
+
+func (staticIDGenerator) NewRunID(context.Context) (agent.RunID, error) {
+	return "run-cassette", nil
+}
+
+func newTestRunner(t *testing.T, model agentreact.Model, tools agentreact.ToolExecutor) *agent.Runner {
+	t.Helper()
+
+	events := eventinginmem.New()
+	loop, err := agentreact.New(model, tools, events)
+	if err != nil {
+		t.Fatalf("new loop: %v", err)
+	}
+	runner, err := agent.NewRunner(agent.Dependencies{
+		IDGenerator: staticIDGenerator{},
+		RunStore:    runstoreinmem.New(),
+		Engine:      loop,
+		EventSink:   events,
+	})
+	if err != nil {
+		t.Fatalf("new runner: %v", err)
+	}
+	return runner
+}
+
+func lookupScript() *scriptedModel {
+	return &scriptedModel{
+		responses: []agent.Message{
+			{
+				Role: agent.RoleAssistant,
+				ToolCalls: []agent.ToolCall{
+					{ID: "call-1", Name: "lookup", Arguments: map[string]any{"q": "Go", "limit": 2}},
+				},
+			},
+			{Role: agent.RoleAssistant, Content: "Go is a language."},
+		},
+	}
+}
+
+var lookupInput = agent.RunInput{
+	UserPrompt: "What is Go?",
+	MaxSteps:   4,
+	Tools:      []agent.ToolDefinition{{Name: "lookup"}},
+}
+
+func TestRecordThenReplay_ReproducesRunState(t *testing.T) {
+	t.Parallel()
+
+	liveCalls := 0
+	registry, err := toolingregistry.New(map[string]toolingregistry.Handler{
+		"lookup": func(_ context.Context, args map[string]any) (string, error) {
+			liveCalls++
+			return "lookup=" + args["q"].(string), nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("new registry: %v", err)
+	}
+
+	recorder := agenttest.NewRecorder()
+	recorded, err := newTestRunner(
+		t,
+		recorder.WrapModel(lookupScript()),
+		recorder.WrapToolExecutor(registry),
+	).Run(context.Background(), lookupInput)
+	if err != nil {
+		t.Fatalf("recorded run: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "session.json")
+	if err := recorder.Save(path); err != nil {
+		t.Fatalf("save cassette: %v", err)
+	}
+	replayer, err := agenttest.NewReplayerFromFile(path)
+	if err != nil {
+		t.Fatalf("load replayer: %v", err)
+	}
+
+	replayed, err := newTestRunner(t, replayer.Model(), replayer.ToolExecutor()).Run(context.Background(), lookupInput)
+	if err != nil {
+		t.Fatalf("replayed run: %v", err)
+	}
+	if liveCalls != 1 {
+		t.Fatalf("replay must not call live tools: calls=%d", liveCalls)
+	}
+	if replayed.State.Status != agent.RunStatusCompleted || replayed.State.Output != recorded.State.Output {
+		t.Fatalf("replayed state mismatch: status=%s output=%q", replayed.State.Status, replayed.State.Output)
+	}
+	if len(replayed.State.Messages) != len(recorded.State.Messages) {
+		t.Fatalf("message count mismatch: got=%d want=%d", len(replayed.State.Messages), len(recorded.State.Messages))
+	}
+	if unused := replayer.Unused(); len(unused) != 0 {
+		t.Fatalf("expected all interactions consumed, got %d unused", len(unused))
+	}
+}
+
+func TestReplay_DivergenceFailsLoudly(t *testing.T) {
+	t.Parallel()
+
+	recorder := agenttest.NewRecorder()
+	registry, err := toolingregistry.New(map[string]toolingregistry.Handler{
+		"lookup": func(_ context.Context, _ map[string]any) (string, error) {
+			return "ok", nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("new registry: %v", err)
+	}
+	if _, err := newTestRunner(
+		t,
+		recorder.WrapModel(lookupScript()),
+		recorder.WrapToolExecutor(registry),
+	).Run(context.Background(), lookupInput); err != nil {
+		t.Fatalf("recorded run: %v", err)
+	}
+
+	replayer, err := agenttest.NewReplayer(recorder.Cassette())
+	if err != nil {
+		t.Fatalf("new replayer: %v", err)
+	}
+	diverged := lookupInput
+	diverged.UserPrompt = "What is Rust?"
+	result, err := newTestRunner(t, replayer.Model(), replayer.ToolExecutor()).Run(context.Background(), diverged)
+	if !errors.Is(err, agenttest.ErrCassetteDivergence) {
+		t.Fatalf("expected ErrCassetteDivergence, got %v", err)
+	}
+	if result.State.Status != agent.RunStatusFailed {
+		t.Fatalf("expected failed status on divergence, got %s", result.State.Status)
+	}
+}
+
+func TestReplay_ReproducesToolSuspendRequest(t *testing.T) {
+	t.Parallel()
+
+	requirement := &agent.PendingRequirement{
+		ID:          "req-1",
+		Kind:        agent.RequirementKindApproval,
+		Origin:      agent.RequirementOriginTool,
+		ToolCallID:  "call-1",
+		Fingerprint: "fp-1",
+	}
+	recorder := agenttest.NewRecorder()
+	registry, err := toolingregistry.New(map[string]toolingregistry.Handler{
+		"lookup": func(_ context.Context, _ map[string]any) (string, error) {
+			return "", &agent.SuspendRequestError{Requirement: requirement, Err: errors.New("needs approval")}
+		},
+	})
+	if err != nil {
+		t.Fatalf("new registry: %v", err)
+	}
+	tools := recorder.WrapToolExecutor(registry)
+	if _, err := tools.Execute(context.Background(), agent.ToolCall{ID: "call-1", Name: "lookup"}); err == nil {
+		t.Fatalf("expected suspend request from live tool")
+	}
+
+	replayer, err := agenttest.NewReplayer(recorder.Cassette())
+	if err != nil {
+		t.Fatalf("new replayer: %v", err)
+	}
+	_, err = replayer.ToolExecutor().Execute(context.Background(), agent.ToolCall{ID: "call-1", Name: "lookup"})
+	var suspendRequestErr *agent.SuspendRequestError
+	if !errors.As(err, &suspendRequestErr) {
+		t.Fatalf("expected SuspendRequestError, got %T (%v)", err, err)
+	}
+	if !reflect.DeepEqual(suspendRequestErr.Requirement, requirement) {
+		t.Fatalf("requirement mismatch: got=%+v want=%+v", suspendRequestErr.Requirement, requirement)
+	}
+	if suspendRequestErr.Err == nil || suspendRequestErr.Err.Error() != "needs approval" {
+		t.Fatalf("unexpected wrapped error: %v", suspendRequestErr.Err)
+	}
+}
+
+func TestModelRequestKey_CoversToolChoice(t *testing.T) {
+	t.Parallel()
+
+	request := agentreact.ModelRequest{
+		Messages: []agent.Message{{Role: agent.RoleUser, Content: "look it up"}},
+		Tools:    []agent.ToolDefinition{{Name: "lookup"}},
+	}
+	auto, err := agenttest.ModelRequestKey(request)
+	if err != nil {
+		t.Fatalf("key auto: %v", err)
+	}
+	request.ToolChoice = agent.ToolChoice{Mode: agent.ToolChoiceNone}
+	none, err := agenttest.ModelRequestKey(request)
+	if err != nil {
+		t.Fatalf("key none: %v", err)
+	}
+	if auto == none {
+		t.Fatalf("tool choice must change the replay key: key=%s", auto)
+	}
+}
+
+func TestModelRequestKey_CoversOutputSchema(t *testing.T) {
+	t.Parallel()
+
+	request := agentreact.ModelRequest{
+		Messages: []agent.Message{{Role: agent.RoleUser, Content: "summarize"}},
+	}
+	plain, err := agenttest.ModelRequestKey(request)
+	if err != nil {
+		t.Fatalf("key plain: %v", err)
+	}
+	request.OutputSchema = map[string]any{
+		"properties": map[string]any{"summary": map[string]any{"type": "string"}},
+	}
+	structured, err := agenttest.ModelRequestKey(request)
+	if err != nil {
+		t.Fatalf("key structured: %v", err)
+	}
+	if plain == structured {
+		t.Fatalf("output schema must change the replay key: key=%s", plain)
+	}
+}
+
+func TestLoadCassette_RejectsUnsupportedVersion(t *testing.T) {
+	t.Parallel()
+
+	if _, err := agenttest.NewReplayer(agenttest.Cassette{Version: 99}); !errors.Is(err, agenttest.ErrCassetteInvalid) {
+		t.Fatalf("expected ErrCassetteInvalid, got %v", err)
+	}
+}
+
+type failingToolExecutor struct {
+	err error
+}
+
+func (e failingToolExecutor) Execute(context.Context, agent.ToolCall) (agent.ToolResult, error) {
+	return agent.ToolResult{}, e.err
+}
+
+func TestReplay_ReproducesTypedToolErrors(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		err        error
+		wantReason agent.ToolFailureReason
+	}{
+		{
+			name:       "timeout",
+			err:        fmt.Errorf("lookup: %w", &agent.ToolTimeoutError{Tool: "lookup", Timeout: 2 * time.Second}),
+			wantReason: agent.ToolFailureReasonTimeout,
+		},
+		{
+			name:       "panic",
+			err:        &agent.ToolPanicError{Tool: "lookup", Value: "boom", Stack: "goroutine 1 [running]:"},
+			wantReason: agent.ToolFailureReasonExecutorError,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			recorder := agenttest.NewRecorder()
+			call := agent.ToolCall{ID: "call-1", Name: "lookup"}
+			if _, err := recorder.WrapToolExecutor(failingToolExecutor{err: tc.err}).Execute(context.Background(), call); err == nil {
+				t.Fatalf("expected error from live tool")
+			}
+			path := filepath.Join(t.TempDir(), "session.json")
+			if err := recorder.Save(path); err != nil {
+				t.Fatalf("save cassette: %v", err)
+			}
+			replayer, err := agenttest.NewReplayerFromFile(path)
+			if err != nil {
+				t.Fatalf("load replayer: %v", err)
+			}
+
+			_, err = replayer.ToolExecutor().Execute(context.Background(), call)
+			if err == nil || err.Error() != tc.err.Error() {
+				t.Fatalf("error message mismatch: got=%v want=%v", err, tc.err)
+			}
+			if got := agent.ToolErrorFailureReason(err); got != tc.wantReason {
+				t.Fatalf("failure reason mismatch: got=%s want=%s", got, tc.wantReason)
+			}
+			if got, want := agent.ToolErrorEventDescription(err), agent.ToolErrorEventDescription(tc.err); got != want {
+				t.Fatalf("event description mismatch: got=%q want=%q", got, want)
+			}
+		})
+	}
+}
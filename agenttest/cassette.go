@@ -0,0 +1,314 @@
+package agenttest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Gurpartap/agentframe/agent"
+	"github.com/Gurpartap/agentframe/agentreact"
+)
+
+// CassetteVersion is the cassette format version written by Recorder.
+const CassetteVersion = 1
+
+// InteractionKind identifies which dependency produced a recorded interaction.
+type InteractionKind string
+
+const (
+	InteractionKindModel InteractionKind = "model"
+	InteractionKindTool  InteractionKind = "tool"
+)
+
+// Cassette is the persisted sequence of recorded model and tool interactions.
+type Cassette struct {
+	Version      int           `json:"version"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction captures one request/response pair keyed by a deterministic request hash.
+type Interaction struct {
+	Kind         InteractionKind                       `json:"kind"`
+	Key          string                                `json:"key"`
+	ModelRequest *ModelRequestRecord                   `json:"model_request,omitempty"`
+	Message      *agent.Message                        `json:"message,omitempty"`
+	ToolCall     *agent.ToolCall                       `json:"tool_call,omitempty"`
+	Override     *agent.ApprovedToolCallReplayOverride `json:"override,omitempty"`
+	ToolResult   *agent.ToolResult                     `json:"tool_result,omitempty"`
+	Error        *RecordedError                        `json:"error,omitempty"`
+}
+
+// ModelRequestRecord is the serializable form of agentreact.ModelRequest.
+type ModelRequestRecord struct {
+	Messages     []agent.Message        `json:"messages,omitempty"`
+	Tools        []agent.ToolDefinition `json:"tools,omitempty"`
+	Resolution   *agent.Resolution      `json:"resolution,omitempty"`
+	OutputSchema map[string]any         `json:"output_schema,omitempty"`
+	ToolChoice   agent.ToolChoice       `json:"tool_choice,omitzero"`
+}
+
+// RecordedErrorKind names the typed error a RecordedError rebuilds on replay.
+type RecordedErrorKind string
+
+const (
+	// RecordedErrorKindToolTimeout rebuilds an agent.ToolTimeoutError.
+	RecordedErrorKindToolTimeout RecordedErrorKind = "tool_timeout"
+	// RecordedErrorKindToolPanic rebuilds an agent.ToolPanicError; the panic
+	// value is replayed as its formatted string.
+	RecordedErrorKindToolPanic RecordedErrorKind = "tool_panic"
+)
+
+// RecordedError captures a dependency error so replay can reproduce it.
+// Requirement is set when the error was an agent.SuspendRequestError; Kind and
+// the fields below it are set when it wrapped a typed tool error, so replayed
+// runs classify the failure as the recording did.
+type RecordedError struct {
+	Message     string                    `json:"message"`
+	Requirement *agent.PendingRequirement `json:"requirement,omitempty"`
+	Kind        RecordedErrorKind         `json:"kind,omitempty"`
+	Tool        string                    `json:"tool,omitempty"`
+	Timeout     time.Duration             `json:"timeout,omitempty"`
+	PanicValue  string                    `json:"panic_value,omitempty"`
+	Stack       string                    `json:"stack,omitempty"`
+}
+
+// LoadCassette reads and validates a cassette file.
+func LoadCassette(path string) (Cassette, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return Cassette{}, fmt.Errorf("load cassette %q: %w", path, err)
+	}
+	var cassette Cassette
+	if err := json.Unmarshal(raw, &cassette); err != nil {
+		return Cassette{}, fmt.Errorf("%w: path=%q decode: %v", ErrCassetteInvalid, path, err)
+	}
+	if err := ValidateCassette(cassette); err != nil {
+		return Cassette{}, err
+	}
+	return cassette, nil
+}
+
+// SaveCassette writes a cassette file as indented JSON.
+func SaveCassette(path string, cassette Cassette) error {
+	if err := ValidateCassette(cassette); err != nil {
+		return err
+	}
+	encoded, err := json.MarshalIndent(cassette, "", "  ")
+	if err != nil {
+		return fmt.Errorf("save cassette %q: encode: %w", path, err)
+	}
+	if err := os.WriteFile(path, append(encoded, '\n'), 0o644); err != nil {
+		return fmt.Errorf("save cassette %q: %w", path, err)
+	}
+	return nil
+}
+
+// ValidateCassette checks cassette structure before replay or persistence.
+func ValidateCassette(cassette Cassette) error {
+	if cassette.Version != CassetteVersion {
+		return fmt.Errorf(
+			"%w: field=version reason=unsupported value=%d want=%d",
+			ErrCassetteInvalid,
+			cassette.Version,
+			CassetteVersion,
+		)
+	}
+	for i, interaction := range cassette.Interactions {
+		if interaction.Key == "" {
+			return fmt.Errorf("%w: field=interactions[%d].key reason=empty", ErrCassetteInvalid, i)
+		}
+		switch interaction.Kind {
+		case InteractionKindModel:
+			if interaction.ModelRequest == nil {
+				return fmt.Errorf("%w: field=interactions[%d].model_request reason=nil", ErrCassetteInvalid, i)
+			}
+			if interaction.Message == nil && interaction.Error == nil {
+				return fmt.Errorf("%w: field=interactions[%d].message reason=nil", ErrCassetteInvalid, i)
+			}
+		case InteractionKindTool:
+			if interaction.ToolCall == nil {
+				return fmt.Errorf("%w: field=interactions[%d].tool_call reason=nil", ErrCassetteInvalid, i)
+			}
+			if interaction.ToolResult == nil && interaction.Error == nil {
+				return fmt.Errorf("%w: field=interactions[%d].tool_result reason=nil", ErrCassetteInvalid, i)
+			}
+			if interaction.Error != nil {
+				switch interaction.Error.Kind {
+				case "", RecordedErrorKindToolTimeout, RecordedErrorKindToolPanic:
+				default:
+					return fmt.Errorf(
+						"%w: field=interactions[%d].error.kind reason=unknown value=%q",
+						ErrCassetteInvalid,
+						i,
+						interaction.Error.Kind,
+					)
+				}
+			}
+		default:
+			return fmt.Errorf(
+				"%w: field=interactions[%d].kind reason=unknown value=%q",
+				ErrCassetteInvalid,
+				i,
+				interaction.Kind,
+			)
+		}
+	}
+	return nil
+}
+
+func newModelRequestRecord(request agentreact.ModelRequest) ModelRequestRecord {
+	return cloneModelRequestRecord(ModelRequestRecord{
+		Messages:     request.Messages,
+		Tools:        request.Tools,
+		Resolution:   request.Resolution,
+		OutputSchema: request.OutputSchema,
+		ToolChoice:   request.ToolChoice,
+	})
+}
+
+func cloneModelRequestRecord(in ModelRequestRecord) ModelRequestRecord {
+	out := in
+	out.Messages = agent.CloneMessages(in.Messages)
+	out.Tools = agent.CloneToolDefinitions(in.Tools)
+	out.OutputSchema = agent.CloneSchema(in.OutputSchema)
+	if in.Resolution != nil {
+		resolutionCopy := *in.Resolution
+		out.Resolution = &resolutionCopy
+	}
+	return out
+}
+
+// ModelRequestKey returns the deterministic hash used to match a model request on replay.
+func ModelRequestKey(request agentreact.ModelRequest) (string, error) {
+	return hashPayload(InteractionKindModel, newModelRequestRecord(request))
+}
+
+// ToolCallKey returns the deterministic hash used to match a tool call on replay.
+// The approved replay override is part of the key because executors may behave
+// differently when a suspended call is resumed.
+func ToolCallKey(call agent.ToolCall, override *agent.ApprovedToolCallReplayOverride) (string, error) {
+	return hashPayload(InteractionKindTool, struct {
+		Call     agent.ToolCall                        `json:"call"`
+		Override *agent.ApprovedToolCallReplayOverride `json:"override,omitempty"`
+	}{
+		Call:     call,
+		Override: override,
+	})
+}
+
+func hashPayload(kind InteractionKind, payload any) (string, error) {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("hash %s request: %w", kind, err)
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func newRecordedError(err error) *RecordedError {
+	if err == nil {
+		return nil
+	}
+	recorded := &RecordedError{Message: err.Error()}
+	var suspendRequestErr *agent.SuspendRequestError
+	if errors.As(err, &suspendRequestErr) && suspendRequestErr.Requirement != nil {
+		requirementCopy := *suspendRequestErr.Requirement
+		recorded.Requirement = &requirementCopy
+		if suspendRequestErr.Err != nil {
+			recorded.Message = suspendRequestErr.Err.Error()
+		}
+		return recorded
+	}
+	var timeoutErr *agent.ToolTimeoutError
+	var panicErr *agent.ToolPanicError
+	switch {
+	case errors.As(err, &timeoutErr):
+		recorded.Kind = RecordedErrorKindToolTimeout
+		recorded.Tool = timeoutErr.Tool
+		recorded.Timeout = timeoutErr.Timeout
+	case errors.As(err, &panicErr):
+		recorded.Kind = RecordedErrorKindToolPanic
+		recorded.Tool = panicErr.Tool
+		recorded.PanicValue = fmt.Sprint(panicErr.Value)
+		recorded.Stack = panicErr.Stack
+	}
+	return recorded
+}
+
+func (e *RecordedError) toError() error {
+	if e == nil {
+		return nil
+	}
+	if e.Requirement != nil {
+		requirementCopy := *e.Requirement
+		return &agent.SuspendRequestError{
+			Requirement: &requirementCopy,
+			Err:         errors.New(e.Message),
+		}
+	}
+	var typed error
+	switch e.Kind {
+	case RecordedErrorKindToolTimeout:
+		typed = &agent.ToolTimeoutError{Tool: e.Tool, Timeout: e.Timeout}
+	case RecordedErrorKindToolPanic:
+		typed = &agent.ToolPanicError{Tool: e.Tool, Value: e.PanicValue, Stack: e.Stack}
+	default:
+		return errors.New(e.Message)
+	}
+	if typed.Error() == e.Message {
+		return typed
+	}
+	return &replayedError{message: e.Message, err: typed}
+}
+
+// replayedError keeps the recorded message of an error that wrapped a typed
+// tool error.
+type replayedError struct {
+	message string
+	err     error
+}
+
+func (e *replayedError) Error() string {
+	return e.message
+}
+
+func (e *replayedError) Unwrap() error {
+	return e.err
+}
+
+func cloneInteraction(in Interaction) Interaction {
+	out := in
+	if in.ModelRequest != nil {
+		record := cloneModelRequestRecord(*in.ModelRequest)
+		out.ModelRequest = &record
+	}
+	if in.Message != nil {
+		message := agent.CloneMessage(*in.Message)
+		out.Message = &message
+	}
+	if in.ToolCall != nil {
+		call := agent.CloneToolCall(*in.ToolCall)
+		out.ToolCall = &call
+	}
+	if in.Override != nil {
+		override := *in.Override
+		out.Override = &override
+	}
+	if in.ToolResult != nil {
+		result := agent.CloneToolResult(*in.ToolResult)
+		out.ToolResult = &result
+	}
+	if in.Error != nil {
+		recorded := *in.Error
+		if in.Error.Requirement != nil {
+			requirementCopy := *in.Error.Requirement
+			recorded.Requirement = &requirementCopy
+		}
+		out.Error = &recorded
+	}
+	return out
+}
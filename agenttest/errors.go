@@ -0,0 +1,10 @@
+package agenttest
+
+import "errors"
+
+var (
+	// ErrCassetteInvalid is returned when a cassette payload violates format contracts.
+	ErrCassetteInvalid = errors.New("cassette is invalid")
+	// ErrCassetteDivergence is returned when a replayed request has no matching recorded interaction.
+	ErrCassetteDivergence = errors.New("cassette divergence")
+)
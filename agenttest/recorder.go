@@ -0,0 +1,133 @@
+package agenttest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Gurpartap/agentframe/agent"
+	"github.com/Gurpartap/agentframe/agentreact"
+)
+
+// Recorder captures model and tool interactions from wrapped dependencies.
+type Recorder struct {
+	mu           sync.Mutex
+	interactions []Interaction
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{interactions: make([]Interaction, 0)}
+}
+
+// WrapModel returns a model that forwards to next and records every completed call.
+func (r *Recorder) WrapModel(next agentreact.Model) agentreact.Model {
+	if next == nil {
+		return nil
+	}
+	return &recordingModel{recorder: r, next: next}
+}
+
+// WrapToolExecutor returns a tool executor that forwards to next and records every completed call.
+func (r *Recorder) WrapToolExecutor(next agentreact.ToolExecutor) agentreact.ToolExecutor {
+	if next == nil {
+		return nil
+	}
+	return &recordingToolExecutor{recorder: r, next: next}
+}
+
+// Cassette returns a snapshot of all interactions recorded so far.
+func (r *Recorder) Cassette() Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	interactions := make([]Interaction, len(r.interactions))
+	for i := range r.interactions {
+		interactions[i] = cloneInteraction(r.interactions[i])
+	}
+	return Cassette{
+		Version:      CassetteVersion,
+		Interactions: interactions,
+	}
+}
+
+// Save writes the recorded cassette to path.
+func (r *Recorder) Save(path string) error {
+	return SaveCassette(path, r.Cassette())
+}
+
+func (r *Recorder) append(interaction Interaction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interactions = append(r.interactions, cloneInteraction(interaction))
+}
+
+type recordingModel struct {
+	recorder *Recorder
+	next     agentreact.Model
+}
+
+func (m *recordingModel) Generate(ctx context.Context, request agentreact.ModelRequest) (agent.Message, error) {
+	record := newModelRequestRecord(request)
+	key, err := ModelRequestKey(request)
+	if err != nil {
+		return agent.Message{}, err
+	}
+
+	message, generateErr := m.next.Generate(ctx, request)
+	if ctx.Err() != nil {
+		return message, generateErr
+	}
+
+	interaction := Interaction{
+		Kind:         InteractionKindModel,
+		Key:          key,
+		ModelRequest: &record,
+		Error:        newRecordedError(generateErr),
+	}
+	if generateErr == nil {
+		messageCopy := agent.CloneMessage(message)
+		interaction.Message = &messageCopy
+	}
+	m.recorder.append(interaction)
+	return message, generateErr
+}
+
+type recordingToolExecutor struct {
+	recorder *Recorder
+	next     agentreact.ToolExecutor
+}
+
+func (e *recordingToolExecutor) Execute(ctx context.Context, call agent.ToolCall) (agent.ToolResult, error) {
+	override := overrideFromContext(ctx)
+	key, err := ToolCallKey(call, override)
+	if err != nil {
+		return agent.ToolResult{}, err
+	}
+
+	result, executeErr := e.next.Execute(ctx, call)
+	if ctx.Err() != nil {
+		return result, executeErr
+	}
+
+	callCopy := agent.CloneToolCall(call)
+	interaction := Interaction{
+		Kind:     InteractionKindTool,
+		Key:      key,
+		ToolCall: &callCopy,
+		Override: override,
+		Error:    newRecordedError(executeErr),
+	}
+	if executeErr == nil {
+		resultCopy := agent.CloneToolResult(result)
+		interaction.ToolResult = &resultCopy
+	}
+	e.recorder.append(interaction)
+	return result, executeErr
+}
+
+func overrideFromContext(ctx context.Context) *agent.ApprovedToolCallReplayOverride {
+	override, ok := agent.ApprovedToolCallReplayOverrideFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	return &override
+}
@@ -0,0 +1,156 @@
+package agenttest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/Gurpartap/agentframe/agent"
+	"github.com/Gurpartap/agentframe/agentreact"
+)
+
+// Replayer serves recorded interactions back by request hash.
+// Identical requests are served in the order they were recorded.
+type Replayer struct {
+	mu       sync.Mutex
+	pending  map[string][]int
+	consumed []bool
+	cassette Cassette
+}
+
+func NewReplayer(cassette Cassette) (*Replayer, error) {
+	if err := ValidateCassette(cassette); err != nil {
+		return nil, fmt.Errorf("new replayer: %w", err)
+	}
+	cloned := Cassette{
+		Version:      cassette.Version,
+		Interactions: make([]Interaction, len(cassette.Interactions)),
+	}
+	pending := make(map[string][]int, len(cassette.Interactions))
+	for i := range cassette.Interactions {
+		cloned.Interactions[i] = cloneInteraction(cassette.Interactions[i])
+		queueKey := replayQueueKey(cloned.Interactions[i].Kind, cloned.Interactions[i].Key)
+		pending[queueKey] = append(pending[queueKey], i)
+	}
+	return &Replayer{
+		pending:  pending,
+		consumed: make([]bool, len(cloned.Interactions)),
+		cassette: cloned,
+	}, nil
+}
+
+// NewReplayerFromFile loads a cassette file and returns a replayer for it.
+func NewReplayerFromFile(path string) (*Replayer, error) {
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewReplayer(cassette)
+}
+
+// Model returns an agentreact.Model backed by recorded model interactions.
+func (r *Replayer) Model() agentreact.Model {
+	return replayModel{replayer: r}
+}
+
+// ToolExecutor returns an agentreact.ToolExecutor backed by recorded tool interactions.
+func (r *Replayer) ToolExecutor() agentreact.ToolExecutor {
+	return replayToolExecutor{replayer: r}
+}
+
+// Unused returns recorded interactions that have not been served yet.
+func (r *Replayer) Unused() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make([]Interaction, 0)
+	for i := range r.cassette.Interactions {
+		if !r.consumed[i] {
+			out = append(out, cloneInteraction(r.cassette.Interactions[i]))
+		}
+	}
+	return out
+}
+
+func (r *Replayer) next(kind InteractionKind, key string) (Interaction, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	queueKey := replayQueueKey(kind, key)
+	queue := r.pending[queueKey]
+	if len(queue) == 0 {
+		return Interaction{}, false
+	}
+	index := queue[0]
+	r.pending[queueKey] = queue[1:]
+	r.consumed[index] = true
+	return cloneInteraction(r.cassette.Interactions[index]), true
+}
+
+func replayQueueKey(kind InteractionKind, key string) string {
+	return string(kind) + ":" + key
+}
+
+type replayModel struct {
+	replayer *Replayer
+}
+
+func (m replayModel) Generate(ctx context.Context, request agentreact.ModelRequest) (agent.Message, error) {
+	if ctx == nil {
+		return agent.Message{}, agent.ErrContextNil
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return agent.Message{}, ctxErr
+	}
+	key, err := ModelRequestKey(request)
+	if err != nil {
+		return agent.Message{}, err
+	}
+	interaction, ok := m.replayer.next(InteractionKindModel, key)
+	if !ok {
+		return agent.Message{}, fmt.Errorf(
+			"%w: kind=%s key=%s messages=%d tools=%d",
+			ErrCassetteDivergence,
+			InteractionKindModel,
+			key,
+			len(request.Messages),
+			len(request.Tools),
+		)
+	}
+	if interaction.Error != nil {
+		return agent.Message{}, interaction.Error.toError()
+	}
+	return agent.CloneMessage(*interaction.Message), nil
+}
+
+type replayToolExecutor struct {
+	replayer *Replayer
+}
+
+func (e replayToolExecutor) Execute(ctx context.Context, call agent.ToolCall) (agent.ToolResult, error) {
+	if ctx == nil {
+		return agent.ToolResult{}, agent.ErrContextNil
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return agent.ToolResult{}, ctxErr
+	}
+	key, err := ToolCallKey(call, overrideFromContext(ctx))
+	if err != nil {
+		return agent.ToolResult{}, err
+	}
+	interaction, ok := e.replayer.next(InteractionKindTool, key)
+	if !ok {
+		return agent.ToolResult{}, fmt.Errorf(
+			"%w: kind=%s key=%s call_id=%q name=%q",
+			ErrCassetteDivergence,
+			InteractionKindTool,
+			key,
+			call.ID,
+			call.Name,
+		)
+	}
+	if interaction.Error != nil {
+		return agent.ToolResult{}, interaction.Error.toError()
+	}
+	return *interaction.ToolResult, nil
+}
@@ -2,6 +2,7 @@ package inmem
 
 import (
 	"context"
+	"slices"
 	"sync"
 
 	"github.com/Gurpartap/agentframe/agent"
@@ -55,8 +56,10 @@ func cloneEvent(in agent.Event) agent.Event {
 		out.Message = &message
 	}
 	if in.ToolResult != nil {
-		result := *in.ToolResult
+		result := agent.CloneToolResult(*in.ToolResult)
 		out.ToolResult = &result
 	}
+	out.Plan = agent.ClonePlan(in.Plan)
+	out.SelectedTools = slices.Clone(in.SelectedTools)
 	return out
 }
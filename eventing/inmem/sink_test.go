@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/Gurpartap/agentframe/agent"
+	"github.com/Gurpartap/agentframe/agentconformance"
 	eventinginmem "github.com/Gurpartap/agentframe/eventing/inmem"
 )
 
@@ -212,3 +213,23 @@ func TestSink_PublishRejectsInvalidEventShapesWithoutSideEffects(t *testing.T) {
 		})
 	}
 }
+
+func TestSink_Conformance(t *testing.T) {
+	t.Parallel()
+
+	agentconformance.EventSinkSuite(t, func(*testing.T) agentconformance.EventSinkHarness {
+		sink := eventinginmem.New()
+		return agentconformance.EventSinkHarness{
+			Sink: sink,
+			Events: func(runID agent.RunID) ([]agent.Event, error) {
+				out := make([]agent.Event, 0)
+				for _, event := range sink.Events() {
+					if event.RunID == runID {
+						out = append(out, event)
+					}
+				}
+				return out, nil
+			},
+		}
+	})
+}
@@ -125,6 +125,20 @@ func (c *Client) Cancel(ctx context.Context, runID string) (RunState, []byte, er
 	return response, raw, nil
 }
 
+func (c *Client) Rollback(ctx context.Context, runID string, request RollbackRequest) (RollbackResponse, []byte, error) {
+	path, err := runPath(runID)
+	if err != nil {
+		return RollbackResponse{}, nil, err
+	}
+
+	var response RollbackResponse
+	raw, err := c.doJSON(ctx, http.MethodPost, path+"/rollback", request, &response, true)
+	if err != nil {
+		return RollbackResponse{}, nil, err
+	}
+	return response, raw, nil
+}
+
 func (c *Client) doJSON(ctx context.Context, method, path string, payload any, out any, requiresAuth bool) ([]byte, error) {
 	raw, err := c.doRaw(ctx, method, path, payload, requiresAuth)
 	if err != nil {
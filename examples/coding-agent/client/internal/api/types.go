@@ -1,10 +1,13 @@
 package api
 
+import "encoding/json"
+
 type StartRequest struct {
-	RunID        string `json:"run_id,omitempty"`
-	SystemPrompt string `json:"system_prompt,omitempty"`
-	UserPrompt   string `json:"user_prompt"`
-	MaxSteps     *int   `json:"max_steps,omitempty"`
+	RunID        string          `json:"run_id,omitempty"`
+	SystemPrompt string          `json:"system_prompt,omitempty"`
+	UserPrompt   string          `json:"user_prompt"`
+	MaxSteps     *int            `json:"max_steps,omitempty"`
+	OutputSchema json.RawMessage `json:"output_schema,omitempty"`
 }
 
 type ContinueRequest struct {
@@ -29,12 +32,23 @@ type FollowUpRequest struct {
 	MaxSteps *int   `json:"max_steps,omitempty"`
 }
 
+type RollbackRequest struct {
+	Step int `json:"step"`
+}
+
+type RollbackResponse struct {
+	RunID    string   `json:"run_id"`
+	Step     int      `json:"step"`
+	Restored []string `json:"restored"`
+}
+
 type RunState struct {
 	RunID              string              `json:"run_id"`
 	Status             string              `json:"status"`
 	Step               int                 `json:"step"`
 	Version            int64               `json:"version"`
 	Output             string              `json:"output,omitempty"`
+	StructuredOutput   json.RawMessage     `json:"structured_output,omitempty"`
 	Error              string              `json:"error,omitempty"`
 	PendingRequirement *PendingRequirement `json:"pending_requirement,omitempty"`
 }
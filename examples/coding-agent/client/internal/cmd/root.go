@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
@@ -21,13 +22,14 @@ const usageText = `Usage:
 Commands:
   chat
   health
-  start --user-prompt <text> [--run-id <id>] [--system-prompt <text>] [--max-steps <n>]
+  start --user-prompt <text> [--run-id <id>] [--system-prompt <text>] [--max-steps <n>] [--output-schema <json>]
   get <run-id>
   events <run-id> [--cursor <n>]
   continue <run-id> [--command-id <id>] [--max-steps <n>] [--requirement-id <id> --kind <kind> --outcome <outcome> [--value <value>]]
   steer <run-id> --instruction <text>
   follow-up <run-id> --prompt <text> [--max-steps <n>]
   cancel <run-id>
+  rollback <run-id> --step <n>
 
 Continue Resolution Examples:
   continue run-000001 --requirement-id req-approval --kind approval --outcome approved
@@ -119,6 +121,8 @@ func executeWithInput(ctx context.Context, args []string, input io.Reader, stdou
 		return runFollowUp(ctx, api, cfg.JSON, commandArgs, stdout)
 	case "cancel":
 		return runCancel(ctx, api, cfg.JSON, commandArgs, stdout)
+	case "rollback":
+		return runRollback(ctx, api, cfg.JSON, commandArgs, stdout)
 	default:
 		fs.Usage()
 		return fmt.Errorf("unsupported command %q", command)
@@ -152,6 +156,7 @@ func runStart(ctx context.Context, client *api.Client, jsonMode bool, args []str
 	systemPrompt := fs.String("system-prompt", "", "system prompt")
 	userPrompt := fs.String("user-prompt", "", "user prompt")
 	maxSteps := fs.Int("max-steps", -1, "max command steps")
+	outputSchema := fs.String("output-schema", "", "JSON schema object the final answer must match")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
@@ -172,6 +177,12 @@ func runStart(ctx context.Context, client *api.Client, jsonMode bool, args []str
 		return err
 	}
 	request.MaxSteps = optionalMaxSteps
+	if trimmed := strings.TrimSpace(*outputSchema); trimmed != "" {
+		if !json.Valid([]byte(trimmed)) {
+			return errors.New("output-schema must be valid JSON")
+		}
+		request.OutputSchema = json.RawMessage(trimmed)
+	}
 
 	state, raw, err := client.Start(ctx, request)
 	if err != nil {
@@ -340,6 +351,45 @@ func runCancel(ctx context.Context, client *api.Client, jsonMode bool, args []st
 	return writeRunState(stdout, state)
 }
 
+func runRollback(ctx context.Context, client *api.Client, jsonMode bool, args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("rollback requires <run-id>")
+	}
+	runID := strings.TrimSpace(args[0])
+
+	fs := flag.NewFlagSet("rollback", flag.ContinueOnError)
+	step := fs.Int("step", -1, "step whose workspace state to restore (0 undoes the whole run)")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if len(fs.Args()) != 0 {
+		return errors.New("rollback accepts one run-id and flags only")
+	}
+	if *step < 0 {
+		return errors.New("rollback requires --step >= 0")
+	}
+
+	response, raw, err := client.Rollback(ctx, runID, api.RollbackRequest{Step: *step})
+	if err != nil {
+		return err
+	}
+	if jsonMode {
+		return writeRaw(stdout, raw)
+	}
+	if _, err := fmt.Fprintf(stdout, "run_id: %s\n", response.RunID); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(stdout, "step: %d\n", response.Step); err != nil {
+		return err
+	}
+	for _, path := range response.Restored {
+		if _, err := fmt.Fprintf(stdout, "restored: %s\n", path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func parseOptionalMaxSteps(raw int) (*int, error) {
 	if raw < -1 {
 		return nil, errors.New("max-steps must be >= -1")
@@ -377,6 +427,11 @@ func writeRunState(out io.Writer, state api.RunState) error {
 			return err
 		}
 	}
+	if len(state.StructuredOutput) > 0 {
+		if _, err := fmt.Fprintf(out, "structured_output: %s\n", state.StructuredOutput); err != nil {
+			return err
+		}
+	}
 	if state.Error != "" {
 		if _, err := fmt.Fprintf(out, "error: %s\n", state.Error); err != nil {
 			return err
@@ -378,3 +378,45 @@ func TestExecutePrintsPendingRequirementReplayBinding(t *testing.T) {
 		t.Fatalf("missing pending requirement replay binding in output: %q", output)
 	}
 }
+
+func TestExecuteRollbackPrintsRestoredPaths(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/runs/run-000001/rollback" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			t.Errorf("authorization header mismatch: got=%q", r.Header.Get("Authorization"))
+		}
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body["step"] != float64(1) {
+			t.Errorf("rollback body mismatch: body=%v err=%v", body, err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"run_id":"run-000001","step":1,"restored":["main.go","notes.txt"]}`+"\n")
+	}))
+	defer server.Close()
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	err := Execute(
+		context.Background(),
+		[]string{"--base-url", server.URL, "--token", "test-token", "rollback", "run-000001", "--step", "1"},
+		&stdout,
+		&stderr,
+	)
+	if err != nil {
+		t.Fatalf("execute: %v stderr=%s", err, stderr.String())
+	}
+	want := "run_id: run-000001\nstep: 1\nrestored: main.go\nrestored: notes.txt\n"
+	if stdout.String() != want {
+		t.Fatalf("rollback stdout mismatch: got=%q want=%q", stdout.String(), want)
+	}
+
+	err = Execute(context.Background(), []string{"--base-url", server.URL, "rollback", "run-000001"}, io.Discard, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "--step") {
+		t.Fatalf("expected missing step error, got %v", err)
+	}
+}
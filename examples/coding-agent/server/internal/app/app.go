@@ -78,6 +78,18 @@ func (a *App) Shutdown(ctx context.Context) error {
 		return errors.New("shutdown: nil context")
 	}
 	a.ready.Store(false)
+	// Deferred calls run last-in first-out: processes stop before their
+	// workspaces are removed.
+	if a.runtime.Workspaces != nil {
+		defer func() {
+			if err := a.runtime.Workspaces.Close(); err != nil {
+				a.logger.Warn("release run workspaces", slog.Any("error", err))
+			}
+		}()
+	}
+	if a.runtime.Processes != nil {
+		defer a.runtime.Processes.Close()
+	}
 
 	err := a.server.Shutdown(ctx)
 	if err == nil {
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -19,6 +21,12 @@ const (
 	defaultProviderTimeout = 30 * time.Second
 	defaultToolMode        = ToolModeReal
 	defaultBashTimeout     = 3 * time.Second
+	defaultBashSandbox     = BashSandboxOff
+	defaultStaleFileMode   = StaleFileModeApprove
+	defaultWorkspaceMode   = WorkspaceModeShared
+	defaultRedaction       = RedactionOn
+	defaultMaxToolResult   = 32 << 10
+	defaultMaxProcesses    = 4
 	defaultLogLevel        = slog.LevelInfo
 )
 
@@ -36,6 +44,45 @@ const (
 	ToolModeReal ToolMode = "real"
 )
 
+// BashSandbox selects how the real toolset isolates bash commands.
+type BashSandbox string
+
+const (
+	BashSandboxOff        BashSandbox = "off"
+	BashSandboxAuto       BashSandbox = "auto"
+	BashSandboxBubblewrap BashSandbox = "bwrap"
+	BashSandboxUnshare    BashSandbox = "unshare"
+)
+
+// WorkspaceMode selects whether runs share WorkspaceRoot or each get a
+// private workspace provisioned from it.
+type WorkspaceMode string
+
+const (
+	WorkspaceModeShared   WorkspaceMode = "shared"
+	WorkspaceModeCopy     WorkspaceMode = "copy"
+	WorkspaceModeWorktree WorkspaceMode = "worktree"
+	WorkspaceModeOverlay  WorkspaceMode = "overlay"
+)
+
+// StaleFileMode selects what write and edit do when their target changed on
+// disk since the run last read it.
+type StaleFileMode string
+
+const (
+	StaleFileModeApprove StaleFileMode = "approve"
+	StaleFileModeRefuse  StaleFileMode = "refuse"
+)
+
+// Redaction selects whether secrets are replaced with placeholders in tool
+// results and published events.
+type Redaction string
+
+const (
+	RedactionOn  Redaction = "on"
+	RedactionOff Redaction = "off"
+)
+
 type LogFormat string
 
 const (
@@ -56,7 +103,32 @@ type Config struct {
 	ProviderTimeout time.Duration
 	ToolMode        ToolMode
 	WorkspaceRoot   string
+	// WorkspaceMode isolates runs in private workspaces under RunWorkspaceDir,
+	// using WorkspaceRoot as their template, unless it is "shared".
+	WorkspaceMode   WorkspaceMode
+	RunWorkspaceDir string
 	BashTimeout     time.Duration
+	// BashSandbox runs bash inside Linux namespaces when not "off", which also
+	// allows build and test commands.
+	BashSandbox BashSandbox
+	// BashPolicyFile replaces the built-in bash command policy with a JSON
+	// policy file when set.
+	BashPolicyFile string
+	// ArtifactDir stores tool results larger than MaxToolResultBytes.
+	ArtifactDir        string
+	MaxToolResultBytes int
+	// SnapshotDir stores file contents captured before write, edit and
+	// apply_patch so runs can be rolled back.
+	SnapshotDir string
+	// MaxProcessesPerRun caps the background processes a run may keep
+	// running through proc_start.
+	MaxProcessesPerRun int
+	StaleFileMode      StaleFileMode
+	// Redaction replaces secrets in tool results and events unless "off".
+	// RedactPatternsFile adds RE2 patterns, one per line, to the built-in
+	// detectors.
+	Redaction          Redaction
+	RedactPatternsFile string
 }
 
 // Load reads runtime configuration from environment variables.
@@ -120,6 +192,12 @@ func Load() (Config, error) {
 	if root := strings.TrimSpace(os.Getenv("CODING_AGENT_WORKSPACE_ROOT")); root != "" {
 		cfg.WorkspaceRoot = root
 	}
+	if mode := strings.TrimSpace(os.Getenv("CODING_AGENT_WORKSPACE_MODE")); mode != "" {
+		cfg.WorkspaceMode = WorkspaceMode(strings.ToLower(mode))
+	}
+	if dir := strings.TrimSpace(os.Getenv("CODING_AGENT_RUN_WORKSPACE_DIR")); dir != "" {
+		cfg.RunWorkspaceDir = dir
+	}
 	if timeout := strings.TrimSpace(os.Getenv("CODING_AGENT_BASH_TIMEOUT")); timeout != "" {
 		parsed, err := time.ParseDuration(timeout)
 		if err != nil {
@@ -130,6 +208,49 @@ func Load() (Config, error) {
 		}
 		cfg.BashTimeout = parsed
 	}
+	if sandbox := strings.TrimSpace(os.Getenv("CODING_AGENT_BASH_SANDBOX")); sandbox != "" {
+		cfg.BashSandbox = BashSandbox(strings.ToLower(sandbox))
+	}
+	if mode := strings.TrimSpace(os.Getenv("CODING_AGENT_STALE_FILE_MODE")); mode != "" {
+		cfg.StaleFileMode = StaleFileMode(strings.ToLower(mode))
+	}
+	if file := strings.TrimSpace(os.Getenv("CODING_AGENT_BASH_POLICY_FILE")); file != "" {
+		cfg.BashPolicyFile = file
+	}
+
+	if redaction := strings.TrimSpace(os.Getenv("CODING_AGENT_REDACTION")); redaction != "" {
+		cfg.Redaction = Redaction(strings.ToLower(redaction))
+	}
+	if file := strings.TrimSpace(os.Getenv("CODING_AGENT_REDACT_PATTERNS_FILE")); file != "" {
+		cfg.RedactPatternsFile = file
+	}
+
+	if dir := strings.TrimSpace(os.Getenv("CODING_AGENT_ARTIFACT_DIR")); dir != "" {
+		cfg.ArtifactDir = dir
+	}
+	if dir := strings.TrimSpace(os.Getenv("CODING_AGENT_SNAPSHOT_DIR")); dir != "" {
+		cfg.SnapshotDir = dir
+	}
+	if limit := strings.TrimSpace(os.Getenv("CODING_AGENT_MAX_TOOL_RESULT_BYTES")); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil {
+			return Config{}, fmt.Errorf("parse CODING_AGENT_MAX_TOOL_RESULT_BYTES: %w", err)
+		}
+		if parsed <= 0 {
+			return Config{}, fmt.Errorf("parse CODING_AGENT_MAX_TOOL_RESULT_BYTES: value must be > 0")
+		}
+		cfg.MaxToolResultBytes = parsed
+	}
+	if limit := strings.TrimSpace(os.Getenv("CODING_AGENT_MAX_PROCESSES_PER_RUN")); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil {
+			return Config{}, fmt.Errorf("parse CODING_AGENT_MAX_PROCESSES_PER_RUN: %w", err)
+		}
+		if parsed <= 0 {
+			return Config{}, fmt.Errorf("parse CODING_AGENT_MAX_PROCESSES_PER_RUN: value must be > 0")
+		}
+		cfg.MaxProcessesPerRun = parsed
+	}
 
 	if err := cfg.Validate(); err != nil {
 		return Config{}, err
@@ -155,7 +276,17 @@ func Default() Config {
 		ProviderTimeout: defaultProviderTimeout,
 		ToolMode:        defaultToolMode,
 		WorkspaceRoot:   workspaceRoot,
+		WorkspaceMode:   defaultWorkspaceMode,
+		RunWorkspaceDir: filepath.Join(os.TempDir(), "coding-agent-workspaces"),
 		BashTimeout:     defaultBashTimeout,
+		BashSandbox:     defaultBashSandbox,
+
+		ArtifactDir:        filepath.Join(os.TempDir(), "coding-agent-artifacts"),
+		MaxToolResultBytes: defaultMaxToolResult,
+		SnapshotDir:        filepath.Join(os.TempDir(), "coding-agent-snapshots"),
+		MaxProcessesPerRun: defaultMaxProcesses,
+		StaleFileMode:      defaultStaleFileMode,
+		Redaction:          defaultRedaction,
 	}
 }
 
@@ -190,9 +321,59 @@ func (c Config) Validate() error {
 		if strings.TrimSpace(c.WorkspaceRoot) == "" {
 			return errors.New("validate config: real tool mode requires CODING_AGENT_WORKSPACE_ROOT")
 		}
+		switch c.WorkspaceMode {
+		case "", WorkspaceModeShared:
+		case WorkspaceModeCopy, WorkspaceModeWorktree, WorkspaceModeOverlay:
+			if strings.TrimSpace(c.RunWorkspaceDir) == "" {
+				return errors.New("validate config: isolated workspace modes require CODING_AGENT_RUN_WORKSPACE_DIR")
+			}
+		default:
+			return fmt.Errorf(
+				"validate config: unsupported CODING_AGENT_WORKSPACE_MODE %q (allowed: %q, %q, %q, %q)",
+				c.WorkspaceMode,
+				WorkspaceModeShared,
+				WorkspaceModeCopy,
+				WorkspaceModeWorktree,
+				WorkspaceModeOverlay,
+			)
+		}
 		if c.BashTimeout <= 0 {
 			return errors.New("validate config: real tool mode requires CODING_AGENT_BASH_TIMEOUT > 0")
 		}
+		switch c.BashSandbox {
+		case "", BashSandboxOff, BashSandboxAuto, BashSandboxBubblewrap, BashSandboxUnshare:
+		default:
+			return fmt.Errorf(
+				"validate config: unsupported CODING_AGENT_BASH_SANDBOX %q (allowed: %q, %q, %q, %q)",
+				c.BashSandbox,
+				BashSandboxOff,
+				BashSandboxAuto,
+				BashSandboxBubblewrap,
+				BashSandboxUnshare,
+			)
+		}
+		if strings.TrimSpace(c.ArtifactDir) == "" {
+			return errors.New("validate config: real tool mode requires CODING_AGENT_ARTIFACT_DIR")
+		}
+		if c.MaxToolResultBytes <= 0 {
+			return errors.New("validate config: real tool mode requires CODING_AGENT_MAX_TOOL_RESULT_BYTES > 0")
+		}
+		if strings.TrimSpace(c.SnapshotDir) == "" {
+			return errors.New("validate config: real tool mode requires CODING_AGENT_SNAPSHOT_DIR")
+		}
+		if c.MaxProcessesPerRun <= 0 {
+			return errors.New("validate config: real tool mode requires CODING_AGENT_MAX_PROCESSES_PER_RUN > 0")
+		}
+		switch c.StaleFileMode {
+		case "", StaleFileModeApprove, StaleFileModeRefuse:
+		default:
+			return fmt.Errorf(
+				"validate config: unsupported CODING_AGENT_STALE_FILE_MODE %q (allowed: %q, %q)",
+				c.StaleFileMode,
+				StaleFileModeApprove,
+				StaleFileModeRefuse,
+			)
+		}
 	default:
 		return fmt.Errorf(
 			"validate config: unsupported CODING_AGENT_TOOL_MODE %q (allowed: %q, %q)",
@@ -202,6 +383,17 @@ func (c Config) Validate() error {
 		)
 	}
 
+	switch c.Redaction {
+	case "", RedactionOn, RedactionOff:
+	default:
+		return fmt.Errorf(
+			"validate config: unsupported CODING_AGENT_REDACTION %q (allowed: %q, %q)",
+			c.Redaction,
+			RedactionOn,
+			RedactionOff,
+		)
+	}
+
 	switch c.LogLevel {
 	case slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError:
 	default:
@@ -0,0 +1,138 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Gurpartap/agentframe/agent"
+	"github.com/Gurpartap/agentframe/examples/coding-agent/server/internal/policylimit"
+)
+
+// MaxImportBodyBytes is the request body limit of POST /v1/runs/import.
+const MaxImportBodyBytes = 32 << 20
+
+type importResponse struct {
+	runStateResponse
+	SourceRunID string `json:"source_run_id"`
+	Events      int    `json:"events"`
+}
+
+func (h *handlers) handleRunExport(w http.ResponseWriter, r *http.Request) {
+	if !h.ensureBundleRuntime(w) {
+		return
+	}
+
+	runID, err := pathRunID(r)
+	if err != nil {
+		writeMappedError(w, err)
+		return
+	}
+
+	state, err := h.runtime.RunStore.Load(r.Context(), runID)
+	if err != nil {
+		writeMappedError(w, err)
+		return
+	}
+	if state.Status == agent.RunStatusRunning {
+		writeError(w, http.StatusConflict, errorCodeConflict, "run is executing; export after it stops")
+		return
+	}
+
+	var events []agent.Event
+	for _, event := range h.runtime.EventSink.Events() {
+		if event.RunID == runID {
+			events = append(events, event)
+		}
+	}
+
+	bundle, err := agent.ExportRunBundle(state, events, h.runtime.ToolDefinitions, h.runtime.Metadata)
+	if err != nil {
+		writeMappedError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, bundle)
+}
+
+// handleRunImport stores a bundle's run under a fresh local run ID, so
+// imported IDs never collide with runs started here, and replays its events
+// into the event history and stream.
+func (h *handlers) handleRunImport(w http.ResponseWriter, r *http.Request) {
+	if !h.ensureBundleRuntime(w) {
+		return
+	}
+	if r.Body == nil {
+		writeInvalidRequest(w, "request body is required")
+		return
+	}
+
+	bundle, err := agent.ImportRunBundle(r.Body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeMappedError(w, fmt.Errorf("%w: request body exceeds %d bytes", policylimit.ErrRequestTooLarge, maxBytesErr.Limit))
+			return
+		}
+		writeMappedError(w, err)
+		return
+	}
+	if bundle.State.Status == agent.RunStatusRunning {
+		writeInvalidRequest(w, "cannot import a run that was executing when exported")
+		return
+	}
+
+	runID, err := h.runtime.IDGenerator.NewRunID(r.Context())
+	if err != nil {
+		writeMappedError(w, err)
+		return
+	}
+	state := bundle.State
+	state.ID = runID
+	state.Version = 0
+	if err := h.runtime.RunStore.Save(r.Context(), state); err != nil {
+		writeMappedError(w, err)
+		return
+	}
+	if err := h.replayImportedEvents(r.Context(), runID, bundle.Events); err != nil {
+		writeMappedError(w, err)
+		return
+	}
+
+	saved, err := h.runtime.RunStore.Load(r.Context(), runID)
+	if err != nil {
+		writeMappedError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, importResponse{
+		runStateResponse: newRunStateResponse(saved),
+		SourceRunID:      string(bundle.State.ID),
+		Events:           len(bundle.Events),
+	})
+}
+
+func (h *handlers) ensureBundleRuntime(w http.ResponseWriter) bool {
+	if !h.ensureRuntime(w) {
+		return false
+	}
+	if h.runtime.EventSink == nil || h.runtime.IDGenerator == nil {
+		writeError(w, http.StatusInternalServerError, errorCodeRuntime, "runtime dependencies are not initialized")
+		return false
+	}
+	return true
+}
+
+// replayImportedEvents publishes events only to the event history and stream
+// broker; tool-side sinks must not act on another environment's run.
+func (h *handlers) replayImportedEvents(ctx context.Context, runID agent.RunID, events []agent.Event) error {
+	for _, event := range events {
+		event.RunID = runID
+		if err := h.runtime.EventSink.Publish(ctx, event); err != nil {
+			return err
+		}
+		if err := h.runtime.StreamBroker.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
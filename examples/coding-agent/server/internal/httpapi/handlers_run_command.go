@@ -10,10 +10,12 @@ import (
 )
 
 type startRequest struct {
-	RunID        string `json:"run_id"`
-	SystemPrompt string `json:"system_prompt"`
-	UserPrompt   string `json:"user_prompt"`
-	MaxSteps     *int   `json:"max_steps"`
+	RunID        string           `json:"run_id"`
+	SystemPrompt string           `json:"system_prompt"`
+	UserPrompt   string           `json:"user_prompt"`
+	MaxSteps     *int             `json:"max_steps"`
+	OutputSchema map[string]any   `json:"output_schema"`
+	ToolChoice   agent.ToolChoice `json:"tool_choice"`
 }
 
 type continueRequest struct {
@@ -70,6 +72,8 @@ func (h *handlers) handleRunStart(w http.ResponseWriter, r *http.Request) {
 		UserPrompt:   request.UserPrompt,
 		MaxSteps:     maxSteps,
 		Tools:        h.runtime.ToolDefinitions,
+		OutputSchema: request.OutputSchema,
+		ToolChoice:   request.ToolChoice,
 	})
 	if err != nil && !isAcceptedRunError(err) {
 		writeMappedError(w, err)
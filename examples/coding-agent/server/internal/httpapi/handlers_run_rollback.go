@@ -0,0 +1,76 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Gurpartap/agentframe/agent"
+	"github.com/Gurpartap/agentframe/examples/coding-agent/server/internal/toolset"
+)
+
+type rollbackRequest struct {
+	Step *int `json:"step"`
+}
+
+type rollbackResponse struct {
+	RunID    string   `json:"run_id"`
+	Step     int      `json:"step"`
+	Restored []string `json:"restored"`
+}
+
+func (h *handlers) handleRunRollback(w http.ResponseWriter, r *http.Request) {
+	if !h.ensureRuntime(w) {
+		return
+	}
+	if h.runtime.Snapshots == nil {
+		writeInvalidRequest(w, "rollback requires real tool mode")
+		return
+	}
+
+	runID, err := pathRunID(r)
+	if err != nil {
+		writeMappedError(w, err)
+		return
+	}
+
+	var request rollbackRequest
+	if err := decodeJSONBody(r, &request); err != nil {
+		writeMappedError(w, err)
+		return
+	}
+	if request.Step == nil {
+		writeInvalidRequest(w, "step is required")
+		return
+	}
+
+	state, err := h.runtime.RunStore.Load(r.Context(), runID)
+	if err != nil {
+		writeMappedError(w, err)
+		return
+	}
+	step := *request.Step
+	if step < 0 || step > state.Step {
+		writeInvalidRequest(w, "step must be between 0 and the run's current step")
+		return
+	}
+	if state.Status == agent.RunStatusRunning {
+		writeError(w, http.StatusConflict, errorCodeConflict, "run is executing; rollback after it stops")
+		return
+	}
+
+	restored, err := h.runtime.Snapshots.Rollback(runID, step)
+	if errors.Is(err, toolset.ErrWorkspaceNotFound) {
+		writeError(w, http.StatusConflict, errorCodeConflict, "run workspace is not available; isolated workspaces are removed when runs end")
+		return
+	}
+	if err != nil {
+		writeMappedError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, rollbackResponse{
+		RunID:    string(runID),
+		Step:     step,
+		Restored: restored,
+	})
+}
@@ -0,0 +1,106 @@
+package httpapi_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Gurpartap/agentframe/agent"
+)
+
+type importResponse struct {
+	runStateResponse
+	SourceRunID string `json:"source_run_id"`
+	Events      int    `json:"events"`
+}
+
+func TestRunExportImportAcrossServers(t *testing.T) {
+	t.Parallel()
+
+	source := newTestServer(t)
+	defer source.Close()
+	target := newTestServer(t)
+	defer target.Close()
+
+	var started runStateResponse
+	status := performJSON(t, source.Client(), http.MethodPost, source.URL+"/v1/runs/start", map[string]any{
+		"user_prompt": "[loop] export me",
+		"max_steps":   1,
+	}, &started)
+	if status != http.StatusOK {
+		t.Fatalf("start status mismatch: got=%d want=%d", status, http.StatusOK)
+	}
+
+	var bundle agent.RunBundle
+	status = performJSON(t, source.Client(), http.MethodGet, source.URL+"/v1/runs/"+started.RunID+"/export", nil, &bundle)
+	if status != http.StatusOK {
+		t.Fatalf("export status mismatch: got=%d want=%d", status, http.StatusOK)
+	}
+	if bundle.Version != agent.RunBundleVersion || string(bundle.State.ID) != started.RunID {
+		t.Fatalf("bundle header mismatch: version=%d run_id=%q", bundle.Version, bundle.State.ID)
+	}
+	if len(bundle.Events) != 6 || len(bundle.Tools) == 0 {
+		t.Fatalf("bundle contents mismatch: events=%d tools=%d", len(bundle.Events), len(bundle.Tools))
+	}
+	if bundle.Metadata["tool_mode"] != "mock" {
+		t.Fatalf("bundle metadata mismatch: got=%v", bundle.Metadata)
+	}
+
+	// The target already owns the source's run ID, so the import must not reuse it.
+	var local runStateResponse
+	if status := performJSON(t, target.Client(), http.MethodPost, target.URL+"/v1/runs/start", map[string]any{
+		"user_prompt": "local run",
+		"max_steps":   2,
+	}, &local); status != http.StatusOK {
+		t.Fatalf("local start status mismatch: got=%d want=%d", status, http.StatusOK)
+	}
+
+	var imported importResponse
+	status = performJSON(t, target.Client(), http.MethodPost, target.URL+"/v1/runs/import", bundle, &imported)
+	if status != http.StatusOK {
+		t.Fatalf("import status mismatch: got=%d want=%d", status, http.StatusOK)
+	}
+	if imported.RunID == "" || imported.RunID == local.RunID {
+		t.Fatalf("imported run_id must be fresh: got=%q local=%q", imported.RunID, local.RunID)
+	}
+	if imported.SourceRunID != started.RunID || imported.Events != 6 {
+		t.Fatalf("import response mismatch: source_run_id=%q events=%d", imported.SourceRunID, imported.Events)
+	}
+
+	var queried runStateResponse
+	status = performJSON(t, target.Client(), http.MethodGet, target.URL+"/v1/runs/"+imported.RunID, nil, &queried)
+	if status != http.StatusOK || queried.Status != started.Status || queried.Step != started.Step {
+		t.Fatalf("imported query mismatch: status=%d run_status=%s step=%d", status, queried.Status, queried.Step)
+	}
+
+	frames := readNDJSONFrames(t, target.Client(), target.URL+"/v1/runs/"+imported.RunID+"/events?cursor=0", 6, 2*time.Second)
+	for i := range frames {
+		if string(frames[i].Event.RunID) != imported.RunID {
+			t.Fatalf("event run_id mismatch at index %d: got=%q want=%q", i, frames[i].Event.RunID, imported.RunID)
+		}
+		if frames[i].Event.Type != bundle.Events[i].Type {
+			t.Fatalf("event type mismatch at index %d: got=%s want=%s", i, frames[i].Event.Type, bundle.Events[i].Type)
+		}
+	}
+}
+
+func TestRunImportRejectsInvalidBundle(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+	defer server.Close()
+
+	var failed errorResponse
+	status := performJSON(t, server.Client(), http.MethodPost, server.URL+"/v1/runs/import", map[string]any{
+		"version": agent.RunBundleVersion + 1,
+		"state":   map[string]any{"id": "run-x", "status": "completed"},
+	}, &failed)
+	if status != http.StatusBadRequest || failed.Error.Code != "invalid_request" {
+		t.Fatalf("invalid bundle mismatch: status=%d code=%q", status, failed.Error.Code)
+	}
+
+	status = performJSON(t, server.Client(), http.MethodGet, server.URL+"/v1/runs/does-not-exist/export", nil, &failed)
+	if status != http.StatusNotFound {
+		t.Fatalf("unknown export status mismatch: got=%d want=%d", status, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,155 @@
+package httpapi_test
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Gurpartap/agentframe/agent"
+	"github.com/Gurpartap/agentframe/examples/coding-agent/server/internal/config"
+	"github.com/Gurpartap/agentframe/examples/coding-agent/server/internal/httpapi"
+	"github.com/Gurpartap/agentframe/examples/coding-agent/server/internal/policylimit"
+)
+
+type rollbackResponse struct {
+	RunID    string   `json:"run_id"`
+	Step     int      `json:"step"`
+	Restored []string `json:"restored"`
+}
+
+func TestRunRollbackRestoresWorkspaceToStep(t *testing.T) {
+	t.Parallel()
+
+	workspace := t.TempDir()
+	server := newTestServerWithRuntimeConfig(
+		t,
+		httpapi.PolicyConfig{
+			AuthToken:           testAuthToken,
+			MaxRequestBodyBytes: 4 << 10,
+			RequestTimeout:      10 * time.Second,
+			MaxCommandSteps:     policylimit.DefaultMaxCommandSteps,
+		},
+		func(cfg *config.Config) {
+			cfg.ModelMode = config.ModelModeMock
+			cfg.ToolMode = config.ToolModeReal
+			cfg.WorkspaceRoot = workspace
+			cfg.ArtifactDir = t.TempDir()
+			cfg.SnapshotDir = t.TempDir()
+		},
+	)
+	defer server.Close()
+
+	var started runStateResponse
+	status := performJSON(t, server.Client(), http.MethodPost, server.URL+"/v1/runs/start", map[string]any{
+		"user_prompt": "[e2e-coding-success]",
+		"max_steps":   8,
+	}, &started)
+	if status != http.StatusOK || started.Status != string(agent.RunStatusCompleted) {
+		t.Fatalf("start mismatch: status=%d run_status=%s error=%q", status, started.Status, started.Error)
+	}
+	notes := filepath.Join(workspace, "notes.txt")
+	if data, _ := os.ReadFile(notes); string(data) != "hello real tools\n" {
+		t.Fatalf("edited notes mismatch: got=%q", data)
+	}
+
+	rollbackURL := server.URL + "/v1/runs/" + started.RunID + "/rollback"
+	var failed errorResponse
+	status = performJSON(t, server.Client(), http.MethodPost, rollbackURL, map[string]any{"step": started.Step + 1}, &failed)
+	if status != http.StatusBadRequest || failed.Error.Code != "invalid_request" {
+		t.Fatalf("future step mismatch: status=%d code=%q", status, failed.Error.Code)
+	}
+
+	var rolledBack rollbackResponse
+	status = performJSON(t, server.Client(), http.MethodPost, rollbackURL, map[string]any{"step": 1}, &rolledBack)
+	if status != http.StatusOK {
+		t.Fatalf("rollback status mismatch: got=%d want=%d", status, http.StatusOK)
+	}
+	if len(rolledBack.Restored) != 1 || rolledBack.Restored[0] != "notes.txt" {
+		t.Fatalf("restored paths mismatch: got=%v want=[notes.txt]", rolledBack.Restored)
+	}
+	if data, _ := os.ReadFile(notes); string(data) != "hello toolset\n" {
+		t.Fatalf("notes after step 1 rollback mismatch: got=%q want=%q", data, "hello toolset\n")
+	}
+
+	status = performJSON(t, server.Client(), http.MethodPost, rollbackURL, map[string]any{"step": 0}, &rolledBack)
+	if status != http.StatusOK {
+		t.Fatalf("rollback status mismatch: got=%d want=%d", status, http.StatusOK)
+	}
+	if _, err := os.Stat(notes); !os.IsNotExist(err) {
+		t.Fatalf("file created by the run must be removed, stat err=%v", err)
+	}
+}
+
+func TestRunRollbackRequiresRealToolMode(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+	defer server.Close()
+
+	var failed errorResponse
+	status := performJSON(t, server.Client(), http.MethodPost, server.URL+"/v1/runs/run-000001/rollback", map[string]any{"step": 0}, &failed)
+	if status != http.StatusBadRequest || failed.Error.Code != "invalid_request" {
+		t.Fatalf("mock mode rollback mismatch: status=%d code=%q", status, failed.Error.Code)
+	}
+}
+
+func TestIsolatedRunWorkspaceIsRemovedWhenRunEnds(t *testing.T) {
+	t.Parallel()
+
+	template := t.TempDir()
+	runWorkspaces := t.TempDir()
+	server := newTestServerWithRuntimeConfig(
+		t,
+		httpapi.PolicyConfig{
+			AuthToken:           testAuthToken,
+			MaxRequestBodyBytes: 4 << 10,
+			RequestTimeout:      10 * time.Second,
+			MaxCommandSteps:     policylimit.DefaultMaxCommandSteps,
+		},
+		func(cfg *config.Config) {
+			cfg.ModelMode = config.ModelModeMock
+			cfg.ToolMode = config.ToolModeReal
+			cfg.WorkspaceRoot = template
+			cfg.WorkspaceMode = config.WorkspaceModeCopy
+			cfg.RunWorkspaceDir = runWorkspaces
+			cfg.ArtifactDir = t.TempDir()
+			cfg.SnapshotDir = t.TempDir()
+		},
+	)
+	defer server.Close()
+
+	var started runStateResponse
+	status := performJSON(t, server.Client(), http.MethodPost, server.URL+"/v1/runs/start", map[string]any{
+		"user_prompt": "[e2e-coding-success]",
+		"max_steps":   8,
+	}, &started)
+	if status != http.StatusOK || started.Status != string(agent.RunStatusCompleted) {
+		t.Fatalf("start mismatch: status=%d run_status=%s error=%q", status, started.Status, started.Error)
+	}
+	if _, err := os.Stat(filepath.Join(template, "notes.txt")); !os.IsNotExist(err) {
+		t.Fatalf("isolated run must not write to the template, stat err=%v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		entries, err := os.ReadDir(runWorkspaces)
+		if err != nil {
+			t.Fatalf("read run workspace dir: %v", err)
+		}
+		if len(entries) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("run workspace not removed after completion: %d entries", len(entries))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	var failed errorResponse
+	status = performJSON(t, server.Client(), http.MethodPost, server.URL+"/v1/runs/"+started.RunID+"/rollback", map[string]any{"step": 0}, &failed)
+	if status != http.StatusConflict || failed.Error.Code != "conflict" {
+		t.Fatalf("rollback after release mismatch: status=%d code=%q", status, failed.Error.Code)
+	}
+}
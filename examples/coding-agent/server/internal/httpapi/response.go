@@ -41,6 +41,7 @@ type runStateResponse struct {
 	Step               int                         `json:"step"`
 	Version            int64                       `json:"version"`
 	Output             string                      `json:"output,omitempty"`
+	StructuredOutput   json.RawMessage             `json:"structured_output,omitempty"`
 	Error              string                      `json:"error,omitempty"`
 	PendingRequirement *pendingRequirementResponse `json:"pending_requirement,omitempty"`
 }
@@ -55,13 +56,18 @@ type pendingRequirementResponse struct {
 }
 
 func writeRunState(w http.ResponseWriter, status int, state agent.RunState) {
+	writeJSON(w, status, newRunStateResponse(state))
+}
+
+func newRunStateResponse(state agent.RunState) runStateResponse {
 	response := runStateResponse{
-		RunID:   string(state.ID),
-		Status:  state.Status,
-		Step:    state.Step,
-		Version: state.Version,
-		Output:  state.Output,
-		Error:   state.Error,
+		RunID:            string(state.ID),
+		Status:           state.Status,
+		Step:             state.Step,
+		Version:          state.Version,
+		Output:           state.Output,
+		StructuredOutput: state.StructuredOutput,
+		Error:            state.Error,
 	}
 	if state.PendingRequirement != nil {
 		response.PendingRequirement = &pendingRequirementResponse{
@@ -73,7 +79,7 @@ func writeRunState(w http.ResponseWriter, status int, state agent.RunState) {
 			Prompt:      state.PendingRequirement.Prompt,
 		}
 	}
-	writeJSON(w, status, response)
+	return response
 }
 
 func writeMappedError(w http.ResponseWriter, err error) {
@@ -156,6 +162,8 @@ func mapRuntimeError(err error) (int, string) {
 		errors.Is(err, agent.ErrCommandUnsupported),
 		errors.Is(err, agent.ErrRunStateInvalid),
 		errors.Is(err, agent.ErrToolDefinitionsInvalid),
+		errors.Is(err, agent.ErrToolChoiceInvalid),
+		errors.Is(err, agent.ErrRunBundleInvalid),
 		errors.Is(err, agent.ErrContextNil):
 		return http.StatusBadRequest, errorCodeInvalidRequest
 	case errors.Is(err, context.Canceled):
@@ -71,13 +71,27 @@ func NewRouter(runtime *runtimewire.Runtime, policy ...PolicyConfig) http.Handle
 		}, reject),
 	)
 
+	// Bundles carry a run's full history, so import accepts larger bodies than
+	// the other mutating routes.
+	applyImportPolicies := chain(
+		policyauth.Middleware(normalized.AuthToken, reject),
+		policylimit.Middleware(policylimit.Config{
+			MaxRequestBodyBytes: max(normalized.MaxRequestBodyBytes, MaxImportBodyBytes),
+			RequestTimeout:      normalized.RequestTimeout,
+			MaxCommandSteps:     normalized.MaxCommandSteps,
+		}, reject),
+	)
+
 	mux := http.NewServeMux()
 	mux.Handle("POST /v1/runs/start", applyMutatingPolicies(http.HandlerFunc(h.handleRunStart)))
 	mux.Handle("POST /v1/runs/{run_id}/continue", applyMutatingPolicies(http.HandlerFunc(h.handleRunContinue)))
 	mux.Handle("POST /v1/runs/{run_id}/cancel", applyMutatingPolicies(http.HandlerFunc(h.handleRunCancel)))
 	mux.Handle("POST /v1/runs/{run_id}/steer", applyMutatingPolicies(http.HandlerFunc(h.handleRunSteer)))
 	mux.Handle("POST /v1/runs/{run_id}/follow-up", applyMutatingPolicies(http.HandlerFunc(h.handleRunFollowUp)))
+	mux.Handle("POST /v1/runs/{run_id}/rollback", applyMutatingPolicies(http.HandlerFunc(h.handleRunRollback)))
+	mux.Handle("POST /v1/runs/import", applyImportPolicies(http.HandlerFunc(h.handleRunImport)))
 	mux.HandleFunc("GET /v1/runs/{run_id}", h.handleRunQuery)
+	mux.HandleFunc("GET /v1/runs/{run_id}/export", h.handleRunExport)
 	mux.HandleFunc("GET /v1/runs/{run_id}/events", h.handleRunEvents)
 	return mux
 }
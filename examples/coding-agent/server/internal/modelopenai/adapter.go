@@ -3,6 +3,7 @@ package modelopenai
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -120,9 +121,21 @@ func (a *Adapter) Generate(ctx context.Context, request agentreact.ModelRequest)
 }
 
 type chatCompletionRequest struct {
-	Model    string        `json:"model"`
-	Messages []chatMessage `json:"messages"`
-	Tools    []chatTool    `json:"tools,omitempty"`
+	Model          string              `json:"model"`
+	Messages       []chatMessage       `json:"messages"`
+	Tools          []chatTool          `json:"tools,omitempty"`
+	ToolChoice     any                 `json:"tool_choice,omitempty"`
+	ResponseFormat *chatResponseFormat `json:"response_format,omitempty"`
+}
+
+type chatResponseFormat struct {
+	Type       string         `json:"type"`
+	JSONSchema chatJSONSchema `json:"json_schema"`
+}
+
+type chatJSONSchema struct {
+	Name   string         `json:"name"`
+	Schema map[string]any `json:"schema"`
 }
 
 type chatCompletionResponse struct {
@@ -139,6 +152,32 @@ type chatMessage struct {
 	Name       string         `json:"name,omitempty"`
 	ToolCallID string         `json:"tool_call_id,omitempty"`
 	ToolCalls  []chatToolCall `json:"tool_calls,omitempty"`
+	// Parts replaces Content with a content part array when set.
+	Parts []chatContentPart `json:"-"`
+}
+
+func (m chatMessage) MarshalJSON() ([]byte, error) {
+	type plainMessage chatMessage
+	if len(m.Parts) == 0 {
+		return json.Marshal(plainMessage(m))
+	}
+	return json.Marshal(struct {
+		plainMessage
+		Content []chatContentPart `json:"content"`
+	}{
+		plainMessage: plainMessage(m),
+		Content:      m.Parts,
+	})
+}
+
+type chatContentPart struct {
+	Type     string        `json:"type"`
+	Text     string        `json:"text,omitempty"`
+	ImageURL *chatImageURL `json:"image_url,omitempty"`
+}
+
+type chatImageURL struct {
+	URL string `json:"url"`
 }
 
 type chatTool struct {
@@ -146,6 +185,15 @@ type chatTool struct {
 	Function chatToolFunction `json:"function"`
 }
 
+type chatNamedToolChoice struct {
+	Type     string                 `json:"type"`
+	Function chatToolChoiceFunction `json:"function"`
+}
+
+type chatToolChoiceFunction struct {
+	Name string `json:"name"`
+}
+
 type chatToolCall struct {
 	ID       string               `json:"id"`
 	Type     string               `json:"type"`
@@ -169,13 +217,24 @@ func buildRequest(model string, request agentreact.ModelRequest) (chatCompletion
 		return chatCompletionRequest{}, err
 	}
 
-	messages := make([]chatMessage, len(normalizedMessages))
+	messages := make([]chatMessage, 0, len(normalizedMessages))
+	var detachedImages []chatContentPart
 	for i := range normalizedMessages {
-		converted, err := toChatMessage(normalizedMessages[i])
+		converted, images, err := toChatMessage(normalizedMessages[i])
 		if err != nil {
 			return chatCompletionRequest{}, err
 		}
-		messages[i] = converted
+		messages = append(messages, converted)
+		detachedImages = append(detachedImages, images...)
+
+		// Image parts are only accepted on user messages, and tool messages must
+		// directly follow their assistant tool calls, so images from other roles
+		// are forwarded in one user message after the current tool result block.
+		nextIsTool := i+1 < len(normalizedMessages) && normalizedMessages[i+1].Role == agent.RoleTool
+		if len(detachedImages) > 0 && !nextIsTool {
+			messages = append(messages, chatMessage{Role: "user", Parts: detachedImages})
+			detachedImages = nil
+		}
 	}
 
 	tools := make([]chatTool, len(request.Tools))
@@ -190,17 +249,56 @@ func buildRequest(model string, request agentreact.ModelRequest) (chatCompletion
 		}
 	}
 
+	toolChoice, err := toChatToolChoice(request.ToolChoice, len(tools) > 0)
+	if err != nil {
+		return chatCompletionRequest{}, err
+	}
+
+	var responseFormat *chatResponseFormat
+	if len(request.OutputSchema) > 0 {
+		responseFormat = &chatResponseFormat{
+			Type:       "json_schema",
+			JSONSchema: chatJSONSchema{Name: "final_answer", Schema: request.OutputSchema},
+		}
+	}
+
 	return chatCompletionRequest{
-		Model:    model,
-		Messages: messages,
-		Tools:    tools,
+		Model:          model,
+		Messages:       messages,
+		Tools:          tools,
+		ToolChoice:     toolChoice,
+		ResponseFormat: responseFormat,
 	}, nil
 }
 
-func toChatMessage(message agent.Message) (chatMessage, error) {
+// toChatToolChoice maps a tool choice to the provider's tool_choice value.
+// Providers reject tool_choice without tools, so it is omitted in that case.
+func toChatToolChoice(choice agent.ToolChoice, hasTools bool) (any, error) {
+	if !hasTools || choice.IsZero() {
+		return nil, nil
+	}
+	switch choice.Mode {
+	case agent.ToolChoiceAuto, agent.ToolChoiceRequired, agent.ToolChoiceNone:
+		return string(choice.Mode), nil
+	case agent.ToolChoiceTool:
+		return chatNamedToolChoice{
+			Type:     "function",
+			Function: chatToolChoiceFunction{Name: choice.Name},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported tool choice mode %q", choice.Mode)
+	}
+}
+
+func toChatMessage(message agent.Message) (chatMessage, []chatContentPart, error) {
 	role, err := toProviderRole(message.Role)
 	if err != nil {
-		return chatMessage{}, err
+		return chatMessage{}, nil, err
+	}
+
+	parts, detachedImages, err := toChatContentParts(message)
+	if err != nil {
+		return chatMessage{}, nil, err
 	}
 
 	toolCalls := make([]chatToolCall, len(message.ToolCalls))
@@ -209,7 +307,7 @@ func toChatMessage(message agent.Message) (chatMessage, error) {
 		if len(message.ToolCalls[i].Arguments) > 0 {
 			encoded, err := json.Marshal(message.ToolCalls[i].Arguments)
 			if err != nil {
-				return chatMessage{}, fmt.Errorf("encode tool call arguments: %w", err)
+				return chatMessage{}, nil, fmt.Errorf("encode tool call arguments: %w", err)
 			}
 			arguments = string(encoded)
 		}
@@ -223,13 +321,85 @@ func toChatMessage(message agent.Message) (chatMessage, error) {
 		}
 	}
 
-	return chatMessage{
+	converted := chatMessage{
 		Role:       role,
-		Content:    message.Content,
 		Name:       message.Name,
 		ToolCallID: message.ToolCallID,
 		ToolCalls:  toolCalls,
-	}, nil
+		Parts:      parts,
+	}
+	if len(parts) == 0 {
+		converted.Content = message.Content
+	}
+	return converted, detachedImages, nil
+}
+
+// toChatContentParts maps multi-part content to provider content parts. It
+// returns nil parts for string-only messages so they keep the plain content
+// encoding. Image parts of non-user messages are returned separately, each
+// preceded by a text label naming the message they came from.
+func toChatContentParts(message agent.Message) ([]chatContentPart, []chatContentPart, error) {
+	if len(message.Parts) == 0 {
+		return nil, nil, nil
+	}
+
+	var parts, detachedImages []chatContentPart
+	if message.Content != "" {
+		parts = append(parts, chatContentPart{Type: "text", Text: message.Content})
+	}
+	for i, part := range message.Parts {
+		switch part.Type {
+		case agent.ContentPartTypeText:
+			parts = append(parts, chatContentPart{Type: "text", Text: part.Text})
+		case agent.ContentPartTypeJSON:
+			var compacted bytes.Buffer
+			if err := json.Compact(&compacted, part.JSON); err != nil {
+				return nil, nil, fmt.Errorf("encode content part %d: %w", i, err)
+			}
+			parts = append(parts, chatContentPart{Type: "text", Text: compacted.String()})
+		case agent.ContentPartTypeFile:
+			parts = append(parts, chatContentPart{Type: "text", Text: fileReferenceText(part)})
+		case agent.ContentPartTypeImage:
+			image := chatContentPart{Type: "image_url", ImageURL: &chatImageURL{URL: imageURL(part)}}
+			if message.Role == agent.RoleUser {
+				parts = append(parts, image)
+				continue
+			}
+			parts = append(parts, chatContentPart{Type: "text", Text: fmt.Sprintf("[image %d attached below]", i)})
+			detachedImages = append(detachedImages,
+				chatContentPart{Type: "text", Text: detachedImageLabel(message, i)},
+				image,
+			)
+		default:
+			return nil, nil, fmt.Errorf("unsupported content part type %q at index %d", part.Type, i)
+		}
+	}
+	return parts, detachedImages, nil
+}
+
+func imageURL(part agent.ContentPart) string {
+	if part.URI != "" {
+		return part.URI
+	}
+	return "data:" + part.MIMEType + ";base64," + base64.StdEncoding.EncodeToString(part.Data)
+}
+
+func fileReferenceText(part agent.ContentPart) string {
+	text := fmt.Sprintf("[file uri=%q", part.URI)
+	if part.Name != "" {
+		text += fmt.Sprintf(" name=%q", part.Name)
+	}
+	if part.MIMEType != "" {
+		text += fmt.Sprintf(" mime_type=%q", part.MIMEType)
+	}
+	return text + "]"
+}
+
+func detachedImageLabel(message agent.Message, index int) string {
+	if message.Role == agent.RoleTool {
+		return fmt.Sprintf("image %d from tool result tool_call_id=%q name=%q:", index, message.ToolCallID, message.Name)
+	}
+	return fmt.Sprintf("image %d from %s message:", index, message.Role)
 }
 
 func toProviderRole(role agent.Role) (string, error) {
@@ -1,6 +1,7 @@
 package modelopenai
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/Gurpartap/agentframe/agent"
@@ -95,3 +96,166 @@ func TestBuildRequest_RejectsToolObservationWithoutAssistantToolCall(t *testing.
 		t.Fatalf("expected buildRequest to fail when tool observation has no assistant tool call")
 	}
 }
+
+func TestBuildRequest_KeepsStringContentEncoding(t *testing.T) {
+	t.Parallel()
+
+	request, err := buildRequest("gpt-4.1-mini", agentreact.ModelRequest{
+		Messages: []agent.Message{{Role: agent.RoleUser, Content: "hello"}},
+	})
+	if err != nil {
+		t.Fatalf("buildRequest returned error: %v", err)
+	}
+	encoded, err := json.Marshal(request.Messages[0])
+	if err != nil {
+		t.Fatalf("marshal message: %v", err)
+	}
+	if string(encoded) != `{"role":"user","content":"hello"}` {
+		t.Fatalf("string content encoding mismatch: got=%s", encoded)
+	}
+}
+
+func TestBuildRequest_MapsUserContentParts(t *testing.T) {
+	t.Parallel()
+
+	request, err := buildRequest("gpt-4.1-mini", agentreact.ModelRequest{
+		Messages: []agent.Message{
+			{
+				Role:    agent.RoleUser,
+				Content: "what is in this screenshot?",
+				Parts: []agent.ContentPart{
+					agent.ImagePart("image/png", []byte("png")),
+					agent.FilePart("notes.txt", "file:///tmp/notes.txt", "text/plain"),
+					agent.JSONPart(json.RawMessage(`{ "a": 1 }`)),
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("buildRequest returned error: %v", err)
+	}
+	encoded, err := json.Marshal(request.Messages[0])
+	if err != nil {
+		t.Fatalf("marshal message: %v", err)
+	}
+	want := `{"role":"user","content":[` +
+		`{"type":"text","text":"what is in this screenshot?"},` +
+		`{"type":"image_url","image_url":{"url":"data:image/png;base64,cG5n"}},` +
+		`{"type":"text","text":"[file uri=\"file:///tmp/notes.txt\" name=\"notes.txt\" mime_type=\"text/plain\"]"},` +
+		`{"type":"text","text":"{\"a\":1}"}]}`
+	if string(encoded) != want {
+		t.Fatalf("content parts encoding mismatch:\n got=%s\nwant=%s", encoded, want)
+	}
+}
+
+func TestBuildRequest_ForwardsToolResultImagesAfterToolBlock(t *testing.T) {
+	t.Parallel()
+
+	request, err := buildRequest("gpt-4.1-mini", agentreact.ModelRequest{
+		Messages: []agent.Message{
+			{Role: agent.RoleUser, Content: "take screenshots"},
+			{
+				Role: agent.RoleAssistant,
+				ToolCalls: []agent.ToolCall{
+					{ID: "call-1", Name: "screenshot"},
+					{ID: "call-2", Name: "screenshot"},
+				},
+			},
+			{
+				Role:       agent.RoleTool,
+				ToolCallID: "call-1",
+				Name:       "screenshot",
+				Parts:      []agent.ContentPart{agent.ImagePart("image/png", []byte("a"))},
+			},
+			{Role: agent.RoleTool, ToolCallID: "call-2", Name: "screenshot", Content: "no display"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("buildRequest returned error: %v", err)
+	}
+
+	roles := make([]string, len(request.Messages))
+	for i := range request.Messages {
+		roles[i] = request.Messages[i].Role
+	}
+	wantRoles := []string{"user", "assistant", "tool", "tool", "user"}
+	if len(roles) != len(wantRoles) {
+		t.Fatalf("provider roles mismatch: got=%v want=%v", roles, wantRoles)
+	}
+	for i := range wantRoles {
+		if roles[i] != wantRoles[i] {
+			t.Fatalf("provider roles mismatch: got=%v want=%v", roles, wantRoles)
+		}
+	}
+	toolParts := request.Messages[2].Parts
+	if len(toolParts) != 1 || toolParts[0].Type != "text" {
+		t.Fatalf("tool message must only carry text parts: got=%+v", toolParts)
+	}
+	forwarded := request.Messages[4].Parts
+	if len(forwarded) != 2 || forwarded[1].ImageURL == nil || forwarded[1].ImageURL.URL != "data:image/png;base64,YQ==" {
+		t.Fatalf("forwarded image mismatch: got=%+v", forwarded)
+	}
+}
+
+func TestBuildRequest_MapsOutputSchemaToResponseFormat(t *testing.T) {
+	t.Parallel()
+
+	schema := map[string]any{"type": "object", "required": []any{"verdict"}}
+	request, err := buildRequest("gpt-4.1-mini", agentreact.ModelRequest{
+		Messages:     []agent.Message{{Role: agent.RoleUser, Content: "grade it"}},
+		OutputSchema: schema,
+	})
+	if err != nil {
+		t.Fatalf("buildRequest returned error: %v", err)
+	}
+	if request.ResponseFormat == nil || request.ResponseFormat.Type != "json_schema" {
+		t.Fatalf("response format mismatch: got=%+v", request.ResponseFormat)
+	}
+	if request.ResponseFormat.JSONSchema.Schema["type"] != "object" {
+		t.Fatalf("response schema mismatch: got=%+v", request.ResponseFormat.JSONSchema.Schema)
+	}
+
+	plain, err := buildRequest("gpt-4.1-mini", agentreact.ModelRequest{
+		Messages: []agent.Message{{Role: agent.RoleUser, Content: "hello"}},
+	})
+	if err != nil {
+		t.Fatalf("buildRequest returned error: %v", err)
+	}
+	if plain.ResponseFormat != nil {
+		t.Fatalf("response format must be omitted without an output schema: got=%+v", plain.ResponseFormat)
+	}
+}
+
+func TestBuildRequest_MapsToolChoice(t *testing.T) {
+	t.Parallel()
+
+	tools := []agent.ToolDefinition{{Name: "bash"}}
+	tests := []struct {
+		choice agent.ToolChoice
+		tools  []agent.ToolDefinition
+		want   string
+	}{
+		{choice: agent.ToolChoice{}, tools: tools, want: `null`},
+		{choice: agent.ToolChoice{Mode: agent.ToolChoiceNone}, tools: tools, want: `"none"`},
+		{choice: agent.ToolChoice{Mode: agent.ToolChoiceRequired}, tools: tools, want: `"required"`},
+		{choice: agent.ToolChoice{Mode: agent.ToolChoiceTool, Name: "bash"}, tools: tools, want: `{"type":"function","function":{"name":"bash"}}`},
+		{choice: agent.ToolChoice{Mode: agent.ToolChoiceNone}, want: `null`},
+	}
+	for _, tt := range tests {
+		request, err := buildRequest("gpt-4.1-mini", agentreact.ModelRequest{
+			Messages:   []agent.Message{{Role: agent.RoleUser, Content: "run it"}},
+			Tools:      tt.tools,
+			ToolChoice: tt.choice,
+		})
+		if err != nil {
+			t.Fatalf("buildRequest returned error: %v", err)
+		}
+		encoded, err := json.Marshal(request.ToolChoice)
+		if err != nil {
+			t.Fatalf("marshal tool choice: %v", err)
+		}
+		if string(encoded) != tt.want {
+			t.Fatalf("tool choice mismatch for %+v: got=%s want=%s", tt.choice, encoded, tt.want)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"sync"
 
 	"github.com/Gurpartap/agentframe/agent"
@@ -151,8 +152,10 @@ func cloneEvent(in agent.Event) agent.Event {
 		out.Message = &message
 	}
 	if in.ToolResult != nil {
-		result := *in.ToolResult
+		result := agent.CloneToolResult(*in.ToolResult)
 		out.ToolResult = &result
 	}
+	out.Plan = agent.ClonePlan(in.Plan)
+	out.SelectedTools = slices.Clone(in.SelectedTools)
 	return out
 }
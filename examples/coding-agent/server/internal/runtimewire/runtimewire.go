@@ -6,12 +6,16 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"os"
+	"strings"
 	"sync"
 
 	"github.com/Gurpartap/agentframe/agent"
 	"github.com/Gurpartap/agentframe/agentreact"
 	eventinginmem "github.com/Gurpartap/agentframe/eventing/inmem"
+	"github.com/Gurpartap/agentframe/policy/redact"
 	runstoreinmem "github.com/Gurpartap/agentframe/runstore/inmem"
+	"github.com/Gurpartap/agentframe/tooling/artifact"
 
 	"github.com/Gurpartap/agentframe/examples/coding-agent/server/internal/config"
 	"github.com/Gurpartap/agentframe/examples/coding-agent/server/internal/modelopenai"
@@ -27,6 +31,18 @@ type Runtime struct {
 	EventSink       *eventinginmem.Sink
 	StreamBroker    *runstream.Broker
 	ToolDefinitions []agent.ToolDefinition
+	// Snapshots records file changes for rollback; nil in mock tool mode.
+	Snapshots *toolset.Snapshots
+	// Processes tracks proc_start background processes; nil in mock tool
+	// mode. They are stopped when their run ends.
+	Processes *toolset.Processes
+	// Workspaces provisions a private workspace per run; nil unless
+	// CODING_AGENT_WORKSPACE_MODE isolates runs.
+	Workspaces *toolset.Workspaces
+	// IDGenerator assigns run IDs; imported runs take a fresh local ID from it.
+	IDGenerator agent.IDGenerator
+	// Metadata describes this server's configuration in exported run bundles.
+	Metadata map[string]string
 }
 
 func New(cfg config.Config) (*Runtime, error) {
@@ -46,23 +62,31 @@ func newRuntime(cfg config.Config, logger *slog.Logger) (*Runtime, error) {
 	events := eventinginmem.New()
 	streamBroker := runstream.New(runstream.DefaultHistoryLimit)
 	eventLogger := newRuntimeEventLogSink(logger, cfg.LogFormat)
-	fanout := newFanoutSink(events, streamBroker, eventLogger)
 
 	model, err := buildModel(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("new runtime model: %w", err)
 	}
-	tools, toolDefinitions, err := buildTools(cfg)
+	redactor, err := buildRedactor(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("new runtime redactor: %w", err)
+	}
+	tools, err := buildTools(cfg, redactor)
 	if err != nil {
 		return nil, fmt.Errorf("new runtime tools: %w", err)
 	}
-	loop, err := agentreact.New(model, tools, fanout)
+	fanout := redact.NewSink(
+		newFanoutSink(append([]agent.EventSink{events, streamBroker, eventLogger}, tools.sinks...)...),
+		redactor,
+	)
+	loop, err := agentreact.New(model, tools.executor, fanout, agentreact.WithRedactor(redactor))
 	if err != nil {
 		return nil, fmt.Errorf("new runtime loop: %w", err)
 	}
 
+	ids := newSequenceIDGenerator()
 	runner, err := agent.NewRunner(agent.Dependencies{
-		IDGenerator: newSequenceIDGenerator(),
+		IDGenerator: ids,
 		RunStore:    store,
 		Engine:      loop,
 		EventSink:   fanout,
@@ -76,10 +100,32 @@ func newRuntime(cfg config.Config, logger *slog.Logger) (*Runtime, error) {
 		RunStore:        store,
 		EventSink:       events,
 		StreamBroker:    streamBroker,
-		ToolDefinitions: toolDefinitions,
+		ToolDefinitions: tools.definitions,
+		Snapshots:       tools.snapshots,
+		Processes:       tools.processes,
+		Workspaces:      tools.workspaces,
+		IDGenerator:     ids,
+		Metadata:        bundleMetadata(cfg),
 	}, nil
 }
 
+// bundleMetadata records the modes that shaped a run, so an imported bundle
+// shows how the exporting server was configured. Secrets are never included.
+func bundleMetadata(cfg config.Config) map[string]string {
+	metadata := map[string]string{
+		"exporter":   "coding-agent-server",
+		"model_mode": string(cfg.ModelMode),
+		"tool_mode":  string(cfg.ToolMode),
+	}
+	if cfg.ModelMode == config.ModelModeProvider {
+		metadata["provider_model"] = cfg.ProviderModel
+	}
+	if cfg.ToolMode == config.ToolModeReal {
+		metadata["workspace_mode"] = string(cfg.WorkspaceMode)
+	}
+	return metadata
+}
+
 func buildModel(cfg config.Config) (agentreact.Model, error) {
 	switch cfg.ModelMode {
 	case config.ModelModeMock:
@@ -101,19 +147,157 @@ func buildModel(cfg config.Config) (agentreact.Model, error) {
 	}
 }
 
-func buildTools(cfg config.Config) (agentreact.ToolExecutor, []agent.ToolDefinition, error) {
+// buildRedactor returns nil when redaction is off, which leaves tool results
+// and events unchanged.
+func buildRedactor(cfg config.Config) (agent.Redactor, error) {
+	if cfg.Redaction == config.RedactionOff {
+		return nil, nil
+	}
+	detectors := redact.DefaultDetectors()
+	if cfg.RedactPatternsFile != "" {
+		custom, err := loadRedactPatterns(cfg.RedactPatternsFile)
+		if err != nil {
+			return nil, err
+		}
+		detectors = append(detectors, custom...)
+	}
+	return redact.New(redact.Config{Detectors: detectors}), nil
+}
+
+// loadRedactPatterns reads one RE2 pattern per line; blank lines and lines
+// starting with # are skipped.
+func loadRedactPatterns(path string) ([]redact.Detector, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read redact patterns: %w", err)
+	}
+	var detectors []redact.Detector
+	for i, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		detector, err := redact.CompileRegexp("custom", line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, i+1, err)
+		}
+		detectors = append(detectors, detector)
+	}
+	return detectors, nil
+}
+
+// toolWiring is the tool side of the runtime. sinks observe run events to
+// release per-run tool state.
+type toolWiring struct {
+	executor    agentreact.ToolExecutor
+	definitions []agent.ToolDefinition
+	snapshots   *toolset.Snapshots
+	processes   *toolset.Processes
+	workspaces  *toolset.Workspaces
+	sinks       []agent.EventSink
+}
+
+// buildTools wires the tool executor. Results are redacted before the artifact
+// executor spills or truncates them, so stored artifacts and previews never
+// hold secrets the loop would redact.
+func buildTools(cfg config.Config, redactor agent.Redactor) (toolWiring, error) {
 	switch cfg.ToolMode {
 	case config.ToolModeMock:
-		return mocks.NewTools(), mocks.Definitions(), nil
+		return toolWiring{executor: mocks.NewTools(), definitions: mocks.Definitions()}, nil
 	case config.ToolModeReal:
-		policy, err := toolset.NewPolicy(cfg.WorkspaceRoot, cfg.BashTimeout)
+	default:
+		return toolWiring{}, fmt.Errorf("unsupported tool mode %q", cfg.ToolMode)
+	}
+
+	var bashPolicy *toolset.BashPolicy
+	if cfg.BashPolicyFile != "" {
+		loaded, err := toolset.LoadBashPolicy(cfg.BashPolicyFile)
 		if err != nil {
-			return nil, nil, err
+			return toolWiring{}, err
 		}
-		return toolset.NewExecutor(policy), toolset.Definitions(), nil
-	default:
-		return nil, nil, fmt.Errorf("unsupported tool mode %q", cfg.ToolMode)
+		bashPolicy = loaded
+	}
+	newPolicy := func(workspaceRoot string) (toolset.Policy, error) {
+		policy, err := toolset.NewPolicy(workspaceRoot, cfg.BashTimeout)
+		if err != nil {
+			return toolset.Policy{}, err
+		}
+		return policy.WithBashPolicy(bashPolicy), nil
+	}
+
+	processes := toolset.NewProcesses(toolset.ProcessConfig{MaxPerRun: cfg.MaxProcessesPerRun})
+	options := []toolset.ExecutorOption{
+		toolset.WithProcesses(processes),
+		toolset.WithStaleFileMode(toolset.StaleFileMode(cfg.StaleFileMode)),
+	}
+	if cfg.BashSandbox != "" && cfg.BashSandbox != config.BashSandboxOff {
+		backend, err := toolset.NewSandboxBackend(toolset.SandboxConfig{
+			Runtime: toolset.SandboxRuntime(cfg.BashSandbox),
+		})
+		if err != nil {
+			return toolWiring{}, err
+		}
+		options = append(options, toolset.WithBashBackend(backend))
+	}
+
+	wiring := toolWiring{processes: processes, sinks: []agent.EventSink{processes}}
+	var next agentreact.ToolExecutor
+	if cfg.WorkspaceMode == "" || cfg.WorkspaceMode == config.WorkspaceModeShared {
+		policy, err := newPolicy(cfg.WorkspaceRoot)
+		if err != nil {
+			return toolWiring{}, err
+		}
+		snapshots, err := toolset.NewSnapshots(cfg.SnapshotDir, policy)
+		if err != nil {
+			return toolWiring{}, err
+		}
+		wiring.snapshots = snapshots
+		next = toolset.NewExecutor(policy, append(options, toolset.WithSnapshots(snapshots))...)
+	} else {
+		workspaces, err := toolset.NewWorkspaces(toolset.WorkspacesConfig{
+			Mode:         toolset.WorkspaceMode(cfg.WorkspaceMode),
+			TemplateRoot: cfg.WorkspaceRoot,
+			Dir:          cfg.RunWorkspaceDir,
+		})
+		if err != nil {
+			return toolWiring{}, err
+		}
+		snapshots, err := toolset.NewWorkspaceSnapshots(cfg.SnapshotDir, workspaces)
+		if err != nil {
+			return toolWiring{}, err
+		}
+		runExecutor, err := toolset.NewRunExecutor(workspaces, func(workspaceRoot string) (*toolset.Executor, error) {
+			policy, err := newPolicy(workspaceRoot)
+			if err != nil {
+				return nil, err
+			}
+			return toolset.NewExecutor(policy, append(options, toolset.WithSnapshots(snapshots))...), nil
+		})
+		if err != nil {
+			return toolWiring{}, err
+		}
+		wiring.snapshots = snapshots
+		wiring.workspaces = workspaces
+		wiring.sinks = append(wiring.sinks, runExecutor)
+		next = runExecutor
+	}
+
+	store, err := artifact.NewFileStore(cfg.ArtifactDir)
+	if err != nil {
+		return toolWiring{}, err
+	}
+	capped, err := artifact.New(artifact.Config{
+		Store:           store,
+		Next:            redact.NewToolExecutor(next, redactor),
+		MaxContentBytes: cfg.MaxToolResultBytes,
+		PreviewBytes:    min(artifact.DefaultPreviewBytes, cfg.MaxToolResultBytes),
+	})
+	if err != nil {
+		return toolWiring{}, err
 	}
+	wiring.executor = capped
+	wiring.definitions = capped.WithDefinition(toolset.Definitions())
+	return wiring, nil
 }
 
 type fanoutSink struct {
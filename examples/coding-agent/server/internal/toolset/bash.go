@@ -1,14 +1,12 @@
 package toolset
 
 import (
-	"bytes"
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"os/exec"
 	"strings"
 
 	"github.com/Gurpartap/agentframe/agent"
@@ -19,50 +17,25 @@ func (e *Executor) executeBash(ctx context.Context, call agent.ToolCall) (string
 	if err != nil {
 		return "", err
 	}
-	if err := e.policy.ValidateBashCommand(command); err != nil {
-		if errors.Is(err, ErrBashCommandDenied) {
-			fingerprint := bashApprovalFingerprint(call, command, e.policy)
-			if err := validateApprovedBashReplay(ctx, call.ID, fingerprint); err != nil {
-				return "", err
-			}
-			if !approvedBashReplay(ctx, call.ID, fingerprint) {
-				return "", &agent.SuspendRequestError{
-					Requirement: &agent.PendingRequirement{
-						ID:          fmt.Sprintf("req-bash-policy-%s", call.ID),
-						Kind:        agent.RequirementKindApproval,
-						Origin:      agent.RequirementOriginTool,
-						ToolCallID:  call.ID,
-						Fingerprint: fingerprint,
-						Prompt:      "approve bash command denied by policy",
-					},
-					Err: err,
-				}
-			}
-		} else {
-			return "", err
-		}
+	if err := e.authorizeBashCommand(ctx, call, command, "req-bash-policy-"); err != nil {
+		return "", err
 	}
 
 	timeoutCtx, cancel := context.WithTimeout(ctx, e.policy.BashTimeout())
 	defer cancel()
 
-	cmd := exec.CommandContext(timeoutCtx, "bash", "-lc", command)
-	cmd.Dir = e.policy.WorkspaceRoot()
-
-	var stdout bytes.Buffer
-	var stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	err = cmd.Run()
+	result, err := e.backend.Run(timeoutCtx, BashRequest{
+		Command:       command,
+		WorkspaceRoot: e.policy.WorkspaceRoot(),
+	})
 	if errors.Is(timeoutCtx.Err(), context.DeadlineExceeded) {
 		return "", fmt.Errorf(
 			"%w: command=%q timeout=%s stdout=%q stderr=%q",
 			ErrBashExecutionTimedOut,
 			command,
 			e.policy.BashTimeout(),
-			stdout.String(),
-			stderr.String(),
+			result.Stdout,
+			result.Stderr,
 		)
 	}
 	if err != nil {
@@ -70,38 +43,75 @@ func (e *Executor) executeBash(ctx context.Context, call agent.ToolCall) (string
 			"bash command %q failed: %w stdout=%q stderr=%q",
 			command,
 			err,
-			stdout.String(),
-			stderr.String(),
+			result.Stdout,
+			result.Stderr,
 		)
 	}
 
-	return fmt.Sprintf(
+	content := fmt.Sprintf(
 		"bash_ok command=%q stdout=%q stderr=%q",
 		command,
-		strings.TrimSpace(stdout.String()),
-		strings.TrimSpace(stderr.String()),
-	), nil
+		strings.TrimSpace(result.Stdout),
+		strings.TrimSpace(result.Stderr),
+	)
+	if result.Truncated {
+		content += " output_truncated=true"
+	}
+	return content, nil
+}
+
+// authorizeBashCommand checks command against the bash policy. A denied
+// command suspends the run for approval under requirementPrefix+call.ID
+// unless ctx carries a matching approved replay.
+func (e *Executor) authorizeBashCommand(ctx context.Context, call agent.ToolCall, command, requirementPrefix string) error {
+	sandboxed := e.backend.Sandboxed()
+	err := e.policy.validateBashCommand(command, sandboxed)
+	if err == nil || !errors.Is(err, ErrBashCommandDenied) {
+		return err
+	}
+	fingerprint := bashApprovalFingerprint(call, command, e.policy, sandboxed)
+	if err := validateApprovedToolReplay(ctx, call.ID, fingerprint, ErrBashReplayMismatch); err != nil {
+		return err
+	}
+	if approvedToolReplay(ctx, call.ID, fingerprint) {
+		return nil
+	}
+	return &agent.SuspendRequestError{
+		Requirement: &agent.PendingRequirement{
+			ID:          requirementPrefix + call.ID,
+			Kind:        agent.RequirementKindApproval,
+			Origin:      agent.RequirementOriginTool,
+			ToolCallID:  call.ID,
+			Fingerprint: fingerprint,
+			Prompt:      fmt.Sprintf("approve %s command denied by policy: %s", call.Name, bashDenialReason(err)),
+		},
+		Err: err,
+	}
 }
 
-func bashApprovalFingerprint(call agent.ToolCall, command string, policy Policy) string {
+func bashApprovalFingerprint(call agent.ToolCall, command string, policy Policy, sandboxed bool) string {
 	payload, _ := json.Marshal(struct {
 		ToolName      string `json:"tool_name"`
 		CallID        string `json:"call_id"`
 		Command       string `json:"command"`
 		WorkspaceRoot string `json:"workspace_root"`
 		BashTimeoutNS int64  `json:"bash_timeout_ns"`
+		Sandboxed     bool   `json:"sandboxed,omitempty"`
 	}{
 		ToolName:      call.Name,
 		CallID:        call.ID,
 		Command:       strings.TrimSpace(command),
 		WorkspaceRoot: policy.WorkspaceRoot(),
 		BashTimeoutNS: int64(policy.BashTimeout()),
+		Sandboxed:     sandboxed,
 	})
 	sum := sha256.Sum256(payload)
 	return hex.EncodeToString(sum[:])
 }
 
-func approvedBashReplay(ctx context.Context, callID, fingerprint string) bool {
+// approvedToolReplay reports whether ctx carries an approval for exactly this
+// call and fingerprint.
+func approvedToolReplay(ctx context.Context, callID, fingerprint string) bool {
 	override, ok := agent.ApprovedToolCallReplayOverrideFromContext(ctx)
 	if !ok {
 		return false
@@ -109,7 +119,9 @@ func approvedBashReplay(ctx context.Context, callID, fingerprint string) bool {
 	return override.ToolCallID == callID && override.Fingerprint == fingerprint
 }
 
-func validateApprovedBashReplay(ctx context.Context, callID, fingerprint string) error {
+// validateApprovedToolReplay rejects an approval override that was issued for
+// a different call or fingerprint, wrapping mismatch.
+func validateApprovedToolReplay(ctx context.Context, callID, fingerprint string, mismatch error) error {
 	override, ok := agent.ApprovedToolCallReplayOverrideFromContext(ctx)
 	if !ok {
 		return nil
@@ -119,7 +131,7 @@ func validateApprovedBashReplay(ctx context.Context, callID, fingerprint string)
 	}
 	return fmt.Errorf(
 		"%w: field=approved_tool_replay_override reason=mismatch got_tool_call_id=%q got_fingerprint=%q want_tool_call_id=%q want_fingerprint=%q",
-		ErrBashReplayMismatch,
+		mismatch,
 		override.ToolCallID,
 		override.Fingerprint,
 		callID,
@@ -0,0 +1,119 @@
+package toolset
+
+import (
+	"context"
+	"os/exec"
+	"time"
+)
+
+// DefaultMaxBashOutputBytes caps the stdout and stderr kept per stream.
+const DefaultMaxBashOutputBytes = 256 << 10
+
+// bashWaitDelay bounds how long Run waits for output pipes held open by
+// background descendants after the command exits or is killed.
+const bashWaitDelay = 500 * time.Millisecond
+
+// BashRequest is one bash invocation rooted at the workspace.
+type BashRequest struct {
+	Command       string
+	WorkspaceRoot string
+}
+
+// BashResult holds the captured output of a bash invocation.
+type BashResult struct {
+	Stdout string
+	Stderr string
+	// Truncated reports that stdout or stderr exceeded the backend output cap.
+	Truncated bool
+}
+
+// BashBackend runs policy-approved bash commands.
+type BashBackend interface {
+	// Run executes request.Command with request.WorkspaceRoot as working
+	// directory. Captured output is returned even when err is non-nil.
+	Run(ctx context.Context, request BashRequest) (BashResult, error)
+	// Sandboxed reports whether commands are isolated from the host, which
+	// widens the bash allowlist to build and test commands.
+	Sandboxed() bool
+}
+
+// BackgroundBackend is implemented by backends that can also start
+// long-running commands for the proc_* tools.
+type BackgroundBackend interface {
+	// Command returns an unstarted command running request.Command with the
+	// same isolation as Run. The caller owns its output and lifetime.
+	Command(request BashRequest) *exec.Cmd
+}
+
+// ExecutorOption customizes an Executor.
+type ExecutorOption func(*Executor)
+
+// WithBashBackend replaces the host bash backend.
+func WithBashBackend(backend BashBackend) ExecutorOption {
+	return func(e *Executor) {
+		if backend != nil {
+			e.backend = backend
+		}
+	}
+}
+
+// HostBackend runs bash directly on the host.
+type HostBackend struct {
+	// MaxOutputBytes caps each output stream. Defaults to DefaultMaxBashOutputBytes.
+	MaxOutputBytes int
+}
+
+func (b HostBackend) Run(ctx context.Context, request BashRequest) (BashResult, error) {
+	cmd := exec.CommandContext(ctx, "bash", "-lc", request.Command)
+	cmd.Dir = request.WorkspaceRoot
+	return runCaptured(cmd, b.MaxOutputBytes)
+}
+
+func (HostBackend) Command(request BashRequest) *exec.Cmd {
+	cmd := exec.Command("bash", "-lc", request.Command)
+	cmd.Dir = request.WorkspaceRoot
+	return cmd
+}
+
+func (HostBackend) Sandboxed() bool {
+	return false
+}
+
+func runCaptured(cmd *exec.Cmd, maxOutputBytes int) (BashResult, error) {
+	if maxOutputBytes <= 0 {
+		maxOutputBytes = DefaultMaxBashOutputBytes
+	}
+	stdout := &cappedBuffer{limit: maxOutputBytes}
+	stderr := &cappedBuffer{limit: maxOutputBytes}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = bashWaitDelay
+
+	err := cmd.Run()
+	return BashResult{
+		Stdout:    string(stdout.data),
+		Stderr:    string(stderr.data),
+		Truncated: stdout.truncated || stderr.truncated,
+	}, err
+}
+
+// cappedBuffer keeps the first limit bytes written and discards the rest, so
+// chatty commands cannot exhaust server memory.
+type cappedBuffer struct {
+	limit     int
+	data      []byte
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	room := b.limit - len(b.data)
+	if room < len(p) {
+		b.truncated = true
+		if room > 0 {
+			b.data = append(b.data, p[:room]...)
+		}
+		return len(p), nil
+	}
+	b.data = append(b.data, p...)
+	return len(p), nil
+}
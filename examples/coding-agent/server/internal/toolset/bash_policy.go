@@ -0,0 +1,376 @@
+package toolset
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// DefaultMaxPipelineStages bounds "a | b | ..." pipelines when a policy does
+// not set MaxPipelineStages.
+const DefaultMaxPipelineStages = 4
+
+var ErrBashPolicyInvalid = errors.New("bash policy is invalid")
+
+// bashSpecialPaths may be passed as path arguments even though they are
+// outside the workspace.
+var bashSpecialPaths = []string{"/dev/null", "/dev/stdin", "/dev/stdout", "/dev/stderr"}
+
+// BashCommandRule declares how one command may be invoked.
+type BashCommandRule struct {
+	// Sandboxed commands run arbitrary project code and are only allowed with
+	// a sandboxed bash backend.
+	Sandboxed bool `json:"sandboxed,omitempty"`
+	// AllowedFlags lists the permitted flags; empty permits any flag that is
+	// not denied. Combined short flags such as -la are checked letter by
+	// letter unless listed verbatim.
+	AllowedFlags []string `json:"allowed_flags,omitempty"`
+	// DeniedFlags lists refused flags. A long flag is also refused when
+	// abbreviated, since getopt accepts any unambiguous prefix.
+	DeniedFlags []string `json:"denied_flags,omitempty"`
+	// ValueFlags consume the following word as their value.
+	ValueFlags []string `json:"value_flags,omitempty"`
+	// PatternArgs leading positional arguments are patterns or scripts rather
+	// than paths, unless one of PatternFlags supplies the pattern instead.
+	PatternArgs  int      `json:"pattern_args,omitempty"`
+	PatternFlags []string `json:"pattern_flags,omitempty"`
+	// PathArgs requires the remaining positional arguments, and flag values
+	// containing "/", to stay inside the workspace. A value glued to a short
+	// option is checked at every point the option letters could end, so
+	// workspace paths are best passed as separate words. Unquoted globs in a
+	// component starting with "." are refused, since bash before 5.2 lets
+	// them match "..".
+	PathArgs bool `json:"path_args,omitempty"`
+	// Subcommands, when set, require the first positional argument to name one
+	// of them; its rule then governs the remaining arguments.
+	Subcommands map[string]BashCommandRule `json:"subcommands,omitempty"`
+}
+
+// BashPolicy is the declarative allowlist for the bash tool. Commands may be
+// joined into pipelines of at most MaxPipelineStages allowlisted stages.
+type BashPolicy struct {
+	Commands          map[string]BashCommandRule `json:"commands"`
+	MaxPipelineStages int                        `json:"max_pipeline_stages,omitempty"`
+}
+
+// DefaultBashPolicy allows read-only inspection commands on the host, and
+// build, test and editing tools only in a sandbox.
+func DefaultBashPolicy() *BashPolicy {
+	inspect := BashCommandRule{PathArgs: true}
+	lines := BashCommandRule{PathArgs: true, ValueFlags: []string{"-n", "-c", "--lines", "--bytes"}}
+	sandboxed := BashCommandRule{Sandboxed: true}
+	return &BashPolicy{
+		MaxPipelineStages: DefaultMaxPipelineStages,
+		Commands: map[string]BashCommandRule{
+			"cat":    inspect,
+			"ls":     inspect,
+			"stat":   inspect,
+			"wc":     inspect,
+			"head":   lines,
+			"tail":   lines,
+			"echo":   {},
+			"printf": {},
+			"pwd":    {},
+			"which":  {},
+			"find": {
+				PathArgs: true,
+				ValueFlags: []string{
+					"-name", "-iname", "-path", "-ipath", "-regex", "-iregex", "-type",
+					"-maxdepth", "-mindepth", "-newer", "-size", "-mtime", "-mmin", "-perm",
+				},
+				DeniedFlags: []string{"-exec", "-execdir", "-ok", "-okdir", "-delete", "-fprint", "-fprint0", "-fprintf", "-fls"},
+			},
+			"grep": {
+				PathArgs:     true,
+				PatternArgs:  1,
+				PatternFlags: []string{"-e", "-f", "--regexp", "--file"},
+				ValueFlags:   []string{"-e", "-f", "-m", "-A", "-B", "-C", "--regexp", "--file", "--max-count"},
+			},
+			"rg": {
+				PathArgs:     true,
+				PatternArgs:  1,
+				PatternFlags: []string{"-e", "-f", "--regexp", "--file"},
+				ValueFlags:   []string{"-e", "-f", "-g", "-t", "-T", "-m", "-A", "-B", "-C", "--regexp", "--file", "--glob", "--type", "--type-not", "--max-count"},
+				DeniedFlags:  []string{"--pre"},
+			},
+			"sort":    {PathArgs: true, DeniedFlags: []string{"-o", "--output", "--compress-program"}},
+			"uniq":    {PathArgs: true},
+			"cargo":   sandboxed,
+			"go":      sandboxed,
+			"gofmt":   sandboxed,
+			"make":    sandboxed,
+			"node":    sandboxed,
+			"npm":     sandboxed,
+			"pytest":  sandboxed,
+			"python3": sandboxed,
+			// sed scripts can run commands and write files, so sed is
+			// sandbox-only.
+			"sed": sandboxed,
+		},
+	}
+}
+
+// LoadBashPolicy reads a JSON bash policy file.
+func LoadBashPolicy(path string) (*BashPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("load bash policy %q: %w", path, err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var policy BashPolicy
+	if err := decoder.Decode(&policy); err != nil {
+		return nil, fmt.Errorf("load bash policy %q: %w: %v", path, ErrBashPolicyInvalid, err)
+	}
+	if err := decoder.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("load bash policy %q: %w: file must contain exactly one JSON object", path, ErrBashPolicyInvalid)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("load bash policy %q: %w", path, err)
+	}
+	return &policy, nil
+}
+
+// Validate checks that the policy is well formed.
+func (b *BashPolicy) Validate() error {
+	if b == nil || len(b.Commands) == 0 {
+		return fmt.Errorf("%w: field=commands reason=empty", ErrBashPolicyInvalid)
+	}
+	if b.MaxPipelineStages < 0 {
+		return fmt.Errorf("%w: field=max_pipeline_stages reason=negative", ErrBashPolicyInvalid)
+	}
+	for name, rule := range b.Commands {
+		if err := rule.validate(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r BashCommandRule) validate(name string) error {
+	if name == "" || strings.ContainsAny(name, "/ \t") {
+		return fmt.Errorf("%w: field=commands reason=invalid_name name=%q", ErrBashPolicyInvalid, name)
+	}
+	if r.PatternArgs < 0 {
+		return fmt.Errorf("%w: field=commands.%s.pattern_args reason=negative", ErrBashPolicyInvalid, name)
+	}
+	for _, flag := range slices.Concat(r.AllowedFlags, r.DeniedFlags, r.ValueFlags, r.PatternFlags) {
+		if len(flag) < 2 || !strings.HasPrefix(flag, "-") {
+			return fmt.Errorf("%w: field=commands.%s reason=invalid_flag flag=%q", ErrBashPolicyInvalid, name, flag)
+		}
+	}
+	for subcommand, subrule := range r.Subcommands {
+		if err := subrule.validate(subcommand); err != nil {
+			return fmt.Errorf("%w (in %s)", err, name)
+		}
+	}
+	return nil
+}
+
+// bashDenial is a policy violation with a human-readable reason, surfaced in
+// the approval prompt.
+type bashDenial struct {
+	reason string
+}
+
+func denyBash(format string, args ...any) error {
+	return &bashDenial{reason: fmt.Sprintf(format, args...)}
+}
+
+func (d *bashDenial) Error() string {
+	return fmt.Sprintf("%s: %s", ErrBashCommandDenied, d.reason)
+}
+
+func (d *bashDenial) Unwrap() error {
+	return ErrBashCommandDenied
+}
+
+// bashDenialReason returns the reason of a policy denial.
+func bashDenialReason(err error) string {
+	var denial *bashDenial
+	if errors.As(err, &denial) {
+		return denial.reason
+	}
+	return err.Error()
+}
+
+func (b *BashPolicy) validate(command string, sandboxed bool, resolvePath func(string) (string, error)) error {
+	stages, err := parseShellPipeline(command)
+	if err != nil {
+		return err
+	}
+	if len(stages) == 0 {
+		return ErrBashCommandEmpty
+	}
+	maxStages := b.MaxPipelineStages
+	if maxStages == 0 {
+		maxStages = DefaultMaxPipelineStages
+	}
+	if len(stages) > maxStages {
+		return denyBash("pipeline has %d stages, at most %d allowed", len(stages), maxStages)
+	}
+
+	for index, words := range stages {
+		name := words[0]
+		prefix := ""
+		if len(stages) > 1 {
+			prefix = fmt.Sprintf("stage %d: ", index+1)
+		}
+		if name.glob || strings.Contains(name.text, "/") {
+			return denyBash("%scommand %q must be a plain command name", prefix, name.text)
+		}
+		rule, ok := b.Commands[name.text]
+		if !ok {
+			return denyBash("%scommand %q is not allowed", prefix, name.text)
+		}
+		if rule.Sandboxed && !sandboxed {
+			return denyBash("%scommand %q requires a sandboxed bash backend", prefix, name.text)
+		}
+		if reason := rule.check(name.text, words[1:], resolvePath); reason != "" {
+			return denyBash("%s%s", prefix, reason)
+		}
+	}
+	return nil
+}
+
+// check validates arguments against the rule and returns a denial reason,
+// or "" when they are allowed.
+func (r BashCommandRule) check(command string, args []shellWord, resolvePath func(string) (string, error)) string {
+	if len(r.Subcommands) > 0 {
+		if len(args) == 0 || strings.HasPrefix(args[0].text, "-") {
+			return fmt.Sprintf("command %q requires a subcommand", command)
+		}
+		subrule, ok := r.Subcommands[args[0].text]
+		if !ok {
+			return fmt.Sprintf("command %q: subcommand %q is not allowed", command, args[0].text)
+		}
+		if subrule.Sandboxed && !r.Sandboxed {
+			return fmt.Sprintf("command %q: subcommand %q requires a sandboxed bash backend", command, args[0].text)
+		}
+		return subrule.check(command+" "+args[0].text, args[1:], resolvePath)
+	}
+
+	patternArgs := r.PatternArgs
+	positional := 0
+	flagsDone := false
+	for i := 0; i < len(args); i++ {
+		arg := args[i].text
+		if !flagsDone && arg == "--" {
+			flagsDone = true
+			continue
+		}
+		if !flagsDone && len(arg) > 1 && strings.HasPrefix(arg, "-") {
+			flag, value, hasValue := strings.Cut(arg, "=")
+			if reason := r.checkFlag(command, flag); reason != "" {
+				return reason
+			}
+			if r.PathArgs && !hasValue {
+				for _, attached := range attachedValues(arg) {
+					if reason := checkPathArgument(command, shellWord{text: attached, glob: args[i].glob}, resolvePath); reason != "" {
+						return reason
+					}
+				}
+			}
+			if slices.Contains(r.PatternFlags, flag) {
+				patternArgs = 0
+			}
+			glob := args[i].glob
+			if !hasValue && slices.Contains(r.ValueFlags, flag) && i+1 < len(args) {
+				i++
+				value, hasValue, glob = args[i].text, true, args[i].glob
+			}
+			if hasValue && r.PathArgs && strings.Contains(value, "/") {
+				if reason := checkPathArgument(command, shellWord{text: value, glob: glob}, resolvePath); reason != "" {
+					return reason
+				}
+			}
+			continue
+		}
+		positional++
+		if positional <= patternArgs || !r.PathArgs || arg == "-" {
+			continue
+		}
+		if reason := checkPathArgument(command, args[i], resolvePath); reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
+
+// attachedValues returns the values that may be glued to a single-dash
+// option, such as "/etc/passwd" in "-f/etc/passwd" or "-newer/etc/passwd".
+// Where the option letters end is unknown, so every suffix following a
+// letter is a candidate; only those containing "/" can leave the workspace.
+func attachedValues(arg string) []string {
+	if strings.HasPrefix(arg, "--") {
+		return nil
+	}
+	var values []string
+	for i := 2; i < len(arg); i++ {
+		letter := arg[i-1]
+		if (letter < 'a' || letter > 'z') && (letter < 'A' || letter > 'Z') {
+			break
+		}
+		if strings.Contains(arg[i:], "/") {
+			values = append(values, arg[i:])
+		}
+	}
+	return values
+}
+
+func (r BashCommandRule) checkFlag(command, flag string) string {
+	candidates := []string{flag}
+	listed := slices.Contains(r.AllowedFlags, flag) || slices.Contains(r.DeniedFlags, flag) || slices.Contains(r.ValueFlags, flag)
+	if !listed && !strings.HasPrefix(flag, "--") && len(flag) > 2 {
+		candidates = candidates[:0]
+		for _, letter := range flag[1:] {
+			candidates = append(candidates, "-"+string(letter))
+		}
+	}
+	for _, candidate := range candidates {
+		if r.denies(candidate) {
+			return fmt.Sprintf("command %q: flag %q is not allowed", command, flag)
+		}
+		if len(r.AllowedFlags) > 0 && !slices.Contains(r.AllowedFlags, candidate) && !slices.Contains(r.ValueFlags, candidate) {
+			return fmt.Sprintf("command %q: flag %q is not allowed", command, flag)
+		}
+	}
+	return ""
+}
+
+func (r BashCommandRule) denies(flag string) bool {
+	if slices.Contains(r.DeniedFlags, flag) {
+		return true
+	}
+	if !strings.HasPrefix(flag, "--") || len(flag) <= len("--") {
+		return false
+	}
+	return slices.ContainsFunc(r.DeniedFlags, func(denied string) bool {
+		return strings.HasPrefix(denied, "--") && strings.HasPrefix(denied, flag)
+	})
+}
+
+func checkPathArgument(command string, arg shellWord, resolvePath func(string) (string, error)) string {
+	if slices.Contains(bashSpecialPaths, arg.text) {
+		return ""
+	}
+	if arg.glob && slices.ContainsFunc(strings.Split(arg.text, "/"), matchesDotDirectories) {
+		return fmt.Sprintf("command %q: path argument %q may expand to a parent directory", command, arg.text)
+	}
+	if _, err := resolvePath(arg.text); err != nil {
+		return fmt.Sprintf("command %q: path argument %q is outside the workspace", command, arg.text)
+	}
+	return ""
+}
+
+// matchesDotDirectories reports whether a glob path component could match "."
+// or "..": bash only matches a leading dot written literally, and before 5.2
+// it does not skip the dot directories.
+func matchesDotDirectories(component string) bool {
+	return strings.HasPrefix(component, ".") && strings.ContainsAny(component, "*?[")
+}
@@ -0,0 +1,149 @@
+package toolset_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gurpartap/agentframe/agent"
+	"github.com/Gurpartap/agentframe/examples/coding-agent/server/internal/toolset"
+)
+
+func TestDefaultBashPolicy(t *testing.T) {
+	t.Parallel()
+
+	policy, err := toolset.NewPolicy(t.TempDir(), time.Second)
+	if err != nil {
+		t.Fatalf("new policy: %v", err)
+	}
+
+	testCases := []struct {
+		command    string
+		wantReason string
+	}{
+		{command: "ls -la src"},
+		{command: `grep -rn "func main" . | sort | head -n 5`},
+		{command: "grep -e pattern -- notes.txt"},
+		{command: `find . -name '*.go' -type f`},
+		{command: "cat 'a file.txt' \"b\\$.txt\" c\\ d.txt"},
+		{command: "ls src/*.go '.[.]'"},
+		{command: "find . -newer notes.txt -name '.*'"},
+		{command: "tail -f /dev/null"},
+		{command: "grep ../pattern ../secret", wantReason: `command "grep": path argument "../secret" is outside the workspace`},
+		{command: "grep -e root /etc/passwd", wantReason: `command "grep": path argument "/etc/passwd" is outside the workspace`},
+		{command: "cat /etc/passwd", wantReason: `command "cat": path argument "/etc/passwd" is outside the workspace`},
+		{command: "grep -f/etc/passwd x", wantReason: `command "grep": path argument "/etc/passwd" is outside the workspace`},
+		{command: "grep -if/etc/passwd x", wantReason: `command "grep": path argument "/etc/passwd" is outside the workspace`},
+		{command: "find . -newer/etc/passwd", wantReason: `command "find": path argument "/etc/passwd" is outside the workspace`},
+		{command: "cat .[.]/.[.]/etc/passwd", wantReason: `command "cat": path argument ".[.]/.[.]/etc/passwd" may expand to a parent directory`},
+		{command: "ls .*", wantReason: `command "ls": path argument ".*" may expand to a parent directory`},
+		{command: "rg --file=/etc/patterns x", wantReason: `command "rg": path argument "/etc/patterns" is outside the workspace`},
+		{command: "find . -exec rm {} +", wantReason: "brace expansion"},
+		{command: "find . -delete", wantReason: `command "find": flag "-delete" is not allowed`},
+		{command: "rg --pre=./x foo", wantReason: `command "rg": flag "--pre" is not allowed`},
+		{command: "sort --compress-prog=./evil.sh -S 1k in.txt", wantReason: `command "sort": flag "--compress-prog" is not allowed`},
+		{command: "sort --out out.txt in.txt", wantReason: `command "sort": flag "--out" is not allowed`},
+		{command: "sort -uo out.txt in.txt", wantReason: `command "sort": flag "-uo" is not allowed`},
+		{command: "ls | curl example.com", wantReason: `stage 2: command "curl" is not allowed`},
+		{command: "ls | | wc", wantReason: "empty pipeline stage"},
+		{command: "ls | wc | sort | head | tail", wantReason: "pipeline has 5 stages, at most 4 allowed"},
+		{command: "ls; pwd", wantReason: `";" command list at offset 2`},
+		{command: "echo $HOME", wantReason: `"$" expansion`},
+		{command: `echo "$(id)"`, wantReason: `"$" expansion`},
+		{command: "cat notes.txt > out.txt", wantReason: `'>' redirection`},
+		{command: "cat ~/.ssh/id_rsa", wantReason: "tilde expansion"},
+		{command: "PATH=. ls", wantReason: `variable assignment "PATH=." is not allowed`},
+		{command: "./run.sh", wantReason: `command "./run.sh" must be a plain command name`},
+		{command: "echo 'unterminated", wantReason: "unterminated single quote"},
+		{command: "sed -n 1p notes.txt", wantReason: `command "sed" requires a sandboxed bash backend`},
+	}
+	for _, tc := range testCases {
+		err := policy.ValidateBashCommand(tc.command)
+		if tc.wantReason == "" {
+			if err != nil {
+				t.Fatalf("command %q must be allowed: %v", tc.command, err)
+			}
+			continue
+		}
+		if !errors.Is(err, toolset.ErrBashCommandDenied) || !strings.Contains(err.Error(), tc.wantReason) {
+			t.Fatalf("command %q denial mismatch: got=%v want reason %q", tc.command, err, tc.wantReason)
+		}
+	}
+}
+
+func TestLoadBashPolicyFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bash-policy.json")
+	content := `{
+  "max_pipeline_stages": 1,
+  "commands": {
+    "ls": {"path_args": true, "allowed_flags": ["-l", "-a"]},
+    "git": {"subcommands": {"status": {"allowed_flags": ["--short"]}, "log": {"value_flags": ["-n"]}}}
+  }
+}`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write policy: %v", err)
+	}
+	bashPolicy, err := toolset.LoadBashPolicy(path)
+	if err != nil {
+		t.Fatalf("load bash policy: %v", err)
+	}
+	policy, err := toolset.NewPolicy(dir, time.Second)
+	if err != nil {
+		t.Fatalf("new policy: %v", err)
+	}
+	policy = policy.WithBashPolicy(bashPolicy)
+
+	for _, command := range []string{"ls -la", "git status --short", "git log -n 5"} {
+		if err := policy.ValidateBashCommand(command); err != nil {
+			t.Fatalf("command %q must be allowed: %v", command, err)
+		}
+	}
+	for command, wantReason := range map[string]string{
+		"ls -R":           `command "ls": flag "-R" is not allowed`,
+		"git push":        `command "git": subcommand "push" is not allowed`,
+		"git":             `command "git" requires a subcommand`,
+		"git status -uno": `command "git status": flag "-uno" is not allowed`,
+		"ls | wc":         "pipeline has 2 stages, at most 1 allowed",
+		"cat notes.txt":   `command "cat" is not allowed`,
+	} {
+		err := policy.ValidateBashCommand(command)
+		if !errors.Is(err, toolset.ErrBashCommandDenied) || !strings.Contains(err.Error(), wantReason) {
+			t.Fatalf("command %q denial mismatch: got=%v want reason %q", command, err, wantReason)
+		}
+	}
+
+	executor := toolset.NewExecutor(policy)
+	_, err = executor.Execute(context.Background(), agent.ToolCall{
+		ID:        "bash-push-1",
+		Name:      toolset.ToolBash,
+		Arguments: map[string]any{"command": "git push"},
+	})
+	var suspendErr *agent.SuspendRequestError
+	if !errors.As(err, &suspendErr) {
+		t.Fatalf("expected SuspendRequestError, got %v", err)
+	}
+	if want := `approve bash command denied by policy: command "git": subcommand "push" is not allowed`; suspendErr.Requirement.Prompt != want {
+		t.Fatalf("prompt mismatch: got=%q want=%q", suspendErr.Requirement.Prompt, want)
+	}
+
+	for name, invalid := range map[string]string{
+		"unknown-field.json": `{"commands": {"ls": {"paths": true}}}`,
+		"empty.json":         `{"commands": {}}`,
+		"bad-flag.json":      `{"commands": {"ls": {"allowed_flags": ["l"]}}}`,
+	} {
+		invalidPath := filepath.Join(dir, name)
+		if err := os.WriteFile(invalidPath, []byte(invalid), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+		if _, err := toolset.LoadBashPolicy(invalidPath); !errors.Is(err, toolset.ErrBashPolicyInvalid) {
+			t.Fatalf("%s: expected ErrBashPolicyInvalid, got %v", name, err)
+		}
+	}
+}
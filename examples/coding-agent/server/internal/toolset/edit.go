@@ -1,12 +1,16 @@
 package toolset
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
+
+	"github.com/Gurpartap/agentframe/agent"
 )
 
-func (e *Executor) executeEdit(arguments map[string]any) (string, error) {
+func (e *Executor) executeEdit(ctx context.Context, call agent.ToolCall) (string, error) {
+	arguments := call.Arguments
 	path, err := stringArgument(arguments, "path")
 	if err != nil {
 		return "", err
@@ -20,11 +24,14 @@ func (e *Executor) executeEdit(arguments map[string]any) (string, error) {
 		return "", err
 	}
 
-	resolved, err := e.policy.ResolvePath(path)
+	resolved, err := e.policy.ResolveWritablePath(path)
 	if err != nil {
 		return "", err
 	}
 
+	if err := e.checkFileFresh(ctx, call, path, resolved); err != nil {
+		return "", err
+	}
 	raw, err := os.ReadFile(resolved)
 	if err != nil {
 		return "", fmt.Errorf("edit %q: read: %w", path, err)
@@ -36,9 +43,13 @@ func (e *Executor) executeEdit(arguments map[string]any) (string, error) {
 	}
 
 	updated := strings.Replace(content, oldValue, newValue, 1)
+	if err := e.snapshot(ctx, call, resolved); err != nil {
+		return "", fmt.Errorf("edit %q: %w", path, err)
+	}
 	if err := os.WriteFile(resolved, []byte(updated), 0o644); err != nil {
 		return "", fmt.Errorf("edit %q: write: %w", path, err)
 	}
+	e.rememberContent(ctx, resolved, []byte(updated))
 
 	return fmt.Sprintf("edit_ok path=%s replacements=1", path), nil
 }
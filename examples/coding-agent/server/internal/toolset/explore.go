@@ -0,0 +1,387 @@
+package toolset
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Gurpartap/agentframe/agent"
+)
+
+const (
+	ToolListDir = "list_dir"
+	ToolGlob    = "glob"
+	ToolSearch  = "search"
+
+	defaultListDirDepth  = 1
+	maxListDirDepth      = 5
+	maxListDirEntries    = 1000
+	maxGlobResults       = 500
+	defaultSearchResults = 100
+	maxSearchResults     = 1000
+	maxSearchLineBytes   = 240
+	binarySniffBytes     = 8000
+)
+
+var errWalkStop = errors.New("walk stopped")
+
+var exploreToolDefinitions = []agent.ToolDefinition{
+	{
+		Name:        ToolListDir,
+		Description: "List a directory within the workspace root, skipping .gitignore'd entries. Directories end with '/'.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"path":  map[string]any{"type": "string"},
+				"depth": map[string]any{"type": "integer", "minimum": 1, "maximum": maxListDirDepth},
+			},
+		},
+	},
+	{
+		Name: ToolGlob,
+		Description: "Find files under path (default the workspace root) matching a glob, skipping .gitignore'd files. " +
+			"A pattern without '/' matches file names at any depth; otherwise it matches the path below path, with ** spanning directories.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"pattern": map[string]any{"type": "string"},
+				"path":    map[string]any{"type": "string"},
+			},
+			"required": []any{"pattern"},
+		},
+	},
+	{
+		Name: ToolSearch,
+		Description: "Search text files under path (a directory or file, default the workspace root) for an RE2 regular expression, " +
+			"skipping .gitignore'd and binary files. Reports path:line: text.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"pattern":          map[string]any{"type": "string"},
+				"path":             map[string]any{"type": "string"},
+				"glob":             map[string]any{"type": "string", "description": "Only search files matching this glob."},
+				"case_insensitive": map[string]any{"type": "boolean"},
+				"max_results":      map[string]any{"type": "integer", "minimum": 1, "maximum": maxSearchResults},
+			},
+			"required": []any{"pattern"},
+		},
+	},
+}
+
+func (e *Executor) executeListDir(ctx context.Context, arguments map[string]any) (string, error) {
+	base, display, err := e.explorePath(arguments)
+	if err != nil {
+		return "", err
+	}
+	depth, err := optionalIntArgument(arguments, "depth", defaultListDirDepth, maxListDirDepth)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(base)
+	if err != nil {
+		return "", fmt.Errorf("list_dir %q: %w", display, err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("list_dir %q: path is not a directory", display)
+	}
+
+	var entries []string
+	truncated, err := e.walkWorkspace(ctx, base, func(rel string, entry fs.DirEntry, level int) (bool, error) {
+		if len(entries) == maxListDirEntries {
+			return true, nil
+		}
+		if entry.IsDir() {
+			entries = append(entries, rel+"/")
+			if level >= depth {
+				return false, fs.SkipDir
+			}
+			return false, nil
+		}
+		entries = append(entries, rel)
+		return false, nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("list_dir %q: %w", display, err)
+	}
+	return exploreOutput(fmt.Sprintf("list_dir_ok path=%s entries=%d truncated=%t", display, len(entries), truncated), entries), nil
+}
+
+func (e *Executor) executeGlob(ctx context.Context, arguments map[string]any) (string, error) {
+	pattern, err := stringArgument(arguments, "pattern")
+	if err != nil {
+		return "", err
+	}
+	segments, err := parseGlob(pattern)
+	if err != nil {
+		return "", err
+	}
+	base, display, err := e.explorePath(arguments)
+	if err != nil {
+		return "", err
+	}
+
+	baseRel := e.workspaceRel(base)
+	var matches []string
+	truncated, err := e.walkWorkspace(ctx, base, func(rel string, entry fs.DirEntry, _ int) (bool, error) {
+		if entry.IsDir() || !globMatches(segments, baseRel, rel) {
+			return false, nil
+		}
+		if len(matches) == maxGlobResults {
+			return true, nil
+		}
+		matches = append(matches, rel)
+		return false, nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("glob %q: %w", display, err)
+	}
+	return exploreOutput(fmt.Sprintf("glob_ok pattern=%q path=%s matches=%d truncated=%t", pattern, display, len(matches), truncated), matches), nil
+}
+
+func (e *Executor) executeSearch(ctx context.Context, arguments map[string]any) (string, error) {
+	pattern, err := stringArgument(arguments, "pattern")
+	if err != nil {
+		return "", err
+	}
+	caseInsensitive, err := optionalBoolArgument(arguments, "case_insensitive")
+	if err != nil {
+		return "", err
+	}
+	source := pattern
+	if caseInsensitive {
+		source = "(?i)" + pattern
+	}
+	expression, err := regexp.Compile(source)
+	if err != nil {
+		return "", fmt.Errorf("%w: argument %q is not a valid regular expression: %v", ErrArgumentInvalid, "pattern", err)
+	}
+	var fileGlob []string
+	if _, ok := arguments["glob"]; ok {
+		raw, err := stringArgument(arguments, "glob")
+		if err != nil {
+			return "", err
+		}
+		if fileGlob, err = parseGlob(raw); err != nil {
+			return "", err
+		}
+	}
+	limit, err := optionalIntArgument(arguments, "max_results", defaultSearchResults, maxSearchResults)
+	if err != nil {
+		return "", err
+	}
+	base, display, err := e.explorePath(arguments)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(base)
+	if err != nil {
+		return "", fmt.Errorf("search %q: %w", display, err)
+	}
+
+	var results []string
+	searchFile := func(resolved, rel string) bool {
+		for _, match := range e.searchFile(resolved, rel, expression) {
+			if len(results) == limit {
+				return true
+			}
+			results = append(results, match)
+		}
+		return false
+	}
+
+	truncated := false
+	if info.Mode().IsRegular() {
+		truncated = searchFile(base, e.workspaceRel(base))
+	} else {
+		baseRel := e.workspaceRel(base)
+		truncated, err = e.walkWorkspace(ctx, base, func(rel string, entry fs.DirEntry, _ int) (bool, error) {
+			if !entry.Type().IsRegular() {
+				return false, nil
+			}
+			if fileGlob != nil && !globMatches(fileGlob, baseRel, rel) {
+				return false, nil
+			}
+			return searchFile(filepath.Join(e.policy.WorkspaceRoot(), filepath.FromSlash(rel)), rel), nil
+		})
+		if err != nil {
+			return "", fmt.Errorf("search %q: %w", display, err)
+		}
+	}
+	return exploreOutput(fmt.Sprintf("search_ok pattern=%q path=%s matches=%d truncated=%t", pattern, display, len(results), truncated), results), nil
+}
+
+// searchFile returns "path:line: text" for each matching line of a text file
+// within the read size limit.
+func (e *Executor) searchFile(resolved, rel string, expression *regexp.Regexp) []string {
+	info, err := os.Stat(resolved)
+	if err != nil || info.Size() > e.policy.maxReadSize {
+		return nil
+	}
+	content, err := os.ReadFile(resolved)
+	if err != nil || bytes.IndexByte(content[:min(len(content), binarySniffBytes)], 0) >= 0 {
+		return nil
+	}
+
+	var matches []string
+	for index, line := range bytes.Split(bytes.TrimSuffix(content, []byte("\n")), []byte("\n")) {
+		line = bytes.TrimRight(line, "\r")
+		if !expression.Match(line) {
+			continue
+		}
+		text := string(line)
+		if len(text) > maxSearchLineBytes {
+			text = strings.ToValidUTF8(text[:maxSearchLineBytes], "") + "..."
+		}
+		matches = append(matches, fmt.Sprintf("%s:%d: %s", rel, index+1, text))
+	}
+	return matches
+}
+
+// walkVisitor is called for every non-ignored entry below the walk root with
+// its slash-separated workspace-relative path and depth (1 for direct
+// children). Returning stop ends the walk and marks the result truncated.
+type walkVisitor func(rel string, entry fs.DirEntry, depth int) (stop bool, err error)
+
+// walkWorkspace walks base in lexical order, skipping entries excluded by
+// .gitignore files between the workspace root and each entry. Symlinks are
+// reported but never followed, and unreadable directories are skipped.
+func (e *Executor) walkWorkspace(ctx context.Context, base string, visit walkVisitor) (bool, error) {
+	root := e.policy.WorkspaceRoot()
+	matcher := &ignoreMatcher{}
+	matcher.loadFile(filepath.Join(root, ".git", "info", "exclude"), "")
+	matcher.loadDir(root, "")
+	baseRel := e.workspaceRel(base)
+	if baseRel != "." {
+		dir := ""
+		for _, segment := range strings.Split(baseRel, "/") {
+			dir = path.Join(dir, segment)
+			matcher.loadDir(filepath.Join(root, filepath.FromSlash(dir)), dir)
+		}
+	}
+	baseDepth := 0
+	if baseRel != "." {
+		baseDepth = strings.Count(baseRel, "/") + 1
+	}
+
+	truncated := false
+	err := filepath.WalkDir(base, func(current string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			if current == base {
+				return walkErr
+			}
+			return nil
+		}
+		if current == base {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel := e.workspaceRel(current)
+		if matcher.ignored(rel, entry.IsDir()) {
+			if entry.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		stop, err := visit(rel, entry, strings.Count(rel, "/")+1-baseDepth)
+		if stop {
+			truncated = true
+			return errWalkStop
+		}
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			matcher.loadDir(current, rel)
+		}
+		return nil
+	})
+	if errors.Is(err, errWalkStop) {
+		err = nil
+	}
+	return truncated, err
+}
+
+// explorePath resolves the optional path argument, defaulting to the
+// workspace root.
+func (e *Executor) explorePath(arguments map[string]any) (string, string, error) {
+	display := "."
+	if _, ok := arguments["path"]; ok {
+		value, err := stringArgument(arguments, "path")
+		if err != nil {
+			return "", "", err
+		}
+		display = value
+	}
+	resolved, err := e.policy.ResolvePath(display)
+	if err != nil {
+		return "", "", err
+	}
+	return resolved, display, nil
+}
+
+func (e *Executor) workspaceRel(resolved string) string {
+	rel, err := filepath.Rel(e.policy.WorkspaceRoot(), resolved)
+	if err != nil {
+		return filepath.ToSlash(resolved)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// parseGlob validates a glob and splits it into path segments.
+func parseGlob(pattern string) ([]string, error) {
+	trimmed := strings.Trim(strings.TrimSpace(pattern), "/")
+	if trimmed == "" {
+		return nil, fmt.Errorf("%w: glob %q is empty", ErrArgumentInvalid, pattern)
+	}
+	segments := strings.Split(trimmed, "/")
+	for _, segment := range segments {
+		if segment == ".." {
+			return nil, fmt.Errorf("%w: glob %q must not contain '..'", ErrArgumentInvalid, pattern)
+		}
+		if _, err := path.Match(segment, ""); err != nil {
+			return nil, fmt.Errorf("%w: glob %q: %v", ErrArgumentInvalid, pattern, err)
+		}
+	}
+	return segments, nil
+}
+
+// globMatches applies gitignore-style semantics: a single segment matches
+// the file name at any depth, otherwise the path below baseRel must match.
+func globMatches(segments []string, baseRel, rel string) bool {
+	if len(segments) == 1 {
+		return matchSegments(segments, []string{path.Base(rel)})
+	}
+	sub := rel
+	if baseRel != "." {
+		sub = strings.TrimPrefix(rel, baseRel+"/")
+	}
+	return matchSegments(segments, strings.Split(sub, "/"))
+}
+
+func optionalIntArgument(arguments map[string]any, key string, fallback, maximum int) (int, error) {
+	raw, ok := arguments[key]
+	if !ok {
+		return fallback, nil
+	}
+	value, isNumber := raw.(float64)
+	if !isNumber || value != float64(int(value)) || value < 1 || value > float64(maximum) {
+		return 0, fmt.Errorf("%w: argument %q must be an integer between 1 and %d", ErrArgumentInvalid, key, maximum)
+	}
+	return int(value), nil
+}
+
+func exploreOutput(header string, lines []string) string {
+	if len(lines) == 0 {
+		return header
+	}
+	return header + "\n" + strings.Join(lines, "\n")
+}
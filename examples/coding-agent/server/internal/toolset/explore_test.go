@@ -0,0 +1,129 @@
+package toolset_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gurpartap/agentframe/agent"
+	"github.com/Gurpartap/agentframe/examples/coding-agent/server/internal/toolset"
+)
+
+func newExploreExecutor(t *testing.T) *toolset.Executor {
+	t.Helper()
+
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		".gitignore":             "build/\n*.log\n!keep.log\n/root-only.txt\n",
+		"main.go":                "package main\n\nfunc main() {\n\tTODO()\n}\n",
+		"root-only.txt":          "TODO hidden\n",
+		"debug.log":              "TODO hidden\n",
+		"keep.log":               "TODO kept\n",
+		"build/out.go":           "TODO hidden\n",
+		"pkg/util/util.go":       "package util\n\n// todo: lower case\n",
+		"pkg/util/root-only.txt": "TODO nested is not anchored-ignored\n",
+		"pkg/.gitignore":         "generated.go\n",
+		"pkg/generated.go":       "TODO hidden\n",
+		"assets/logo.bin":        "TODO\x00binary",
+		".git/config":            "TODO hidden\n",
+	} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("seed %s: %v", name, err)
+		}
+	}
+	policy, err := toolset.NewPolicy(root, time.Second)
+	if err != nil {
+		t.Fatalf("new policy: %v", err)
+	}
+	return toolset.NewExecutor(policy)
+}
+
+func explore(t *testing.T, executor *toolset.Executor, name string, arguments map[string]any) string {
+	t.Helper()
+
+	result, err := executor.Execute(context.Background(), agent.ToolCall{ID: name + "-1", Name: name, Arguments: arguments})
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return result.Content
+}
+
+func TestExploreToolsRespectGitignore(t *testing.T) {
+	t.Parallel()
+
+	executor := newExploreExecutor(t)
+
+	listed := explore(t, executor, toolset.ToolListDir, map[string]any{"depth": float64(3)})
+	wantListed := strings.Join([]string{
+		"list_dir_ok path=. entries=10 truncated=false",
+		".gitignore",
+		"assets/",
+		"assets/logo.bin",
+		"keep.log",
+		"main.go",
+		"pkg/",
+		"pkg/.gitignore",
+		"pkg/util/",
+		"pkg/util/root-only.txt",
+		"pkg/util/util.go",
+	}, "\n")
+	if listed != wantListed {
+		t.Fatalf("list_dir mismatch:\ngot=%s\nwant=%s", listed, wantListed)
+	}
+
+	globbed := explore(t, executor, toolset.ToolGlob, map[string]any{"pattern": "*.go"})
+	if globbed != "glob_ok pattern=\"*.go\" path=. matches=2 truncated=false\nmain.go\npkg/util/util.go" {
+		t.Fatalf("glob mismatch: %q", globbed)
+	}
+	anchored := explore(t, executor, toolset.ToolGlob, map[string]any{"pattern": "util/**", "path": "pkg"})
+	if anchored != "glob_ok pattern=\"util/**\" path=pkg matches=2 truncated=false\npkg/util/root-only.txt\npkg/util/util.go" {
+		t.Fatalf("anchored glob mismatch: %q", anchored)
+	}
+
+	searched := explore(t, executor, toolset.ToolSearch, map[string]any{"pattern": "todo", "case_insensitive": true})
+	wantSearched := strings.Join([]string{
+		`search_ok pattern="todo" path=. matches=4 truncated=false`,
+		"keep.log:1: TODO kept",
+		"main.go:4: \tTODO()",
+		"pkg/util/root-only.txt:1: TODO nested is not anchored-ignored",
+		"pkg/util/util.go:3: // todo: lower case",
+	}, "\n")
+	if searched != wantSearched {
+		t.Fatalf("search mismatch:\ngot=%s\nwant=%s", searched, wantSearched)
+	}
+
+	capped := explore(t, executor, toolset.ToolSearch, map[string]any{"pattern": "todo", "case_insensitive": true, "glob": "*.go", "max_results": float64(1)})
+	if capped != "search_ok pattern=\"todo\" path=. matches=1 truncated=true\nmain.go:4: \tTODO()" {
+		t.Fatalf("capped search mismatch: %q", capped)
+	}
+}
+
+func TestExploreToolsStayInsideWorkspace(t *testing.T) {
+	t.Parallel()
+
+	executor := newExploreExecutor(t)
+	for _, call := range []agent.ToolCall{
+		{Name: toolset.ToolListDir, Arguments: map[string]any{"path": ".."}},
+		{Name: toolset.ToolSearch, Arguments: map[string]any{"pattern": "x", "path": "/etc"}},
+	} {
+		if _, err := executor.Execute(context.Background(), call); !errors.Is(err, toolset.ErrPathOutsideWorkspace) {
+			t.Fatalf("%s: expected ErrPathOutsideWorkspace, got %v", call.Name, err)
+		}
+	}
+	for _, arguments := range []map[string]any{
+		{"pattern": "../*"},
+		{"pattern": "[unclosed"},
+	} {
+		if _, err := executor.Execute(context.Background(), agent.ToolCall{Name: toolset.ToolGlob, Arguments: arguments}); !errors.Is(err, toolset.ErrArgumentInvalid) {
+			t.Fatalf("glob %v: expected ErrArgumentInvalid, got %v", arguments, err)
+		}
+	}
+}
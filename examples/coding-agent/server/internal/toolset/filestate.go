@@ -0,0 +1,174 @@
+package toolset
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/Gurpartap/agentframe/agent"
+)
+
+// StaleFileMode selects what write and edit do when the target changed on
+// disk since the run last read or wrote it.
+type StaleFileMode string
+
+const (
+	// StaleFileApprove suspends the run for operator approval.
+	StaleFileApprove StaleFileMode = "approve"
+	// StaleFileRefuse fails the call so the model re-reads the file.
+	StaleFileRefuse StaleFileMode = "refuse"
+
+	// maxTrackedReadRuns bounds how many runs keep read hashes; the oldest
+	// run is forgotten first.
+	maxTrackedReadRuns = 256
+)
+
+var (
+	ErrFileChangedSinceRead    = errors.New("file changed on disk since it was last read")
+	ErrStaleFileReplayMismatch = errors.New("stale file replay override mismatch")
+)
+
+// WithStaleFileMode overrides the default StaleFileApprove.
+func WithStaleFileMode(mode StaleFileMode) ExecutorOption {
+	return func(e *Executor) {
+		if mode != "" {
+			e.staleFileMode = mode
+		}
+	}
+}
+
+// fileReads remembers, per run, the content hash of each file as the model
+// last saw it through read, write, edit or apply_patch. A missing file is
+// recorded as "".
+type fileReads struct {
+	mu    sync.Mutex
+	runs  map[agent.RunID]map[string]string
+	order []agent.RunID
+}
+
+func newFileReads() *fileReads {
+	return &fileReads{runs: map[agent.RunID]map[string]string{}}
+}
+
+func (r *fileReads) remember(runID agent.RunID, resolved, sum string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	files, ok := r.runs[runID]
+	if !ok {
+		if len(r.order) >= maxTrackedReadRuns {
+			delete(r.runs, r.order[0])
+			r.order = slices.Delete(r.order, 0, 1)
+		}
+		files = map[string]string{}
+		r.runs[runID] = files
+		r.order = append(r.order, runID)
+	}
+	files[resolved] = sum
+}
+
+func (r *fileReads) lookup(runID agent.RunID, resolved string) (string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sum, ok := r.runs[runID][resolved]
+	return sum, ok
+}
+
+// rememberContent records content as the run's view of resolved after a
+// successful write.
+func (e *Executor) rememberContent(ctx context.Context, resolved string, content []byte) {
+	runID, _ := agent.RunIDFromContext(ctx)
+	e.reads.remember(runID, resolved, contentSHA256(content))
+}
+
+// checkFileFresh rejects or suspends a write to resolved when the run has seen
+// the file before and its contents changed since. Files the run never saw are
+// not checked.
+func (e *Executor) checkFileFresh(ctx context.Context, call agent.ToolCall, path, resolved string) error {
+	runID, _ := agent.RunIDFromContext(ctx)
+	seen, ok := e.reads.lookup(runID, resolved)
+	if !ok {
+		return nil
+	}
+	current, err := fileSHA256(resolved)
+	if err != nil {
+		return fmt.Errorf("%s %q: %w", call.Name, path, err)
+	}
+	if current == seen {
+		return nil
+	}
+
+	staleErr := fmt.Errorf("%w: path=%q seen_sha256=%s current_sha256=%s", ErrFileChangedSinceRead, path, shortSHA(seen), shortSHA(current))
+	if e.staleFileMode == StaleFileRefuse {
+		return fmt.Errorf("%w; read it again before changing it", staleErr)
+	}
+	fingerprint := staleFileApprovalFingerprint(call, resolved, seen, current)
+	if err := validateApprovedToolReplay(ctx, call.ID, fingerprint, ErrStaleFileReplayMismatch); err != nil {
+		return err
+	}
+	if approvedToolReplay(ctx, call.ID, fingerprint) {
+		return nil
+	}
+	return &agent.SuspendRequestError{
+		Requirement: &agent.PendingRequirement{
+			ID:          fmt.Sprintf("req-stale-file-%s", call.ID),
+			Kind:        agent.RequirementKindApproval,
+			Origin:      agent.RequirementOriginTool,
+			ToolCallID:  call.ID,
+			Fingerprint: fingerprint,
+			Prompt:      fmt.Sprintf("approve %s of %s: the file changed on disk since the model last read it", call.Name, path),
+		},
+		Err: staleErr,
+	}
+}
+
+func staleFileApprovalFingerprint(call agent.ToolCall, resolved, seen, current string) string {
+	payload, _ := json.Marshal(struct {
+		ToolName      string         `json:"tool_name"`
+		CallID        string         `json:"call_id"`
+		Arguments     map[string]any `json:"arguments"`
+		Path          string         `json:"path"`
+		SeenSHA256    string         `json:"seen_sha256"`
+		CurrentSHA256 string         `json:"current_sha256"`
+	}{
+		ToolName:      call.Name,
+		CallID:        call.ID,
+		Arguments:     call.Arguments,
+		Path:          resolved,
+		SeenSHA256:    seen,
+		CurrentSHA256: current,
+	})
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// fileSHA256 hashes the file at resolved, returning "" when it does not exist.
+func fileSHA256(resolved string) (string, error) {
+	content, err := os.ReadFile(resolved)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return contentSHA256(content), nil
+}
+
+func contentSHA256(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func shortSHA(sum string) string {
+	if sum == "" {
+		return "none"
+	}
+	return sum[:12]
+}
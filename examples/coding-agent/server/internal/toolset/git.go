@@ -0,0 +1,365 @@
+package toolset
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/Gurpartap/agentframe/agent"
+)
+
+const (
+	ToolGitStatus = "git_status"
+	ToolGitDiff   = "git_diff"
+	ToolGitLog    = "git_log"
+	ToolGitBranch = "git_branch"
+	ToolGitCommit = "git_commit"
+
+	// GitRunTrailer is the commit trailer git_commit adds to link a commit to
+	// the run that made it.
+	GitRunTrailer = "Agent-Run-Id"
+
+	defaultGitLogCount = 20
+	maxGitLogCount     = 200
+)
+
+var (
+	ErrGitFailed         = errors.New("git command failed")
+	ErrGitReplayMismatch = errors.New("git replay override mismatch")
+)
+
+var gitToolDefinitions = []agent.ToolDefinition{
+	{
+		Name:        ToolGitStatus,
+		Description: "Show the branch and changed files of the workspace git repository in porcelain format.",
+		InputSchema: map[string]any{
+			"type":       "object",
+			"properties": map[string]any{},
+		},
+	},
+	{
+		Name:        ToolGitDiff,
+		Description: "Show unstaged changes, or staged changes when staged is true, optionally limited to paths within the workspace root.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"staged": map[string]any{"type": "boolean"},
+				"paths":  map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			},
+		},
+	},
+	{
+		Name:        ToolGitLog,
+		Description: "List recent commits (hash, author, date, subject), optionally limited to one path.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"max_count": map[string]any{"type": "integer", "minimum": 1, "maximum": maxGitLogCount},
+				"path":      map[string]any{"type": "string"},
+			},
+		},
+	},
+	{
+		Name:        ToolGitBranch,
+		Description: "Create a branch at HEAD and switch to it. Defaults to agent/<run-id>.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"name": map[string]any{"type": "string"},
+			},
+		},
+	},
+	{
+		Name: ToolGitCommit,
+		Description: "Stage and commit changes within the workspace root (or only paths) on the current branch. " +
+			"Requires operator approval; the commit message gets an " + GitRunTrailer + " trailer.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"message": map[string]any{"type": "string"},
+				"paths":   map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			},
+			"required": []any{"message"},
+		},
+	},
+}
+
+func (e *Executor) executeGitStatus(ctx context.Context) (string, error) {
+	output, err := e.git(ctx, "status", "--porcelain=v1", "--branch", "--untracked-files=all", "--", ".")
+	if err != nil {
+		return "", err
+	}
+	return "git_status_ok\n" + output, nil
+}
+
+func (e *Executor) executeGitDiff(ctx context.Context, arguments map[string]any) (string, error) {
+	staged, err := optionalBoolArgument(arguments, "staged")
+	if err != nil {
+		return "", err
+	}
+	pathspecs, err := e.gitPathspecs(arguments)
+	if err != nil {
+		return "", err
+	}
+
+	args := []string{"diff", "--no-color", "--no-ext-diff", "--no-textconv"}
+	if staged {
+		args = append(args, "--cached")
+	}
+	output, err := e.git(ctx, append(append(args, "--"), pathspecs...)...)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("git_diff_ok staged=%t\n%s", staged, output), nil
+}
+
+func (e *Executor) executeGitLog(ctx context.Context, arguments map[string]any) (string, error) {
+	count, err := optionalIntArgument(arguments, "max_count", defaultGitLogCount, maxGitLogCount)
+	if err != nil {
+		return "", err
+	}
+
+	args := []string{"log", "--no-color", "--date=iso-strict", "--format=%H %an %ad %s", fmt.Sprintf("--max-count=%d", count)}
+	if _, ok := arguments["path"]; ok {
+		path, err := stringArgument(arguments, "path")
+		if err != nil {
+			return "", err
+		}
+		pathspec, err := e.gitPathspec(path)
+		if err != nil {
+			return "", err
+		}
+		args = append(args, "--", pathspec)
+	}
+	output, err := e.git(ctx, args...)
+	if err != nil {
+		return "", err
+	}
+	return "git_log_ok\n" + output, nil
+}
+
+func (e *Executor) executeGitBranch(ctx context.Context, arguments map[string]any) (string, error) {
+	var name string
+	if _, ok := arguments["name"]; ok {
+		value, err := stringArgument(arguments, "name")
+		if err != nil {
+			return "", err
+		}
+		name = strings.TrimSpace(value)
+	} else {
+		runID, ok := agent.RunIDFromContext(ctx)
+		if !ok {
+			return "", fmt.Errorf("%w: missing argument %q", ErrArgumentInvalid, "name")
+		}
+		name = "agent/" + string(runID)
+	}
+	if strings.HasPrefix(name, "-") {
+		return "", fmt.Errorf("%w: argument %q must not start with '-'", ErrArgumentInvalid, "name")
+	}
+	if _, err := e.git(ctx, "check-ref-format", "--branch", name); err != nil {
+		return "", fmt.Errorf("%w: argument %q is not a valid branch name: %w", ErrArgumentInvalid, "name", err)
+	}
+	if _, err := e.git(ctx, "switch", "--create", name); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("git_branch_ok branch=%q", name), nil
+}
+
+func (e *Executor) executeGitCommit(ctx context.Context, call agent.ToolCall) (string, error) {
+	message, err := stringArgument(call.Arguments, "message")
+	if err != nil {
+		return "", err
+	}
+	pathspecs, err := e.gitPathspecs(call.Arguments)
+	if err != nil {
+		return "", err
+	}
+	runID, _ := agent.RunIDFromContext(ctx)
+
+	// The approval covers the exact message, paths and working tree state.
+	head, err := e.git(ctx, "rev-parse", "--verify", "--quiet", "HEAD")
+	if err != nil && !errors.Is(err, ErrGitFailed) {
+		return "", err
+	}
+	status, err := e.git(ctx, "status", "--porcelain=v1", "--untracked-files=all", "--", ".")
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(status) == "" {
+		return "", fmt.Errorf("%w: nothing to commit", ErrGitFailed)
+	}
+	fingerprint := gitCommitApprovalFingerprint(call, message, pathspecs, runID, e.policy, head, status)
+	if err := validateApprovedToolReplay(ctx, call.ID, fingerprint, ErrGitReplayMismatch); err != nil {
+		return "", err
+	}
+	if !approvedToolReplay(ctx, call.ID, fingerprint) {
+		return "", &agent.SuspendRequestError{
+			Requirement: &agent.PendingRequirement{
+				ID:          fmt.Sprintf("req-git-commit-%s", call.ID),
+				Kind:        agent.RequirementKindApproval,
+				Origin:      agent.RequirementOriginTool,
+				ToolCallID:  call.ID,
+				Fingerprint: fingerprint,
+				Prompt:      fmt.Sprintf("approve git commit %q of changes:\n%s", firstLine(message), strings.TrimRight(status, "\n")),
+			},
+		}
+	}
+
+	fullMessage := strings.TrimRight(message, "\n")
+	if runID != "" {
+		fullMessage += fmt.Sprintf("\n\n%s: %s", GitRunTrailer, runID)
+	}
+	if _, err := e.git(ctx, append([]string{"add", "--all", "--"}, pathspecs...)...); err != nil {
+		return "", err
+	}
+	if _, err := e.git(ctx, append([]string{"commit", "--no-verify", "--quiet", "--message", fullMessage, "--"}, pathspecs...)...); err != nil {
+		return "", err
+	}
+	commit, err := e.git(ctx, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("git_commit_ok commit=%s run_id=%q", strings.TrimSpace(commit), runID), nil
+}
+
+// git runs git in the workspace root with hooks, pagers, prompts and optional
+// index locks disabled, bounded by the bash timeout. With a sandboxed bash
+// backend git runs inside the sandbox too, so filters or other commands
+// configured in the repository cannot reach the host.
+func (e *Executor) git(ctx context.Context, args ...string) (string, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, e.policy.BashTimeout())
+	defer cancel()
+
+	gitArgs := append([]string{
+		"-c", "core.hooksPath=" + os.DevNull,
+		"-c", "core.pager=cat",
+		"-c", "core.fsmonitor=false",
+		"-c", "color.ui=false",
+	}, args...)
+	gitEnv := []string{"GIT_TERMINAL_PROMPT=0", "GIT_OPTIONAL_LOCKS=0", "LC_ALL=C"}
+
+	var result BashResult
+	var err error
+	if e.backend.Sandboxed() {
+		words := append(append([]string{"env"}, gitEnv...), "git")
+		result, err = e.backend.Run(timeoutCtx, BashRequest{
+			Command:       shellJoin(append(words, gitArgs...)),
+			WorkspaceRoot: e.policy.WorkspaceRoot(),
+		})
+	} else {
+		cmd := exec.CommandContext(timeoutCtx, "git", gitArgs...)
+		cmd.Dir = e.policy.WorkspaceRoot()
+		cmd.Env = append(os.Environ(), gitEnv...)
+		result, err = runCaptured(cmd, DefaultMaxBashOutputBytes)
+	}
+	if errors.Is(timeoutCtx.Err(), context.DeadlineExceeded) {
+		return "", fmt.Errorf("%w: git %s timed out after %s", ErrGitFailed, args[0], e.policy.BashTimeout())
+	}
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", fmt.Errorf("%w: git %s exit=%d stderr=%q", ErrGitFailed, args[0], exitErr.ExitCode(), strings.TrimSpace(result.Stderr))
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	output := result.Stdout
+	if result.Truncated {
+		output += "\n[output truncated]"
+	}
+	return output, nil
+}
+
+// shellJoin quotes words for bash so each is passed through as one argument.
+func shellJoin(words []string) string {
+	quoted := make([]string, len(words))
+	for i, word := range words {
+		quoted[i] = "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
+
+// gitPathspecs resolves the optional paths argument to literal pathspecs
+// relative to the workspace root, defaulting to the whole workspace.
+func (e *Executor) gitPathspecs(arguments map[string]any) ([]string, error) {
+	raw, ok := arguments["paths"]
+	if !ok {
+		return []string{"."}, nil
+	}
+	items, isList := raw.([]any)
+	if !isList || len(items) == 0 {
+		return nil, fmt.Errorf("%w: argument %q must be a non-empty array of strings", ErrArgumentInvalid, "paths")
+	}
+	pathspecs := make([]string, 0, len(items))
+	for index, item := range items {
+		path, isString := item.(string)
+		if !isString {
+			return nil, fmt.Errorf("%w: argument %q item %d must be a string", ErrArgumentInvalid, "paths", index)
+		}
+		pathspec, err := e.gitPathspec(path)
+		if err != nil {
+			return nil, err
+		}
+		pathspecs = append(pathspecs, pathspec)
+	}
+	return pathspecs, nil
+}
+
+func (e *Executor) gitPathspec(path string) (string, error) {
+	resolved, err := e.policy.ResolvePath(path)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(e.policy.WorkspaceRoot(), resolved)
+	if err != nil {
+		return "", fmt.Errorf("resolve path %q: %w", path, err)
+	}
+	return ":(literal)" + filepath.ToSlash(rel), nil
+}
+
+func gitCommitApprovalFingerprint(call agent.ToolCall, message string, pathspecs []string, runID agent.RunID, policy Policy, head, status string) string {
+	payload, _ := json.Marshal(struct {
+		ToolName      string   `json:"tool_name"`
+		CallID        string   `json:"call_id"`
+		Message       string   `json:"message"`
+		Pathspecs     []string `json:"pathspecs"`
+		RunID         string   `json:"run_id,omitempty"`
+		WorkspaceRoot string   `json:"workspace_root"`
+		Head          string   `json:"head"`
+		Status        string   `json:"status"`
+	}{
+		ToolName:      call.Name,
+		CallID:        call.ID,
+		Message:       message,
+		Pathspecs:     pathspecs,
+		RunID:         string(runID),
+		WorkspaceRoot: policy.WorkspaceRoot(),
+		Head:          strings.TrimSpace(head),
+		Status:        status,
+	})
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+func optionalBoolArgument(arguments map[string]any, key string) (bool, error) {
+	raw, ok := arguments[key]
+	if !ok {
+		return false, nil
+	}
+	value, isBool := raw.(bool)
+	if !isBool {
+		return false, fmt.Errorf("%w: argument %q must be a boolean", ErrArgumentInvalid, key)
+	}
+	return value, nil
+}
+
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	return line
+}
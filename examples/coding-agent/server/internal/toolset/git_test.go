@@ -0,0 +1,189 @@
+package toolset_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gurpartap/agentframe/agent"
+	"github.com/Gurpartap/agentframe/examples/coding-agent/server/internal/toolset"
+)
+
+func newGitExecutor(t *testing.T) (*toolset.Executor, string) {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	root := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "--quiet", "--initial-branch=main"},
+		{"config", "user.name", "Test Agent"},
+		{"config", "user.email", "agent@example.com"},
+	} {
+		runGit(t, root, args...)
+	}
+	policy, err := toolset.NewPolicy(root, 5*time.Second)
+	if err != nil {
+		t.Fatalf("new policy: %v", err)
+	}
+	return toolset.NewExecutor(policy), root
+}
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v output=%s", args, err, output)
+	}
+	return string(output)
+}
+
+func TestGitToolsCommitOnRunBranchRequiresApproval(t *testing.T) {
+	t.Parallel()
+
+	executor, root := newGitExecutor(t)
+	ctx := agent.WithRunID(context.Background(), "run-000007")
+	execute := func(ctx context.Context, call agent.ToolCall) (string, error) {
+		result, err := executor.Execute(ctx, call)
+		return result.Content, err
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatalf("seed main.go: %v", err)
+	}
+	status, err := execute(ctx, agent.ToolCall{ID: "status-1", Name: toolset.ToolGitStatus})
+	if err != nil || !strings.Contains(status, "?? main.go") {
+		t.Fatalf("status mismatch: content=%q err=%v", status, err)
+	}
+
+	branch, err := execute(ctx, agent.ToolCall{ID: "branch-1", Name: toolset.ToolGitBranch, Arguments: map[string]any{}})
+	if err != nil || branch != `git_branch_ok branch="agent/run-000007"` {
+		t.Fatalf("branch mismatch: content=%q err=%v", branch, err)
+	}
+	_, err = execute(ctx, agent.ToolCall{ID: "branch-2", Name: toolset.ToolGitBranch, Arguments: map[string]any{"name": "bad..name"}})
+	if !errors.Is(err, toolset.ErrArgumentInvalid) {
+		t.Fatalf("expected ErrArgumentInvalid for bad branch name, got %v", err)
+	}
+
+	commit := agent.ToolCall{ID: "commit-1", Name: toolset.ToolGitCommit, Arguments: map[string]any{"message": "Add main package"}}
+	_, err = execute(ctx, commit)
+	var suspendErr *agent.SuspendRequestError
+	if !errors.As(err, &suspendErr) || suspendErr.Requirement.ID != "req-git-commit-commit-1" || suspendErr.Requirement.Fingerprint == "" {
+		t.Fatalf("expected commit approval suspension, got %v", err)
+	}
+	if !strings.Contains(suspendErr.Requirement.Prompt, "?? main.go") {
+		t.Fatalf("approval prompt must list changes: %q", suspendErr.Requirement.Prompt)
+	}
+
+	mismatched := agent.WithApprovedToolCallReplayOverride(ctx, agent.ApprovedToolCallReplayOverride{
+		ToolCallID:  commit.ID,
+		Fingerprint: "stale",
+	})
+	if _, err := execute(mismatched, commit); !errors.Is(err, toolset.ErrGitReplayMismatch) {
+		t.Fatalf("expected ErrGitReplayMismatch, got %v", err)
+	}
+
+	approved := agent.WithApprovedToolCallReplayOverride(ctx, agent.ApprovedToolCallReplayOverride{
+		ToolCallID:  commit.ID,
+		Fingerprint: suspendErr.Requirement.Fingerprint,
+	})
+	committed, err := execute(approved, commit)
+	if err != nil || !strings.HasPrefix(committed, "git_commit_ok commit=") {
+		t.Fatalf("approved commit mismatch: content=%q err=%v", committed, err)
+	}
+
+	if got := strings.TrimSpace(runGit(t, root, "rev-parse", "--abbrev-ref", "HEAD")); got != "agent/run-000007" {
+		t.Fatalf("commit branch mismatch: got=%q want=%q", got, "agent/run-000007")
+	}
+	if body := runGit(t, root, "log", "-1", "--format=%B"); !strings.Contains(body, "Add main package\n\nAgent-Run-Id: run-000007") {
+		t.Fatalf("commit message must carry run trailer: %q", body)
+	}
+	logContent, err := execute(ctx, agent.ToolCall{ID: "log-1", Name: toolset.ToolGitLog, Arguments: map[string]any{"max_count": float64(5)}})
+	if err != nil || !strings.Contains(logContent, "Test Agent") || !strings.Contains(logContent, "Add main package") {
+		t.Fatalf("log mismatch: content=%q err=%v", logContent, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatalf("modify main.go: %v", err)
+	}
+	diff, err := execute(ctx, agent.ToolCall{ID: "diff-1", Name: toolset.ToolGitDiff, Arguments: map[string]any{"paths": []any{"main.go"}}})
+	if err != nil || !strings.Contains(diff, "+func main() {}") {
+		t.Fatalf("diff mismatch: content=%q err=%v", diff, err)
+	}
+	_, err = execute(ctx, agent.ToolCall{ID: "diff-2", Name: toolset.ToolGitDiff, Arguments: map[string]any{"paths": []any{"../outside"}}})
+	if !errors.Is(err, toolset.ErrPathOutsideWorkspace) {
+		t.Fatalf("expected ErrPathOutsideWorkspace, got %v", err)
+	}
+}
+
+func TestWriteToolsRefuseGitDirectory(t *testing.T) {
+	t.Parallel()
+
+	executor, root := newGitExecutor(t)
+	configPath := filepath.Join(root, ".git", "config")
+	before, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("read git config: %v", err)
+	}
+
+	calls := []agent.ToolCall{
+		{ID: "write-1", Name: toolset.ToolWrite, Arguments: map[string]any{"path": ".git/config", "content": "[filter \"x\"]\n\tclean = ./evil.sh\n"}},
+		{ID: "write-2", Name: toolset.ToolWrite, Arguments: map[string]any{"path": "sub/../.GIT/hooks/pre-commit", "content": "#!/bin/sh\n"}},
+		{ID: "edit-1", Name: toolset.ToolEdit, Arguments: map[string]any{"path": ".git/config", "old": "[core]", "new": "[core]\n\tfsmonitor = ./evil.sh"}},
+		{ID: "patch-1", Name: toolset.ToolApplyPatch, Arguments: map[string]any{"edits": []any{
+			map[string]any{"path": ".git/info/attributes", "old": "", "new": "* filter=x\n"},
+		}}},
+	}
+	for _, call := range calls {
+		_, err := executor.Execute(context.Background(), call)
+		if !errors.Is(err, toolset.ErrPathProtected) {
+			t.Fatalf("%s: expected ErrPathProtected, got %v", call.ID, err)
+		}
+	}
+
+	after, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("read git config: %v", err)
+	}
+	if string(after) != string(before) {
+		t.Fatalf("git config must be unchanged:\n%s", after)
+	}
+	if _, err := os.Stat(filepath.Join(root, ".git", "info", "attributes")); !os.IsNotExist(err) {
+		t.Fatalf("attributes file must not be created, stat err=%v", err)
+	}
+}
+
+func TestGitToolsRunThroughSandboxedBackend(t *testing.T) {
+	t.Parallel()
+
+	policy, err := toolset.NewPolicy(t.TempDir(), time.Second)
+	if err != nil {
+		t.Fatalf("new policy: %v", err)
+	}
+	sandbox := &recordingBackend{sandboxed: true}
+	executor := toolset.NewExecutor(policy, toolset.WithBashBackend(sandbox))
+
+	result, err := executor.Execute(context.Background(), agent.ToolCall{ID: "status-1", Name: toolset.ToolGitStatus})
+	if err != nil {
+		t.Fatalf("git status: %v", err)
+	}
+	if result.Content != "git_status_ok\nran" {
+		t.Fatalf("status content mismatch: got=%q", result.Content)
+	}
+	if len(sandbox.requests) != 1 {
+		t.Fatalf("sandbox request count mismatch: got=%d want=1", len(sandbox.requests))
+	}
+	command := sandbox.requests[0].Command
+	if !strings.HasPrefix(command, "'env' 'GIT_TERMINAL_PROMPT=0'") || !strings.Contains(command, "'git' '-c' 'core.hooksPath=/dev/null'") || !strings.Contains(command, "'status'") {
+		t.Fatalf("sandboxed git command mismatch: %s", command)
+	}
+}
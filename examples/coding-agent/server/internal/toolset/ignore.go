@@ -0,0 +1,138 @@
+package toolset
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreRule is one parsed .gitignore pattern.
+type ignoreRule struct {
+	// base is the slash-separated workspace-relative directory holding the
+	// ignore file; "" for the workspace root.
+	base     string
+	segments []string
+	negate   bool
+	dirOnly  bool
+	// anchored patterns match the full path below base; the rest match the
+	// final path element at any depth.
+	anchored bool
+}
+
+// ignoreMatcher evaluates .gitignore rules found inside the workspace. Later
+// rules override earlier ones, as in git. Ignore files above the workspace
+// root are never consulted.
+type ignoreMatcher struct {
+	rules []ignoreRule
+}
+
+// loadDir appends the rules of dir/.gitignore, where rel is dir relative to
+// the workspace root.
+func (m *ignoreMatcher) loadDir(dir, rel string) {
+	m.loadFile(filepath.Join(dir, ".gitignore"), rel)
+}
+
+func (m *ignoreMatcher) loadFile(file, rel string) {
+	handle, err := os.Open(file)
+	if err != nil {
+		return
+	}
+	defer handle.Close()
+
+	scanner := bufio.NewScanner(handle)
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreRule(scanner.Text(), rel); ok {
+			m.rules = append(m.rules, rule)
+		}
+	}
+}
+
+func parseIgnoreRule(line, base string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, "\r")
+	if !strings.HasSuffix(line, `\ `) {
+		line = strings.TrimRight(line, " ")
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	rule := ignoreRule{base: base}
+	switch {
+	case strings.HasPrefix(line, "!"):
+		rule.negate = true
+		line = line[1:]
+	case strings.HasPrefix(line, `\!`), strings.HasPrefix(line, `\#`):
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		rule.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+	rule.segments = strings.Split(line, "/")
+	return rule, true
+}
+
+// ignored reports whether the slash-separated workspace-relative path is
+// excluded. The .git directory is always excluded.
+func (m *ignoreMatcher) ignored(rel string, isDir bool) bool {
+	if isDir && path.Base(rel) == ".git" {
+		return true
+	}
+	ignored := false
+	for _, rule := range m.rules {
+		if rule.matches(rel, isDir) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+func (r ignoreRule) matches(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	sub := rel
+	if r.base != "" {
+		if !strings.HasPrefix(rel, r.base+"/") {
+			return false
+		}
+		sub = strings.TrimPrefix(rel, r.base+"/")
+	}
+	if r.anchored {
+		return matchSegments(r.segments, strings.Split(sub, "/"))
+	}
+	matched, _ := path.Match(r.segments[0], path.Base(sub))
+	return matched
+}
+
+// matchSegments matches path segments against glob segments, where "**"
+// matches zero or more whole segments.
+func matchSegments(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		for skip := 0; skip <= len(parts); skip++ {
+			if matchSegments(pattern[1:], parts[skip:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	if matched, _ := path.Match(pattern[0], parts[0]); !matched {
+		return false
+	}
+	return matchSegments(pattern[1:], parts[1:])
+}
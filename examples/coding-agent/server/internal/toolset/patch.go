@@ -0,0 +1,602 @@
+package toolset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Gurpartap/agentframe/agent"
+)
+
+var (
+	ErrPatchInvalid  = errors.New("patch is invalid")
+	ErrPatchRejected = errors.New("patch does not apply")
+)
+
+const devNull = "/dev/null"
+
+var hunkHeaderPattern = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// executeApplyPatch applies a unified diff or a list of anchored edits. Every
+// hunk or edit is checked against the current file contents first; files are
+// only written when all of them apply, so a rejected patch changes nothing.
+func (e *Executor) executeApplyPatch(ctx context.Context, call agent.ToolCall) (string, error) {
+	arguments := call.Arguments
+	patchText, hasPatch := arguments["patch"]
+	editList, hasEdits := arguments["edits"]
+	if hasPatch == hasEdits {
+		return "", fmt.Errorf("%w: exactly one of %q or %q is required", ErrArgumentInvalid, "patch", "edits")
+	}
+
+	changes := newPatchChanges(e.policy)
+	var (
+		report []string
+		failed bool
+		err    error
+	)
+	if hasPatch {
+		text, ok := patchText.(string)
+		if !ok || strings.TrimSpace(text) == "" {
+			return "", fmt.Errorf("%w: argument %q must be a non-empty string", ErrArgumentInvalid, "patch")
+		}
+		report, failed, err = changes.applyUnifiedDiff(text)
+	} else {
+		report, failed, err = changes.applyEdits(editList)
+	}
+	if err != nil {
+		return "", err
+	}
+	if failed {
+		return "", fmt.Errorf("%w: no files were changed\n%s", ErrPatchRejected, strings.Join(report, "\n"))
+	}
+	if err := e.snapshot(ctx, call, changes.order...); err != nil {
+		return "", fmt.Errorf("apply_patch: %w", err)
+	}
+	if err := changes.commit(); err != nil {
+		return "", err
+	}
+	runID, _ := agent.RunIDFromContext(ctx)
+	for _, resolved := range changes.order {
+		file := changes.files[resolved]
+		sum := ""
+		if file.present {
+			sum = contentSHA256([]byte(file.content))
+		}
+		e.reads.remember(runID, resolved, sum)
+	}
+	return fmt.Sprintf("apply_patch_ok files=%d\n%s", len(changes.order), strings.Join(report, "\n")), nil
+}
+
+// patchFile is the staged content of one file touched by a patch.
+type patchFile struct {
+	path     string
+	resolved string
+	// existed and original describe the file before the patch; present and
+	// content describe the staged result.
+	existed  bool
+	original []byte
+	mode     os.FileMode
+	present  bool
+	content  string
+}
+
+type patchChanges struct {
+	policy Policy
+	files  map[string]*patchFile
+	order  []string
+}
+
+func newPatchChanges(policy Policy) *patchChanges {
+	return &patchChanges{policy: policy, files: map[string]*patchFile{}}
+}
+
+// load stages path, reading the current contents on first use so several
+// hunks or edits against one file see each other's changes.
+func (c *patchChanges) load(path string) (*patchFile, error) {
+	resolved, err := c.policy.ResolveWritablePath(path)
+	if err != nil {
+		return nil, err
+	}
+	if file, ok := c.files[resolved]; ok {
+		return file, nil
+	}
+	file := &patchFile{path: path, resolved: resolved, mode: 0o644}
+	info, err := os.Stat(resolved)
+	switch {
+	case err == nil && info.IsDir():
+		return nil, fmt.Errorf("apply_patch %q: path is a directory", path)
+	case err == nil:
+		if info.Size() > c.policy.maxReadSize {
+			return nil, fmt.Errorf("apply_patch %q: file size %d exceeds limit %d", path, info.Size(), c.policy.maxReadSize)
+		}
+		raw, readErr := os.ReadFile(resolved)
+		if readErr != nil {
+			return nil, fmt.Errorf("apply_patch %q: read: %w", path, readErr)
+		}
+		file.existed = true
+		file.present = true
+		file.original = raw
+		file.content = string(raw)
+		file.mode = info.Mode().Perm()
+	case !os.IsNotExist(err):
+		return nil, fmt.Errorf("apply_patch %q: stat: %w", path, err)
+	}
+	c.files[resolved] = file
+	c.order = append(c.order, resolved)
+	return file, nil
+}
+
+// commit writes staged files. If a write fails, files already written are
+// restored to their original contents before the error is returned.
+func (c *patchChanges) commit() error {
+	var written []*patchFile
+	for _, resolved := range c.order {
+		file := c.files[resolved]
+		if err := file.write(); err != nil {
+			for _, done := range written {
+				_ = done.restore()
+			}
+			return err
+		}
+		written = append(written, file)
+	}
+	return nil
+}
+
+func (f *patchFile) write() error {
+	if !f.present {
+		if !f.existed {
+			return nil
+		}
+		if err := os.Remove(f.resolved); err != nil {
+			return fmt.Errorf("apply_patch %q: delete: %w", f.path, err)
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(f.resolved), 0o755); err != nil {
+		return fmt.Errorf("apply_patch %q: create parent directory: %w", f.path, err)
+	}
+	if err := writeFileAtomic(f.resolved, []byte(f.content), f.mode); err != nil {
+		return fmt.Errorf("apply_patch %q: write: %w", f.path, err)
+	}
+	return nil
+}
+
+func (f *patchFile) restore() error {
+	if !f.existed {
+		return os.Remove(f.resolved)
+	}
+	return writeFileAtomic(f.resolved, f.original, f.mode)
+}
+
+// writeFileAtomic replaces path through a temporary file in the same
+// directory so readers never observe a partially written file.
+func writeFileAtomic(path string, data []byte, mode os.FileMode) error {
+	temp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tempName := temp.Name()
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		os.Remove(tempName)
+		return err
+	}
+	if err := temp.Chmod(mode); err != nil {
+		temp.Close()
+		os.Remove(tempName)
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		os.Remove(tempName)
+		return err
+	}
+	if err := os.Rename(tempName, path); err != nil {
+		os.Remove(tempName)
+		return err
+	}
+	return nil
+}
+
+// applyEdits stages a list of {path, old, new, anchor} edits. old must occur
+// exactly once in the file, or after the unique anchor when one is given. An
+// empty old creates a file that does not exist yet.
+func (c *patchChanges) applyEdits(raw any) ([]string, bool, error) {
+	list, ok := raw.([]any)
+	if !ok || len(list) == 0 {
+		return nil, false, fmt.Errorf("%w: argument %q must be a non-empty array", ErrArgumentInvalid, "edits")
+	}
+	var report []string
+	failed := false
+	for i, item := range list {
+		fields, ok := item.(map[string]any)
+		if !ok {
+			return nil, false, fmt.Errorf("%w: edits[%d] must be an object", ErrArgumentInvalid, i)
+		}
+		path, err := stringArgument(fields, "path")
+		if err != nil {
+			return nil, false, fmt.Errorf("edits[%d]: %w", i, err)
+		}
+		oldValue, err := optionalString(fields, "old")
+		if err != nil {
+			return nil, false, fmt.Errorf("edits[%d]: %w", i, err)
+		}
+		newValue, err := optionalString(fields, "new")
+		if err != nil {
+			return nil, false, fmt.Errorf("edits[%d]: %w", i, err)
+		}
+		anchor, err := optionalString(fields, "anchor")
+		if err != nil {
+			return nil, false, fmt.Errorf("edits[%d]: %w", i, err)
+		}
+
+		file, err := c.load(path)
+		if err != nil {
+			return nil, false, err
+		}
+		line, reason := file.applyEdit(oldValue, newValue, anchor)
+		if reason != "" {
+			failed = true
+			report = append(report, fmt.Sprintf("edit index=%d path=%s status=rejected reason=%s", i, path, reason))
+			continue
+		}
+		report = append(report, fmt.Sprintf("edit index=%d path=%s status=applied line=%d", i, path, line))
+	}
+	return report, failed, nil
+}
+
+func (f *patchFile) applyEdit(oldValue, newValue, anchor string) (int, string) {
+	if oldValue == "" {
+		if f.present {
+			return 0, "old_required"
+		}
+		f.content = newValue
+		f.present = true
+		return 1, ""
+	}
+	if !f.present {
+		return 0, "file_not_found"
+	}
+
+	start := 0
+	if anchor != "" {
+		anchors := occurrences(f.content, anchor, 0)
+		switch {
+		case len(anchors) == 0:
+			return 0, "anchor_not_found"
+		case len(anchors) > 1:
+			return 0, fmt.Sprintf("ambiguous_anchor matches=%d lines=%s", len(anchors), lineList(f.content, anchors))
+		}
+		start = anchors[0] + len(anchor)
+	}
+	matches := occurrences(f.content, oldValue, start)
+	switch {
+	case len(matches) == 0:
+		return 0, "old_not_found"
+	case len(matches) > 1:
+		return 0, fmt.Sprintf("ambiguous_match matches=%d lines=%s", len(matches), lineList(f.content, matches))
+	}
+	at := matches[0]
+	f.content = f.content[:at] + newValue + f.content[at+len(oldValue):]
+	return lineAt(f.content, at), ""
+}
+
+// occurrences returns the byte offsets of every, possibly overlapping,
+// occurrence of needle in content at or after start.
+func occurrences(content, needle string, start int) []int {
+	var out []int
+	for offset := start; offset <= len(content); {
+		index := strings.Index(content[offset:], needle)
+		if index < 0 {
+			break
+		}
+		out = append(out, offset+index)
+		offset += index + 1
+	}
+	return out
+}
+
+func lineAt(content string, offset int) int {
+	return strings.Count(content[:offset], "\n") + 1
+}
+
+func lineList(content string, offsets []int) string {
+	lines := make([]string, len(offsets))
+	for i, offset := range offsets {
+		lines[i] = strconv.Itoa(lineAt(content, offset))
+	}
+	return strings.Join(lines, ",")
+}
+
+func optionalString(arguments map[string]any, key string) (string, error) {
+	value, ok := arguments[key]
+	if !ok || value == nil {
+		return "", nil
+	}
+	text, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("%w: argument %q must be a string", ErrArgumentInvalid, key)
+	}
+	return text, nil
+}
+
+// filePatch is one file section of a unified diff.
+type filePatch struct {
+	oldPath string
+	newPath string
+	hunks   []hunk
+}
+
+type hunk struct {
+	oldStart int
+	oldCount int
+	oldLines []string
+	newLines []string
+}
+
+// applyUnifiedDiff stages every file section of diff.
+func (c *patchChanges) applyUnifiedDiff(diff string) ([]string, bool, error) {
+	patches, err := parseUnifiedDiff(diff)
+	if err != nil {
+		return nil, false, err
+	}
+	var report []string
+	failed := false
+	for _, patch := range patches {
+		path := patch.newPath
+		if path == devNull {
+			path = patch.oldPath
+		}
+		file, err := c.load(path)
+		if err != nil {
+			return nil, false, err
+		}
+		switch {
+		case patch.oldPath == devNull && file.present:
+			failed = true
+			report = append(report, fmt.Sprintf("file path=%s status=rejected reason=already_exists", path))
+			continue
+		case patch.oldPath != devNull && !file.present:
+			failed = true
+			report = append(report, fmt.Sprintf("file path=%s status=rejected reason=file_not_found", path))
+			continue
+		}
+		fileReport, fileFailed := file.applyHunks(patch.hunks)
+		report = append(report, fileReport...)
+		if fileFailed {
+			failed = true
+			continue
+		}
+		file.present = true
+		if patch.newPath == devNull {
+			if file.content != "" {
+				failed = true
+				report = append(report, fmt.Sprintf("file path=%s status=rejected reason=delete_left_content", path))
+				continue
+			}
+			file.present = false
+		}
+	}
+	return report, failed, nil
+}
+
+// applyHunks locates each hunk by its old lines. A hunk applies at its stated
+// line (adjusted by earlier hunks) or at its only match elsewhere; a hunk
+// whose old lines match several other places is rejected as ambiguous.
+func (f *patchFile) applyHunks(hunks []hunk) ([]string, bool) {
+	lines := splitLines(f.content)
+	var report []string
+	failed := false
+	drift := 0
+	for i, h := range hunks {
+		expected := h.oldStart - 1 + drift
+		if h.oldCount == 0 {
+			expected = h.oldStart + drift
+		}
+		at, reason := locateHunk(lines, h.oldLines, expected)
+		if reason != "" {
+			failed = true
+			report = append(report, fmt.Sprintf("hunk path=%s index=%d status=rejected reason=%s", f.path, i, reason))
+			continue
+		}
+		updated := make([]string, 0, len(lines)-len(h.oldLines)+len(h.newLines))
+		updated = append(updated, lines[:at]...)
+		updated = append(updated, h.newLines...)
+		updated = append(updated, lines[at+len(h.oldLines):]...)
+		lines = updated
+		entry := fmt.Sprintf("hunk path=%s index=%d status=applied line=%d", f.path, i, at+1)
+		if offset := at - expected; offset != 0 {
+			entry += fmt.Sprintf(" offset=%+d", offset)
+		}
+		report = append(report, entry)
+		drift = at - (h.oldStart - 1) + len(h.newLines) - len(h.oldLines)
+		if h.oldCount == 0 {
+			drift = at - h.oldStart + len(h.newLines)
+		}
+	}
+	f.content = strings.Join(lines, "")
+	return report, failed
+}
+
+func locateHunk(lines, old []string, expected int) (int, string) {
+	if len(old) == 0 {
+		if expected < 0 || expected > len(lines) {
+			return 0, fmt.Sprintf("line_out_of_range line=%d", expected+1)
+		}
+		return expected, ""
+	}
+	var matches []int
+	for at := 0; at+len(old) <= len(lines); at++ {
+		if equalLines(lines[at:at+len(old)], old) {
+			if at == expected {
+				return at, ""
+			}
+			matches = append(matches, at)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return 0, "context_not_found"
+	case 1:
+		return matches[0], ""
+	default:
+		starts := make([]string, len(matches))
+		for i, at := range matches {
+			starts[i] = strconv.Itoa(at + 1)
+		}
+		return 0, fmt.Sprintf("ambiguous_match matches=%d lines=%s", len(matches), strings.Join(starts, ","))
+	}
+}
+
+func equalLines(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// splitLines splits content into lines that keep their "\n" terminator.
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func parseUnifiedDiff(diff string) ([]filePatch, error) {
+	lines := strings.Split(strings.ReplaceAll(diff, "\r\n", "\n"), "\n")
+	var patches []filePatch
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, "--- "):
+			if i+1 >= len(lines) || !strings.HasPrefix(lines[i+1], "+++ ") {
+				return nil, fmt.Errorf("%w: line=%d reason=missing_new_file_header", ErrPatchInvalid, i+1)
+			}
+			patches = append(patches, filePatch{
+				oldPath: diffPath(line[4:], "a/"),
+				newPath: diffPath(lines[i+1][4:], "b/"),
+			})
+			i++
+		case strings.HasPrefix(line, "@@"):
+			if len(patches) == 0 {
+				return nil, fmt.Errorf("%w: line=%d reason=hunk_without_file_header", ErrPatchInvalid, i+1)
+			}
+			parsed, next, err := parseHunk(lines, i)
+			if err != nil {
+				return nil, err
+			}
+			current := &patches[len(patches)-1]
+			current.hunks = append(current.hunks, parsed)
+			i = next - 1
+		}
+	}
+	if len(patches) == 0 {
+		return nil, fmt.Errorf("%w: reason=no_file_headers", ErrPatchInvalid)
+	}
+	for _, patch := range patches {
+		if patch.oldPath == devNull && patch.newPath == devNull {
+			return nil, fmt.Errorf("%w: reason=both_paths_dev_null", ErrPatchInvalid)
+		}
+		if len(patch.hunks) == 0 {
+			return nil, fmt.Errorf("%w: path=%s reason=no_hunks", ErrPatchInvalid, patch.newPath)
+		}
+	}
+	return patches, nil
+}
+
+// parseHunk reads the hunk starting at lines[start] and returns it with the
+// index of the first line after it.
+func parseHunk(lines []string, start int) (hunk, int, error) {
+	match := hunkHeaderPattern.FindStringSubmatch(lines[start])
+	if match == nil {
+		return hunk{}, 0, fmt.Errorf("%w: line=%d reason=malformed_hunk_header", ErrPatchInvalid, start+1)
+	}
+	h := hunk{oldStart: atoiDefault(match[1], 0), oldCount: atoiDefault(match[2], 1)}
+	newCount := atoiDefault(match[4], 1)
+
+	// A "\ No newline at end of file" marker strips the terminator from the
+	// line before it, on the side(s) that line belongs to.
+	var lastKind byte
+	trimLast := func() {
+		if (lastKind == ' ' || lastKind == '-') && len(h.oldLines) > 0 {
+			h.oldLines[len(h.oldLines)-1] = strings.TrimSuffix(h.oldLines[len(h.oldLines)-1], "\n")
+		}
+		if (lastKind == ' ' || lastKind == '+') && len(h.newLines) > 0 {
+			h.newLines[len(h.newLines)-1] = strings.TrimSuffix(h.newLines[len(h.newLines)-1], "\n")
+		}
+	}
+
+	oldRemaining, newRemaining := h.oldCount, newCount
+	i := start + 1
+	for ; i < len(lines) && (oldRemaining > 0 || newRemaining > 0); i++ {
+		line := lines[i]
+		if strings.HasPrefix(line, `\`) {
+			trimLast()
+			continue
+		}
+		// Some editors strip the single space of empty context lines.
+		kind, text := byte(' '), "\n"
+		if line != "" {
+			kind, text = line[0], line[1:]+"\n"
+		}
+		switch kind {
+		case ' ':
+			h.oldLines = append(h.oldLines, text)
+			h.newLines = append(h.newLines, text)
+			oldRemaining--
+			newRemaining--
+		case '-':
+			h.oldLines = append(h.oldLines, text)
+			oldRemaining--
+		case '+':
+			h.newLines = append(h.newLines, text)
+			newRemaining--
+		default:
+			return hunk{}, 0, fmt.Errorf("%w: line=%d reason=unexpected_hunk_line", ErrPatchInvalid, i+1)
+		}
+		lastKind = kind
+		if oldRemaining < 0 || newRemaining < 0 {
+			return hunk{}, 0, fmt.Errorf("%w: line=%d reason=hunk_length_mismatch", ErrPatchInvalid, i+1)
+		}
+	}
+	if oldRemaining > 0 || newRemaining > 0 {
+		return hunk{}, 0, fmt.Errorf("%w: line=%d reason=truncated_hunk", ErrPatchInvalid, start+1)
+	}
+	for i < len(lines) && strings.HasPrefix(lines[i], `\`) {
+		trimLast()
+		i++
+	}
+	return h, i, nil
+}
+
+func diffPath(raw, prefix string) string {
+	path, _, _ := strings.Cut(raw, "\t")
+	path = strings.TrimSpace(path)
+	if path == devNull {
+		return path
+	}
+	return strings.TrimPrefix(path, prefix)
+}
+
+func atoiDefault(raw string, fallback int) int {
+	if raw == "" {
+		return fallback
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return fallback
+	}
+	return value
+}
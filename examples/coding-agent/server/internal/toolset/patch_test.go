@@ -0,0 +1,180 @@
+package toolset_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gurpartap/agentframe/agent"
+	"github.com/Gurpartap/agentframe/examples/coding-agent/server/internal/toolset"
+)
+
+func newPatchExecutor(t *testing.T, files map[string]string) (*toolset.Executor, string) {
+	t.Helper()
+
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("seed %s: %v", name, err)
+		}
+	}
+	policy, err := toolset.NewPolicy(root, time.Second)
+	if err != nil {
+		t.Fatalf("new policy: %v", err)
+	}
+	return toolset.NewExecutor(policy), root
+}
+
+func applyPatch(executor *toolset.Executor, arguments map[string]any) (agent.ToolResult, error) {
+	return executor.Execute(context.Background(), agent.ToolCall{
+		ID:        "patch-1",
+		Name:      toolset.ToolApplyPatch,
+		Arguments: arguments,
+	})
+}
+
+func readFile(t *testing.T, root, name string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(root, name))
+	if err != nil {
+		t.Fatalf("read %s: %v", name, err)
+	}
+	return string(data)
+}
+
+func TestApplyPatchUnifiedDiffAcrossFiles(t *testing.T) {
+	t.Parallel()
+
+	executor, root := newPatchExecutor(t, map[string]string{
+		"main.go":  "package main\n\nfunc main() {\n\tprintln(\"old\")\n}\n",
+		"stale.go": "package main\n",
+	})
+	diff := strings.Join([]string{
+		"diff --git a/main.go b/main.go",
+		"--- a/main.go",
+		"+++ b/main.go",
+		"@@ -3,3 +3,4 @@",
+		" func main() {",
+		"-\tprintln(\"old\")",
+		"+\tprintln(\"new\")",
+		"+\tprintln(\"more\")",
+		" }",
+		"--- /dev/null",
+		"+++ b/pkg/util.go",
+		"@@ -0,0 +1,2 @@",
+		"+package pkg",
+		"+// util",
+		"\\ No newline at end of file",
+		"--- a/stale.go",
+		"+++ /dev/null",
+		"@@ -1 +0,0 @@",
+		"-package main",
+		"",
+	}, "\n")
+
+	result, err := applyPatch(executor, map[string]any{"patch": diff})
+	if err != nil {
+		t.Fatalf("apply_patch: %v", err)
+	}
+	if !strings.HasPrefix(result.Content, "apply_patch_ok files=3") ||
+		!strings.Contains(result.Content, "hunk path=main.go index=0 status=applied line=3") {
+		t.Fatalf("report mismatch: %q", result.Content)
+	}
+	if got, want := readFile(t, root, "main.go"), "package main\n\nfunc main() {\n\tprintln(\"new\")\n\tprintln(\"more\")\n}\n"; got != want {
+		t.Fatalf("main.go mismatch: got=%q want=%q", got, want)
+	}
+	if got := readFile(t, root, "pkg/util.go"); got != "package pkg\n// util" {
+		t.Fatalf("created file mismatch: got=%q", got)
+	}
+	if _, err := os.Stat(filepath.Join(root, "stale.go")); !os.IsNotExist(err) {
+		t.Fatalf("deleted file must be removed, stat err=%v", err)
+	}
+}
+
+func TestApplyPatchRejectsAmbiguousHunkAtomically(t *testing.T) {
+	t.Parallel()
+
+	original := "a\nx\nb\na\nx\nb\n"
+	executor, root := newPatchExecutor(t, map[string]string{
+		"dup.txt":   original,
+		"other.txt": "keep\n",
+	})
+	diff := strings.Join([]string{
+		"--- a/other.txt",
+		"+++ b/other.txt",
+		"@@ -1 +1 @@",
+		"-keep",
+		"+changed",
+		"--- a/dup.txt",
+		"+++ b/dup.txt",
+		"@@ -10,3 +10,3 @@",
+		" a",
+		"-x",
+		"+y",
+		" b",
+	}, "\n")
+
+	_, err := applyPatch(executor, map[string]any{"patch": diff})
+	if !errors.Is(err, toolset.ErrPatchRejected) {
+		t.Fatalf("expected ErrPatchRejected, got %v", err)
+	}
+	for _, want := range []string{
+		"hunk path=other.txt index=0 status=applied",
+		"hunk path=dup.txt index=0 status=rejected reason=ambiguous_match matches=2 lines=1,4",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("report missing %q: %v", want, err)
+		}
+	}
+	if readFile(t, root, "dup.txt") != original || readFile(t, root, "other.txt") != "keep\n" {
+		t.Fatalf("rejected patch must not change any file")
+	}
+}
+
+func TestApplyPatchAnchoredEdits(t *testing.T) {
+	t.Parallel()
+
+	executor, root := newPatchExecutor(t, map[string]string{
+		"config.yaml": "dev:\n  port: 80\nprod:\n  port: 80\n",
+	})
+
+	_, err := applyPatch(executor, map[string]any{"edits": []any{
+		map[string]any{"path": "config.yaml", "old": "port: 80", "new": "port: 8080"},
+	}})
+	if !errors.Is(err, toolset.ErrPatchRejected) || !strings.Contains(err.Error(), "reason=ambiguous_match matches=2 lines=2,4") {
+		t.Fatalf("expected ambiguous edit rejection, got %v", err)
+	}
+
+	result, err := applyPatch(executor, map[string]any{"edits": []any{
+		map[string]any{"path": "config.yaml", "anchor": "prod:", "old": "port: 80", "new": "port: 443"},
+		map[string]any{"path": "notes/README.md", "old": "", "new": "# notes\n"},
+	}})
+	if err != nil {
+		t.Fatalf("apply edits: %v", err)
+	}
+	if !strings.Contains(result.Content, "edit index=0 path=config.yaml status=applied line=4") {
+		t.Fatalf("edit report mismatch: %q", result.Content)
+	}
+	if got, want := readFile(t, root, "config.yaml"), "dev:\n  port: 80\nprod:\n  port: 443\n"; got != want {
+		t.Fatalf("config mismatch: got=%q want=%q", got, want)
+	}
+	if got := readFile(t, root, "notes/README.md"); got != "# notes\n" {
+		t.Fatalf("created file mismatch: got=%q", got)
+	}
+
+	_, err = applyPatch(executor, map[string]any{"edits": []any{
+		map[string]any{"path": "../escape.txt", "old": "", "new": "x"},
+	}})
+	if !errors.Is(err, toolset.ErrPathOutsideWorkspace) {
+		t.Fatalf("expected ErrPathOutsideWorkspace, got %v", err)
+	}
+}
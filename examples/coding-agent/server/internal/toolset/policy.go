@@ -18,6 +18,8 @@ const (
 	ToolEdit  = "edit"
 	ToolBash  = "bash"
 
+	ToolApplyPatch = "apply_patch"
+
 	DefaultBashTimeout = 3 * time.Second
 	DefaultMaxReadSize = 1 << 20
 )
@@ -25,6 +27,7 @@ const (
 var (
 	ErrPathRequired          = errors.New("tool path is required")
 	ErrPathOutsideWorkspace  = errors.New("tool path escapes workspace root")
+	ErrPathProtected         = errors.New("tool path is protected")
 	ErrArgumentInvalid       = errors.New("tool arguments are invalid")
 	ErrBashCommandEmpty      = errors.New("bash command is empty")
 	ErrBashCommandDenied     = errors.New("bash command violates policy")
@@ -34,12 +37,15 @@ var (
 
 var toolDefinitions = []agent.ToolDefinition{
 	{
-		Name:        ToolRead,
-		Description: "Read a UTF-8 text file within the workspace root.",
+		Name: ToolRead,
+		Description: "Read a UTF-8 text file within the workspace root as numbered lines. " +
+			"offset (1-based) and limit select a line range; follow next_offset in the header to read on.",
 		InputSchema: map[string]any{
 			"type": "object",
 			"properties": map[string]any{
-				"path": map[string]any{"type": "string"},
+				"path":   map[string]any{"type": "string"},
+				"offset": map[string]any{"type": "integer", "minimum": 1},
+				"limit":  map[string]any{"type": "integer", "minimum": 1, "maximum": MaxReadLineLimit},
 			},
 			"required": []any{"path"},
 		},
@@ -69,6 +75,31 @@ var toolDefinitions = []agent.ToolDefinition{
 			"required": []any{"path", "old", "new"},
 		},
 	},
+	{
+		Name: ToolApplyPatch,
+		Description: "Atomically apply a unified diff or a list of anchored edits across files within the workspace root. " +
+			"Provide exactly one of patch or edits. Each edit's old text must match exactly once, after anchor when given; " +
+			"an empty old creates a new file. Nothing is written unless every hunk or edit applies.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"patch": map[string]any{"type": "string", "description": "Unified diff with ---/+++ file headers and @@ hunks."},
+				"edits": map[string]any{
+					"type": "array",
+					"items": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"path":   map[string]any{"type": "string"},
+							"old":    map[string]any{"type": "string"},
+							"new":    map[string]any{"type": "string"},
+							"anchor": map[string]any{"type": "string"},
+						},
+						"required": []any{"path", "old", "new"},
+					},
+				},
+			},
+		},
+	},
 	{
 		Name:        ToolBash,
 		Description: "Run a bounded command in the workspace root under command policy restrictions.",
@@ -82,27 +113,11 @@ var toolDefinitions = []agent.ToolDefinition{
 	},
 }
 
-var allowedBashCommands = map[string]struct{}{
-	"cat":    {},
-	"echo":   {},
-	"find":   {},
-	"grep":   {},
-	"head":   {},
-	"ls":     {},
-	"pwd":    {},
-	"rg":     {},
-	"sed":    {},
-	"stat":   {},
-	"tail":   {},
-	"wc":     {},
-	"which":  {},
-	"printf": {},
-}
-
 type Policy struct {
 	workspaceRoot string
 	bashTimeout   time.Duration
 	maxReadSize   int64
+	bash          *BashPolicy
 }
 
 func NewPolicy(workspaceRoot string, bashTimeout time.Duration) (Policy, error) {
@@ -139,9 +154,19 @@ func NewPolicy(workspaceRoot string, bashTimeout time.Duration) (Policy, error)
 		workspaceRoot: rootResolved,
 		bashTimeout:   bashTimeout,
 		maxReadSize:   DefaultMaxReadSize,
+		bash:          DefaultBashPolicy(),
 	}, nil
 }
 
+// WithBashPolicy returns a copy of p that validates bash commands against
+// bashPolicy instead of DefaultBashPolicy.
+func (p Policy) WithBashPolicy(bashPolicy *BashPolicy) Policy {
+	if bashPolicy != nil {
+		p.bash = bashPolicy
+	}
+	return p
+}
+
 func (p Policy) WorkspaceRoot() string {
 	return p.workspaceRoot
 }
@@ -180,40 +205,86 @@ func (p Policy) ResolvePath(raw string) (string, error) {
 	return candidateAbs, nil
 }
 
-func (p Policy) ValidateBashCommand(command string) error {
-	trimmed := strings.TrimSpace(command)
-	if trimmed == "" {
-		return ErrBashCommandEmpty
+// ResolveWritablePath is ResolvePath for tools that modify files. It also
+// refuses paths inside a .git directory (or a .git file of a worktree): git
+// config and attributes written there would let git run arbitrary commands
+// as filters when the git tools run.
+func (p Policy) ResolveWritablePath(raw string) (string, error) {
+	resolved, err := p.ResolvePath(raw)
+	if err != nil {
+		return "", err
 	}
+	target, err := resolveExistingPrefix(resolved)
+	if err != nil {
+		return "", fmt.Errorf("resolve path %q: %w", raw, err)
+	}
+	if insideGitDir(p.workspaceRoot, resolved) || insideGitDir(p.workspaceRoot, target) {
+		return "", fmt.Errorf("%w: %q is inside .git", ErrPathProtected, strings.TrimSpace(raw))
+	}
+	return resolved, nil
+}
 
-	forbiddenTokens := []string{"\n", "\r", ";", "&&", "||", "|", ">", "<", "`", "$", "(", ")"}
-	for _, token := range forbiddenTokens {
-		if strings.Contains(trimmed, token) {
-			return fmt.Errorf("%w: forbidden token %q", ErrBashCommandDenied, token)
+// insideGitDir reports whether path is, or is below, a .git entry of root.
+func insideGitDir(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return true
+	}
+	for _, part := range strings.Split(filepath.ToSlash(rel), "/") {
+		if strings.EqualFold(part, ".git") {
+			return true
 		}
 	}
+	return false
+}
 
-	parts := strings.Fields(trimmed)
-	if len(parts) == 0 {
+// ValidateBashCommand checks command against the bash policy for a host
+// (unsandboxed) backend.
+func (p Policy) ValidateBashCommand(command string) error {
+	return p.validateBashCommand(command, false)
+}
+
+func (p Policy) validateBashCommand(command string, sandboxed bool) error {
+	if strings.TrimSpace(command) == "" {
 		return ErrBashCommandEmpty
 	}
-	verb := parts[0]
-	if _, allowed := allowedBashCommands[verb]; !allowed {
-		return fmt.Errorf("%w: command %q is not allowed", ErrBashCommandDenied, verb)
+	bashPolicy := p.bash
+	if bashPolicy == nil {
+		bashPolicy = DefaultBashPolicy()
 	}
-	return nil
+	return bashPolicy.validate(command, sandboxed, p.ResolvePath)
 }
 
 func Definitions() []agent.ToolDefinition {
-	return agent.CloneToolDefinitions(toolDefinitions)
+	definitions := agent.CloneToolDefinitions(toolDefinitions)
+	definitions = append(definitions, agent.CloneToolDefinitions(exploreToolDefinitions)...)
+	definitions = append(definitions, agent.CloneToolDefinitions(gitToolDefinitions)...)
+	return append(definitions, agent.CloneToolDefinitions(processToolDefinitions)...)
 }
 
 type Executor struct {
-	policy Policy
+	policy    Policy
+	backend   BashBackend
+	snapshots *Snapshots
+	processes *Processes
+	reads     *fileReads
+	// staleFileMode applies when write or edit targets a file that changed
+	// since the run last saw it.
+	staleFileMode StaleFileMode
 }
 
-func NewExecutor(policy Policy) *Executor {
-	return &Executor{policy: policy}
+func NewExecutor(policy Policy, options ...ExecutorOption) *Executor {
+	executor := &Executor{
+		policy:        policy,
+		backend:       HostBackend{},
+		processes:     NewProcesses(ProcessConfig{}),
+		reads:         newFileReads(),
+		staleFileMode: StaleFileApprove,
+	}
+	for _, option := range options {
+		option(executor)
+	}
+	return executor
 }
 
 func (e *Executor) Execute(ctx context.Context, call agent.ToolCall) (agent.ToolResult, error) {
@@ -231,13 +302,39 @@ func (e *Executor) Execute(ctx context.Context, call agent.ToolCall) (agent.Tool
 
 	switch call.Name {
 	case ToolRead:
-		content, err = e.executeRead(call.Arguments)
+		content, err = e.executeRead(ctx, call.Arguments)
 	case ToolWrite:
-		content, err = e.executeWrite(call.Arguments)
+		content, err = e.executeWrite(ctx, call)
 	case ToolEdit:
-		content, err = e.executeEdit(call.Arguments)
+		content, err = e.executeEdit(ctx, call)
+	case ToolApplyPatch:
+		content, err = e.executeApplyPatch(ctx, call)
 	case ToolBash:
 		content, err = e.executeBash(ctx, call)
+	case ToolListDir:
+		content, err = e.executeListDir(ctx, call.Arguments)
+	case ToolGlob:
+		content, err = e.executeGlob(ctx, call.Arguments)
+	case ToolSearch:
+		content, err = e.executeSearch(ctx, call.Arguments)
+	case ToolGitStatus:
+		content, err = e.executeGitStatus(ctx)
+	case ToolGitDiff:
+		content, err = e.executeGitDiff(ctx, call.Arguments)
+	case ToolGitLog:
+		content, err = e.executeGitLog(ctx, call.Arguments)
+	case ToolGitBranch:
+		content, err = e.executeGitBranch(ctx, call.Arguments)
+	case ToolGitCommit:
+		content, err = e.executeGitCommit(ctx, call)
+	case ToolProcStart:
+		content, err = e.executeProcStart(ctx, call)
+	case ToolProcOutput:
+		content, err = e.executeProcOutput(ctx, call.Arguments)
+	case ToolProcStop:
+		content, err = e.executeProcStop(ctx, call.Arguments)
+	case ToolProcList:
+		content, err = e.executeProcList(ctx)
 	default:
 		return agent.ToolResult{}, fmt.Errorf("toolset: unsupported tool %q", call.Name)
 	}
@@ -252,6 +349,15 @@ func (e *Executor) Execute(ctx context.Context, call agent.ToolCall) (agent.Tool
 	}, nil
 }
 
+// snapshot records the prior contents of resolvedPaths when snapshots are
+// enabled.
+func (e *Executor) snapshot(ctx context.Context, call agent.ToolCall, resolvedPaths ...string) error {
+	if e.snapshots == nil {
+		return nil
+	}
+	return e.snapshots.Record(ctx, call, resolvedPaths...)
+}
+
 func stringArgument(arguments map[string]any, key string) (string, error) {
 	if arguments == nil {
 		return "", fmt.Errorf("%w: missing argument %q", ErrArgumentInvalid, key)
@@ -0,0 +1,507 @@
+package toolset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Gurpartap/agentframe/agent"
+)
+
+const (
+	ToolProcStart  = "proc_start"
+	ToolProcOutput = "proc_output"
+	ToolProcStop   = "proc_stop"
+	ToolProcList   = "proc_list"
+
+	DefaultMaxProcessesPerRun    = 4
+	DefaultMaxProcessOutputBytes = 1 << 20
+
+	defaultProcOutputBytes = 16 << 10
+	maxProcOutputBytes     = 64 << 10
+	maxProcOutputWaitMS    = 10_000
+
+	// processStopGrace is how long a stopped process group may take to exit
+	// after SIGTERM before it is killed.
+	processStopGrace = 2 * time.Second
+)
+
+var (
+	ErrProcessLimit       = errors.New("process limit reached")
+	ErrProcessNotFound    = errors.New("process not found")
+	ErrProcessRunRequired = errors.New("process tools require a run id")
+	ErrProcessUnsupported = errors.New("bash backend cannot start background processes")
+	ErrProcessesClosed    = errors.New("process manager is closed")
+)
+
+var processToolDefinitions = []agent.ToolDefinition{
+	{
+		Name: ToolProcStart,
+		Description: "Start a long-running command (dev server, watcher) in the background in the workspace root under the bash command policy. " +
+			"Returns a process id; the process is stopped when the run ends. " +
+			"With a sandboxed bash backend every command gets its own network namespace, so later bash commands cannot connect to its ports.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"command": map[string]any{"type": "string"},
+			},
+			"required": []any{"command"},
+		},
+	},
+	{
+		Name: ToolProcOutput,
+		Description: "Read combined stdout and stderr of a background process from cursor (default 0). " +
+			"Pass the returned next_cursor to continue; wait_ms waits for new output or exit.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"id":        map[string]any{"type": "string"},
+				"cursor":    map[string]any{"type": "integer", "minimum": 0},
+				"max_bytes": map[string]any{"type": "integer", "minimum": 1, "maximum": maxProcOutputBytes},
+				"wait_ms":   map[string]any{"type": "integer", "minimum": 1, "maximum": maxProcOutputWaitMS},
+			},
+			"required": []any{"id"},
+		},
+	},
+	{
+		Name:        ToolProcStop,
+		Description: "Stop a background process and its children.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"id": map[string]any{"type": "string"},
+			},
+			"required": []any{"id"},
+		},
+	},
+	{
+		Name:        ToolProcList,
+		Description: "List the background processes of the current run.",
+		InputSchema: map[string]any{
+			"type":       "object",
+			"properties": map[string]any{},
+		},
+	},
+}
+
+// ProcessConfig bounds background processes. Zero values use the package
+// defaults.
+type ProcessConfig struct {
+	// MaxPerRun caps the processes running at once for one run.
+	MaxPerRun int
+	// MaxOutputBytes caps the output retained per process; older output is
+	// dropped first.
+	MaxOutputBytes int
+}
+
+// Processes tracks background processes started by proc_start, keyed by run.
+// It is also an agent.EventSink: a run_completed, run_failed or run_cancelled
+// event stops every process of that run.
+type Processes struct {
+	config ProcessConfig
+
+	mu     sync.Mutex
+	runs   map[agent.RunID]*runProcesses
+	closed bool
+}
+
+type runProcesses struct {
+	next      int
+	processes []*process
+}
+
+// NewProcesses returns an empty process manager.
+func NewProcesses(cfg ProcessConfig) *Processes {
+	if cfg.MaxPerRun <= 0 {
+		cfg.MaxPerRun = DefaultMaxProcessesPerRun
+	}
+	if cfg.MaxOutputBytes <= 0 {
+		cfg.MaxOutputBytes = DefaultMaxProcessOutputBytes
+	}
+	return &Processes{config: cfg, runs: map[agent.RunID]*runProcesses{}}
+}
+
+// WithProcesses shares a process manager with the executor, so the caller can
+// stop processes when runs end.
+func WithProcesses(processes *Processes) ExecutorOption {
+	return func(e *Executor) {
+		if processes != nil {
+			e.processes = processes
+		}
+	}
+}
+
+// Publish stops the processes of runs that reached a terminal event.
+func (p *Processes) Publish(ctx context.Context, event agent.Event) error {
+	if ctx == nil {
+		return agent.ErrContextNil
+	}
+	if runEnded(event) {
+		go p.StopRun(event.RunID)
+	}
+	return nil
+}
+
+// StopRun stops and forgets every process of runID, waiting for them to exit.
+func (p *Processes) StopRun(runID agent.RunID) {
+	p.mu.Lock()
+	run := p.runs[runID]
+	delete(p.runs, runID)
+	p.mu.Unlock()
+
+	if run != nil {
+		stopProcesses(run.processes)
+	}
+}
+
+// Close stops every process and rejects further starts.
+func (p *Processes) Close() {
+	p.mu.Lock()
+	p.closed = true
+	var all []*process
+	for _, run := range p.runs {
+		all = append(all, run.processes...)
+	}
+	clear(p.runs)
+	p.mu.Unlock()
+
+	stopProcesses(all)
+}
+
+func (p *Processes) start(runID agent.RunID, command string, cmd *exec.Cmd) (*process, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil, ErrProcessesClosed
+	}
+	run := p.runs[runID]
+	if run == nil {
+		run = &runProcesses{}
+		p.runs[runID] = run
+	}
+	running := 0
+	for _, proc := range run.processes {
+		if proc.snapshot().status == processRunning {
+			running++
+		}
+	}
+	if running >= p.config.MaxPerRun {
+		return nil, fmt.Errorf("%w: run_id=%q running=%d max=%d", ErrProcessLimit, runID, running, p.config.MaxPerRun)
+	}
+
+	proc := &process{
+		id:      fmt.Sprintf("proc-%d", run.next+1),
+		command: command,
+		cmd:     cmd,
+		limit:   p.config.MaxOutputBytes,
+		changed: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	cmd.Stdout = proc
+	cmd.Stderr = proc
+	cmd.WaitDelay = bashWaitDelay
+	configureProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start process %q: %w", command, err)
+	}
+	run.next++
+	run.processes = append(run.processes, proc)
+	go proc.wait()
+	return proc, nil
+}
+
+func (p *Processes) lookup(runID agent.RunID, id string) (*process, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if run := p.runs[runID]; run != nil {
+		index := slices.IndexFunc(run.processes, func(proc *process) bool { return proc.id == id })
+		if index >= 0 {
+			return run.processes[index], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: id=%q", ErrProcessNotFound, id)
+}
+
+func (p *Processes) list(runID agent.RunID) []*process {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if run := p.runs[runID]; run != nil {
+		return slices.Clone(run.processes)
+	}
+	return nil
+}
+
+func stopProcesses(processes []*process) {
+	var wg sync.WaitGroup
+	for _, proc := range processes {
+		wg.Go(proc.stop)
+	}
+	wg.Wait()
+}
+
+type processStatus string
+
+const (
+	processRunning processStatus = "running"
+	processExited  processStatus = "exited"
+	processStopped processStatus = "stopped"
+)
+
+// process is one background command. Its combined output is addressed by
+// absolute byte offsets so readers can resume from a cursor after older
+// output has been dropped.
+type process struct {
+	id      string
+	command string
+	cmd     *exec.Cmd
+	limit   int
+	done    chan struct{}
+
+	mu sync.Mutex
+	// buf holds output bytes [written-len(buf), written).
+	buf      []byte
+	written  int64
+	stopping bool
+	exited   bool
+	exitCode int
+	// changed is closed and replaced whenever output arrives or the process
+	// exits.
+	changed chan struct{}
+}
+
+type processSnapshot struct {
+	status   processStatus
+	exitCode int
+	written  int64
+}
+
+func (p *process) Write(data []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.buf = append(p.buf, data...)
+	p.written += int64(len(data))
+	// Compact lazily so steady output does not copy the buffer per write.
+	if len(p.buf) > 2*p.limit {
+		p.buf = append([]byte(nil), p.buf[len(p.buf)-p.limit:]...)
+	}
+	p.notifyLocked()
+	return len(data), nil
+}
+
+func (p *process) wait() {
+	_ = p.cmd.Wait()
+
+	p.mu.Lock()
+	p.exited = true
+	p.exitCode = p.cmd.ProcessState.ExitCode()
+	p.notifyLocked()
+	p.mu.Unlock()
+	close(p.done)
+}
+
+func (p *process) notifyLocked() {
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
+
+func (p *process) snapshot() processSnapshot {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.snapshotLocked()
+}
+
+func (p *process) snapshotLocked() processSnapshot {
+	snapshot := processSnapshot{status: processRunning, exitCode: p.exitCode, written: p.written}
+	switch {
+	case p.exited && p.stopping:
+		snapshot.status = processStopped
+	case p.exited:
+		snapshot.status = processExited
+	}
+	return snapshot
+}
+
+// read returns up to maxBytes of output starting at cursor, the cursor to
+// continue from and how many bytes before it were already dropped.
+func (p *process) read(cursor int64, maxBytes int) ([]byte, int64, int64, processSnapshot) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	first := p.written - int64(len(p.buf))
+	var dropped int64
+	if cursor < first {
+		dropped = first - cursor
+		cursor = first
+	}
+	if cursor > p.written {
+		cursor = p.written
+	}
+	chunk := p.buf[cursor-first:]
+	if len(chunk) > maxBytes {
+		chunk = chunk[:maxBytes]
+	}
+	return slices.Clone(chunk), cursor + int64(len(chunk)), dropped, p.snapshotLocked()
+}
+
+// waitOutput blocks until output past cursor exists, the process exits, ctx
+// ends or timeout elapses.
+func (p *process) waitOutput(ctx context.Context, cursor int64, timeout time.Duration) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		p.mu.Lock()
+		ready := p.written > cursor || p.exited
+		changed := p.changed
+		p.mu.Unlock()
+		if ready {
+			return
+		}
+		select {
+		case <-changed:
+		case <-timer.C:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// stop terminates the process group, escalating to SIGKILL after
+// processStopGrace, and waits for the process to exit.
+func (p *process) stop() {
+	p.mu.Lock()
+	if p.exited {
+		p.mu.Unlock()
+		return
+	}
+	p.stopping = true
+	p.mu.Unlock()
+
+	terminateProcessGroup(p.cmd)
+	select {
+	case <-p.done:
+		return
+	case <-time.After(processStopGrace):
+	}
+	killProcessGroup(p.cmd)
+	<-p.done
+}
+
+func (e *Executor) executeProcStart(ctx context.Context, call agent.ToolCall) (string, error) {
+	command, err := stringArgument(call.Arguments, "command")
+	if err != nil {
+		return "", err
+	}
+	runID, ok := agent.RunIDFromContext(ctx)
+	if !ok {
+		return "", ErrProcessRunRequired
+	}
+	backend, ok := e.backend.(BackgroundBackend)
+	if !ok {
+		return "", ErrProcessUnsupported
+	}
+	if err := e.authorizeBashCommand(ctx, call, command, "req-proc-policy-"); err != nil {
+		return "", err
+	}
+
+	proc, err := e.processes.start(runID, command, backend.Command(BashRequest{
+		Command:       command,
+		WorkspaceRoot: e.policy.WorkspaceRoot(),
+	}))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("proc_start_ok id=%s pid=%d command=%q", proc.id, proc.cmd.Process.Pid, command), nil
+}
+
+func (e *Executor) executeProcOutput(ctx context.Context, arguments map[string]any) (string, error) {
+	proc, err := e.processArgument(ctx, arguments)
+	if err != nil {
+		return "", err
+	}
+	var cursor int64
+	if raw, ok := arguments["cursor"]; ok {
+		value, isNumber := raw.(float64)
+		if !isNumber || value != float64(int64(value)) || value < 0 {
+			return "", fmt.Errorf("%w: argument %q must be a non-negative integer", ErrArgumentInvalid, "cursor")
+		}
+		cursor = int64(value)
+	}
+	maxBytes, err := optionalIntArgument(arguments, "max_bytes", defaultProcOutputBytes, maxProcOutputBytes)
+	if err != nil {
+		return "", err
+	}
+	waitMS, err := optionalIntArgument(arguments, "wait_ms", 0, maxProcOutputWaitMS)
+	if err != nil {
+		return "", err
+	}
+	if waitMS > 0 {
+		proc.waitOutput(ctx, cursor, time.Duration(waitMS)*time.Millisecond)
+	}
+
+	output, next, dropped, snapshot := proc.read(cursor, maxBytes)
+	header := fmt.Sprintf("proc_output_ok id=%s status=%s next_cursor=%d dropped=%d", proc.id, snapshot.status, next, dropped)
+	if snapshot.status != processRunning {
+		header += fmt.Sprintf(" exit_code=%d", snapshot.exitCode)
+	}
+	if next < snapshot.written {
+		header += " more=true"
+	}
+	if len(output) == 0 {
+		return header, nil
+	}
+	return header + "\n" + string(output), nil
+}
+
+func (e *Executor) executeProcStop(ctx context.Context, arguments map[string]any) (string, error) {
+	proc, err := e.processArgument(ctx, arguments)
+	if err != nil {
+		return "", err
+	}
+	proc.stop()
+	snapshot := proc.snapshot()
+	return fmt.Sprintf("proc_stop_ok id=%s status=%s exit_code=%d", proc.id, snapshot.status, snapshot.exitCode), nil
+}
+
+func (e *Executor) executeProcList(ctx context.Context) (string, error) {
+	runID, ok := agent.RunIDFromContext(ctx)
+	if !ok {
+		return "", ErrProcessRunRequired
+	}
+	processes := e.processes.list(runID)
+	lines := make([]string, 0, len(processes))
+	for _, proc := range processes {
+		snapshot := proc.snapshot()
+		line := fmt.Sprintf("%s status=%s pid=%d output_bytes=%d", proc.id, snapshot.status, proc.cmd.Process.Pid, snapshot.written)
+		if snapshot.status != processRunning {
+			line += fmt.Sprintf(" exit_code=%d", snapshot.exitCode)
+		}
+		lines = append(lines, line+fmt.Sprintf(" command=%q", proc.command))
+	}
+	header := fmt.Sprintf("proc_list_ok processes=%d", len(processes))
+	if len(lines) == 0 {
+		return header, nil
+	}
+	return header + "\n" + strings.Join(lines, "\n"), nil
+}
+
+func (e *Executor) processArgument(ctx context.Context, arguments map[string]any) (*process, error) {
+	id, err := stringArgument(arguments, "id")
+	if err != nil {
+		return nil, err
+	}
+	runID, ok := agent.RunIDFromContext(ctx)
+	if !ok {
+		return nil, ErrProcessRunRequired
+	}
+	return e.processes.lookup(runID, id)
+}
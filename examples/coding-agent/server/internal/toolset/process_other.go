@@ -0,0 +1,16 @@
+//go:build !unix
+
+package toolset
+
+import "os/exec"
+
+// Process groups are unix-only; elsewhere only the direct child is stopped.
+func configureProcessGroup(*exec.Cmd) {}
+
+func terminateProcessGroup(cmd *exec.Cmd) {
+	_ = cmd.Process.Kill()
+}
+
+func killProcessGroup(cmd *exec.Cmd) {
+	_ = cmd.Process.Kill()
+}
@@ -0,0 +1,158 @@
+package toolset_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/Gurpartap/agentframe/agent"
+	"github.com/Gurpartap/agentframe/examples/coding-agent/server/internal/toolset"
+)
+
+func TestExecutorProcessOutputCursor(t *testing.T) {
+	t.Parallel()
+
+	executor := newProcessExecutor(t, toolset.ProcessConfig{})
+	ctx := agent.WithRunID(context.Background(), "run-proc-output")
+
+	started := executeProcTool(t, executor, ctx, toolset.ToolProcStart, map[string]any{"command": "printf 'ready\\ndone\\n'"})
+	if !strings.HasPrefix(started, "proc_start_ok id=proc-1 pid=") {
+		t.Fatalf("start content mismatch: %q", started)
+	}
+
+	first := executeProcTool(t, executor, ctx, toolset.ToolProcOutput, map[string]any{"id": "proc-1", "max_bytes": float64(6), "wait_ms": float64(10_000)})
+	waitProcExit(t, executor, ctx, "proc-1")
+	if want := "\nready\n"; !strings.HasSuffix(first, want) || !strings.Contains(first, "next_cursor=6") {
+		t.Fatalf("first output mismatch: got=%q want suffix %q", first, want)
+	}
+	rest := executeProcTool(t, executor, ctx, toolset.ToolProcOutput, map[string]any{"id": "proc-1", "cursor": float64(6)})
+	if want := "proc_output_ok id=proc-1 status=exited next_cursor=11 dropped=0 exit_code=0\ndone\n"; rest != want {
+		t.Fatalf("rest output mismatch: got=%q want=%q", rest, want)
+	}
+
+	other := agent.WithRunID(context.Background(), "run-proc-other")
+	_, err := executor.Execute(other, agent.ToolCall{ID: "out-other", Name: toolset.ToolProcOutput, Arguments: map[string]any{"id": "proc-1"}})
+	if !errors.Is(err, toolset.ErrProcessNotFound) {
+		t.Fatalf("expected ErrProcessNotFound for another run, got %v", err)
+	}
+	_, err = executor.Execute(context.Background(), agent.ToolCall{ID: "list-none", Name: toolset.ToolProcList})
+	if !errors.Is(err, toolset.ErrProcessRunRequired) {
+		t.Fatalf("expected ErrProcessRunRequired, got %v", err)
+	}
+}
+
+func TestExecutorProcessLimitAndRunEnd(t *testing.T) {
+	t.Parallel()
+
+	processes := toolset.NewProcesses(toolset.ProcessConfig{MaxPerRun: 1})
+	t.Cleanup(processes.Close)
+	policy, err := toolset.NewPolicy(t.TempDir(), time.Second)
+	if err != nil {
+		t.Fatalf("new policy: %v", err)
+	}
+	executor := toolset.NewExecutor(policy, toolset.WithProcesses(processes))
+	ctx := agent.WithRunID(context.Background(), "run-proc-limit")
+
+	started := executeProcTool(t, executor, ctx, toolset.ToolProcStart, map[string]any{"command": "tail -f /dev/null"})
+	pid, err := strconv.Atoi(regexp.MustCompile(`pid=(\d+)`).FindStringSubmatch(started)[1])
+	if err != nil {
+		t.Fatalf("parse pid from %q: %v", started, err)
+	}
+	_, err = executor.Execute(ctx, agent.ToolCall{ID: "start-2", Name: toolset.ToolProcStart, Arguments: map[string]any{"command": "tail -f /dev/null"}})
+	if !errors.Is(err, toolset.ErrProcessLimit) {
+		t.Fatalf("expected ErrProcessLimit, got %v", err)
+	}
+	listed := executeProcTool(t, executor, ctx, toolset.ToolProcList, nil)
+	if !strings.HasPrefix(listed, "proc_list_ok processes=1\nproc-1 status=running") {
+		t.Fatalf("list content mismatch: %q", listed)
+	}
+
+	maxSteps := agent.Event{
+		RunID:       "run-proc-limit",
+		Type:        agent.EventTypeRunFailed,
+		Description: fmt.Sprintf("run failed: %v", agent.ErrMaxStepsExceeded),
+	}
+	if err := processes.Publish(context.Background(), maxSteps); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if syscall.Kill(pid, 0) != nil {
+		t.Fatalf("process %d must survive a max steps failure, since the run can be continued", pid)
+	}
+
+	if err := processes.Publish(context.Background(), agent.Event{RunID: "run-proc-limit", Type: agent.EventTypeRunCancelled}); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for syscall.Kill(pid, 0) == nil {
+		if time.Now().After(deadline) {
+			t.Fatalf("process %d still running after run_cancelled", pid)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if listed := executeProcTool(t, executor, ctx, toolset.ToolProcList, nil); listed != "proc_list_ok processes=0" {
+		t.Fatalf("list after cancel mismatch: %q", listed)
+	}
+}
+
+func TestExecutorProcessStartDeniedCommandSuspends(t *testing.T) {
+	t.Parallel()
+
+	executor := newProcessExecutor(t, toolset.ProcessConfig{})
+	ctx := agent.WithRunID(context.Background(), "run-proc-denied")
+	_, err := executor.Execute(ctx, agent.ToolCall{ID: "start-dev", Name: toolset.ToolProcStart, Arguments: map[string]any{"command": "python3 -m http.server"}})
+	var suspendErr *agent.SuspendRequestError
+	if !errors.As(err, &suspendErr) {
+		t.Fatalf("expected SuspendRequestError, got %v", err)
+	}
+	if got, want := suspendErr.Requirement.ID, "req-proc-policy-start-dev"; got != want {
+		t.Fatalf("requirement id mismatch: got=%q want=%q", got, want)
+	}
+	if want := `approve proc_start command denied by policy: command "python3" requires a sandboxed bash backend`; suspendErr.Requirement.Prompt != want {
+		t.Fatalf("prompt mismatch: got=%q want=%q", suspendErr.Requirement.Prompt, want)
+	}
+}
+
+func newProcessExecutor(t *testing.T, cfg toolset.ProcessConfig) *toolset.Executor {
+	t.Helper()
+
+	processes := toolset.NewProcesses(cfg)
+	t.Cleanup(processes.Close)
+	policy, err := toolset.NewPolicy(t.TempDir(), time.Second)
+	if err != nil {
+		t.Fatalf("new policy: %v", err)
+	}
+	return toolset.NewExecutor(policy, toolset.WithProcesses(processes))
+}
+
+func executeProcTool(t *testing.T, executor *toolset.Executor, ctx context.Context, name string, arguments map[string]any) string {
+	t.Helper()
+
+	result, err := executor.Execute(ctx, agent.ToolCall{ID: name + "-call", Name: name, Arguments: arguments})
+	if err != nil {
+		t.Fatalf("%s returned error: %v", name, err)
+	}
+	return result.Content
+}
+
+func waitProcExit(t *testing.T, executor *toolset.Executor, ctx context.Context, id string) {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		listed := executeProcTool(t, executor, ctx, toolset.ToolProcList, nil)
+		if strings.Contains(listed, id+" status=exited") {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("process %s did not exit: %q", id, listed)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -1,21 +1,56 @@
 package toolset
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Gurpartap/agentframe/agent"
+)
+
+const (
+	DefaultReadLineLimit = 2000
+	MaxReadLineLimit     = 10000
+
+	// maxReadLineBytes caps each returned line so minified files cannot fill
+	// the output with a single line.
+	maxReadLineBytes = 2000
+	// maxReadScanBytes caps how much of a file read scans for line numbers
+	// and hashes, since ranges let it read files beyond the policy read size.
+	maxReadScanBytes = 64 << 20
 )
 
-func (e *Executor) executeRead(arguments map[string]any) (string, error) {
+// executeRead returns lines offset..offset+limit-1 of a file, numbered from
+// 1, and records the file's content hash as seen by the current run. Output
+// stops early at the policy read size; the header then names next_offset.
+// Non-regular files and files larger than maxReadScanBytes are refused.
+func (e *Executor) executeRead(ctx context.Context, arguments map[string]any) (string, error) {
 	path, err := stringArgument(arguments, "path")
 	if err != nil {
 		return "", err
 	}
+	offset, err := optionalIntArgument(arguments, "offset", 1, math.MaxInt32)
+	if err != nil {
+		return "", err
+	}
+	limit, err := optionalIntArgument(arguments, "limit", DefaultReadLineLimit, MaxReadLineLimit)
+	if err != nil {
+		return "", err
+	}
 
 	resolved, err := e.policy.ResolvePath(path)
 	if err != nil {
 		return "", err
 	}
-
 	info, err := os.Stat(resolved)
 	if err != nil {
 		return "", fmt.Errorf("read %q: %w", path, err)
@@ -23,13 +58,120 @@ func (e *Executor) executeRead(arguments map[string]any) (string, error) {
 	if info.IsDir() {
 		return "", fmt.Errorf("read %q: path is a directory", path)
 	}
-	if info.Size() > e.policy.maxReadSize {
-		return "", fmt.Errorf("read %q: file size %d exceeds limit %d", path, info.Size(), e.policy.maxReadSize)
+	// Opening a FIFO or device could block or never end.
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("read %q: path is not a regular file", path)
 	}
-
-	content, err := os.ReadFile(resolved)
+	if info.Size() > maxReadScanBytes {
+		return "", fmt.Errorf("read %q: file size %d exceeds limit %d", path, info.Size(), maxReadScanBytes)
+	}
+	file, err := os.Open(resolved)
 	if err != nil {
 		return "", fmt.Errorf("read %q: %w", path, err)
 	}
-	return string(content), nil
+	defer file.Close()
+
+	// The whole file is hashed even when only a range is returned, so the
+	// recorded hash detects changes anywhere in it.
+	hasher := sha256.New()
+	limited := &io.LimitedReader{R: file, N: maxReadScanBytes + 1}
+	reader := bufio.NewReader(io.TeeReader(limited, hasher))
+	var (
+		body      strings.Builder
+		total     int
+		first     int
+		last      int
+		truncated bool
+	)
+	for {
+		line, ok, lineTruncated, readErr := readBoundedLine(reader, maxReadLineBytes)
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return "", fmt.Errorf("read %q: %w", path, readErr)
+		}
+		if limited.N == 0 {
+			return "", fmt.Errorf("read %q: file grew beyond limit %d", path, maxReadScanBytes)
+		}
+		if ok {
+			total++
+			if total >= offset && total < offset+limit && !truncated {
+				text := string(line)
+				if lineTruncated {
+					text += " [line truncated]"
+				}
+				entry := fmt.Sprintf("%6d\t%s\n", total, text)
+				if int64(body.Len()+len(entry)) > e.policy.maxReadSize && first != 0 {
+					truncated = true
+				} else {
+					body.WriteString(entry)
+					if first == 0 {
+						first = total
+					}
+					last = total
+				}
+			}
+		}
+		if readErr != nil {
+			break
+		}
+	}
+	runID, _ := agent.RunIDFromContext(ctx)
+	e.reads.remember(runID, resolved, hex.EncodeToString(hasher.Sum(nil)))
+
+	header := fmt.Sprintf("read_ok path=%s lines=none total_lines=%d", path, total)
+	if first != 0 {
+		header = fmt.Sprintf("read_ok path=%s lines=%d-%d total_lines=%d", path, first, last, total)
+	}
+	if last != 0 && last < total {
+		header += fmt.Sprintf(" next_offset=%d", last+1)
+	}
+	if truncated {
+		header += " truncated=true"
+	}
+	if body.Len() == 0 {
+		return header, nil
+	}
+	return header + "\n" + strings.TrimSuffix(body.String(), "\n"), nil
+}
+
+// readBoundedLine reads through the next newline and returns the line
+// without its terminator, keeping at most maxBytes cut at a UTF-8 boundary so
+// one huge line cannot exhaust memory. ok reports that a line was read and
+// truncated that bytes were dropped.
+func readBoundedLine(reader *bufio.Reader, maxBytes int) (line []byte, ok bool, truncated bool, err error) {
+	for {
+		chunk, readErr := reader.ReadSlice('\n')
+		ok = ok || len(chunk) > 0
+		content := chunk
+		if !errors.Is(readErr, bufio.ErrBufferFull) {
+			content = bytes.TrimRight(chunk, "\r\n")
+		}
+		if !truncated {
+			if room := maxBytes - len(line); len(content) > room {
+				line = append(line, content[:room]...)
+				truncated = true
+			} else {
+				line = append(line, content...)
+			}
+		}
+		if errors.Is(readErr, bufio.ErrBufferFull) {
+			continue
+		}
+		if truncated {
+			line = trimPartialRune(line)
+		}
+		return line, ok, truncated, readErr
+	}
+}
+
+// trimPartialRune drops an incomplete multi-byte sequence at the end of b.
+func trimPartialRune(b []byte) []byte {
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if !utf8.FullRune(b[i:]) {
+				return b[:i]
+			}
+			break
+		}
+	}
+	return b
 }
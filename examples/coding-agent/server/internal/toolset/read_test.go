@@ -0,0 +1,210 @@
+package toolset_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Gurpartap/agentframe/agent"
+	"github.com/Gurpartap/agentframe/examples/coding-agent/server/internal/toolset"
+)
+
+func TestExecutorReadLineRanges(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	var content strings.Builder
+	for line := 1; line <= 5; line++ {
+		fmt.Fprintf(&content, "line %d\n", line)
+	}
+	if err := os.WriteFile(filepath.Join(root, "notes.txt"), []byte(content.String()), 0o644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	policy, err := toolset.NewPolicy(root, time.Second)
+	if err != nil {
+		t.Fatalf("new policy: %v", err)
+	}
+	executor := toolset.NewExecutor(policy)
+
+	testCases := []struct {
+		arguments map[string]any
+		want      string
+	}{
+		{
+			arguments: map[string]any{"path": "notes.txt", "offset": float64(2), "limit": float64(2)},
+			want:      "read_ok path=notes.txt lines=2-3 total_lines=5 next_offset=4\n     2\tline 2\n     3\tline 3",
+		},
+		{
+			arguments: map[string]any{"path": "notes.txt", "offset": float64(5)},
+			want:      "read_ok path=notes.txt lines=5-5 total_lines=5\n     5\tline 5",
+		},
+		{
+			arguments: map[string]any{"path": "notes.txt", "offset": float64(9)},
+			want:      "read_ok path=notes.txt lines=none total_lines=5",
+		},
+	}
+	for _, tc := range testCases {
+		result, err := executor.Execute(context.Background(), agent.ToolCall{ID: "read-range", Name: toolset.ToolRead, Arguments: tc.arguments})
+		if err != nil {
+			t.Fatalf("read %v: %v", tc.arguments, err)
+		}
+		if result.Content != tc.want {
+			t.Fatalf("read %v content mismatch: got=%q want=%q", tc.arguments, result.Content, tc.want)
+		}
+	}
+
+	_, err = executor.Execute(context.Background(), agent.ToolCall{ID: "read-bad", Name: toolset.ToolRead, Arguments: map[string]any{"path": "notes.txt", "offset": float64(0)}})
+	if !errors.Is(err, toolset.ErrArgumentInvalid) {
+		t.Fatalf("expected ErrArgumentInvalid for offset 0, got %v", err)
+	}
+}
+
+func TestExecutorReadBoundsLinesAndRefusesSpecialFiles(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	long := strings.Repeat("a", 1999) + strings.Repeat("é", 2000)
+	if err := os.WriteFile(filepath.Join(root, "wide.txt"), []byte("\n"+long+"\nend\n"), 0o644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	policy, err := toolset.NewPolicy(root, time.Second)
+	if err != nil {
+		t.Fatalf("new policy: %v", err)
+	}
+	executor := toolset.NewExecutor(policy)
+
+	result, err := executor.Execute(context.Background(), agent.ToolCall{ID: "read-wide", Name: toolset.ToolRead, Arguments: map[string]any{"path": "wide.txt"}})
+	if err != nil {
+		t.Fatalf("read wide: %v", err)
+	}
+	want := "read_ok path=wide.txt lines=1-3 total_lines=3\n     1\t\n     2\t" + strings.Repeat("a", 1999) + " [line truncated]\n     3\tend"
+	if result.Content != want {
+		t.Fatalf("wide content mismatch: got=%q want=%q", result.Content, want)
+	}
+	if !utf8.ValidString(result.Content) {
+		t.Fatalf("truncated line must stay valid UTF-8: %q", result.Content)
+	}
+
+	if _, err := exec.LookPath("mkfifo"); err != nil {
+		t.Skip("mkfifo is not installed")
+	}
+	if output, err := exec.Command("mkfifo", filepath.Join(root, "pipe")).CombinedOutput(); err != nil {
+		t.Fatalf("mkfifo: %v output=%s", err, output)
+	}
+	_, err = executor.Execute(context.Background(), agent.ToolCall{ID: "read-pipe", Name: toolset.ToolRead, Arguments: map[string]any{"path": "pipe"}})
+	if err == nil || !strings.Contains(err.Error(), "not a regular file") {
+		t.Fatalf("expected regular file error, got %v", err)
+	}
+}
+
+func TestExecutorEditDetectsFileChangedSinceRead(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	path := filepath.Join(root, "main.go")
+	if err := os.WriteFile(path, []byte("package main\n"), 0o644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	policy, err := toolset.NewPolicy(root, time.Second)
+	if err != nil {
+		t.Fatalf("new policy: %v", err)
+	}
+	executor := toolset.NewExecutor(policy)
+	ctx := agent.WithRunID(context.Background(), "run-stale")
+
+	if _, err := executor.Execute(ctx, agent.ToolCall{ID: "read-1", Name: toolset.ToolRead, Arguments: map[string]any{"path": "main.go"}}); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	// The model's own edits keep its view current.
+	edit := agent.ToolCall{ID: "edit-1", Name: toolset.ToolEdit, Arguments: map[string]any{"path": "main.go", "old": "package main", "new": "package app"}}
+	if _, err := executor.Execute(ctx, edit); err != nil {
+		t.Fatalf("edit after read: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("package app\n\nfunc changed() {}\n"), 0o644); err != nil {
+		t.Fatalf("change file: %v", err)
+	}
+	edit = agent.ToolCall{ID: "edit-2", Name: toolset.ToolEdit, Arguments: map[string]any{"path": "main.go", "old": "package app", "new": "package lib"}}
+	_, err = executor.Execute(ctx, edit)
+	var suspendErr *agent.SuspendRequestError
+	if !errors.As(err, &suspendErr) || !errors.Is(err, toolset.ErrFileChangedSinceRead) {
+		t.Fatalf("expected stale file suspension, got %v", err)
+	}
+	if got, want := suspendErr.Requirement.ID, "req-stale-file-edit-2"; got != want {
+		t.Fatalf("requirement id mismatch: got=%q want=%q", got, want)
+	}
+
+	// Another run never saw the file, so it is not checked.
+	if _, err := executor.Execute(agent.WithRunID(context.Background(), "run-other"), agent.ToolCall{
+		ID: "write-other", Name: toolset.ToolWrite, Arguments: map[string]any{"path": "other.go", "content": "package other\n"},
+	}); err != nil {
+		t.Fatalf("write in other run: %v", err)
+	}
+
+	approved := agent.WithApprovedToolCallReplayOverride(ctx, agent.ApprovedToolCallReplayOverride{
+		ToolCallID:  suspendErr.Requirement.ToolCallID,
+		Fingerprint: suspendErr.Requirement.Fingerprint,
+	})
+	if _, err := executor.Execute(approved, edit); err != nil {
+		t.Fatalf("approved edit: %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read back: %v", err)
+	}
+	if want := "package lib\n\nfunc changed() {}\n"; string(content) != want {
+		t.Fatalf("content mismatch: got=%q want=%q", content, want)
+	}
+}
+
+func TestExecutorWriteRefusesFileChangedSinceRead(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	path := filepath.Join(root, "notes.txt")
+	if err := os.WriteFile(path, []byte("v1\n"), 0o644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	policy, err := toolset.NewPolicy(root, time.Second)
+	if err != nil {
+		t.Fatalf("new policy: %v", err)
+	}
+	executor := toolset.NewExecutor(policy, toolset.WithStaleFileMode(toolset.StaleFileRefuse))
+	ctx := agent.WithRunID(context.Background(), "run-refuse")
+
+	if _, err := executor.Execute(ctx, agent.ToolCall{ID: "read-1", Name: toolset.ToolRead, Arguments: map[string]any{"path": "notes.txt"}}); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("remove file: %v", err)
+	}
+	write := agent.ToolCall{ID: "write-1", Name: toolset.ToolWrite, Arguments: map[string]any{"path": "notes.txt", "content": "v2\n"}}
+	_, err = executor.Execute(ctx, write)
+	var suspendErr *agent.SuspendRequestError
+	if !errors.Is(err, toolset.ErrFileChangedSinceRead) || errors.As(err, &suspendErr) {
+		t.Fatalf("expected refusal without suspension, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "current_sha256=none") {
+		t.Fatalf("error must report the deleted file: %v", err)
+	}
+
+	if _, err := executor.Execute(ctx, agent.ToolCall{ID: "read-2", Name: toolset.ToolRead, Arguments: map[string]any{"path": "notes.txt"}}); err == nil {
+		t.Fatalf("read of deleted file must fail")
+	}
+	if err := os.WriteFile(path, []byte("v1b\n"), 0o644); err != nil {
+		t.Fatalf("recreate file: %v", err)
+	}
+	if _, err := executor.Execute(ctx, agent.ToolCall{ID: "read-3", Name: toolset.ToolRead, Arguments: map[string]any{"path": "notes.txt"}}); err != nil {
+		t.Fatalf("re-read: %v", err)
+	}
+	if _, err := executor.Execute(ctx, write); err != nil {
+		t.Fatalf("write after re-read: %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package toolset
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SandboxRuntime selects the isolation tool used by SandboxBackend.
+type SandboxRuntime string
+
+const (
+	// SandboxRuntimeAuto prefers bubblewrap and falls back to unshare.
+	SandboxRuntimeAuto SandboxRuntime = "auto"
+	// SandboxRuntimeBubblewrap runs commands under bwrap.
+	SandboxRuntimeBubblewrap SandboxRuntime = "bwrap"
+	// SandboxRuntimeUnshare runs commands under util-linux unshare in an
+	// unprivileged user namespace, dropping capabilities with setpriv.
+	SandboxRuntimeUnshare SandboxRuntime = "unshare"
+)
+
+const (
+	DefaultSandboxCPUSeconds    = 60
+	DefaultSandboxMemoryBytes   = 4 << 30
+	DefaultSandboxFileSizeBytes = 1 << 30
+)
+
+var (
+	ErrSandboxUnavailable = errors.New("bash sandbox is unavailable")
+	ErrSandboxConfig      = errors.New("bash sandbox config is invalid")
+)
+
+// SandboxConfig bounds commands run by SandboxBackend. Zero limits use the
+// package defaults.
+type SandboxConfig struct {
+	Runtime SandboxRuntime
+	// CPUSeconds caps CPU time per process (RLIMIT_CPU).
+	CPUSeconds int
+	// MemoryBytes caps virtual memory per process (RLIMIT_AS).
+	MemoryBytes int64
+	// FileSizeBytes caps the size of any file a process writes (RLIMIT_FSIZE).
+	FileSizeBytes int64
+	// MaxOutputBytes caps each captured output stream.
+	MaxOutputBytes int
+}
+
+func (c SandboxConfig) withDefaults() (SandboxConfig, error) {
+	switch c.Runtime {
+	case "":
+		c.Runtime = SandboxRuntimeAuto
+	case SandboxRuntimeAuto, SandboxRuntimeBubblewrap, SandboxRuntimeUnshare:
+	default:
+		return SandboxConfig{}, fmt.Errorf("%w: field=runtime reason=unsupported value=%q", ErrSandboxConfig, c.Runtime)
+	}
+	if c.CPUSeconds < 0 || c.MemoryBytes < 0 || c.FileSizeBytes < 0 || c.MaxOutputBytes < 0 {
+		return SandboxConfig{}, fmt.Errorf("%w: field=limits reason=negative", ErrSandboxConfig)
+	}
+	if c.CPUSeconds == 0 {
+		c.CPUSeconds = DefaultSandboxCPUSeconds
+	}
+	if c.MemoryBytes == 0 {
+		c.MemoryBytes = DefaultSandboxMemoryBytes
+	}
+	if c.FileSizeBytes == 0 {
+		c.FileSizeBytes = DefaultSandboxFileSizeBytes
+	}
+	if c.MaxOutputBytes == 0 {
+		c.MaxOutputBytes = DefaultMaxBashOutputBytes
+	}
+	return c, nil
+}
+
+// limitsScript returns the shell prefix applying the configured rlimits.
+// ulimit takes kibibytes for -v and -f.
+func (c SandboxConfig) limitsScript() string {
+	return strings.Join([]string{
+		fmt.Sprintf("ulimit -t %d", c.CPUSeconds),
+		fmt.Sprintf("ulimit -v %d", c.MemoryBytes/1024),
+		fmt.Sprintf("ulimit -f %d", c.FileSizeBytes/1024),
+	}, "; ")
+}
@@ -0,0 +1,164 @@
+//go:build linux
+
+package toolset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const sandboxProbeTimeout = 5 * time.Second
+
+// unshareSetupScript runs as root of a fresh user, mount, network and PID
+// namespace. It mounts a private /tmp, re-binds the workspace (through the
+// working directory, since /tmp may hide it), remounts every other mount
+// read-only and finally execs the command with rlimits applied through
+// setpriv, which empties the capability sets and sets no_new_privs so the
+// command cannot undo the read-only remounts. Remounts keep each mount's
+// existing flags, which the kernel locks in a user namespace; if any mount
+// cannot be made read-only the script exits before running the command.
+// Positional arguments: $1 workspace root, $2 rlimit script, $3 command,
+// $4 setpriv path.
+const unshareSetupScript = `set -e
+ws=$1
+cd "$ws"
+mount -t tmpfs -o mode=1777 tmpfs /tmp
+mkdir -p "$ws"
+mount --no-canonicalize --bind /proc/self/cwd "$ws"
+while read -r m opts; do
+  case "$m" in "$ws"|"$ws"/*|/tmp|/proc|/proc/*|/dev|/dev/*|/sys|/sys/*) continue;; esac
+  if ! mount -o "remount,bind,$opts,ro" "$m"; then
+    echo "sandbox: cannot remount $m read-only" >&2
+    exit 1
+  fi
+done <<< "$(awk '{print $5, $6}' /proc/self/mountinfo)"
+cd "$ws"
+set +e
+eval "$2"
+exec "$4" --bounding-set=-all --inh-caps=-all --ambient-caps=-all --no-new-privs bash -lc "$3"`
+
+// bubblewrapLimitScript applies rlimits inside bwrap before running the
+// command. Positional arguments: $1 rlimit script, $2 command.
+const bubblewrapLimitScript = `eval "$1"
+exec bash -lc "$2"`
+
+// SandboxBackend runs bash inside Linux namespaces: the network is
+// unavailable, everything outside the workspace and a private /tmp is mounted
+// read-only, processes cannot see host PIDs, and CPU, memory, file size and
+// output are bounded. The command runs without capabilities, so it cannot
+// remount or unshare its way out. No seccomp filter is installed; isolation
+// relies on the unprivileged namespaces and the dropped capabilities.
+type SandboxBackend struct {
+	runtime SandboxRuntime
+	path    string
+	setpriv string
+	config  SandboxConfig
+}
+
+// NewSandboxBackend resolves the configured runtime and probes that it can
+// create namespaces on this host.
+func NewSandboxBackend(cfg SandboxConfig) (*SandboxBackend, error) {
+	resolved, err := cfg.withDefaults()
+	if err != nil {
+		return nil, fmt.Errorf("new sandbox backend: %w", err)
+	}
+
+	candidates := []SandboxRuntime{resolved.Runtime}
+	if resolved.Runtime == SandboxRuntimeAuto {
+		candidates = []SandboxRuntime{SandboxRuntimeBubblewrap, SandboxRuntimeUnshare}
+	}
+	var probeErr error
+	for _, runtime := range candidates {
+		path, lookErr := exec.LookPath(string(runtime))
+		if lookErr != nil {
+			probeErr = errors.Join(probeErr, fmt.Errorf("runtime=%s: %w", runtime, lookErr))
+			continue
+		}
+		backend := &SandboxBackend{runtime: runtime, path: path, config: resolved}
+		if runtime == SandboxRuntimeUnshare {
+			// unshare leaves the command as namespace root with full
+			// capabilities; refuse it unless setpriv can drop them.
+			setpriv, lookErr := exec.LookPath("setpriv")
+			if lookErr != nil {
+				probeErr = errors.Join(probeErr, fmt.Errorf("runtime=%s: %w", runtime, lookErr))
+				continue
+			}
+			backend.setpriv = setpriv
+		}
+		if err := backend.probe(); err != nil {
+			probeErr = errors.Join(probeErr, fmt.Errorf("runtime=%s: %w", runtime, err))
+			continue
+		}
+		return backend, nil
+	}
+	return nil, fmt.Errorf("new sandbox backend: %w: %w", ErrSandboxUnavailable, probeErr)
+}
+
+// Runtime returns the isolation tool in use.
+func (b *SandboxBackend) Runtime() SandboxRuntime {
+	return b.runtime
+}
+
+func (b *SandboxBackend) Run(ctx context.Context, request BashRequest) (BashResult, error) {
+	cmd := exec.CommandContext(ctx, b.path, b.args(request.WorkspaceRoot, request.Command)...)
+	cmd.Dir = request.WorkspaceRoot
+	return runCaptured(cmd, b.config.MaxOutputBytes)
+}
+
+func (b *SandboxBackend) Command(request BashRequest) *exec.Cmd {
+	cmd := exec.Command(b.path, b.args(request.WorkspaceRoot, request.Command)...)
+	cmd.Dir = request.WorkspaceRoot
+	return cmd
+}
+
+func (*SandboxBackend) Sandboxed() bool {
+	return true
+}
+
+func (b *SandboxBackend) args(workspaceRoot, command string) []string {
+	limits := b.config.limitsScript()
+	if b.runtime == SandboxRuntimeBubblewrap {
+		return []string{
+			"--ro-bind", "/", "/",
+			"--dev", "/dev",
+			"--proc", "/proc",
+			"--tmpfs", "/tmp",
+			"--bind", workspaceRoot, workspaceRoot,
+			"--chdir", workspaceRoot,
+			"--unshare-all",
+			"--die-with-parent",
+			"--new-session",
+			"--",
+			"bash", "-c", bubblewrapLimitScript, "sandbox", limits, command,
+		}
+	}
+	return []string{
+		"--user", "--map-root-user",
+		"--net", "--mount", "--pid", "--ipc", "--uts",
+		"--fork", "--mount-proc", "--kill-child",
+		"--",
+		"bash", "-c", unshareSetupScript, "sandbox", workspaceRoot, limits, command, b.setpriv,
+	}
+}
+
+func (b *SandboxBackend) probe() error {
+	ctx, cancel := context.WithTimeout(context.Background(), sandboxProbeTimeout)
+	defer cancel()
+
+	workspace, err := os.MkdirTemp("", "bash-sandbox-probe-")
+	if err != nil {
+		return fmt.Errorf("probe workspace: %w", err)
+	}
+	defer os.RemoveAll(workspace)
+
+	result, err := b.Run(ctx, BashRequest{Command: "true", WorkspaceRoot: workspace})
+	if err != nil {
+		return fmt.Errorf("probe: %w stderr=%q", err, strings.TrimSpace(result.Stderr))
+	}
+	return nil
+}
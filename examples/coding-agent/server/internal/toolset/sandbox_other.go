@@ -0,0 +1,32 @@
+//go:build !linux
+
+package toolset
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+)
+
+// SandboxBackend is only implemented on Linux.
+type SandboxBackend struct{}
+
+// NewSandboxBackend reports ErrSandboxUnavailable on non-Linux hosts.
+func NewSandboxBackend(cfg SandboxConfig) (*SandboxBackend, error) {
+	if _, err := cfg.withDefaults(); err != nil {
+		return nil, fmt.Errorf("new sandbox backend: %w", err)
+	}
+	return nil, fmt.Errorf("new sandbox backend: %w: os=%s", ErrSandboxUnavailable, runtime.GOOS)
+}
+
+func (*SandboxBackend) Runtime() SandboxRuntime {
+	return ""
+}
+
+func (*SandboxBackend) Run(context.Context, BashRequest) (BashResult, error) {
+	return BashResult{}, ErrSandboxUnavailable
+}
+
+func (*SandboxBackend) Sandboxed() bool {
+	return true
+}
@@ -0,0 +1,149 @@
+package toolset_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gurpartap/agentframe/agent"
+	"github.com/Gurpartap/agentframe/examples/coding-agent/server/internal/toolset"
+)
+
+type recordingBackend struct {
+	sandboxed bool
+	requests  []toolset.BashRequest
+}
+
+func (b *recordingBackend) Run(_ context.Context, request toolset.BashRequest) (toolset.BashResult, error) {
+	b.requests = append(b.requests, request)
+	return toolset.BashResult{Stdout: "ran"}, nil
+}
+
+func (b *recordingBackend) Sandboxed() bool {
+	return b.sandboxed
+}
+
+func TestExecutorBashBuildCommandsRequireSandbox(t *testing.T) {
+	t.Parallel()
+
+	policy, err := toolset.NewPolicy(t.TempDir(), time.Second)
+	if err != nil {
+		t.Fatalf("new policy: %v", err)
+	}
+	call := agent.ToolCall{ID: "bash-go-1", Name: toolset.ToolBash, Arguments: map[string]any{"command": "go test ./..."}}
+
+	host := &recordingBackend{}
+	_, err = toolset.NewExecutor(policy, toolset.WithBashBackend(host)).Execute(context.Background(), call)
+	var suspendErr *agent.SuspendRequestError
+	if !errors.As(err, &suspendErr) || !strings.Contains(err.Error(), "requires a sandboxed bash backend") {
+		t.Fatalf("expected suspension for unsandboxed build command, got %v", err)
+	}
+	if len(host.requests) != 0 {
+		t.Fatalf("denied command must not run: requests=%d", len(host.requests))
+	}
+
+	sandbox := &recordingBackend{sandboxed: true}
+	result, err := toolset.NewExecutor(policy, toolset.WithBashBackend(sandbox)).Execute(context.Background(), call)
+	if err != nil {
+		t.Fatalf("sandboxed build command returned error: %v", err)
+	}
+	if len(sandbox.requests) != 1 || sandbox.requests[0].WorkspaceRoot != policy.WorkspaceRoot() {
+		t.Fatalf("backend request mismatch: %+v", sandbox.requests)
+	}
+	if !strings.Contains(result.Content, `stdout="ran"`) {
+		t.Fatalf("result content mismatch: %q", result.Content)
+	}
+}
+
+func TestHostBackendTruncatesOutput(t *testing.T) {
+	t.Parallel()
+
+	policy, err := toolset.NewPolicy(t.TempDir(), 10*time.Second)
+	if err != nil {
+		t.Fatalf("new policy: %v", err)
+	}
+	executor := toolset.NewExecutor(policy, toolset.WithBashBackend(toolset.HostBackend{MaxOutputBytes: 4}))
+	result, err := executor.Execute(context.Background(), agent.ToolCall{
+		ID:        "bash-cap-1",
+		Name:      toolset.ToolBash,
+		Arguments: map[string]any{"command": "printf abcdefgh"},
+	})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if !strings.Contains(result.Content, `stdout="abcd"`) || !strings.HasSuffix(result.Content, "output_truncated=true") {
+		t.Fatalf("truncated content mismatch: %q", result.Content)
+	}
+}
+
+func TestSandboxBackendIsolatesWorkspace(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS != "linux" {
+		t.Skip("sandbox backend is linux-only")
+	}
+	backend, err := toolset.NewSandboxBackend(toolset.SandboxConfig{})
+	if errors.Is(err, toolset.ErrSandboxUnavailable) {
+		t.Skipf("sandbox unavailable on this host: %v", err)
+	}
+	if err != nil {
+		t.Fatalf("new sandbox backend: %v", err)
+	}
+
+	workspace := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "outside.txt")
+	for path, content := range map[string]string{filepath.Join(workspace, "inside.txt"): "before", outside: "before"} {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("seed %s: %v", path, err)
+		}
+	}
+	run := func(command string) (toolset.BashResult, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return backend.Run(ctx, toolset.BashRequest{Command: command, WorkspaceRoot: workspace})
+	}
+
+	if _, err := run("sed -i s/before/after/ inside.txt"); err != nil {
+		t.Fatalf("workspace write failed: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(workspace, "inside.txt")); string(data) != "after" {
+		t.Fatalf("workspace file mismatch: got=%q want=%q", data, "after")
+	}
+
+	if _, err := run("sed -i s/before/after/ " + outside); err == nil {
+		t.Fatalf("write outside workspace must fail")
+	}
+	if data, _ := os.ReadFile(outside); string(data) != "before" {
+		t.Fatalf("outside file mutated: got=%q", data)
+	}
+	if _, err := run("sed -i s/a/b/ /etc/hostname"); err == nil {
+		t.Fatalf("write to read-only host path must fail")
+	}
+	if _, err := run("mount -o remount,bind,rw / || mount -o remount,bind,rw /etc"); err == nil {
+		t.Fatalf("remounting host paths read-write must fail")
+	}
+
+	interfaces, err := run("cat /proc/net/dev")
+	if err != nil {
+		t.Fatalf("read network devices: %v", err)
+	}
+	var devices []string
+	for _, line := range strings.Split(interfaces.Stdout, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !strings.HasSuffix(fields[0], ":") {
+			continue
+		}
+		if _, numeric := strconv.ParseUint(fields[1], 10, 64); numeric == nil {
+			devices = append(devices, strings.TrimSuffix(fields[0], ":"))
+		}
+	}
+	if len(devices) != 1 || devices[0] != "lo" {
+		t.Fatalf("sandbox must only see loopback: got=%v", devices)
+	}
+}
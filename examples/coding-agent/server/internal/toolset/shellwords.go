@@ -0,0 +1,158 @@
+package toolset
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// shellWord is one parsed shell word after quote removal.
+type shellWord struct {
+	text string
+	// glob reports unquoted *, ? or [ that bash will expand.
+	glob bool
+}
+
+var shellAssignment = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*=`)
+
+// parseShellPipeline splits command into pipeline stages of shell words. It
+// accepts only the subset of bash whose meaning is fully determined by the
+// text: words, single and double quotes, backslash escapes, pathname globs
+// and "|". Anything that would expand, redirect, sequence or spawn subshells
+// is rejected with a reason naming the construct.
+func parseShellPipeline(command string) ([][]shellWord, error) {
+	var (
+		stages  [][]shellWord
+		stage   []shellWord
+		word    strings.Builder
+		started bool
+		glob    bool
+	)
+	endWord := func() {
+		if started {
+			stage = append(stage, shellWord{text: word.String(), glob: glob})
+		}
+		word.Reset()
+		started, glob = false, false
+	}
+	unsupported := func(construct string, offset int) ([][]shellWord, error) {
+		return nil, denyBash("unsupported shell syntax: %s at offset %d", construct, offset)
+	}
+
+	runes := []rune(command)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch r {
+		case ' ', '\t':
+			endWord()
+		case '\n', '\r':
+			return unsupported("newline", i)
+		case '|':
+			if i+1 < len(runes) && runes[i+1] == '|' {
+				return unsupported(`"||" list`, i)
+			}
+			endWord()
+			if len(stage) == 0 {
+				return unsupported("empty pipeline stage", i)
+			}
+			stages = append(stages, stage)
+			stage = nil
+		case '&':
+			return unsupported(`"&" background or list`, i)
+		case ';':
+			return unsupported(`";" command list`, i)
+		case '<', '>':
+			return unsupported(fmt.Sprintf("%q redirection", r), i)
+		case '`':
+			return unsupported("command substitution", i)
+		case '$':
+			return unsupported(`"$" expansion`, i)
+		case '(', ')':
+			return unsupported("subshell", i)
+		case '{', '}':
+			return unsupported("brace expansion", i)
+		case '#', '~':
+			if !started {
+				if r == '#' {
+					return unsupported("comment", i)
+				}
+				return unsupported("tilde expansion", i)
+			}
+			word.WriteRune(r)
+		case '\\':
+			if i+1 >= len(runes) {
+				return unsupported("trailing backslash", i)
+			}
+			i++
+			if runes[i] == '\n' || runes[i] == '\r' {
+				return unsupported("newline", i)
+			}
+			word.WriteRune(runes[i])
+			started = true
+		case '\'':
+			closed := false
+			for i++; i < len(runes); i++ {
+				if runes[i] == '\'' {
+					closed = true
+					break
+				}
+				if runes[i] == '\n' || runes[i] == '\r' {
+					return unsupported("newline", i)
+				}
+				word.WriteRune(runes[i])
+			}
+			if !closed {
+				return unsupported("unterminated single quote", len(runes))
+			}
+			started = true
+		case '"':
+			closed := false
+			for i++; i < len(runes); i++ {
+				c := runes[i]
+				if c == '"' {
+					closed = true
+					break
+				}
+				switch c {
+				case '$':
+					return unsupported(`"$" expansion`, i)
+				case '`':
+					return unsupported("command substitution", i)
+				case '\n', '\r':
+					return unsupported("newline", i)
+				case '\\':
+					if i+1 < len(runes) && strings.ContainsRune("$`\"\\", runes[i+1]) {
+						i++
+						c = runes[i]
+					}
+				}
+				word.WriteRune(c)
+			}
+			if !closed {
+				return unsupported("unterminated double quote", len(runes))
+			}
+			started = true
+		case '*', '?', '[':
+			word.WriteRune(r)
+			started, glob = true, true
+		default:
+			word.WriteRune(r)
+			started = true
+		}
+	}
+	endWord()
+	if len(stage) == 0 {
+		if len(stages) > 0 {
+			return unsupported("empty pipeline stage", len(runes))
+		}
+		return nil, nil
+	}
+	stages = append(stages, stage)
+
+	for index, words := range stages {
+		if shellAssignment.MatchString(words[0].text) {
+			return nil, denyBash("stage %d: variable assignment %q is not allowed", index+1, words[0].text)
+		}
+	}
+	return stages, nil
+}
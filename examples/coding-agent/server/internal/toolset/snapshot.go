@@ -0,0 +1,302 @@
+package toolset
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/Gurpartap/agentframe/agent"
+)
+
+var (
+	ErrSnapshotStepInvalid = errors.New("snapshot rollback step is invalid")
+	ErrSnapshotCorrupt     = errors.New("snapshot store is corrupt")
+)
+
+// FileChange records the contents of one workspace file before a tool
+// mutated it.
+type FileChange struct {
+	Step   int    `json:"step"`
+	CallID string `json:"call_id,omitempty"`
+	Tool   string `json:"tool"`
+	// Path is relative to the workspace root.
+	Path string `json:"path"`
+	// Blob is the SHA-256 digest of the previous contents, or empty when the
+	// file did not exist.
+	Blob string      `json:"blob,omitempty"`
+	Mode os.FileMode `json:"mode,omitempty"`
+}
+
+// Snapshots is a content-addressed store of file contents captured before
+// write, edit and apply_patch mutate the workspace, plus an append-only
+// change set per run. Blobs live under dir/objects and change sets under
+// dir/runs, so the workspace itself is never polluted.
+type Snapshots struct {
+	dir string
+	// workspaceRoot returns the workspace a run's paths are relative to.
+	workspaceRoot func(agent.RunID) (string, error)
+
+	mu sync.Mutex
+}
+
+func NewSnapshots(dir string, policy Policy) (*Snapshots, error) {
+	root := policy.WorkspaceRoot()
+	return newSnapshots(dir, func(agent.RunID) (string, error) { return root, nil })
+}
+
+// NewWorkspaceSnapshots is NewSnapshots for isolated runs: each run's changes
+// are recorded and restored relative to its own workspace. Rollback fails
+// with ErrWorkspaceNotFound once the workspace has been released.
+func NewWorkspaceSnapshots(dir string, workspaces *Workspaces) (*Snapshots, error) {
+	return newSnapshots(dir, workspaces.Root)
+}
+
+func newSnapshots(dir string, workspaceRoot func(agent.RunID) (string, error)) (*Snapshots, error) {
+	trimmed := strings.TrimSpace(dir)
+	if trimmed == "" {
+		return nil, fmt.Errorf("new snapshots: directory is required")
+	}
+	absolute, err := filepath.Abs(trimmed)
+	if err != nil {
+		return nil, fmt.Errorf("new snapshots: resolve directory: %w", err)
+	}
+	for _, sub := range []string{"objects", "runs"} {
+		if err := os.MkdirAll(filepath.Join(absolute, sub), 0o755); err != nil {
+			return nil, fmt.Errorf("new snapshots: create %s directory: %w", sub, err)
+		}
+	}
+	return &Snapshots{dir: absolute, workspaceRoot: workspaceRoot}, nil
+}
+
+// WithSnapshots records the prior contents of every file mutated by write,
+// edit and apply_patch. Calls without a run ID in context are not recorded.
+func WithSnapshots(snapshots *Snapshots) ExecutorOption {
+	return func(e *Executor) {
+		e.snapshots = snapshots
+	}
+}
+
+// Record captures the current contents of resolvedPaths for the run and step
+// carried by ctx before call mutates them.
+func (s *Snapshots) Record(ctx context.Context, call agent.ToolCall, resolvedPaths ...string) error {
+	runID, ok := agent.RunIDFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	step, _ := agent.StepFromContext(ctx)
+
+	root, err := s.workspaceRoot(runID)
+	if err != nil {
+		return fmt.Errorf("snapshot: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changes := make([]FileChange, 0, len(resolvedPaths))
+	for _, resolved := range resolvedPaths {
+		rel, err := filepath.Rel(root, resolved)
+		if err != nil || !hasPathPrefix(root, resolved) {
+			return fmt.Errorf("snapshot %q: %w", resolved, ErrPathOutsideWorkspace)
+		}
+		change := FileChange{Step: step, CallID: call.ID, Tool: call.Name, Path: filepath.ToSlash(rel)}
+		info, err := os.Stat(resolved)
+		switch {
+		case err == nil && info.Mode().IsRegular():
+			content, readErr := os.ReadFile(resolved)
+			if readErr != nil {
+				return fmt.Errorf("snapshot %q: read: %w", change.Path, readErr)
+			}
+			digest, blobErr := s.putBlob(content)
+			if blobErr != nil {
+				return fmt.Errorf("snapshot %q: %w", change.Path, blobErr)
+			}
+			change.Blob = digest
+			change.Mode = info.Mode().Perm()
+		case err == nil:
+			return fmt.Errorf("snapshot %q: not a regular file", change.Path)
+		case !os.IsNotExist(err):
+			return fmt.Errorf("snapshot %q: stat: %w", change.Path, err)
+		}
+		changes = append(changes, change)
+	}
+	return s.appendChanges(runID, changes)
+}
+
+// Changes returns the recorded change set of a run in mutation order.
+func (s *Snapshots) Changes(runID agent.RunID) ([]FileChange, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.readChanges(runID)
+}
+
+// Rollback restores every file the run changed after step to its contents at
+// the end of that step; step 0 undoes the whole run. The undone changes are
+// dropped from the change set. It returns the restored paths.
+func (s *Snapshots) Rollback(runID agent.RunID, step int) ([]string, error) {
+	if step < 0 {
+		return nil, fmt.Errorf("%w: step=%d reason=negative", ErrSnapshotStepInvalid, step)
+	}
+
+	root, err := s.workspaceRoot(runID)
+	if err != nil {
+		return nil, fmt.Errorf("rollback: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changes, err := s.readChanges(runID)
+	if err != nil {
+		return nil, err
+	}
+	// The earliest change after step holds each file's contents as of step.
+	targets := map[string]FileChange{}
+	var kept []FileChange
+	for _, change := range changes {
+		if change.Step <= step {
+			kept = append(kept, change)
+			continue
+		}
+		if _, seen := targets[change.Path]; !seen {
+			targets[change.Path] = change
+		}
+	}
+
+	restored := make([]string, 0, len(targets))
+	for path, change := range targets {
+		if err := s.restore(root, change); err != nil {
+			return nil, err
+		}
+		restored = append(restored, path)
+	}
+	slices.Sort(restored)
+	if err := s.writeChanges(runID, kept); err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
+
+func (s *Snapshots) restore(root string, change FileChange) error {
+	target := filepath.Join(root, filepath.FromSlash(change.Path))
+	real, err := resolveExistingPrefix(target)
+	if err != nil {
+		return fmt.Errorf("restore %q: %w", change.Path, err)
+	}
+	if !hasPathPrefix(root, target) || !hasPathPrefix(root, real) {
+		return fmt.Errorf("restore %q: %w", change.Path, ErrPathOutsideWorkspace)
+	}
+	if insideGitDir(root, target) || insideGitDir(root, real) {
+		return fmt.Errorf("restore %q: %w: inside .git", change.Path, ErrPathProtected)
+	}
+	if change.Blob == "" {
+		if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("restore %q: remove: %w", change.Path, err)
+		}
+		return nil
+	}
+	content, err := os.ReadFile(s.blobPath(change.Blob))
+	if err != nil {
+		return fmt.Errorf("restore %q: %w: missing blob %s: %w", change.Path, ErrSnapshotCorrupt, change.Blob, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("restore %q: create parent directory: %w", change.Path, err)
+	}
+	mode := change.Mode
+	if mode == 0 {
+		mode = 0o644
+	}
+	if err := writeFileAtomic(target, content, mode); err != nil {
+		return fmt.Errorf("restore %q: write: %w", change.Path, err)
+	}
+	return nil
+}
+
+func (s *Snapshots) putBlob(content []byte) (string, error) {
+	sum := sha256.Sum256(content)
+	digest := hex.EncodeToString(sum[:])
+	path := s.blobPath(digest)
+	if _, err := os.Stat(path); err == nil {
+		return digest, nil
+	}
+	if err := writeFileAtomic(path, content, 0o644); err != nil {
+		return "", fmt.Errorf("store blob: %w", err)
+	}
+	return digest, nil
+}
+
+func (s *Snapshots) blobPath(digest string) string {
+	return filepath.Join(s.dir, "objects", digest)
+}
+
+func (s *Snapshots) changesPath(runID agent.RunID) string {
+	return filepath.Join(s.dir, "runs", url.PathEscape(string(runID))+".jsonl")
+}
+
+func (s *Snapshots) appendChanges(runID agent.RunID, changes []FileChange) error {
+	file, err := os.OpenFile(s.changesPath(runID), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("record changes run_id=%q: %w", runID, err)
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	for _, change := range changes {
+		if err := encoder.Encode(change); err != nil {
+			return fmt.Errorf("record changes run_id=%q: %w", runID, err)
+		}
+	}
+	return nil
+}
+
+func (s *Snapshots) writeChanges(runID agent.RunID, changes []FileChange) error {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	for _, change := range changes {
+		if err := encoder.Encode(change); err != nil {
+			return fmt.Errorf("rewrite changes run_id=%q: %w", runID, err)
+		}
+	}
+	if err := writeFileAtomic(s.changesPath(runID), buffer.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("rewrite changes run_id=%q: %w", runID, err)
+	}
+	return nil
+}
+
+func (s *Snapshots) readChanges(runID agent.RunID) ([]FileChange, error) {
+	file, err := os.Open(s.changesPath(runID))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read changes run_id=%q: %w", runID, err)
+	}
+	defer file.Close()
+
+	var changes []FileChange
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64<<10), 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		var change FileChange
+		if err := json.Unmarshal(scanner.Bytes(), &change); err != nil {
+			return nil, fmt.Errorf("%w: run_id=%q line=%d: %w", ErrSnapshotCorrupt, runID, line, err)
+		}
+		changes = append(changes, change)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read changes run_id=%q: %w", runID, err)
+	}
+	return changes, nil
+}
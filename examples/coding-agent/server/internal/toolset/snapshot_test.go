@@ -0,0 +1,97 @@
+package toolset_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Gurpartap/agentframe/agent"
+	"github.com/Gurpartap/agentframe/examples/coding-agent/server/internal/toolset"
+)
+
+func TestSnapshotsRollbackApplyPatchChangeSet(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "keep.txt"), []byte("v0\n"), 0o600); err != nil {
+		t.Fatalf("seed keep.txt: %v", err)
+	}
+	policy, err := toolset.NewPolicy(root, time.Second)
+	if err != nil {
+		t.Fatalf("new policy: %v", err)
+	}
+	snapshots, err := toolset.NewSnapshots(t.TempDir(), policy)
+	if err != nil {
+		t.Fatalf("new snapshots: %v", err)
+	}
+	executor := toolset.NewExecutor(policy, toolset.WithSnapshots(snapshots))
+
+	ctx := agent.WithRunID(context.Background(), "run-snap")
+	edits := [][]any{
+		{map[string]any{"path": "keep.txt", "old": "v0", "new": "v1"}},
+		{
+			map[string]any{"path": "keep.txt", "old": "v1", "new": "v2"},
+			map[string]any{"path": "new/added.txt", "old": "", "new": "added\n"},
+		},
+	}
+	for index, batch := range edits {
+		step := index + 1
+		_, err := executor.Execute(agent.WithStep(ctx, step), agent.ToolCall{
+			ID:        "patch-" + strconv.Itoa(step),
+			Name:      toolset.ToolApplyPatch,
+			Arguments: map[string]any{"edits": batch},
+		})
+		if err != nil {
+			t.Fatalf("step %d apply_patch: %v", step, err)
+		}
+	}
+
+	changes, err := snapshots.Changes("run-snap")
+	if err != nil {
+		t.Fatalf("changes: %v", err)
+	}
+	if len(changes) != 3 || changes[2].Path != "new/added.txt" || changes[2].Blob != "" || changes[2].Step != 2 {
+		t.Fatalf("change set mismatch: %+v", changes)
+	}
+
+	restored, err := snapshots.Rollback("run-snap", 1)
+	if err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	if want := []string{"keep.txt", "new/added.txt"}; !slices.Equal(restored, want) {
+		t.Fatalf("restored mismatch: got=%v want=%v", restored, want)
+	}
+	if got := readFile(t, root, "keep.txt"); got != "v1\n" {
+		t.Fatalf("keep.txt mismatch: got=%q want=%q", got, "v1\n")
+	}
+	if info, err := os.Stat(filepath.Join(root, "keep.txt")); err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("restored mode mismatch: info=%v err=%v", info, err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "new/added.txt")); !os.IsNotExist(err) {
+		t.Fatalf("added file must be removed, stat err=%v", err)
+	}
+	if changes, _ := snapshots.Changes("run-snap"); len(changes) != 1 {
+		t.Fatalf("change set after rollback mismatch: %+v", changes)
+	}
+
+	if _, err := executor.Execute(context.Background(), agent.ToolCall{
+		ID:        "write-no-run",
+		Name:      toolset.ToolWrite,
+		Arguments: map[string]any{"path": "other.txt", "content": "x"},
+	}); err != nil {
+		t.Fatalf("write without run: %v", err)
+	}
+	if changes, _ := snapshots.Changes("run-snap"); len(changes) != 1 {
+		t.Fatalf("writes outside a run must not be recorded: %+v", changes)
+	}
+	if _, err := snapshots.Rollback("run-snap", 0); err != nil {
+		t.Fatalf("rollback to start: %v", err)
+	}
+	if got := readFile(t, root, "keep.txt"); got != "v0\n" {
+		t.Fatalf("keep.txt after full rollback mismatch: got=%q want=%q", got, "v0\n")
+	}
+}
@@ -0,0 +1,419 @@
+package toolset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Gurpartap/agentframe/agent"
+)
+
+// WorkspaceMode selects how a run's private workspace is provisioned from the
+// template root.
+type WorkspaceMode string
+
+const (
+	// WorkspaceModeCopy copies the template tree, including .git.
+	WorkspaceModeCopy WorkspaceMode = "copy"
+	// WorkspaceModeWorktree adds a detached git worktree at the template's
+	// HEAD; uncommitted template changes are not included.
+	WorkspaceModeWorktree WorkspaceMode = "worktree"
+	// WorkspaceModeOverlay mounts the template read-only under a per-run
+	// upper directory with fuse-overlayfs.
+	WorkspaceModeOverlay WorkspaceMode = "overlay"
+
+	// workspaceCommandTimeout bounds git and mount helpers.
+	workspaceCommandTimeout = 2 * time.Minute
+)
+
+var (
+	ErrWorkspaceConfig      = errors.New("workspace config is invalid")
+	ErrWorkspaceUnavailable = errors.New("workspace mode is unavailable")
+	ErrWorkspaceNotFound    = errors.New("run workspace not found")
+	ErrWorkspaceRunRequired = errors.New("isolated workspaces require a run id")
+)
+
+// WorkspacesConfig describes per-run workspace provisioning.
+type WorkspacesConfig struct {
+	Mode WorkspaceMode
+	// TemplateRoot is the directory every run workspace starts from.
+	TemplateRoot string
+	// Dir holds the run workspaces, one subdirectory per run.
+	Dir string
+}
+
+// Workspaces provisions an isolated workspace per run on first use and
+// removes it on Release.
+type Workspaces struct {
+	mode     WorkspaceMode
+	template string
+	dir      string
+	// unmount is the fusermount binary in overlay mode.
+	unmount string
+
+	mu   sync.Mutex
+	runs map[agent.RunID]*runWorkspace
+}
+
+type runWorkspace struct {
+	mu       sync.Mutex
+	base     string
+	root     string
+	ready    bool
+	released bool
+}
+
+// NewWorkspaces validates cfg and checks that the selected mode can work on
+// this host.
+func NewWorkspaces(cfg WorkspacesConfig) (*Workspaces, error) {
+	template, err := filepath.Abs(strings.TrimSpace(cfg.TemplateRoot))
+	if err != nil || strings.TrimSpace(cfg.TemplateRoot) == "" {
+		return nil, fmt.Errorf("new workspaces: %w: field=template_root reason=invalid", ErrWorkspaceConfig)
+	}
+	if info, err := os.Stat(template); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("new workspaces: %w: field=template_root reason=not_a_directory path=%q", ErrWorkspaceConfig, template)
+	}
+	if strings.TrimSpace(cfg.Dir) == "" {
+		return nil, fmt.Errorf("new workspaces: %w: field=dir reason=empty", ErrWorkspaceConfig)
+	}
+	dir, err := filepath.Abs(cfg.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("new workspaces: resolve directory: %w", err)
+	}
+	if hasPathPrefix(template, dir) {
+		return nil, fmt.Errorf("new workspaces: %w: field=dir reason=inside_template", ErrWorkspaceConfig)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("new workspaces: create directory: %w", err)
+	}
+
+	workspaces := &Workspaces{mode: cfg.Mode, template: template, dir: dir, runs: map[agent.RunID]*runWorkspace{}}
+	switch cfg.Mode {
+	case WorkspaceModeCopy:
+	case WorkspaceModeWorktree:
+		if _, err := runWorkspaceCommand(template, "git", "rev-parse", "--verify", "HEAD"); err != nil {
+			return nil, fmt.Errorf("new workspaces: %w: template is not a git repository with commits: %w", ErrWorkspaceUnavailable, err)
+		}
+	case WorkspaceModeOverlay:
+		if _, err := exec.LookPath("fuse-overlayfs"); err != nil {
+			return nil, fmt.Errorf("new workspaces: %w: %w", ErrWorkspaceUnavailable, err)
+		}
+		for _, name := range []string{"fusermount3", "fusermount"} {
+			if path, err := exec.LookPath(name); err == nil {
+				workspaces.unmount = path
+				break
+			}
+		}
+		if workspaces.unmount == "" {
+			return nil, fmt.Errorf("new workspaces: %w: fusermount not found", ErrWorkspaceUnavailable)
+		}
+	default:
+		return nil, fmt.Errorf("new workspaces: %w: field=mode reason=unsupported value=%q", ErrWorkspaceConfig, cfg.Mode)
+	}
+	return workspaces, nil
+}
+
+// Acquire returns the workspace root of runID, provisioning it on first use.
+func (w *Workspaces) Acquire(runID agent.RunID) (string, error) {
+	w.mu.Lock()
+	run, ok := w.runs[runID]
+	if !ok {
+		base := filepath.Join(w.dir, url.PathEscape(string(runID)))
+		run = &runWorkspace{base: base, root: filepath.Join(base, "workspace")}
+		w.runs[runID] = run
+	}
+	w.mu.Unlock()
+
+	// Provisioning runs under the run's own lock so one slow copy does not
+	// block other runs.
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	if run.released {
+		return "", fmt.Errorf("%w: run_id=%q", ErrWorkspaceNotFound, runID)
+	}
+	if run.ready {
+		return run.root, nil
+	}
+	if err := w.provision(run); err != nil {
+		_ = w.remove(run)
+		return "", fmt.Errorf("provision workspace run_id=%q mode=%s: %w", runID, w.mode, err)
+	}
+	// Policies resolve symlinks in the root; keep paths comparable.
+	if resolved, err := filepath.EvalSymlinks(run.root); err == nil {
+		run.root = resolved
+	}
+	run.ready = true
+	return run.root, nil
+}
+
+// Root returns the workspace root of runID when it has been provisioned.
+func (w *Workspaces) Root(runID agent.RunID) (string, error) {
+	w.mu.Lock()
+	run, ok := w.runs[runID]
+	w.mu.Unlock()
+	if ok {
+		run.mu.Lock()
+		defer run.mu.Unlock()
+		if run.ready && !run.released {
+			return run.root, nil
+		}
+	}
+	return "", fmt.Errorf("%w: run_id=%q", ErrWorkspaceNotFound, runID)
+}
+
+// Release removes the workspace of runID. Releasing an unknown run is a
+// no-op.
+func (w *Workspaces) Release(runID agent.RunID) error {
+	w.mu.Lock()
+	run, ok := w.runs[runID]
+	w.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	if run.released {
+		return nil
+	}
+	run.released = true
+	if !run.ready {
+		return nil
+	}
+	if err := w.remove(run); err != nil {
+		return fmt.Errorf("release workspace run_id=%q: %w", runID, err)
+	}
+	return nil
+}
+
+// Close releases every workspace.
+func (w *Workspaces) Close() error {
+	w.mu.Lock()
+	runIDs := make([]agent.RunID, 0, len(w.runs))
+	for runID := range w.runs {
+		runIDs = append(runIDs, runID)
+	}
+	w.mu.Unlock()
+
+	var result error
+	for _, runID := range runIDs {
+		result = errors.Join(result, w.Release(runID))
+	}
+	return result
+}
+
+func (w *Workspaces) provision(run *runWorkspace) error {
+	if err := os.MkdirAll(run.base, 0o755); err != nil {
+		return err
+	}
+	switch w.mode {
+	case WorkspaceModeCopy:
+		return copyTree(w.template, run.root)
+	case WorkspaceModeWorktree:
+		_, err := runWorkspaceCommand(w.template, "git", "worktree", "add", "--detach", "--quiet", run.root, "HEAD")
+		return err
+	case WorkspaceModeOverlay:
+		upper, work := filepath.Join(run.base, "upper"), filepath.Join(run.base, "work")
+		for _, dir := range []string{upper, work, run.root} {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return err
+			}
+		}
+		options := fmt.Sprintf("lowerdir=%s,upperdir=%s,workdir=%s", w.template, upper, work)
+		_, err := runWorkspaceCommand(run.base, "fuse-overlayfs", "-o", options, run.root)
+		return err
+	}
+	return fmt.Errorf("%w: mode=%q", ErrWorkspaceConfig, w.mode)
+}
+
+func (w *Workspaces) remove(run *runWorkspace) error {
+	var result error
+	switch w.mode {
+	case WorkspaceModeWorktree:
+		if _, err := runWorkspaceCommand(w.template, "git", "worktree", "remove", "--force", run.root); err != nil {
+			result = errors.Join(result, err)
+		}
+	case WorkspaceModeOverlay:
+		if _, err := runWorkspaceCommand(run.base, w.unmount, "-u", run.root); err != nil {
+			result = errors.Join(result, err)
+		}
+	}
+	if err := os.RemoveAll(run.base); err != nil {
+		result = errors.Join(result, err)
+	}
+	if w.mode == WorkspaceModeWorktree {
+		_, _ = runWorkspaceCommand(w.template, "git", "worktree", "prune")
+	}
+	return result
+}
+
+func runWorkspaceCommand(dir, name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), workspaceCommandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "LC_ALL=C")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%s %s: %w output=%q", name, args[0], err, strings.TrimSpace(string(output)))
+	}
+	return string(output), nil
+}
+
+// copyTree copies regular files, directories and symlinks from src to dst,
+// preserving permissions. Other file types are skipped.
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case entry.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0o700)
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.Mode().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+		}
+		return nil
+	})
+}
+
+func copyFile(src, dst string, mode fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// RunExecutor gives every run its own Executor bound to the run's workspace.
+// It is also an agent.EventSink: when a run completes, fails or is cancelled
+// its processes are stopped and its workspace is removed.
+type RunExecutor struct {
+	workspaces  *Workspaces
+	newExecutor func(workspaceRoot string) (*Executor, error)
+
+	mu        sync.Mutex
+	executors map[agent.RunID]*Executor
+}
+
+// NewRunExecutor dispatches tool calls to executors built by newExecutor for
+// each run's workspace.
+func NewRunExecutor(workspaces *Workspaces, newExecutor func(workspaceRoot string) (*Executor, error)) (*RunExecutor, error) {
+	if workspaces == nil || newExecutor == nil {
+		return nil, fmt.Errorf("new run executor: %w: workspaces and executor factory are required", ErrWorkspaceConfig)
+	}
+	return &RunExecutor{workspaces: workspaces, newExecutor: newExecutor, executors: map[agent.RunID]*Executor{}}, nil
+}
+
+func (r *RunExecutor) Execute(ctx context.Context, call agent.ToolCall) (agent.ToolResult, error) {
+	if ctx == nil {
+		return agent.ToolResult{}, agent.ErrContextNil
+	}
+	runID, ok := agent.RunIDFromContext(ctx)
+	if !ok {
+		return agent.ToolResult{}, ErrWorkspaceRunRequired
+	}
+	executor, err := r.executor(runID)
+	if err != nil {
+		return agent.ToolResult{}, err
+	}
+	return executor.Execute(ctx, call)
+}
+
+func (r *RunExecutor) executor(runID agent.RunID) (*Executor, error) {
+	r.mu.Lock()
+	executor, ok := r.executors[runID]
+	r.mu.Unlock()
+	if ok {
+		return executor, nil
+	}
+
+	root, err := r.workspaces.Acquire(runID)
+	if err != nil {
+		return nil, err
+	}
+	executor, err = r.newExecutor(root)
+	if err != nil {
+		return nil, fmt.Errorf("new executor run_id=%q: %w", runID, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.executors[runID]; ok {
+		return existing, nil
+	}
+	r.executors[runID] = executor
+	return executor, nil
+}
+
+// Publish releases the workspace of runs that reached a terminal event.
+func (r *RunExecutor) Publish(ctx context.Context, event agent.Event) error {
+	if ctx == nil {
+		return agent.ErrContextNil
+	}
+	if runEnded(event) {
+		go func() { _ = r.Release(event.RunID) }()
+	}
+	return nil
+}
+
+// runEnded reports whether event ends its run for good. Engines also publish
+// run_failed when a run exceeds its max steps, but such a run can be
+// continued, so its processes and workspace are kept.
+func runEnded(event agent.Event) bool {
+	switch event.Type {
+	case agent.EventTypeRunCompleted, agent.EventTypeRunCancelled:
+		return true
+	case agent.EventTypeRunFailed:
+		return !strings.Contains(event.Description, agent.ErrMaxStepsExceeded.Error())
+	default:
+		return false
+	}
+}
+
+// Release stops the run's processes and removes its workspace.
+func (r *RunExecutor) Release(runID agent.RunID) error {
+	r.mu.Lock()
+	executor, ok := r.executors[runID]
+	delete(r.executors, runID)
+	r.mu.Unlock()
+
+	if ok {
+		executor.processes.StopRun(runID)
+	}
+	return r.workspaces.Release(runID)
+}
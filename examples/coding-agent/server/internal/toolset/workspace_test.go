@@ -0,0 +1,254 @@
+package toolset_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gurpartap/agentframe/agent"
+	"github.com/Gurpartap/agentframe/agentreact"
+	"github.com/Gurpartap/agentframe/examples/coding-agent/server/internal/toolset"
+	runstoreinmem "github.com/Gurpartap/agentframe/runstore/inmem"
+)
+
+func newRunExecutor(t *testing.T, cfg toolset.WorkspacesConfig) (*toolset.RunExecutor, *toolset.Snapshots) {
+	t.Helper()
+
+	workspaces, err := toolset.NewWorkspaces(cfg)
+	if err != nil {
+		t.Fatalf("new workspaces: %v", err)
+	}
+	t.Cleanup(func() { _ = workspaces.Close() })
+	snapshots, err := toolset.NewWorkspaceSnapshots(t.TempDir(), workspaces)
+	if err != nil {
+		t.Fatalf("new snapshots: %v", err)
+	}
+	executor, err := toolset.NewRunExecutor(workspaces, func(root string) (*toolset.Executor, error) {
+		policy, err := toolset.NewPolicy(root, 5*time.Second)
+		if err != nil {
+			return nil, err
+		}
+		return toolset.NewExecutor(policy, toolset.WithSnapshots(snapshots)), nil
+	})
+	if err != nil {
+		t.Fatalf("new run executor: %v", err)
+	}
+	return executor, snapshots
+}
+
+func TestRunExecutorCopyWorkspacesAreIsolated(t *testing.T) {
+	t.Parallel()
+
+	template := t.TempDir()
+	if err := os.WriteFile(filepath.Join(template, "notes.txt"), []byte("template\n"), 0o644); err != nil {
+		t.Fatalf("write template: %v", err)
+	}
+	dir := t.TempDir()
+	executor, snapshots := newRunExecutor(t, toolset.WorkspacesConfig{Mode: toolset.WorkspaceModeCopy, TemplateRoot: template, Dir: dir})
+
+	write := func(runID agent.RunID, content string) {
+		t.Helper()
+		ctx := agent.WithStep(agent.WithRunID(context.Background(), runID), 1)
+		if _, err := executor.Execute(ctx, agent.ToolCall{
+			ID: "write-" + string(runID), Name: toolset.ToolWrite, Arguments: map[string]any{"path": "notes.txt", "content": content},
+		}); err != nil {
+			t.Fatalf("write in %s: %v", runID, err)
+		}
+	}
+	read := func(runID agent.RunID) string {
+		t.Helper()
+		result, err := executor.Execute(agent.WithRunID(context.Background(), runID), agent.ToolCall{
+			ID: "read-" + string(runID), Name: toolset.ToolRead, Arguments: map[string]any{"path": "notes.txt"},
+		})
+		if err != nil {
+			t.Fatalf("read in %s: %v", runID, err)
+		}
+		return result.Content
+	}
+	write("run-a", "run a\n")
+	write("run-b", "run b\n")
+	if got := read("run-a"); !strings.HasSuffix(got, "\trun a") {
+		t.Fatalf("run-a content mismatch: %q", got)
+	}
+	if got := read("run-b"); !strings.HasSuffix(got, "\trun b") {
+		t.Fatalf("run-b content mismatch: %q", got)
+	}
+	if data, _ := os.ReadFile(filepath.Join(template, "notes.txt")); string(data) != "template\n" {
+		t.Fatalf("template must be untouched: %q", data)
+	}
+
+	restored, err := snapshots.Rollback("run-a", 0)
+	if err != nil {
+		t.Fatalf("rollback run-a: %v", err)
+	}
+	if len(restored) != 1 || !strings.HasSuffix(read("run-a"), "\ttemplate") {
+		t.Fatalf("run-a rollback mismatch: restored=%v content=%q", restored, read("run-a"))
+	}
+	if got := read("run-b"); !strings.HasSuffix(got, "\trun b") {
+		t.Fatalf("run-b must be unaffected by run-a rollback: %q", got)
+	}
+
+	if err := executor.Publish(context.Background(), agent.Event{RunID: "run-a", Type: agent.EventTypeRunCompleted}); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	runDir := filepath.Join(dir, "run-a")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(runDir); os.IsNotExist(err) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("workspace %s not removed after run_completed", runDir)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := snapshots.Rollback("run-a", 0); !errors.Is(err, toolset.ErrWorkspaceNotFound) {
+		t.Fatalf("expected ErrWorkspaceNotFound after release, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "run-b", "workspace", "notes.txt")); err != nil {
+		t.Fatalf("run-b workspace must survive: %v", err)
+	}
+
+	if _, err := executor.Execute(context.Background(), agent.ToolCall{ID: "no-run", Name: toolset.ToolRead, Arguments: map[string]any{"path": "notes.txt"}}); !errors.Is(err, toolset.ErrWorkspaceRunRequired) {
+		t.Fatalf("expected ErrWorkspaceRunRequired, got %v", err)
+	}
+}
+
+type fixedRunID agent.RunID
+
+func (id fixedRunID) NewRunID(context.Context) (agent.RunID, error) {
+	return agent.RunID(id), nil
+}
+
+// readingModel asks to read notes.txt on every step, ignoring tool choice, so
+// runs end by exceeding their step budget.
+type readingModel struct {
+	calls int
+}
+
+func (m *readingModel) Generate(context.Context, agentreact.ModelRequest) (agent.Message, error) {
+	m.calls++
+	return agent.Message{
+		Role: agent.RoleAssistant,
+		ToolCalls: []agent.ToolCall{{
+			ID: fmt.Sprintf("read-%d", m.calls), Name: toolset.ToolRead, Arguments: map[string]any{"path": "notes.txt"},
+		}},
+	}, nil
+}
+
+func TestRunExecutorKeepsWorkspaceAfterMaxSteps(t *testing.T) {
+	t.Parallel()
+
+	template := t.TempDir()
+	if err := os.WriteFile(filepath.Join(template, "notes.txt"), []byte("template\n"), 0o644); err != nil {
+		t.Fatalf("write template: %v", err)
+	}
+	dir := t.TempDir()
+	executor, snapshots := newRunExecutor(t, toolset.WorkspacesConfig{Mode: toolset.WorkspaceModeCopy, TemplateRoot: template, Dir: dir})
+	store := runstoreinmem.New()
+	loop, err := agentreact.New(&readingModel{}, executor, executor)
+	if err != nil {
+		t.Fatalf("new loop: %v", err)
+	}
+	runner, err := agent.NewRunner(agent.Dependencies{
+		IDGenerator: fixedRunID("run-max"),
+		RunStore:    store,
+		Engine:      loop,
+		EventSink:   executor,
+	})
+	if err != nil {
+		t.Fatalf("new runner: %v", err)
+	}
+	tools := toolset.Definitions()
+
+	result, err := runner.Run(context.Background(), agent.RunInput{UserPrompt: "read notes", MaxSteps: 1, Tools: tools})
+	if !errors.Is(err, agent.ErrMaxStepsExceeded) || result.State.Status != agent.RunStatusMaxStepsExceeded {
+		t.Fatalf("run mismatch: status=%s err=%v", result.State.Status, err)
+	}
+	// Release runs asynchronously; give a wrongly scheduled one time to land.
+	time.Sleep(100 * time.Millisecond)
+
+	result, err = runner.Continue(context.Background(), "run-max", 1, tools, nil)
+	if !errors.Is(err, agent.ErrMaxStepsExceeded) {
+		t.Fatalf("continue error mismatch: got=%v want=%v", err, agent.ErrMaxStepsExceeded)
+	}
+	last := result.State.Messages[len(result.State.Messages)-1]
+	if last.Role != agent.RoleTool || !strings.HasSuffix(last.Content, "\ttemplate") {
+		t.Fatalf("tool call after continue must succeed: role=%s content=%q", last.Role, last.Content)
+	}
+	if _, err := snapshots.Rollback("run-max", 0); err != nil {
+		t.Fatalf("rollback after max steps: %v", err)
+	}
+}
+
+func TestRunExecutorWorktreeWorkspace(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	template := t.TempDir()
+	if err := os.WriteFile(filepath.Join(template, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatalf("write template: %v", err)
+	}
+	for _, args := range [][]string{
+		{"init", "--quiet", "--initial-branch=main"},
+		{"add", "main.go"},
+		{"-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "init"},
+	} {
+		runGit(t, template, args...)
+	}
+	if err := os.WriteFile(filepath.Join(template, "dirty.txt"), []byte("uncommitted\n"), 0o644); err != nil {
+		t.Fatalf("write dirty file: %v", err)
+	}
+
+	dir := t.TempDir()
+	executor, _ := newRunExecutor(t, toolset.WorkspacesConfig{Mode: toolset.WorkspaceModeWorktree, TemplateRoot: template, Dir: dir})
+	ctx := agent.WithRunID(context.Background(), "run-wt")
+	result, err := executor.Execute(ctx, agent.ToolCall{ID: "status", Name: toolset.ToolGitStatus})
+	if err != nil {
+		t.Fatalf("git_status: %v", err)
+	}
+	if !strings.Contains(result.Content, "## HEAD (no branch)") || strings.Contains(result.Content, "dirty.txt") {
+		t.Fatalf("worktree status mismatch: %q", result.Content)
+	}
+	if _, err := executor.Execute(ctx, agent.ToolCall{ID: "branch", Name: toolset.ToolGitBranch}); err != nil {
+		t.Fatalf("git_branch: %v", err)
+	}
+
+	if err := executor.Release("run-wt"); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "run-wt")); !os.IsNotExist(err) {
+		t.Fatalf("worktree directory must be removed: %v", err)
+	}
+	if worktrees := runGit(t, template, "worktree", "list"); strings.Count(worktrees, "\n") != 1 {
+		t.Fatalf("worktree must be pruned: %q", worktrees)
+	}
+}
+
+func TestNewWorkspacesRejectsInvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	template := t.TempDir()
+	testCases := []struct {
+		cfg  toolset.WorkspacesConfig
+		want error
+	}{
+		{cfg: toolset.WorkspacesConfig{Mode: "zfs", TemplateRoot: template, Dir: t.TempDir()}, want: toolset.ErrWorkspaceConfig},
+		{cfg: toolset.WorkspacesConfig{Mode: toolset.WorkspaceModeCopy, TemplateRoot: template, Dir: filepath.Join(template, "runs")}, want: toolset.ErrWorkspaceConfig},
+		{cfg: toolset.WorkspacesConfig{Mode: toolset.WorkspaceModeCopy, TemplateRoot: filepath.Join(template, "missing"), Dir: t.TempDir()}, want: toolset.ErrWorkspaceConfig},
+		{cfg: toolset.WorkspacesConfig{Mode: toolset.WorkspaceModeWorktree, TemplateRoot: template, Dir: t.TempDir()}, want: toolset.ErrWorkspaceUnavailable},
+	}
+	for _, tc := range testCases {
+		if _, err := toolset.NewWorkspaces(tc.cfg); !errors.Is(err, tc.want) {
+			t.Fatalf("config %+v: expected %v, got %v", tc.cfg, tc.want, err)
+		}
+	}
+}
@@ -1,12 +1,16 @@
 package toolset
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+
+	"github.com/Gurpartap/agentframe/agent"
 )
 
-func (e *Executor) executeWrite(arguments map[string]any) (string, error) {
+func (e *Executor) executeWrite(ctx context.Context, call agent.ToolCall) (string, error) {
+	arguments := call.Arguments
 	path, err := stringArgument(arguments, "path")
 	if err != nil {
 		return "", err
@@ -16,11 +20,17 @@ func (e *Executor) executeWrite(arguments map[string]any) (string, error) {
 		return "", err
 	}
 
-	resolved, err := e.policy.ResolvePath(path)
+	resolved, err := e.policy.ResolveWritablePath(path)
 	if err != nil {
 		return "", err
 	}
 
+	if err := e.checkFileFresh(ctx, call, path, resolved); err != nil {
+		return "", err
+	}
+	if err := e.snapshot(ctx, call, resolved); err != nil {
+		return "", fmt.Errorf("write %q: %w", path, err)
+	}
 	if err := os.MkdirAll(filepath.Dir(resolved), 0o755); err != nil {
 		return "", fmt.Errorf("write %q: create parent directory: %w", path, err)
 	}
@@ -28,6 +38,7 @@ func (e *Executor) executeWrite(arguments map[string]any) (string, error) {
 	if err := os.WriteFile(resolved, []byte(content), 0o644); err != nil {
 		return "", fmt.Errorf("write %q: %w", path, err)
 	}
+	e.rememberContent(ctx, resolved, []byte(content))
 
 	return fmt.Sprintf("write_ok path=%s bytes=%d", path, len(content)), nil
 }
@@ -0,0 +1,318 @@
+// Package enginekit holds the event, run lifecycle and tool call contract
+// helpers shared by the agentreact, agentgraph and agentplan engines.
+package enginekit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Gurpartap/agentframe/agent"
+)
+
+// NoopEventSink discards events; engines use it when no sink is configured.
+type NoopEventSink struct{}
+
+func (NoopEventSink) Publish(context.Context, agent.Event) error {
+	return nil
+}
+
+// PublishEvent validates event and publishes it, wrapping sink failures in
+// agent.ErrEventPublish.
+func PublishEvent(ctx context.Context, sink agent.EventSink, event agent.Event) error {
+	if err := agent.ValidateEvent(event); err != nil {
+		return err
+	}
+	if err := sink.Publish(ctx, event); err != nil {
+		return errors.Join(
+			agent.ErrEventPublish,
+			fmt.Errorf(
+				"type=%s run_id=%s step=%d: %w",
+				event.Type,
+				event.RunID,
+				event.Step,
+				err,
+			),
+		)
+	}
+	return nil
+}
+
+func CloneResolution(in *agent.Resolution) *agent.Resolution {
+	if in == nil {
+		return nil
+	}
+	resolutionCopy := *in
+	return &resolutionCopy
+}
+
+// ResolutionMessage is the user message that tells the model how a pending
+// requirement was resolved.
+func ResolutionMessage(resolution *agent.Resolution) agent.Message {
+	if resolution == nil {
+		return agent.Message{}
+	}
+	content := fmt.Sprintf(
+		"[resolution] requirement_id=%q kind=%s outcome=%s",
+		resolution.RequirementID,
+		resolution.Kind,
+		resolution.Outcome,
+	)
+	if resolution.Value != "" {
+		content = fmt.Sprintf("%s value=%q", content, resolution.Value)
+	}
+	return agent.Message{
+		Role:    agent.RoleUser,
+		Content: content,
+	}
+}
+
+// ContextCancellationError returns the cancellation cause when ctx is done or
+// err is a context error, and nil otherwise.
+func ContextCancellationError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return context.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return context.DeadlineExceeded
+	default:
+		return nil
+	}
+}
+
+// ToolErrorResult is the error observation recorded for a failed tool call.
+func ToolErrorResult(call agent.ToolCall, reason agent.ToolFailureReason, err error) agent.ToolResult {
+	message := string(reason)
+	if err != nil {
+		message = fmt.Sprintf("%s: %s", reason, err.Error())
+	}
+	return agent.ToolResult{
+		CallID:        call.ID,
+		Name:          call.Name,
+		Content:       message,
+		IsError:       true,
+		FailureReason: reason,
+	}
+}
+
+func ValidateToolResultIdentity(call agent.ToolCall, result agent.ToolResult) error {
+	if result.CallID != "" && result.CallID != call.ID {
+		return fmt.Errorf("tool result call id mismatch: got=%q want=%q", result.CallID, call.ID)
+	}
+	if result.Name != "" && result.Name != call.Name {
+		return fmt.Errorf("tool result name mismatch: got=%q want=%q", result.Name, call.Name)
+	}
+	return nil
+}
+
+// ValidateToolCallShape requires non-empty, unique call IDs and non-empty
+// names. Failures wrap invalid, the calling engine's sentinel.
+func ValidateToolCallShape(calls []agent.ToolCall, invalid error) error {
+	seen := make(map[string]int, len(calls))
+	for i, call := range calls {
+		if call.ID == "" {
+			return fmt.Errorf("%w: index=%d reason=empty_id", invalid, i)
+		}
+		if call.Name == "" {
+			return fmt.Errorf("%w: index=%d id=%q reason=empty_name", invalid, i, call.ID)
+		}
+		if firstIndex, exists := seen[call.ID]; exists {
+			return fmt.Errorf(
+				"%w: index=%d id=%q reason=duplicate_id first_index=%d",
+				invalid,
+				i,
+				call.ID,
+				firstIndex,
+			)
+		}
+		seen[call.ID] = i
+	}
+	return nil
+}
+
+func ValidateModelRequirement(state *agent.RunState, requirement *agent.PendingRequirement) error {
+	if requirement.Origin != agent.RequirementOriginModel {
+		return fmt.Errorf(
+			"%w: field=pending_requirement.origin reason=invalid_for_source source=model value=%q want=%q",
+			agent.ErrRunStateInvalid,
+			requirement.Origin,
+			agent.RequirementOriginModel,
+		)
+	}
+	return ValidateRequirementContract(state, requirement)
+}
+
+// ValidateToolSuspendRequest returns a copy of the requirement a tool asked to
+// suspend on, bound to call.
+func ValidateToolSuspendRequest(
+	state *agent.RunState,
+	call agent.ToolCall,
+	request *agent.SuspendRequestError,
+) (*agent.PendingRequirement, error) {
+	if request == nil || request.Requirement == nil {
+		return nil, fmt.Errorf(
+			"%w: field=pending_requirement reason=nil source=tool",
+			agent.ErrRunStateInvalid,
+		)
+	}
+	requirementCopy := *request.Requirement
+	if requirementCopy.Origin != agent.RequirementOriginTool {
+		return nil, fmt.Errorf(
+			"%w: field=pending_requirement.origin reason=invalid_for_source source=tool value=%q want=%q",
+			agent.ErrRunStateInvalid,
+			requirementCopy.Origin,
+			agent.RequirementOriginTool,
+		)
+	}
+	if requirementCopy.ToolCallID != "" && requirementCopy.ToolCallID != call.ID {
+		return nil, fmt.Errorf(
+			"%w: field=pending_requirement.tool_call_id reason=mismatch source=tool value=%q want=%q",
+			agent.ErrRunStateInvalid,
+			requirementCopy.ToolCallID,
+			call.ID,
+		)
+	}
+	requirementCopy.ToolCallID = call.ID
+	if err := ValidateRequirementContract(state, &requirementCopy); err != nil {
+		return nil, err
+	}
+	return &requirementCopy, nil
+}
+
+// ValidateRequirementContract checks requirement as the pending requirement of
+// state once suspended.
+func ValidateRequirementContract(state *agent.RunState, requirement *agent.PendingRequirement) error {
+	if state == nil {
+		return errors.New("requirement validation state is nil")
+	}
+	return agent.ValidateRunState(agent.RunState{
+		ID:                 state.ID,
+		Version:            state.Version,
+		Step:               state.Step,
+		Status:             agent.RunStatusSuspended,
+		PendingRequirement: requirement,
+	})
+}
+
+// ApprovedToolReplayCall returns the suspended tool call to execute once when
+// an approved tool-origin requirement is resumed with a matching override.
+func ApprovedToolReplayCall(
+	ctx context.Context,
+	state *agent.RunState,
+	input agent.EngineInput,
+) (agent.ToolCall, bool, error) {
+	if input.ResolvedRequirement == nil || input.Resolution == nil {
+		return agent.ToolCall{}, false, nil
+	}
+	requirement := input.ResolvedRequirement
+	resolution := input.Resolution
+	if requirement.Origin != agent.RequirementOriginTool {
+		return agent.ToolCall{}, false, nil
+	}
+	if resolution.Kind != agent.RequirementKindApproval || resolution.Outcome != agent.ResolutionOutcomeApproved {
+		return agent.ToolCall{}, false, nil
+	}
+	if requirement.Kind != agent.RequirementKindApproval {
+		return agent.ToolCall{}, false, fmt.Errorf(
+			"%w: field=resolved_requirement.kind reason=invalid_for_approved_tool_replay got=%q want=%q",
+			agent.ErrRunStateInvalid,
+			requirement.Kind,
+			agent.RequirementKindApproval,
+		)
+	}
+	if err := ValidateRequirementContract(state, requirement); err != nil {
+		return agent.ToolCall{}, false, err
+	}
+	if resolution.RequirementID != requirement.ID {
+		return agent.ToolCall{}, false, fmt.Errorf(
+			"%w: field=resolution.requirement_id reason=mismatch got=%q want=%q",
+			agent.ErrRunStateInvalid,
+			resolution.RequirementID,
+			requirement.ID,
+		)
+	}
+	call, found := FindToolCallByID(state.Messages, requirement.ToolCallID)
+	if !found {
+		return agent.ToolCall{}, false, fmt.Errorf(
+			"%w: field=resolved_requirement.tool_call_id reason=not_found value=%q",
+			agent.ErrRunStateInvalid,
+			requirement.ToolCallID,
+		)
+	}
+	override, ok := agent.ApprovedToolCallReplayOverrideFromContext(ctx)
+	if !ok {
+		return agent.ToolCall{}, false, fmt.Errorf(
+			"%w: field=approved_tool_replay_override reason=missing",
+			agent.ErrRunStateInvalid,
+		)
+	}
+	if override.ToolCallID != requirement.ToolCallID {
+		return agent.ToolCall{}, false, fmt.Errorf(
+			"%w: field=approved_tool_replay_override.tool_call_id reason=mismatch got=%q want=%q",
+			agent.ErrRunStateInvalid,
+			override.ToolCallID,
+			requirement.ToolCallID,
+		)
+	}
+	if override.Fingerprint != requirement.Fingerprint {
+		return agent.ToolCall{}, false, fmt.Errorf(
+			"%w: field=approved_tool_replay_override.fingerprint reason=mismatch got=%q want=%q",
+			agent.ErrRunStateInvalid,
+			override.Fingerprint,
+			requirement.Fingerprint,
+		)
+	}
+	return call, true, nil
+}
+
+// ExecuteApprovedToolReplay runs the approved call once. Tool errors become
+// error observations; a second suspension request is a contract violation.
+func ExecuteApprovedToolReplay(
+	ctx context.Context,
+	execute func(context.Context, agent.ToolCall) (agent.ToolResult, error),
+	call agent.ToolCall,
+) (agent.ToolResult, error) {
+	replayed, replayErr := execute(ctx, call)
+	if replayErr != nil {
+		if cancellationErr := ContextCancellationError(ctx, replayErr); cancellationErr != nil {
+			return agent.ToolResult{}, cancellationErr
+		}
+		var suspendRequestErr *agent.SuspendRequestError
+		if errors.As(replayErr, &suspendRequestErr) {
+			return agent.ToolResult{}, fmt.Errorf(
+				"%w: field=replay_tool_result reason=suspend_request_forbidden call_id=%q",
+				agent.ErrRunStateInvalid,
+				call.ID,
+			)
+		}
+		return ToolErrorResult(call, agent.ToolErrorFailureReason(replayErr), replayErr), nil
+	}
+	if identityErr := ValidateToolResultIdentity(call, replayed); identityErr != nil {
+		return ToolErrorResult(call, agent.ToolFailureReasonExecutorError, identityErr), nil
+	}
+	return replayed, nil
+}
+
+// FindToolCallByID returns a copy of the most recent assistant tool call with
+// the given ID.
+func FindToolCallByID(messages []agent.Message, toolCallID string) (agent.ToolCall, bool) {
+	if toolCallID == "" {
+		return agent.ToolCall{}, false
+	}
+	for i := len(messages) - 1; i >= 0; i-- {
+		message := messages[i]
+		if message.Role != agent.RoleAssistant {
+			continue
+		}
+		for j := len(message.ToolCalls) - 1; j >= 0; j-- {
+			call := message.ToolCalls[j]
+			if call.ID == toolCallID {
+				return agent.CloneToolCall(call), true
+			}
+		}
+	}
+	return agent.ToolCall{}, false
+}
@@ -0,0 +1,94 @@
+package enginekit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Gurpartap/agentframe/agent"
+	"github.com/Gurpartap/agentframe/internal/enginekit"
+)
+
+func TestApprovedToolReplayCall(t *testing.T) {
+	t.Parallel()
+
+	state := agent.RunState{
+		ID:      "run-1",
+		Version: 2,
+		Step:    1,
+		Status:  agent.RunStatusRunning,
+		Messages: []agent.Message{
+			{Role: agent.RoleAssistant, ToolCalls: []agent.ToolCall{{ID: "call-1", Name: "deploy"}}},
+		},
+	}
+	requirement := agent.PendingRequirement{
+		ID:          "req-1",
+		Kind:        agent.RequirementKindApproval,
+		Origin:      agent.RequirementOriginTool,
+		ToolCallID:  "call-1",
+		Fingerprint: "fp-1",
+	}
+	approved := agent.Resolution{
+		RequirementID: "req-1",
+		Kind:          agent.RequirementKindApproval,
+		Outcome:       agent.ResolutionOutcomeApproved,
+	}
+	withOverride := func(toolCallID, fingerprint string) context.Context {
+		return agent.WithApprovedToolCallReplayOverride(context.Background(), agent.ApprovedToolCallReplayOverride{
+			ToolCallID:  toolCallID,
+			Fingerprint: fingerprint,
+		})
+	}
+	rejected := approved
+	rejected.Outcome = agent.ResolutionOutcomeRejected
+	wrongKind := requirement
+	wrongKind.Kind = agent.RequirementKindUserInput
+
+	testCases := []struct {
+		name        string
+		ctx         context.Context
+		requirement agent.PendingRequirement
+		resolution  agent.Resolution
+		wantReplay  bool
+		wantErr     error
+	}{
+		{name: "approved", ctx: withOverride("call-1", "fp-1"), requirement: requirement, resolution: approved, wantReplay: true},
+		{name: "rejected", ctx: withOverride("call-1", "fp-1"), requirement: requirement, resolution: rejected},
+		{name: "missing override", ctx: context.Background(), requirement: requirement, resolution: approved, wantErr: agent.ErrRunStateInvalid},
+		{name: "stale fingerprint", ctx: withOverride("call-1", "fp-0"), requirement: requirement, resolution: approved, wantErr: agent.ErrRunStateInvalid},
+		{name: "non-approval requirement", ctx: withOverride("call-1", "fp-1"), requirement: wrongKind, resolution: approved, wantErr: agent.ErrRunStateInvalid},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			state := agent.CloneRunState(state)
+			call, replay, err := enginekit.ApprovedToolReplayCall(tc.ctx, &state, agent.EngineInput{
+				ResolvedRequirement: &tc.requirement,
+				Resolution:          &tc.resolution,
+			})
+			if !errors.Is(err, tc.wantErr) || (tc.wantErr == nil && err != nil) {
+				t.Fatalf("error mismatch: got=%v want=%v", err, tc.wantErr)
+			}
+			if replay != tc.wantReplay {
+				t.Fatalf("replay mismatch: got=%v want=%v", replay, tc.wantReplay)
+			}
+			if replay && call.ID != "call-1" {
+				t.Fatalf("call id mismatch: got=%q want=%q", call.ID, "call-1")
+			}
+		})
+	}
+}
+
+func TestValidateToolCallShapeWrapsSentinel(t *testing.T) {
+	t.Parallel()
+
+	invalid := errors.New("engine tool call is invalid")
+	err := enginekit.ValidateToolCallShape([]agent.ToolCall{
+		{ID: "call-1", Name: "lookup"},
+		{ID: "call-1", Name: "lookup"},
+	}, invalid)
+	if !errors.Is(err, invalid) {
+		t.Fatalf("expected sentinel, got %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package enginekit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Gurpartap/agentframe/agent"
+)
+
+// The terminal transitions below move state to its final status, record the
+// error and publish the terminal event. They return the run error joined with
+// eventErr and any publish failure.
+
+func FailRun(ctx context.Context, events agent.EventSink, state agent.RunState, runErr error, eventErr error) (agent.RunState, error) {
+	if runErr == nil {
+		runErr = errors.New("run failed")
+	}
+	if transitionErr := agent.TransitionRunStatus(&state, agent.RunStatusFailed); transitionErr != nil {
+		return state, errors.Join(runErr, transitionErr, eventErr)
+	}
+	state.Error = runErr.Error()
+	eventErr = errors.Join(eventErr, PublishEvent(ctx, events, agent.Event{
+		RunID:       state.ID,
+		Step:        state.Step,
+		Type:        agent.EventTypeRunFailed,
+		Description: failureEventDescription(runErr),
+	}))
+	return state, errors.Join(runErr, eventErr)
+}
+
+func CancelRun(ctx context.Context, events agent.EventSink, state agent.RunState, runErr error, eventErr error) (agent.RunState, error) {
+	if runErr == nil {
+		runErr = context.Canceled
+	}
+	if transitionErr := agent.TransitionRunStatus(&state, agent.RunStatusCancelled); transitionErr != nil {
+		return state, errors.Join(runErr, transitionErr, eventErr)
+	}
+	state.Error = runErr.Error()
+	eventErr = errors.Join(eventErr, PublishEvent(ctx, events, agent.Event{
+		RunID:       state.ID,
+		Step:        state.Step,
+		Type:        agent.EventTypeRunCancelled,
+		Description: runErr.Error(),
+	}))
+	return state, errors.Join(runErr, eventErr)
+}
+
+func ExceedMaxSteps(ctx context.Context, events agent.EventSink, state agent.RunState, eventErr error) (agent.RunState, error) {
+	if err := agent.TransitionRunStatus(&state, agent.RunStatusMaxStepsExceeded); err != nil {
+		return state, errors.Join(agent.ErrMaxStepsExceeded, err, eventErr)
+	}
+	state.Error = agent.ErrMaxStepsExceeded.Error()
+	eventErr = errors.Join(eventErr, PublishEvent(ctx, events, agent.Event{
+		RunID:       state.ID,
+		Step:        state.Step,
+		Type:        agent.EventTypeRunFailed,
+		Description: failureEventDescription(agent.ErrMaxStepsExceeded),
+	}))
+	return state, errors.Join(agent.ErrMaxStepsExceeded, eventErr)
+}
+
+func failureEventDescription(runErr error) string {
+	if runErr == nil {
+		return "run failed"
+	}
+	return fmt.Sprintf("run failed: %v", runErr)
+}
@@ -1,4 +1,4 @@
-package agentreact
+package enginekit_test
 
 import (
 	"context"
@@ -6,13 +6,14 @@ import (
 	"testing"
 
 	"github.com/Gurpartap/agentframe/agent"
+	"github.com/Gurpartap/agentframe/internal/enginekit"
 )
 
 func TestPublishEventRejectsInvalidPayloadBeforeSink(t *testing.T) {
 	t.Parallel()
 
 	sink := &countingEventSink{}
-	err := publishEvent(context.Background(), sink, agent.Event{
+	err := enginekit.PublishEvent(context.Background(), sink, agent.Event{
 		RunID: "run-1",
 		Step:  1,
 		Type:  agent.EventTypeToolResult,
@@ -33,7 +34,7 @@ func TestPublishEventPublishesValidPayload(t *testing.T) {
 	t.Parallel()
 
 	sink := &countingEventSink{}
-	err := publishEvent(context.Background(), sink, agent.Event{
+	err := enginekit.PublishEvent(context.Background(), sink, agent.Event{
 		RunID: "run-1",
 		Step:  1,
 		Type:  agent.EventTypeToolResult,
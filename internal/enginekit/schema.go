@@ -0,0 +1,254 @@
+package enginekit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/Gurpartap/agentframe/agent"
+)
+
+// ValidateToolArguments checks arguments against the subset of JSON Schema
+// used by tool input schemas: required fields, additionalProperties and
+// property types.
+func ValidateToolArguments(schema map[string]any, arguments map[string]any) error {
+	if len(schema) == 0 {
+		return nil
+	}
+
+	required, err := parseRequiredFields(schema["required"])
+	if err != nil {
+		return err
+	}
+	for _, field := range required {
+		if _, ok := arguments[field]; !ok {
+			return fmt.Errorf("missing required argument %q", field)
+		}
+	}
+
+	properties, hasProperties := asStringAnyMap(schema["properties"])
+	additionalAllowed, err := parseAdditionalProperties(schema["additionalProperties"])
+	if err != nil {
+		return err
+	}
+
+	keys := sortedArgumentKeys(arguments)
+	for _, key := range keys {
+		value := arguments[key]
+		propertySchema, hasProperty := properties[key]
+		if !hasProperty {
+			if hasProperties && !additionalAllowed {
+				return fmt.Errorf("unknown argument %q", key)
+			}
+			continue
+		}
+
+		expectedType, hasType, err := parsePropertyType(propertySchema)
+		if err != nil {
+			return err
+		}
+		if !hasType {
+			continue
+		}
+		if !matchesToolArgumentType(expectedType, value) {
+			return fmt.Errorf("argument %q must be %q", key, expectedType)
+		}
+	}
+
+	return nil
+}
+
+func parseRequiredFields(raw any) ([]string, error) {
+	switch value := raw.(type) {
+	case nil:
+		return nil, nil
+	case []string:
+		out := make([]string, len(value))
+		copy(out, value)
+		return out, nil
+	case []any:
+		out := make([]string, 0, len(value))
+		for _, item := range value {
+			field, ok := item.(string)
+			if !ok {
+				return nil, errors.New(`input schema "required" entries must be strings`)
+			}
+			out = append(out, field)
+		}
+		return out, nil
+	default:
+		return nil, errors.New(`input schema "required" must be an array`)
+	}
+}
+
+func parseAdditionalProperties(raw any) (bool, error) {
+	switch value := raw.(type) {
+	case nil:
+		return true, nil
+	case bool:
+		return value, nil
+	default:
+		return false, errors.New(`input schema "additionalProperties" must be a bool`)
+	}
+}
+
+func parsePropertyType(propertySchema any) (string, bool, error) {
+	propertyMap, ok := asStringAnyMap(propertySchema)
+	if !ok {
+		return "", false, errors.New(`input schema "properties" entries must be objects`)
+	}
+	rawType, ok := propertyMap["type"]
+	if !ok {
+		return "", false, nil
+	}
+	typeName, ok := rawType.(string)
+	if !ok {
+		return "", false, errors.New(`input schema property "type" must be a string`)
+	}
+	return typeName, true, nil
+}
+
+func asStringAnyMap(raw any) (map[string]any, bool) {
+	switch value := raw.(type) {
+	case nil:
+		return nil, false
+	case map[string]any:
+		return value, true
+	default:
+		return nil, false
+	}
+}
+
+func sortedArgumentKeys(arguments map[string]any) []string {
+	keys := make([]string, 0, len(arguments))
+	for key := range arguments {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func matchesToolArgumentType(expected string, value any) bool {
+	switch expected {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		return isNumber(value)
+	case "integer":
+		return isInteger(value)
+	case "object":
+		if value == nil {
+			return false
+		}
+		if _, ok := value.(map[string]any); ok {
+			return true
+		}
+		return reflect.TypeOf(value).Kind() == reflect.Map
+	case "array":
+		if value == nil {
+			return false
+		}
+		kind := reflect.TypeOf(value).Kind()
+		return kind == reflect.Array || kind == reflect.Slice
+	default:
+		return true
+	}
+}
+
+func isNumber(value any) bool {
+	switch typed := value.(type) {
+	case int, int8, int16, int32, int64:
+		return true
+	case uint, uint8, uint16, uint32, uint64:
+		return true
+	case float32:
+		return isFinite(float64(typed))
+	case float64:
+		return isFinite(typed)
+	default:
+		return false
+	}
+}
+
+// isInteger accepts integral floats because tool arguments decoded from
+// provider JSON and structured final answers carry every number as float64;
+// without it no decoded value could satisfy an "integer" property.
+func isInteger(value any) bool {
+	switch typed := value.(type) {
+	case int, int8, int16, int32, int64:
+		return true
+	case uint, uint8, uint16, uint32, uint64:
+		return true
+	case float64:
+		return isFinite(typed) && typed == math.Trunc(typed)
+	default:
+		return false
+	}
+}
+
+// isFinite rejects NaN and ±Inf, which JSON cannot represent.
+func isFinite(value float64) bool {
+	return !math.IsNaN(value) && !math.IsInf(value, 0)
+}
+
+// ParseStructuredOutput decodes a final answer as a JSON object and validates it
+// against schema, tolerating surrounding whitespace and a Markdown code fence.
+// It returns the compacted object.
+func ParseStructuredOutput(content string, schema map[string]any) (json.RawMessage, error) {
+	trimmed := stripCodeFence(strings.TrimSpace(content))
+
+	var object map[string]any
+	if err := json.Unmarshal([]byte(trimmed), &object); err != nil {
+		return nil, fmt.Errorf("final answer is not a JSON object: %w", err)
+	}
+	if object == nil {
+		return nil, fmt.Errorf("final answer is not a JSON object: got null")
+	}
+	if err := ValidateToolArguments(schema, object); err != nil {
+		return nil, err
+	}
+
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, []byte(trimmed)); err != nil {
+		return nil, fmt.Errorf("final answer is not a JSON object: %w", err)
+	}
+	return compacted.Bytes(), nil
+}
+
+func stripCodeFence(content string) string {
+	if !strings.HasPrefix(content, "```") || !strings.HasSuffix(content, "```") {
+		return content
+	}
+	body := strings.TrimSuffix(content, "```")
+	newline := strings.IndexByte(body, '\n')
+	if newline < 0 {
+		return content
+	}
+	return strings.TrimSpace(body[newline+1:])
+}
+
+// OutputRepairMessage asks the model to answer again with a JSON object
+// matching schema.
+func OutputRepairMessage(schema map[string]any, validationErr error) agent.Message {
+	encodedSchema, err := json.Marshal(schema)
+	if err != nil {
+		encodedSchema = []byte("{}")
+	}
+	return agent.Message{
+		Role: agent.RoleUser,
+		Content: fmt.Sprintf(
+			"[output_validation] error=%q\nRespond with only a JSON object that matches this schema: %s",
+			validationErr.Error(),
+			encodedSchema,
+		),
+	}
+}
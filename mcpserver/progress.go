@@ -0,0 +1,94 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/Gurpartap/agentframe/agent"
+	"github.com/Gurpartap/agentframe/tooling/mcp"
+)
+
+// ProgressSink forwards run events to MCP progress notifications. Runs started
+// by a tools/call request that carries a progress token publish through a
+// context that identifies the request, so one sink can serve every session.
+//
+// Install it as (or inside) the Runner's EventSink; events published outside
+// an MCP request are only forwarded to Next.
+type ProgressSink struct {
+	next agent.EventSink
+}
+
+var _ agent.EventSink = (*ProgressSink)(nil)
+
+// NewProgressSink returns a sink that reports progress and then publishes to
+// next. next may be nil.
+func NewProgressSink(next agent.EventSink) *ProgressSink {
+	return &ProgressSink{next: next}
+}
+
+func (s *ProgressSink) Publish(ctx context.Context, event agent.Event) error {
+	if ctx == nil {
+		return agent.ErrContextNil
+	}
+	if reporter, ok := ctx.Value(progressReporterKey{}).(*progressReporter); ok {
+		reporter.report(event)
+	}
+	if s.next == nil {
+		return nil
+	}
+	return s.next.Publish(ctx, event)
+}
+
+type progressReporterKey struct{}
+
+// progressReporter emits notifications/progress for one tools/call request.
+type progressReporter struct {
+	token  json.RawMessage
+	notify func(mcp.Message)
+	count  atomic.Int64
+}
+
+func withProgressReporter(ctx context.Context, token json.RawMessage, notify func(mcp.Message)) context.Context {
+	if len(token) == 0 || notify == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, progressReporterKey{}, &progressReporter{token: token, notify: notify})
+}
+
+type progressParams struct {
+	ProgressToken json.RawMessage `json:"progressToken"`
+	Progress      int64           `json:"progress"`
+	Message       string          `json:"message,omitempty"`
+}
+
+func (r *progressReporter) report(event agent.Event) {
+	notification, err := mcp.NewNotification("notifications/progress", progressParams{
+		ProgressToken: r.token,
+		Progress:      r.count.Add(1),
+		Message:       progressMessage(event),
+	})
+	if err != nil {
+		return
+	}
+	r.notify(notification)
+}
+
+func progressMessage(event agent.Event) string {
+	message := fmt.Sprintf("run_id=%s step=%d event=%s", event.RunID, event.Step, event.Type)
+	switch {
+	case event.ToolResult != nil:
+		message += fmt.Sprintf(" tool=%s is_error=%t", event.ToolResult.Name, event.ToolResult.IsError)
+	case event.Message != nil && len(event.Message.ToolCalls) > 0:
+		for _, call := range event.Message.ToolCalls {
+			message += " tool_call=" + call.Name
+		}
+	}
+	if event.Description != "" {
+		summary, _, _ := strings.Cut(event.Description, "\n")
+		message += ": " + summary
+	}
+	return message
+}
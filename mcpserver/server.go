@@ -0,0 +1,385 @@
+// Package mcpserver exposes an agent.Runner to Model Context Protocol hosts.
+// The server offers start_run, continue_run and get_run tools, and reports the
+// events of a running tool call as MCP progress notifications.
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/Gurpartap/agentframe/agent"
+	"github.com/Gurpartap/agentframe/tooling/mcp"
+)
+
+const (
+	// DefaultName identifies the server during initialization when Config.Name is empty.
+	DefaultName = "agentframe"
+
+	ToolStartRun    = "start_run"
+	ToolContinueRun = "continue_run"
+	ToolGetRun      = "get_run"
+
+	defaultVersion = "0.0.0"
+)
+
+var (
+	ErrMissingRunner    = errors.New("mcp server runner is required")
+	ErrMissingRunStore  = errors.New("mcp server run store is required")
+	ErrInvalidArguments = errors.New("invalid mcp tool arguments")
+)
+
+// Config defines the runs that MCP hosts may start.
+type Config struct {
+	// Runner executes runs. Its EventSink should include a ProgressSink so that
+	// hosts receive progress notifications.
+	Runner *agent.Runner
+	// RunStore must be the store backing Runner; get_run reads from it.
+	RunStore agent.RunStore
+
+	// SystemPrompt, MaxSteps and Tools configure every run started or
+	// continued through the server.
+	SystemPrompt string
+	MaxSteps     int
+	Tools        []agent.ToolDefinition
+
+	Name    string
+	Version string
+}
+
+// Server answers MCP requests. It is transport independent; ServeStream and
+// ServeHTTP bind it to stdio and streamable HTTP.
+type Server struct {
+	runner       *agent.Runner
+	store        agent.RunStore
+	systemPrompt string
+	maxSteps     int
+	tools        []agent.ToolDefinition
+	info         mcp.Implementation
+}
+
+func New(cfg Config) (*Server, error) {
+	if cfg.Runner == nil {
+		return nil, fmt.Errorf("new mcp server: %w", ErrMissingRunner)
+	}
+	if cfg.RunStore == nil {
+		return nil, fmt.Errorf("new mcp server: %w", ErrMissingRunStore)
+	}
+	name := strings.TrimSpace(cfg.Name)
+	if name == "" {
+		name = DefaultName
+	}
+	version := strings.TrimSpace(cfg.Version)
+	if version == "" {
+		version = defaultVersion
+	}
+	return &Server{
+		runner:       cfg.Runner,
+		store:        cfg.RunStore,
+		systemPrompt: cfg.SystemPrompt,
+		maxSteps:     cfg.MaxSteps,
+		tools:        agent.CloneToolDefinitions(cfg.Tools),
+		info:         mcp.Implementation{Name: name, Version: version},
+	}, nil
+}
+
+// Tools returns the MCP tool list advertised by the server.
+func Tools() []mcp.Tool {
+	runIDProperty := map[string]any{"type": "string", "description": "Run identifier returned by start_run."}
+	maxStepsProperty := map[string]any{"type": "integer", "minimum": 1, "description": "Step budget for this command."}
+	return []mcp.Tool{
+		{
+			Name:        ToolStartRun,
+			Description: "Start an agent run for a task. Returns when the run completes, fails or waits for a requirement.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"prompt":    map[string]any{"type": "string", "description": "Task for the agent."},
+					"max_steps": maxStepsProperty,
+				},
+				"required":             []any{"prompt"},
+				"additionalProperties": false,
+			},
+		},
+		{
+			Name: ToolContinueRun,
+			Description: "Continue a run. For a suspended run pass requirement_id and an outcome to resolve its " +
+				"pending requirement (approved or rejected for approvals, provided with value for user input).",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"run_id":         runIDProperty,
+					"requirement_id": map[string]any{"type": "string"},
+					"outcome": map[string]any{
+						"type": "string",
+						"enum": []any{
+							string(agent.ResolutionOutcomeApproved),
+							string(agent.ResolutionOutcomeRejected),
+							string(agent.ResolutionOutcomeProvided),
+							string(agent.ResolutionOutcomeCompleted),
+						},
+					},
+					"value":     map[string]any{"type": "string"},
+					"max_steps": maxStepsProperty,
+				},
+				"required":             []any{"run_id"},
+				"additionalProperties": false,
+			},
+		},
+		{
+			Name:        ToolGetRun,
+			Description: "Get the status, output and pending requirement of a run.",
+			InputSchema: map[string]any{
+				"type":                 "object",
+				"properties":           map[string]any{"run_id": runIDProperty},
+				"required":             []any{"run_id"},
+				"additionalProperties": false,
+			},
+		},
+	}
+}
+
+type callParams struct {
+	Name      string         `json:"name"`
+	Arguments map[string]any `json:"arguments"`
+	Meta      struct {
+		ProgressToken json.RawMessage `json:"progressToken"`
+	} `json:"_meta"`
+}
+
+// Handle answers one request. notify delivers notifications that belong to the
+// request, such as progress; it may be nil. Handle returns false for
+// notifications, which have no response.
+func (s *Server) Handle(ctx context.Context, request mcp.Message, notify func(mcp.Message)) (mcp.Message, bool) {
+	if !request.IsRequest() {
+		return mcp.Message{}, false
+	}
+	var (
+		result any
+		err    error
+	)
+	switch request.Method {
+	case "initialize":
+		result = mcp.InitializeResult{
+			ProtocolVersion: mcp.ProtocolVersion,
+			Capabilities:    map[string]any{"tools": map[string]any{}},
+			ServerInfo:      s.info,
+		}
+	case "ping":
+		result = struct{}{}
+	case "tools/list":
+		result = mcp.ListToolsResult{Tools: Tools()}
+	case "tools/call":
+		var params callParams
+		if err := json.Unmarshal(request.Params, &params); err != nil {
+			return mcp.NewErrorResponse(request.ID, mcp.CodeInvalidParams, "tools/call params must be an object"), true
+		}
+		result, err = s.callTool(withProgressReporter(ctx, params.Meta.ProgressToken, notify), params)
+		if err != nil {
+			return mcp.NewErrorResponse(request.ID, mcp.CodeInvalidParams, err.Error()), true
+		}
+	default:
+		return mcp.NewErrorResponse(request.ID, mcp.CodeMethodNotFound, fmt.Sprintf("method %q is not supported", request.Method)), true
+	}
+	response, err := mcp.NewResult(request.ID, result)
+	if err != nil {
+		return mcp.NewErrorResponse(request.ID, mcp.CodeInternalError, err.Error()), true
+	}
+	return response, true
+}
+
+// callTool returns a JSON-RPC level error only for unknown tools. Invalid
+// arguments and run failures are reported as error results the host model can
+// act on.
+func (s *Server) callTool(ctx context.Context, params callParams) (mcp.CallToolResult, error) {
+	var (
+		state  agent.RunState
+		runErr error
+	)
+	switch params.Name {
+	case ToolStartRun:
+		state, runErr = s.startRun(ctx, params.Arguments)
+	case ToolContinueRun:
+		state, runErr = s.continueRun(ctx, params.Arguments)
+	case ToolGetRun:
+		state, runErr = s.getRun(ctx, params.Arguments)
+	default:
+		return mcp.CallToolResult{}, fmt.Errorf("unknown tool %q", params.Name)
+	}
+	if runErr != nil && state.ID == "" {
+		return mcp.CallToolResult{
+			Content: []mcp.Content{{Type: mcp.ContentTypeText, Text: runErr.Error()}},
+			IsError: true,
+		}, nil
+	}
+	return runResult(state, runErr), nil
+}
+
+func (s *Server) startRun(ctx context.Context, arguments map[string]any) (agent.RunState, error) {
+	prompt, err := stringArgument(arguments, "prompt", true)
+	if err != nil {
+		return agent.RunState{}, err
+	}
+	maxSteps, err := s.maxStepsArgument(arguments)
+	if err != nil {
+		return agent.RunState{}, err
+	}
+	result, err := s.runner.Run(ctx, agent.RunInput{
+		SystemPrompt: s.systemPrompt,
+		UserPrompt:   prompt,
+		MaxSteps:     maxSteps,
+		Tools:        agent.CloneToolDefinitions(s.tools),
+	})
+	return result.State, err
+}
+
+func (s *Server) continueRun(ctx context.Context, arguments map[string]any) (agent.RunState, error) {
+	runID, err := stringArgument(arguments, "run_id", true)
+	if err != nil {
+		return agent.RunState{}, err
+	}
+	requirementID, err := stringArgument(arguments, "requirement_id", false)
+	if err != nil {
+		return agent.RunState{}, err
+	}
+	outcome, err := stringArgument(arguments, "outcome", false)
+	if err != nil {
+		return agent.RunState{}, err
+	}
+	value, err := stringArgument(arguments, "value", false)
+	if err != nil {
+		return agent.RunState{}, err
+	}
+	maxSteps, err := s.maxStepsArgument(arguments)
+	if err != nil {
+		return agent.RunState{}, err
+	}
+
+	var resolution *agent.Resolution
+	if requirementID != "" {
+		// The host only names the requirement; its kind comes from the
+		// persisted run so that hosts cannot resolve it as a different kind.
+		state, err := s.store.Load(ctx, agent.RunID(runID))
+		if err != nil {
+			return agent.RunState{}, fmt.Errorf("load run %q: %w", runID, err)
+		}
+		if state.PendingRequirement == nil || state.PendingRequirement.ID != requirementID {
+			return agent.RunState{}, fmt.Errorf(
+				"%w: field=requirement_id reason=not_pending run_id=%q value=%q",
+				ErrInvalidArguments,
+				runID,
+				requirementID,
+			)
+		}
+		if outcome == "" {
+			return agent.RunState{}, fmt.Errorf("%w: field=outcome reason=required_with_requirement_id", ErrInvalidArguments)
+		}
+		resolution = &agent.Resolution{
+			RequirementID: requirementID,
+			Kind:          state.PendingRequirement.Kind,
+			Outcome:       agent.ResolutionOutcome(outcome),
+			Value:         value,
+		}
+	}
+	result, err := s.runner.Continue(ctx, agent.RunID(runID), maxSteps, agent.CloneToolDefinitions(s.tools), resolution)
+	return result.State, err
+}
+
+func (s *Server) getRun(ctx context.Context, arguments map[string]any) (agent.RunState, error) {
+	runID, err := stringArgument(arguments, "run_id", true)
+	if err != nil {
+		return agent.RunState{}, err
+	}
+	state, err := s.store.Load(ctx, agent.RunID(runID))
+	if err != nil {
+		return agent.RunState{}, fmt.Errorf("load run %q: %w", runID, err)
+	}
+	return state, nil
+}
+
+func (s *Server) maxStepsArgument(arguments map[string]any) (int, error) {
+	raw, ok := arguments["max_steps"]
+	if !ok || raw == nil {
+		return s.maxSteps, nil
+	}
+	value, ok := raw.(float64)
+	if !ok || value != math.Trunc(value) || value < 1 || value > math.MaxInt32 {
+		return 0, fmt.Errorf("%w: field=max_steps reason=not_positive_integer", ErrInvalidArguments)
+	}
+	return int(value), nil
+}
+
+func stringArgument(arguments map[string]any, key string, required bool) (string, error) {
+	raw, ok := arguments[key]
+	if !ok || raw == nil {
+		if required {
+			return "", fmt.Errorf("%w: field=%s reason=required", ErrInvalidArguments, key)
+		}
+		return "", nil
+	}
+	value, ok := raw.(string)
+	if !ok {
+		return "", fmt.Errorf("%w: field=%s reason=not_string", ErrInvalidArguments, key)
+	}
+	value = strings.TrimSpace(value)
+	if required && value == "" {
+		return "", fmt.Errorf("%w: field=%s reason=empty", ErrInvalidArguments, key)
+	}
+	return value, nil
+}
+
+// RunSummary is the structured content returned by every run tool.
+type RunSummary struct {
+	RunID              agent.RunID               `json:"run_id"`
+	Status             agent.RunStatus           `json:"status"`
+	Step               int                       `json:"step"`
+	Output             string                    `json:"output,omitempty"`
+	StructuredOutput   json.RawMessage           `json:"structured_output,omitempty"`
+	Error              string                    `json:"error,omitempty"`
+	PendingRequirement *agent.PendingRequirement `json:"pending_requirement,omitempty"`
+}
+
+func runResult(state agent.RunState, runErr error) mcp.CallToolResult {
+	summary := RunSummary{
+		RunID:              state.ID,
+		Status:             state.Status,
+		Step:               state.Step,
+		Output:             state.Output,
+		StructuredOutput:   state.StructuredOutput,
+		Error:              state.Error,
+		PendingRequirement: state.PendingRequirement,
+	}
+	if runErr != nil && summary.Error == "" {
+		summary.Error = runErr.Error()
+	}
+
+	lines := []string{fmt.Sprintf("run_id=%s status=%s step=%d", state.ID, state.Status, state.Step)}
+	switch {
+	case state.PendingRequirement != nil:
+		requirement := state.PendingRequirement
+		lines = append(lines, fmt.Sprintf(
+			"pending requirement_id=%s kind=%s prompt=%q; resolve it with %s",
+			requirement.ID,
+			requirement.Kind,
+			requirement.Prompt,
+			ToolContinueRun,
+		))
+	case summary.Error != "":
+		lines = append(lines, "error: "+summary.Error)
+	case state.Output != "":
+		lines = append(lines, state.Output)
+	}
+
+	structured, err := json.Marshal(summary)
+	if err != nil {
+		structured = nil
+	}
+	return mcp.CallToolResult{
+		Content:           []mcp.Content{{Type: mcp.ContentTypeText, Text: strings.Join(lines, "\n")}},
+		StructuredContent: structured,
+		IsError:           runErr != nil || state.Status == agent.RunStatusFailed,
+	}
+}
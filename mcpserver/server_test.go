@@ -0,0 +1,313 @@
+package mcpserver_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Gurpartap/agentframe/agent"
+	"github.com/Gurpartap/agentframe/agentreact"
+	eventinginmem "github.com/Gurpartap/agentframe/eventing/inmem"
+	"github.com/Gurpartap/agentframe/mcpserver"
+	runstoreinmem "github.com/Gurpartap/agentframe/runstore/inmem"
+	"github.com/Gurpartap/agentframe/tooling/mcp"
+	toolingregistry "github.com/Gurpartap/agentframe/tooling/registry"
+)
+
+type scriptedModel struct {
+	mu        sync.Mutex
+	responses []agent.Message
+}
+
+func (m *scriptedModel) Generate(_ context.Context, _ agentreact.ModelRequest) (agent.Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.responses) == 0 {
+		return agent.Message{}, fmt.Errorf("script exhausted")
+	}
+	next := m.responses[0]
+	m.responses = m.responses[1:]
+	next.Role = agent.RoleAssistant
+	return next, nil
+}
+
+type sequenceIDs struct {
+	next atomic.Int64
+}
+
+func (g *sequenceIDs) NewRunID(context.Context) (agent.RunID, error) {
+	return agent.RunID(fmt.Sprintf("run-%d", g.next.Add(1))), nil
+}
+
+func newServer(t *testing.T, responses ...agent.Message) *mcpserver.Server {
+	t.Helper()
+
+	store := runstoreinmem.New()
+	sink := mcpserver.NewProgressSink(eventinginmem.New())
+	tools, err := toolingregistry.New(nil)
+	if err != nil {
+		t.Fatalf("new registry: %v", err)
+	}
+	loop, err := agentreact.New(&scriptedModel{responses: responses}, tools, sink)
+	if err != nil {
+		t.Fatalf("new loop: %v", err)
+	}
+	runner, err := agent.NewRunner(agent.Dependencies{
+		IDGenerator: &sequenceIDs{},
+		RunStore:    store,
+		Engine:      loop,
+		EventSink:   sink,
+	})
+	if err != nil {
+		t.Fatalf("new runner: %v", err)
+	}
+	server, err := mcpserver.New(mcpserver.Config{Runner: runner, RunStore: store, MaxSteps: 4})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	return server
+}
+
+// streamSession drives ServeStream with raw JSON-RPC lines so that
+// notifications can be observed alongside responses.
+type streamSession struct {
+	t       *testing.T
+	writer  *io.PipeWriter
+	scanner *bufio.Scanner
+	nextID  int
+}
+
+func newStreamSession(t *testing.T, server *mcpserver.Server) *streamSession {
+	t.Helper()
+
+	serverReader, clientWriter := io.Pipe()
+	clientReader, serverWriter := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- server.ServeStream(context.Background(), serverReader, serverWriter)
+		serverWriter.Close()
+	}()
+	t.Cleanup(func() {
+		clientWriter.Close()
+		if err := <-done; err != nil {
+			t.Errorf("serve stream: %v", err)
+		}
+	})
+	return &streamSession{t: t, writer: clientWriter, scanner: bufio.NewScanner(clientReader)}
+}
+
+func (s *streamSession) call(method string, params any) (mcp.Message, []mcp.Message) {
+	s.t.Helper()
+
+	s.nextID++
+	id := json.RawMessage(fmt.Sprint(s.nextID))
+	request, err := mcp.NewRequest(id, method, params)
+	if err != nil {
+		s.t.Fatalf("new request: %v", err)
+	}
+	encoded, _ := json.Marshal(request)
+	if _, err := s.writer.Write(append(encoded, '\n')); err != nil {
+		s.t.Fatalf("write request: %v", err)
+	}
+	var notifications []mcp.Message
+	for s.scanner.Scan() {
+		var message mcp.Message
+		if err := json.Unmarshal(s.scanner.Bytes(), &message); err != nil {
+			s.t.Fatalf("decode message: %v", err)
+		}
+		if message.IsNotification() {
+			notifications = append(notifications, message)
+			continue
+		}
+		if string(message.ID) != string(id) {
+			s.t.Fatalf("response id mismatch: got=%s want=%s", message.ID, id)
+		}
+		return message, notifications
+	}
+	s.t.Fatalf("stream ended before response to %s", method)
+	return mcp.Message{}, nil
+}
+
+func decodeSummary(t *testing.T, response mcp.Message) (mcp.CallToolResult, mcpserver.RunSummary) {
+	t.Helper()
+
+	if response.Error != nil {
+		t.Fatalf("unexpected rpc error: %v", response.Error)
+	}
+	var result mcp.CallToolResult
+	if err := json.Unmarshal(response.Result, &result); err != nil {
+		t.Fatalf("decode tool result: %v", err)
+	}
+	var summary mcpserver.RunSummary
+	if err := json.Unmarshal(result.StructuredContent, &summary); err != nil {
+		t.Fatalf("decode run summary: %v", err)
+	}
+	return result, summary
+}
+
+func TestServeStream_DrivesApprovalRun(t *testing.T) {
+	t.Parallel()
+
+	server := newServer(t,
+		agent.Message{
+			Content: "need approval",
+			Requirement: &agent.PendingRequirement{
+				ID:     "req-1",
+				Kind:   agent.RequirementKindApproval,
+				Origin: agent.RequirementOriginModel,
+				Prompt: "Deploy?",
+			},
+		},
+		agent.Message{Content: "deployed"},
+	)
+	session := newStreamSession(t, server)
+
+	initialize, _ := session.call("initialize", mcp.InitializeParams{ProtocolVersion: mcp.ProtocolVersion})
+	var info mcp.InitializeResult
+	if err := json.Unmarshal(initialize.Result, &info); err != nil || info.ServerInfo.Name != mcpserver.DefaultName {
+		t.Fatalf("initialize mismatch: result=%s err=%v", initialize.Result, err)
+	}
+	list, _ := session.call("tools/list", nil)
+	var tools mcp.ListToolsResult
+	if err := json.Unmarshal(list.Result, &tools); err != nil || len(tools.Tools) != 3 {
+		t.Fatalf("tools/list mismatch: result=%s err=%v", list.Result, err)
+	}
+
+	started, progress := session.call("tools/call", map[string]any{
+		"name":      mcpserver.ToolStartRun,
+		"arguments": map[string]any{"prompt": "deploy it"},
+		"_meta":     map[string]any{"progressToken": "tok-1"},
+	})
+	result, summary := decodeSummary(t, started)
+	if result.IsError || summary.Status != agent.RunStatusSuspended || summary.PendingRequirement == nil {
+		t.Fatalf("start_run mismatch: %+v", summary)
+	}
+	if len(progress) == 0 {
+		t.Fatalf("expected progress notifications for start_run")
+	}
+	for i, notification := range progress {
+		var params struct {
+			ProgressToken string `json:"progressToken"`
+			Progress      int    `json:"progress"`
+			Message       string `json:"message"`
+		}
+		if err := json.Unmarshal(notification.Params, &params); err != nil {
+			t.Fatalf("decode progress: %v", err)
+		}
+		if notification.Method != "notifications/progress" || params.ProgressToken != "tok-1" || params.Progress != i+1 {
+			t.Fatalf("progress notification mismatch: method=%s params=%+v", notification.Method, params)
+		}
+	}
+	if !strings.Contains(result.Content[0].Text, "requirement_id=req-1") {
+		t.Fatalf("start_run text mismatch: %q", result.Content[0].Text)
+	}
+
+	continued, progress := session.call("tools/call", map[string]any{
+		"name": mcpserver.ToolContinueRun,
+		"arguments": map[string]any{
+			"run_id":         string(summary.RunID),
+			"requirement_id": "req-1",
+			"outcome":        "approved",
+		},
+	})
+	if len(progress) != 0 {
+		t.Fatalf("progress must only be sent with a progress token: got=%d", len(progress))
+	}
+	result, summary = decodeSummary(t, continued)
+	if result.IsError || summary.Status != agent.RunStatusCompleted || summary.Output != "deployed" {
+		t.Fatalf("continue_run mismatch: %+v", summary)
+	}
+
+	fetched, _ := session.call("tools/call", map[string]any{
+		"name":      mcpserver.ToolGetRun,
+		"arguments": map[string]any{"run_id": string(summary.RunID)},
+	})
+	if _, got := decodeSummary(t, fetched); got.Status != agent.RunStatusCompleted {
+		t.Fatalf("get_run status mismatch: got=%s", got.Status)
+	}
+}
+
+func TestServeStream_ReportsInvalidArgumentsAsToolErrors(t *testing.T) {
+	t.Parallel()
+
+	session := newStreamSession(t, newServer(t))
+
+	response, _ := session.call("tools/call", map[string]any{
+		"name":      mcpserver.ToolContinueRun,
+		"arguments": map[string]any{"run_id": "run-missing", "requirement_id": "req-1", "outcome": "approved"},
+	})
+	var result mcp.CallToolResult
+	if err := json.Unmarshal(response.Result, &result); err != nil {
+		t.Fatalf("decode tool result: %v", err)
+	}
+	if !result.IsError || !strings.Contains(result.Content[0].Text, "run not found") {
+		t.Fatalf("tool error mismatch: %+v", result)
+	}
+
+	unknown, _ := session.call("tools/call", map[string]any{"name": "delete_everything"})
+	if unknown.Error == nil || unknown.Error.Code != mcp.CodeInvalidParams {
+		t.Fatalf("expected invalid params rpc error, got %+v", unknown)
+	}
+}
+
+func TestServeHTTP_StreamsProgressBeforeResponse(t *testing.T) {
+	t.Parallel()
+
+	httpServer := httptest.NewServer(newServer(t, agent.Message{Content: "done"}))
+	defer httpServer.Close()
+
+	body := `{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"start_run",` +
+		`"arguments":{"prompt":"go"},"_meta":{"progressToken":42}}}`
+	response, err := httpServer.Client().Post(httpServer.URL, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	defer response.Body.Close()
+	if response.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("content type mismatch: got=%q", response.Header.Get("Content-Type"))
+	}
+
+	var messages []mcp.Message
+	scanner := bufio.NewScanner(response.Body)
+	for scanner.Scan() {
+		if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+			var message mcp.Message
+			if err := json.Unmarshal([]byte(data), &message); err != nil {
+				t.Fatalf("decode event: %v", err)
+			}
+			messages = append(messages, message)
+		}
+	}
+	if len(messages) < 2 {
+		t.Fatalf("expected progress events and a response, got %d messages", len(messages))
+	}
+	last := messages[len(messages)-1]
+	if string(last.ID) != "7" {
+		t.Fatalf("final event must be the response: got=%+v", last)
+	}
+	if _, summary := decodeSummary(t, last); summary.Status != agent.RunStatusCompleted {
+		t.Fatalf("run status mismatch: got=%s", summary.Status)
+	}
+	for _, message := range messages[:len(messages)-1] {
+		if message.Method != "notifications/progress" {
+			t.Fatalf("unexpected event before response: %+v", message)
+		}
+	}
+
+	rejected, err := httpServer.Client().Get(httpServer.URL)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	rejected.Body.Close()
+	if rejected.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("status mismatch: got=%d want=%d", rejected.StatusCode, http.StatusMethodNotAllowed)
+	}
+}
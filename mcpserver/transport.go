@@ -0,0 +1,199 @@
+package mcpserver
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/Gurpartap/agentframe/tooling/mcp"
+)
+
+// maxMessageBytes caps one incoming JSON-RPC message.
+const maxMessageBytes = 4 << 20
+
+type cancelledParams struct {
+	RequestID json.RawMessage `json:"requestId"`
+}
+
+// ServeStream serves newline-delimited JSON-RPC over r and w, as an MCP host
+// does when it launches the server as a subprocess on stdin and stdout.
+// Requests are handled concurrently so get_run can be answered while a run is
+// in progress. ServeStream returns when r ends or ctx is cancelled; in-flight
+// runs are cancelled first.
+func (s *Server) ServeStream(ctx context.Context, r io.Reader, w io.Writer) error {
+	if ctx == nil {
+		return errors.New("serve mcp stream: context is nil")
+	}
+	ctx, cancelAll := context.WithCancel(ctx)
+	defer cancelAll()
+
+	var (
+		writeMu  sync.Mutex
+		writeErr error
+		mu       sync.Mutex
+		inFlight = map[string]context.CancelFunc{}
+		wg       sync.WaitGroup
+	)
+	write := func(message mcp.Message) {
+		encoded, err := json.Marshal(message)
+		if err != nil {
+			return
+		}
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		if writeErr != nil {
+			return
+		}
+		if _, err := w.Write(append(encoded, '\n')); err != nil {
+			writeErr = err
+			cancelAll()
+		}
+	}
+
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64<<10), maxMessageBytes)
+		for scanner.Scan() {
+			select {
+			case lines <- append([]byte(nil), scanner.Bytes()...):
+			case <-ctx.Done():
+				readErr <- nil
+				return
+			}
+		}
+		readErr <- scanner.Err()
+	}()
+
+	var serveErr error
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case serveErr = <-readErr:
+			break loop
+		case line := <-lines:
+			if strings.TrimSpace(string(line)) == "" {
+				continue
+			}
+			var message mcp.Message
+			if err := json.Unmarshal(line, &message); err != nil {
+				write(mcp.NewErrorResponse(nil, mcp.CodeParseError, "message is not valid JSON-RPC"))
+				continue
+			}
+			if message.IsNotification() {
+				if message.Method == "notifications/cancelled" {
+					var params cancelledParams
+					if json.Unmarshal(message.Params, &params) == nil {
+						mu.Lock()
+						if cancel, ok := inFlight[string(params.RequestID)]; ok {
+							cancel()
+						}
+						mu.Unlock()
+					}
+				}
+				continue
+			}
+			if !message.IsRequest() {
+				continue
+			}
+			requestCtx, cancel := context.WithCancel(ctx)
+			key := string(message.ID)
+			mu.Lock()
+			inFlight[key] = cancel
+			mu.Unlock()
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() {
+					mu.Lock()
+					delete(inFlight, key)
+					mu.Unlock()
+					cancel()
+				}()
+				if response, ok := s.Handle(requestCtx, message, write); ok {
+					write(response)
+				}
+			}()
+		}
+	}
+	cancelAll()
+	wg.Wait()
+
+	writeMu.Lock()
+	defer writeMu.Unlock()
+	if serveErr == nil {
+		serveErr = writeErr
+	}
+	if serveErr != nil {
+		return fmt.Errorf("serve mcp stream: %w", serveErr)
+	}
+	return nil
+}
+
+// ServeHTTP implements the stateless form of the MCP streamable HTTP
+// transport. tools/call requests that carry a progress token are answered with
+// an event stream that delivers progress notifications before the response;
+// every other request is answered with a JSON body.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "mcp endpoint accepts POST only", http.StatusMethodNotAllowed)
+		return
+	}
+	var message mcp.Message
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxMessageBytes)).Decode(&message); err != nil {
+		writeJSONMessage(w, http.StatusBadRequest, mcp.NewErrorResponse(nil, mcp.CodeParseError, "message is not valid JSON-RPC"))
+		return
+	}
+	if !message.IsRequest() {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	flusher, canStream := w.(http.Flusher)
+	if !canStream || message.Method != "tools/call" || !hasProgressToken(message.Params) {
+		response, _ := s.Handle(r.Context(), message, nil)
+		writeJSONMessage(w, http.StatusOK, response)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	var writeMu sync.Mutex
+	writeEvent := func(event mcp.Message) {
+		encoded, err := json.Marshal(event)
+		if err != nil {
+			return
+		}
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		fmt.Fprintf(w, "event: message\ndata: %s\n\n", encoded)
+		flusher.Flush()
+	}
+	response, _ := s.Handle(r.Context(), message, writeEvent)
+	writeEvent(response)
+}
+
+func hasProgressToken(params json.RawMessage) bool {
+	var decoded callParams
+	if err := json.Unmarshal(params, &decoded); err != nil {
+		return false
+	}
+	return len(decoded.Meta.ProgressToken) > 0
+}
+
+func writeJSONMessage(w http.ResponseWriter, status int, message mcp.Message) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(message)
+}
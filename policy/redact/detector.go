@@ -0,0 +1,175 @@
+package redact
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Match is one secret found in a text, as a byte range and the kind named in
+// its placeholder.
+type Match struct {
+	Start int
+	End   int
+	Kind  string
+}
+
+// Detector finds secrets in a text.
+type Detector interface {
+	Detect(text string) []Match
+}
+
+// DetectorFunc adapts a function to Detector.
+type DetectorFunc func(text string) []Match
+
+func (f DetectorFunc) Detect(text string) []Match {
+	return f(text)
+}
+
+// Regexp detects matches of pattern as secrets of the given kind. When the
+// pattern has a capture group named "secret", only that group is redacted so
+// surrounding context such as a variable name stays visible.
+func Regexp(kind string, pattern *regexp.Regexp) Detector {
+	group := pattern.SubexpIndex("secret")
+	return DetectorFunc(func(text string) []Match {
+		var matches []Match
+		for _, loc := range pattern.FindAllStringSubmatchIndex(text, -1) {
+			start, end := loc[0], loc[1]
+			if group > 0 {
+				start, end = loc[2*group], loc[2*group+1]
+			}
+			if start < 0 || start == end {
+				continue
+			}
+			matches = append(matches, Match{Start: start, End: end, Kind: kind})
+		}
+		return matches
+	})
+}
+
+// CompileRegexp is Regexp for a pattern in RE2 syntax.
+func CompileRegexp(kind, pattern string) (Detector, error) {
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("compile redaction pattern %q: %w", pattern, err)
+	}
+	return Regexp(kind, compiled), nil
+}
+
+var apiKeyPatterns = []struct {
+	kind    string
+	pattern *regexp.Regexp
+}{
+	{"private_key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+	{"aws_access_key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"github_token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{22,255})\b`)},
+	{"anthropic_api_key", regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_-]{20,}`)},
+	{"openai_api_key", regexp.MustCompile(`\bsk-(?:proj-)?[A-Za-z0-9_-]{20,}`)},
+	{"slack_token", regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{"stripe_key", regexp.MustCompile(`\b(?:sk|rk)_live_[A-Za-z0-9]{16,}`)},
+	{"google_api_key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}`)},
+	{"jwt", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`)},
+	{"bearer_token", regexp.MustCompile(`(?i)\bbearer\s+(?P<secret>[A-Za-z0-9._~+/-]{16,}=*)`)},
+	{"url_password", regexp.MustCompile(`\b[a-zA-Z][a-zA-Z0-9+.-]*://[^\s:/@]+:(?P<secret>[^\s@/]+)@`)},
+}
+
+// APIKeys detects well-known credential formats: cloud and SaaS API keys,
+// GitHub tokens, JWTs, bearer tokens, PEM private keys and passwords in URLs.
+func APIKeys() Detector {
+	detectors := make([]Detector, len(apiKeyPatterns))
+	for i, entry := range apiKeyPatterns {
+		detectors[i] = Regexp(entry.kind, entry.pattern)
+	}
+	return DetectorFunc(func(text string) []Match {
+		var matches []Match
+		for _, detector := range detectors {
+			matches = append(matches, detector.Detect(text)...)
+		}
+		return matches
+	})
+}
+
+var (
+	// envAssignmentPattern matches NAME=value lines of env dumps and .env
+	// files whose upper-case name suggests a credential.
+	envAssignmentPattern = regexp.MustCompile(`(?m)^[ \t]*(?:export[ \t]+)?[A-Z0-9_]*(?:PASSWORD|PASSWD|SECRET|TOKEN|API_?KEY|ACCESS_?KEY|PRIVATE_?KEY|CREDENTIALS?)[A-Z0-9_]*[ \t]*=[ \t]*["']?(?P<secret>[^\s"']+)`)
+	// quotedAssignmentPattern matches "password": "value" style entries of
+	// JSON, YAML and source literals. Unquoted values are left alone so code
+	// such as `Password: cfg.Password` stays readable.
+	quotedAssignmentPattern = regexp.MustCompile(`(?i)\b(?:password|passwd|secret|client_?secret|api_?key|access_?key|auth_?token|access_?token|refresh_?token)["']?[ \t]*[:=][ \t]*["'](?P<secret>[^"'\s]{4,})["']`)
+)
+
+// Assignments detects credential values assigned to names such as PASSWORD or
+// api_key in env dumps, config files and string literals.
+func Assignments() Detector {
+	env := Regexp("secret", envAssignmentPattern)
+	quoted := Regexp("secret", quotedAssignmentPattern)
+	return DetectorFunc(func(text string) []Match {
+		return append(env.Detect(text), quoted.Detect(text)...)
+	})
+}
+
+const (
+	DefaultEntropyMinLength = 24
+	// DefaultEntropyBitsPerChar sits above the 4 bits/char ceiling of hex, so
+	// commit hashes and checksums in hex are never redacted.
+	DefaultEntropyBitsPerChar = 4.3
+)
+
+var entropyTokenPattern = regexp.MustCompile(`[A-Za-z0-9+/=_-]+`)
+
+// HighEntropy detects base64- and base62-like tokens of at least minLength
+// characters that mix letters and digits and whose Shannon entropy is at
+// least bitsPerChar. Zero arguments select the defaults.
+func HighEntropy(minLength int, bitsPerChar float64) Detector {
+	if minLength <= 0 {
+		minLength = DefaultEntropyMinLength
+	}
+	if bitsPerChar <= 0 {
+		bitsPerChar = DefaultEntropyBitsPerChar
+	}
+	return DetectorFunc(func(text string) []Match {
+		var matches []Match
+		for _, loc := range entropyTokenPattern.FindAllStringIndex(text, -1) {
+			token := text[loc[0]:loc[1]]
+			if len(token) < minLength || !hasLetterAndDigit(token) {
+				continue
+			}
+			if shannonEntropy(token) < bitsPerChar {
+				continue
+			}
+			matches = append(matches, Match{Start: loc[0], End: loc[1], Kind: "high_entropy"})
+		}
+		return matches
+	})
+}
+
+func hasLetterAndDigit(token string) bool {
+	return strings.IndexFunc(token, unicode.IsLetter) >= 0 && strings.IndexFunc(token, unicode.IsDigit) >= 0
+}
+
+func shannonEntropy(token string) float64 {
+	var counts [256]int
+	for i := 0; i < len(token); i++ {
+		counts[token[i]]++
+	}
+	var entropy float64
+	length := float64(len(token))
+	for _, count := range counts {
+		if count == 0 {
+			continue
+		}
+		p := float64(count) / length
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// DefaultDetectors returns APIKeys and Assignments. HighEntropy is left out
+// because it also matches checksums such as go.sum h1: hashes, npm sha512-
+// integrity values and base64 fixtures, which file reads return verbatim.
+func DefaultDetectors() []Detector {
+	return []Detector{APIKeys(), Assignments()}
+}
@@ -0,0 +1,167 @@
+// Package redact replaces secrets in transcripts and events with stable
+// placeholder tokens.
+package redact
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Gurpartap/agentframe/agent"
+)
+
+// Config controls which secrets a Redactor finds and how placeholders are
+// derived.
+type Config struct {
+	// Detectors find secrets; nil selects DefaultDetectors.
+	Detectors []Detector
+	// Key derives placeholder digests. Redactors sharing a key map a secret to
+	// the same placeholder; nil selects a random key.
+	Key []byte
+}
+
+// Redactor replaces every detected secret with a placeholder of the form
+// [REDACTED:<kind>:<digest>]. The digest is a keyed hash of the secret, so
+// repeated occurrences of one secret share a placeholder and the model can
+// still tell distinct secrets apart without seeing them.
+type Redactor struct {
+	detectors []Detector
+	key       []byte
+}
+
+var _ agent.Redactor = (*Redactor)(nil)
+
+var placeholderPattern = regexp.MustCompile(`\[REDACTED:[a-z0-9_]+:[0-9a-f]{8}\]`)
+
+func New(cfg Config) *Redactor {
+	detectors := cfg.Detectors
+	if detectors == nil {
+		detectors = DefaultDetectors()
+	}
+	key := slices.Clone(cfg.Key)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		_, _ = rand.Read(key)
+	}
+	return &Redactor{detectors: detectors, key: key}
+}
+
+// Redact implements agent.Redactor. Overlapping matches keep the earliest and
+// then longest one; text inside existing placeholders is never redacted again.
+func (r *Redactor) Redact(text string) string {
+	if text == "" {
+		return text
+	}
+	var matches []Match
+	for _, detector := range r.detectors {
+		matches = append(matches, detector.Detect(text)...)
+	}
+	if len(matches) == 0 {
+		return text
+	}
+	slices.SortStableFunc(matches, func(a, b Match) int {
+		if a.Start != b.Start {
+			return a.Start - b.Start
+		}
+		return b.End - a.End
+	})
+	existing := placeholderPattern.FindAllStringIndex(text, -1)
+
+	var out strings.Builder
+	last := 0
+	for _, match := range matches {
+		if match.Start < last || match.End > len(text) || overlapsAny(match, existing) {
+			continue
+		}
+		out.WriteString(text[last:match.Start])
+		out.WriteString(r.placeholder(match.Kind, text[match.Start:match.End]))
+		last = match.End
+	}
+	if last == 0 {
+		return text
+	}
+	out.WriteString(text[last:])
+	return out.String()
+}
+
+func (r *Redactor) placeholder(kind, secret string) string {
+	mac := hmac.New(sha256.New, r.key)
+	mac.Write([]byte(secret))
+	return "[REDACTED:" + placeholderKind(kind) + ":" + hex.EncodeToString(mac.Sum(nil))[:8] + "]"
+}
+
+// placeholderKind folds kind into the [a-z0-9_] alphabet placeholders use.
+func placeholderKind(kind string) string {
+	kind = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		default:
+			return '_'
+		}
+	}, kind)
+	if kind == "" {
+		return "secret"
+	}
+	return kind
+}
+
+func overlapsAny(match Match, spans [][]int) bool {
+	for _, span := range spans {
+		if match.Start < span[1] && span[0] < match.End {
+			return true
+		}
+	}
+	return false
+}
+
+// NewSink returns a sink that publishes redacted copies of events to next.
+// A nil redactor publishes events unchanged.
+func NewSink(next agent.EventSink, redactor agent.Redactor) agent.EventSink {
+	return &sink{next: next, redactor: redactor}
+}
+
+type sink struct {
+	next     agent.EventSink
+	redactor agent.Redactor
+}
+
+func (s *sink) Publish(ctx context.Context, event agent.Event) error {
+	if s.redactor == nil {
+		return s.next.Publish(ctx, event)
+	}
+	return s.next.Publish(ctx, agent.RedactEvent(s.redactor, event))
+}
+
+// ToolExecutor resolves and executes tool calls.
+type ToolExecutor interface {
+	Execute(ctx context.Context, call agent.ToolCall) (agent.ToolResult, error)
+}
+
+// NewToolExecutor returns an executor that redacts the results of next. Place
+// it below decorators that keep full tool output, such as artifact spilling,
+// so secrets are replaced before output is stored or truncated. A nil
+// redactor returns results unchanged.
+func NewToolExecutor(next ToolExecutor, redactor agent.Redactor) ToolExecutor {
+	return &toolExecutor{next: next, redactor: redactor}
+}
+
+type toolExecutor struct {
+	next     ToolExecutor
+	redactor agent.Redactor
+}
+
+func (e *toolExecutor) Execute(ctx context.Context, call agent.ToolCall) (agent.ToolResult, error) {
+	result, err := e.next.Execute(ctx, call)
+	if e.redactor == nil {
+		return result, err
+	}
+	return agent.RedactToolResult(e.redactor, result), err
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/Gurpartap/agentframe/agent"
+	"github.com/Gurpartap/agentframe/agentconformance"
 	runstoreinmem "github.com/Gurpartap/agentframe/runstore/inmem"
 )
 
@@ -350,3 +351,11 @@ func TestStore_LoadFailsFastOnDoneContext(t *testing.T) {
 		})
 	}
 }
+
+func TestStore_Conformance(t *testing.T) {
+	t.Parallel()
+
+	agentconformance.RunStoreSuite(t, func(*testing.T) agent.RunStore {
+		return runstoreinmem.New()
+	})
+}
@@ -0,0 +1,248 @@
+// Package artifact caps the size of tool results. Oversized content is stored
+// in an artifact Store and replaced by a truncated preview plus a reference
+// that the model can page through with the read_artifact tool.
+package artifact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Gurpartap/agentframe/agent"
+)
+
+const (
+	// DefaultReadToolName is the paging tool name used when Config.ReadToolName is empty.
+	DefaultReadToolName = "read_artifact"
+	// DefaultMaxContentBytes is the result size cap used when Config.MaxContentBytes is zero.
+	DefaultMaxContentBytes = 16 << 10
+	// DefaultPreviewBytes is the preview size used when Config.PreviewBytes is zero.
+	DefaultPreviewBytes = 4 << 10
+
+	// URIScheme prefixes artifact references in file content parts.
+	URIScheme = "artifact:"
+)
+
+var (
+	ErrMissingStore         = errors.New("artifact store is required")
+	ErrMissingNext          = errors.New("next tool executor is required")
+	ErrInvalidConfig        = errors.New("artifact executor config is invalid")
+	ErrInvalidReadArguments = errors.New("invalid read_artifact arguments")
+)
+
+// ToolExecutor resolves and executes tool calls.
+type ToolExecutor interface {
+	Execute(ctx context.Context, call agent.ToolCall) (agent.ToolResult, error)
+}
+
+// Config controls result capping and the paging tool.
+type Config struct {
+	Store Store
+	// Next executes every tool call that is not addressed to the paging tool.
+	Next ToolExecutor
+
+	// MaxContentBytes caps ToolResult.Content and read_artifact pages.
+	MaxContentBytes int
+	// PreviewBytes is the amount of original content kept inline when a result
+	// is spilled. It must not exceed MaxContentBytes.
+	PreviewBytes int
+	// ReadToolName is the paging tool name. Defaults to DefaultReadToolName.
+	ReadToolName string
+}
+
+// Executor decorates a ToolExecutor with result size limits and serves the
+// paging tool itself.
+type Executor struct {
+	store        Store
+	next         ToolExecutor
+	maxBytes     int
+	previewBytes int
+	readToolName string
+}
+
+func New(cfg Config) (*Executor, error) {
+	if cfg.Store == nil {
+		return nil, fmt.Errorf("new artifact executor: %w", ErrMissingStore)
+	}
+	if cfg.Next == nil {
+		return nil, fmt.Errorf("new artifact executor: %w", ErrMissingNext)
+	}
+	maxBytes := cfg.MaxContentBytes
+	if maxBytes == 0 {
+		maxBytes = DefaultMaxContentBytes
+	}
+	previewBytes := cfg.PreviewBytes
+	if previewBytes == 0 {
+		previewBytes = min(DefaultPreviewBytes, maxBytes)
+	}
+	if maxBytes < 0 || previewBytes < 0 || previewBytes > maxBytes {
+		return nil, fmt.Errorf(
+			"new artifact executor: %w: max_content_bytes=%d preview_bytes=%d",
+			ErrInvalidConfig,
+			maxBytes,
+			previewBytes,
+		)
+	}
+	readToolName := strings.TrimSpace(cfg.ReadToolName)
+	if readToolName == "" {
+		readToolName = DefaultReadToolName
+	}
+	return &Executor{
+		store:        cfg.Store,
+		next:         cfg.Next,
+		maxBytes:     maxBytes,
+		previewBytes: previewBytes,
+		readToolName: readToolName,
+	}, nil
+}
+
+// Definition returns the paging tool definition to advertise to the model.
+func (e *Executor) Definition() agent.ToolDefinition {
+	return agent.ToolDefinition{
+		Name: e.readToolName,
+		Description: "Read a page of a tool result that was truncated and stored as an artifact. " +
+			"Offsets and limits are in bytes.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"artifact_id": map[string]any{"type": "string"},
+				"offset":      map[string]any{"type": "integer"},
+				"limit":       map[string]any{"type": "integer"},
+			},
+			"required":             []any{"artifact_id"},
+			"additionalProperties": false,
+		},
+	}
+}
+
+// WithDefinition returns definitions with the paging tool definition appended.
+func (e *Executor) WithDefinition(definitions []agent.ToolDefinition) []agent.ToolDefinition {
+	out := agent.CloneToolDefinitions(definitions)
+	return append(out, e.Definition())
+}
+
+func (e *Executor) Execute(ctx context.Context, call agent.ToolCall) (agent.ToolResult, error) {
+	if ctx == nil {
+		return agent.ToolResult{}, agent.ErrContextNil
+	}
+	if call.Name == e.readToolName {
+		return e.executeRead(ctx, call)
+	}
+
+	result, err := e.next.Execute(ctx, call)
+	if err != nil || len(result.Content) <= e.maxBytes {
+		return result, err
+	}
+
+	id, err := e.store.Put(ctx, []byte(result.Content))
+	if err != nil {
+		return agent.ToolResult{}, fmt.Errorf("spill tool result call_id=%q: %w", call.ID, err)
+	}
+	total := len(result.Content)
+	preview := truncateUTF8(result.Content, e.previewBytes)
+	result.Content = fmt.Sprintf(
+		"%s\n[truncated: showing %d of %d bytes; full content stored as artifact_id=%q; "+
+			"call %s with artifact_id, offset and limit to read more]",
+		preview,
+		len(preview),
+		total,
+		id,
+		e.readToolName,
+	)
+	result.Parts = append(result.Parts, agent.FilePart(call.Name+" output", URIScheme+id, "text/plain"))
+	return result, nil
+}
+
+func (e *Executor) executeRead(ctx context.Context, call agent.ToolCall) (agent.ToolResult, error) {
+	id, ok := call.Arguments["artifact_id"].(string)
+	if !ok || strings.TrimSpace(id) == "" {
+		return agent.ToolResult{}, fmt.Errorf("%w: artifact_id must be a non-empty string", ErrInvalidReadArguments)
+	}
+	offset, err := intArgument(call.Arguments, "offset", 0)
+	if err != nil {
+		return agent.ToolResult{}, err
+	}
+	limit, err := intArgument(call.Arguments, "limit", int64(e.maxBytes))
+	if err != nil {
+		return agent.ToolResult{}, err
+	}
+	if offset < 0 || limit <= 0 {
+		return agent.ToolResult{}, fmt.Errorf(
+			"%w: offset must be >= 0 and limit must be > 0: offset=%d limit=%d",
+			ErrInvalidReadArguments,
+			offset,
+			limit,
+		)
+	}
+	limit = min(limit, int64(e.maxBytes))
+
+	chunk, size, err := e.store.ReadRange(ctx, strings.TrimSpace(id), offset, limit)
+	if err != nil {
+		return agent.ToolResult{}, err
+	}
+	end := offset + int64(len(chunk))
+	if end < size {
+		// Keep pages on rune boundaries so every page is valid UTF-8 text.
+		trimmed := trimIncompleteRune(string(chunk))
+		if len(trimmed) > 0 {
+			chunk = chunk[:len(trimmed)]
+			end = offset + int64(len(chunk))
+		}
+	}
+
+	content := string(chunk)
+	if end < size {
+		content = fmt.Sprintf("%s\n[bytes %d-%d of %d; next offset=%d]", content, offset, end, size, end)
+	} else {
+		content = fmt.Sprintf("%s\n[bytes %d-%d of %d; end of artifact]", content, offset, end, size)
+	}
+	return agent.ToolResult{
+		CallID:  call.ID,
+		Name:    call.Name,
+		Content: content,
+	}, nil
+}
+
+// truncateUTF8 returns the longest prefix of s that is at most maxBytes long
+// and does not end in a partial multi-byte rune.
+func truncateUTF8(s string, maxBytes int) string {
+	if len(s) > maxBytes {
+		s = s[:maxBytes]
+	}
+	return trimIncompleteRune(s)
+}
+
+func trimIncompleteRune(s string) string {
+	for i := len(s) - 1; i >= 0 && i >= len(s)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(s[i]) {
+			if utf8.FullRuneInString(s[i:]) {
+				return s
+			}
+			return s[:i]
+		}
+	}
+	return s
+}
+
+func intArgument(arguments map[string]any, key string, fallback int64) (int64, error) {
+	raw, ok := arguments[key]
+	if !ok || raw == nil {
+		return fallback, nil
+	}
+	switch value := raw.(type) {
+	case int:
+		return int64(value), nil
+	case int64:
+		return value, nil
+	case float64:
+		if value != math.Trunc(value) {
+			return 0, fmt.Errorf("%w: %s must be an integer", ErrInvalidReadArguments, key)
+		}
+		return int64(value), nil
+	default:
+		return 0, fmt.Errorf("%w: %s must be an integer", ErrInvalidReadArguments, key)
+	}
+}
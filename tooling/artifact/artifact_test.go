@@ -0,0 +1,141 @@
+package artifact_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Gurpartap/agentframe/agent"
+	"github.com/Gurpartap/agentframe/tooling/artifact"
+	toolingregistry "github.com/Gurpartap/agentframe/tooling/registry"
+)
+
+func newExecutor(t *testing.T, output string) (*artifact.Executor, *artifact.FileStore) {
+	t.Helper()
+
+	store, err := artifact.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("new file store: %v", err)
+	}
+	next, err := toolingregistry.New(map[string]toolingregistry.Handler{
+		"dump": func(context.Context, map[string]any) (string, error) {
+			return output, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("new registry: %v", err)
+	}
+	executor, err := artifact.New(artifact.Config{
+		Store:           store,
+		Next:            next,
+		MaxContentBytes: 64,
+		PreviewBytes:    16,
+	})
+	if err != nil {
+		t.Fatalf("new executor: %v", err)
+	}
+	return executor, store
+}
+
+func TestExecutor_PassesSmallResultsThrough(t *testing.T) {
+	t.Parallel()
+
+	executor, _ := newExecutor(t, "short output")
+	result, err := executor.Execute(context.Background(), agent.ToolCall{ID: "call-1", Name: "dump"})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if result.Content != "short output" || len(result.Parts) != 0 {
+		t.Fatalf("result mismatch: got=%+v", result)
+	}
+}
+
+func TestExecutor_SpillsLargeResultAndPagesThroughArtifact(t *testing.T) {
+	t.Parallel()
+
+	// The two-byte rune straddles the 100-byte page boundary.
+	output := strings.Repeat("0123456789", 10)[:99] + "é-tail"
+	executor, _ := newExecutor(t, output)
+
+	result, err := executor.Execute(context.Background(), agent.ToolCall{ID: "call-1", Name: "dump"})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if !strings.HasPrefix(result.Content, output[:16]+"\n[truncated: showing 16 of 106 bytes;") {
+		t.Fatalf("preview mismatch: got=%q", result.Content)
+	}
+	if len(result.Parts) != 1 || result.Parts[0].Type != agent.ContentPartTypeFile {
+		t.Fatalf("artifact reference part mismatch: got=%+v", result.Parts)
+	}
+	artifactID := strings.TrimPrefix(result.Parts[0].URI, artifact.URIScheme)
+
+	var reassembled strings.Builder
+	offset := 0
+	for page := 0; ; page++ {
+		if page > 4 {
+			t.Fatalf("paging did not terminate")
+		}
+		pageResult, err := executor.Execute(context.Background(), agent.ToolCall{
+			ID:        "call-page",
+			Name:      artifact.DefaultReadToolName,
+			Arguments: map[string]any{"artifact_id": artifactID, "offset": float64(offset), "limit": float64(50)},
+		})
+		if err != nil {
+			t.Fatalf("read page %d: %v", page, err)
+		}
+		body, footer, found := strings.Cut(pageResult.Content, "\n[bytes ")
+		if !found {
+			t.Fatalf("page footer missing: %q", pageResult.Content)
+		}
+		reassembled.WriteString(body)
+		offset += len(body)
+		if strings.HasSuffix(footer, "end of artifact]") {
+			break
+		}
+	}
+	if reassembled.String() != output {
+		t.Fatalf("reassembled artifact mismatch: got=%q want=%q", reassembled.String(), output)
+	}
+}
+
+func TestExecutor_ReadRejectsInvalidArtifactID(t *testing.T) {
+	t.Parallel()
+
+	executor, _ := newExecutor(t, "")
+	_, err := executor.Execute(context.Background(), agent.ToolCall{
+		ID:        "call-1",
+		Name:      artifact.DefaultReadToolName,
+		Arguments: map[string]any{"artifact_id": "../../etc/passwd"},
+	})
+	if !errors.Is(err, artifact.ErrArtifactIDInvalid) {
+		t.Fatalf("expected ErrArtifactIDInvalid, got %v", err)
+	}
+}
+
+func TestFileStore_DeduplicatesContent(t *testing.T) {
+	t.Parallel()
+
+	store, err := artifact.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("new file store: %v", err)
+	}
+	first, err := store.Put(context.Background(), []byte("payload"))
+	if err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	second, err := store.Put(context.Background(), []byte("payload"))
+	if err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if first != second {
+		t.Fatalf("artifact id mismatch: got=%q want=%q", second, first)
+	}
+	chunk, size, err := store.ReadRange(context.Background(), first, 3, 100)
+	if err != nil {
+		t.Fatalf("read range: %v", err)
+	}
+	if string(chunk) != "load" || size != 7 {
+		t.Fatalf("read range mismatch: chunk=%q size=%d", chunk, size)
+	}
+}
@@ -0,0 +1,135 @@
+package artifact
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	ErrArtifactNotFound  = errors.New("artifact not found")
+	ErrArtifactIDInvalid = errors.New("artifact id is invalid")
+)
+
+// Store persists full tool result payloads that were too large to keep in the
+// transcript.
+type Store interface {
+	// Put stores content and returns its artifact identifier.
+	Put(ctx context.Context, content []byte) (string, error)
+	// ReadRange returns at most limit bytes of the artifact starting at offset,
+	// together with the total artifact size.
+	ReadRange(ctx context.Context, id string, offset, limit int64) ([]byte, int64, error)
+}
+
+// FileStore is a content-addressed Store backed by a directory. Artifact
+// identifiers are the hex SHA-256 digest of the content, so storing the same
+// payload twice yields one file.
+type FileStore struct {
+	dir string
+}
+
+var _ Store = (*FileStore)(nil)
+
+func NewFileStore(dir string) (*FileStore, error) {
+	trimmed := strings.TrimSpace(dir)
+	if trimmed == "" {
+		return nil, fmt.Errorf("new artifact file store: directory is required")
+	}
+	absolute, err := filepath.Abs(trimmed)
+	if err != nil {
+		return nil, fmt.Errorf("new artifact file store: resolve directory: %w", err)
+	}
+	if err := os.MkdirAll(absolute, 0o700); err != nil {
+		return nil, fmt.Errorf("new artifact file store: create directory: %w", err)
+	}
+	return &FileStore{dir: absolute}, nil
+}
+
+// Dir returns the absolute directory holding artifact files.
+func (s *FileStore) Dir() string {
+	return s.dir
+}
+
+func (s *FileStore) Put(ctx context.Context, content []byte) (string, error) {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return "", ctxErr
+	}
+	digest := sha256.Sum256(content)
+	id := hex.EncodeToString(digest[:])
+	path := filepath.Join(s.dir, id)
+	if _, err := os.Stat(path); err == nil {
+		return id, nil
+	}
+
+	tmp, err := os.CreateTemp(s.dir, ".put-*")
+	if err != nil {
+		return "", fmt.Errorf("put artifact: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("put artifact: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("put artifact: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return "", fmt.Errorf("put artifact: %w", err)
+	}
+	return id, nil
+}
+
+func (s *FileStore) ReadRange(ctx context.Context, id string, offset, limit int64) ([]byte, int64, error) {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, 0, ctxErr
+	}
+	if !isContentID(id) {
+		return nil, 0, fmt.Errorf("%w: %q", ErrArtifactIDInvalid, id)
+	}
+	if offset < 0 || limit < 0 {
+		return nil, 0, fmt.Errorf("read artifact %q: offset and limit must not be negative", id)
+	}
+
+	file, err := os.Open(filepath.Join(s.dir, id))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, 0, fmt.Errorf("%w: %q", ErrArtifactNotFound, id)
+		}
+		return nil, 0, fmt.Errorf("read artifact %q: %w", id, err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, 0, fmt.Errorf("read artifact %q: %w", id, err)
+	}
+	size := info.Size()
+	if offset >= size {
+		return []byte{}, size, nil
+	}
+	chunk := make([]byte, min(limit, size-offset))
+	n, err := file.ReadAt(chunk, offset)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, 0, fmt.Errorf("read artifact %q: %w", id, err)
+	}
+	return chunk[:n], size, nil
+}
+
+func isContentID(id string) bool {
+	if len(id) != sha256.Size*2 {
+		return false
+	}
+	for _, r := range id {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
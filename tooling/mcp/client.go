@@ -0,0 +1,251 @@
+// Package mcp connects agentframe to Model Context Protocol servers. A Client
+// lists a server's tools as agent.ToolDefinition values and executes tool calls
+// by forwarding them to the server's tools/call method.
+package mcp
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/Gurpartap/agentframe/agent"
+)
+
+const (
+	// DefaultClientName identifies the client during initialization when
+	// Config.ClientName is empty.
+	DefaultClientName = "agentframe"
+
+	defaultClientVersion = "0.0.0"
+)
+
+// Transport carries JSON-RPC messages between a Client and an MCP server.
+type Transport interface {
+	// RoundTrip sends a request and returns the response with the same ID.
+	RoundTrip(ctx context.Context, request Message) (Message, error)
+	// Notify sends a notification. No response is expected.
+	Notify(ctx context.Context, notification Message) error
+	Close() error
+}
+
+// Config describes one MCP server connection.
+type Config struct {
+	Transport Transport
+
+	ClientName    string
+	ClientVersion string
+
+	// ToolPrefix is prepended to every server tool name advertised to the
+	// model, so that several servers can be combined without name collisions.
+	ToolPrefix string
+}
+
+// Client is an initialized session with an MCP server. It implements the
+// agentreact.ToolExecutor contract for the server's tools.
+type Client struct {
+	transport  Transport
+	toolPrefix string
+	server     InitializeResult
+	nextID     atomic.Int64
+}
+
+// Connect performs the MCP initialization handshake over cfg.Transport. A
+// server that answers with a protocol revision other than ProtocolVersion is
+// rejected with ErrProtocol, since the client speaks only that revision.
+func Connect(ctx context.Context, cfg Config) (*Client, error) {
+	if ctx == nil {
+		return nil, agent.ErrContextNil
+	}
+	if cfg.Transport == nil {
+		return nil, fmt.Errorf("connect mcp client: %w", ErrMissingTransport)
+	}
+	clientName := strings.TrimSpace(cfg.ClientName)
+	if clientName == "" {
+		clientName = DefaultClientName
+	}
+	clientVersion := strings.TrimSpace(cfg.ClientVersion)
+	if clientVersion == "" {
+		clientVersion = defaultClientVersion
+	}
+
+	client := &Client{transport: cfg.Transport, toolPrefix: cfg.ToolPrefix}
+	if err := client.call(ctx, "initialize", InitializeParams{
+		ProtocolVersion: ProtocolVersion,
+		Capabilities:    map[string]any{},
+		ClientInfo:      Implementation{Name: clientName, Version: clientVersion},
+	}, &client.server); err != nil {
+		return nil, fmt.Errorf("connect mcp client: %w", err)
+	}
+	if client.server.ProtocolVersion != ProtocolVersion {
+		return nil, fmt.Errorf(
+			"connect mcp client: %w: field=protocolVersion reason=unsupported value=%q want=%q",
+			ErrProtocol,
+			client.server.ProtocolVersion,
+			ProtocolVersion,
+		)
+	}
+	initialized, err := NewNotification("notifications/initialized", nil)
+	if err != nil {
+		return nil, fmt.Errorf("connect mcp client: %w", err)
+	}
+	if err := cfg.Transport.Notify(ctx, initialized); err != nil {
+		return nil, fmt.Errorf("connect mcp client: %w", err)
+	}
+	return client, nil
+}
+
+// ServerInfo returns the initialization result reported by the server.
+func (c *Client) ServerInfo() InitializeResult {
+	return c.server
+}
+
+// Close ends the session and releases the transport.
+func (c *Client) Close() error {
+	return c.transport.Close()
+}
+
+// ListTools returns every tool the server exposes, following pagination.
+func (c *Client) ListTools(ctx context.Context) ([]agent.ToolDefinition, error) {
+	if ctx == nil {
+		return nil, agent.ErrContextNil
+	}
+	var definitions []agent.ToolDefinition
+	cursor := ""
+	seen := map[string]struct{}{}
+	for {
+		var page ListToolsResult
+		if err := c.call(ctx, "tools/list", ListToolsParams{Cursor: cursor}, &page); err != nil {
+			return nil, fmt.Errorf("list mcp tools: %w", err)
+		}
+		for _, tool := range page.Tools {
+			if strings.TrimSpace(tool.Name) == "" {
+				return nil, fmt.Errorf("list mcp tools: %w: field=tools.name reason=empty", ErrProtocol)
+			}
+			definitions = append(definitions, c.definition(tool))
+		}
+		if page.NextCursor == "" {
+			return definitions, nil
+		}
+		if _, repeated := seen[page.NextCursor]; repeated {
+			return nil, fmt.Errorf("list mcp tools: %w: field=nextCursor reason=repeated value=%q", ErrProtocol, page.NextCursor)
+		}
+		seen[page.NextCursor] = struct{}{}
+		cursor = page.NextCursor
+	}
+}
+
+func (c *Client) definition(tool Tool) agent.ToolDefinition {
+	description := tool.Description
+	if description == "" {
+		description = tool.Title
+	}
+	schema := tool.InputSchema
+	if schema == nil {
+		schema = map[string]any{"type": "object"}
+	}
+	return agent.CloneToolDefinition(agent.ToolDefinition{
+		Name:        c.toolPrefix + tool.Name,
+		Description: description,
+		InputSchema: schema,
+	})
+}
+
+// Execute forwards call to the server's tools/call method. Tool-level failures
+// reported by the server become error results; JSON-RPC errors, such as an
+// unknown tool, are returned as errors wrapping *RPCError.
+func (c *Client) Execute(ctx context.Context, call agent.ToolCall) (agent.ToolResult, error) {
+	if ctx == nil {
+		return agent.ToolResult{}, agent.ErrContextNil
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return agent.ToolResult{}, ctxErr
+	}
+	name, ok := strings.CutPrefix(call.Name, c.toolPrefix)
+	if !ok || name == "" {
+		return agent.ToolResult{}, fmt.Errorf("%w: %q", ErrToolUnregistered, call.Name)
+	}
+
+	var outcome CallToolResult
+	if err := c.call(ctx, "tools/call", CallToolParams{Name: name, Arguments: call.Arguments}, &outcome); err != nil {
+		return agent.ToolResult{}, fmt.Errorf("call mcp tool %q: %w", name, err)
+	}
+	result, err := toolResult(call, outcome)
+	if err != nil {
+		return agent.ToolResult{}, fmt.Errorf("call mcp tool %q: %w", name, err)
+	}
+	return result, nil
+}
+
+func (c *Client) call(ctx context.Context, method string, params any, out any) error {
+	id := json.RawMessage(strconv.FormatInt(c.nextID.Add(1), 10))
+	request, err := NewRequest(id, method, params)
+	if err != nil {
+		return err
+	}
+	response, err := c.transport.RoundTrip(ctx, request)
+	if err != nil {
+		return err
+	}
+	if response.Error != nil {
+		return response.Error
+	}
+	if len(response.Result) == 0 {
+		return fmt.Errorf("%w: field=result reason=missing method=%s", ErrProtocol, method)
+	}
+	if err := json.Unmarshal(response.Result, out); err != nil {
+		return fmt.Errorf("%w: decode %s result: %w", ErrProtocol, method, err)
+	}
+	return nil
+}
+
+// toolResult maps MCP content blocks onto a ToolResult. Text blocks and
+// embedded text resources form Content; images and resource references are
+// kept as content parts.
+func toolResult(call agent.ToolCall, outcome CallToolResult) (agent.ToolResult, error) {
+	result := agent.ToolResult{CallID: call.ID, Name: call.Name}
+	var texts []string
+	for i, block := range outcome.Content {
+		switch block.Type {
+		case ContentTypeText:
+			texts = append(texts, block.Text)
+		case ContentTypeImage:
+			data, err := base64.StdEncoding.DecodeString(block.Data)
+			if err != nil {
+				return agent.ToolResult{}, fmt.Errorf("%w: field=content[%d].data reason=invalid_base64", ErrProtocol, i)
+			}
+			result.Parts = append(result.Parts, agent.ImagePart(block.MIMEType, data))
+		case ContentTypeResourceLink:
+			result.Parts = append(result.Parts, agent.FilePart(block.Name, block.URI, block.MIMEType))
+		case ContentTypeResource:
+			if block.Resource == nil || block.Resource.URI == "" {
+				return agent.ToolResult{}, fmt.Errorf("%w: field=content[%d].resource reason=missing_uri", ErrProtocol, i)
+			}
+			if block.Resource.Text != "" {
+				texts = append(texts, block.Resource.Text)
+			}
+			result.Parts = append(result.Parts, agent.FilePart("", block.Resource.URI, block.Resource.MIMEType))
+		default:
+			texts = append(texts, fmt.Sprintf("[%s content omitted mime_type=%s]", block.Type, block.MIMEType))
+		}
+	}
+	result.Content = strings.Join(texts, "\n")
+
+	if len(outcome.StructuredContent) > 0 && string(outcome.StructuredContent) != "null" {
+		if !json.Valid(outcome.StructuredContent) {
+			return agent.ToolResult{}, fmt.Errorf("%w: field=structuredContent reason=invalid_json", ErrProtocol)
+		}
+		result.Structured = append(json.RawMessage(nil), outcome.StructuredContent...)
+		if result.Content == "" {
+			result.Content = string(outcome.StructuredContent)
+		}
+	}
+	if outcome.IsError {
+		result.IsError = true
+		result.FailureReason = agent.ToolFailureReasonExecutorError
+	}
+	return result, nil
+}
@@ -0,0 +1,331 @@
+package mcp_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Gurpartap/agentframe/agent"
+	"github.com/Gurpartap/agentframe/tooling/mcp"
+)
+
+const stubServerEnv = "AGENTFRAME_MCP_STUB_SERVER"
+
+// TestMain lets the test binary act as a stdio MCP server subprocess.
+func TestMain(m *testing.M) {
+	if os.Getenv(stubServerEnv) == "1" {
+		serveStubStdio(os.Stdin, os.Stdout)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func serveStubStdio(r io.Reader, w io.Writer) {
+	scanner := bufio.NewScanner(r)
+	encoder := json.NewEncoder(w)
+	for scanner.Scan() {
+		var request mcp.Message
+		if err := json.Unmarshal(scanner.Bytes(), &request); err != nil {
+			continue
+		}
+		if response, ok := handleStub(request); ok {
+			_ = encoder.Encode(response)
+		}
+	}
+}
+
+// handleStub implements a two-tool MCP server whose tool list spans two pages.
+func handleStub(request mcp.Message) (mcp.Message, bool) {
+	if request.IsNotification() {
+		return mcp.Message{}, false
+	}
+	var result any
+	switch request.Method {
+	case "initialize":
+		result = mcp.InitializeResult{
+			ProtocolVersion: mcp.ProtocolVersion,
+			Capabilities:    map[string]any{"tools": map[string]any{}},
+			ServerInfo:      mcp.Implementation{Name: "stub", Version: "1.0.0"},
+		}
+	case "tools/list":
+		var params mcp.ListToolsParams
+		_ = json.Unmarshal(request.Params, &params)
+		if params.Cursor == "" {
+			result = mcp.ListToolsResult{
+				Tools: []mcp.Tool{{
+					Name:        "echo",
+					Description: "Echo text back.",
+					InputSchema: map[string]any{
+						"type":       "object",
+						"properties": map[string]any{"text": map[string]any{"type": "string"}},
+						"required":   []any{"text"},
+					},
+				}},
+				NextCursor: "page-2",
+			}
+		} else {
+			result = mcp.ListToolsResult{Tools: []mcp.Tool{{Name: "fail", Title: "Always fails"}}}
+		}
+	case "tools/call":
+		var params mcp.CallToolParams
+		_ = json.Unmarshal(request.Params, &params)
+		switch params.Name {
+		case "echo":
+			text, _ := params.Arguments["text"].(string)
+			structured, _ := json.Marshal(map[string]any{"length": len(text)})
+			result = mcp.CallToolResult{
+				Content: []mcp.Content{
+					{Type: mcp.ContentTypeText, Text: "echo: " + text},
+					{Type: mcp.ContentTypeImage, MIMEType: "image/png", Data: base64.StdEncoding.EncodeToString([]byte("png"))},
+				},
+				StructuredContent: structured,
+			}
+		case "fail":
+			result = mcp.CallToolResult{
+				Content: []mcp.Content{{Type: mcp.ContentTypeText, Text: "disk full"}},
+				IsError: true,
+			}
+		default:
+			return mcp.NewErrorResponse(request.ID, mcp.CodeInvalidParams, fmt.Sprintf("unknown tool %q", params.Name)), true
+		}
+	default:
+		return mcp.NewErrorResponse(request.ID, mcp.CodeMethodNotFound, "method not found"), true
+	}
+	response, err := mcp.NewResult(request.ID, result)
+	if err != nil {
+		return mcp.NewErrorResponse(request.ID, mcp.CodeInternalError, err.Error()), true
+	}
+	return response, true
+}
+
+func exerciseClient(t *testing.T, client *mcp.Client, prefix string) {
+	t.Helper()
+	ctx := context.Background()
+
+	if client.ServerInfo().ServerInfo.Name != "stub" {
+		t.Fatalf("server info mismatch: got=%+v", client.ServerInfo())
+	}
+	definitions, err := client.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("list tools: %v", err)
+	}
+	if len(definitions) != 2 || definitions[0].Name != prefix+"echo" || definitions[1].Name != prefix+"fail" {
+		t.Fatalf("tool definitions mismatch: got=%+v", definitions)
+	}
+	if definitions[0].InputSchema["required"] == nil || definitions[1].InputSchema["type"] != "object" {
+		t.Fatalf("tool schema mismatch: got=%+v", definitions)
+	}
+	if definitions[1].Description != "Always fails" {
+		t.Fatalf("title fallback mismatch: got=%q", definitions[1].Description)
+	}
+
+	result, err := client.Execute(ctx, agent.ToolCall{ID: "call-1", Name: prefix + "echo", Arguments: map[string]any{"text": "hi"}})
+	if err != nil {
+		t.Fatalf("execute echo: %v", err)
+	}
+	if result.CallID != "call-1" || result.Name != prefix+"echo" || result.Content != "echo: hi" || result.IsError {
+		t.Fatalf("echo result mismatch: got=%+v", result)
+	}
+	if string(result.Structured) != `{"length":2}` {
+		t.Fatalf("structured content mismatch: got=%s", result.Structured)
+	}
+	if len(result.Parts) != 1 || result.Parts[0].Type != agent.ContentPartTypeImage || string(result.Parts[0].Data) != "png" {
+		t.Fatalf("image part mismatch: got=%+v", result.Parts)
+	}
+
+	failed, err := client.Execute(ctx, agent.ToolCall{ID: "call-2", Name: prefix + "fail"})
+	if err != nil {
+		t.Fatalf("execute fail: %v", err)
+	}
+	if !failed.IsError || failed.FailureReason != agent.ToolFailureReasonExecutorError || failed.Content != "disk full" {
+		t.Fatalf("error result mismatch: got=%+v", failed)
+	}
+
+	_, err = client.Execute(ctx, agent.ToolCall{ID: "call-3", Name: prefix + "missing"})
+	var rpcErr *mcp.RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != mcp.CodeInvalidParams {
+		t.Fatalf("expected invalid params rpc error, got %v", err)
+	}
+}
+
+func TestClient_StdioSubprocess(t *testing.T) {
+	t.Parallel()
+
+	transport, err := mcp.NewStdioTransport(mcp.StdioConfig{
+		Command: os.Args[0],
+		Env:     append(os.Environ(), stubServerEnv+"=1"),
+	})
+	if err != nil {
+		t.Fatalf("new stdio transport: %v", err)
+	}
+	client, err := mcp.Connect(context.Background(), mcp.Config{Transport: transport, ToolPrefix: "stub_"})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	exerciseClient(t, client, "stub_")
+
+	_, err = client.Execute(context.Background(), agent.ToolCall{ID: "call-4", Name: "echo"})
+	if !errors.Is(err, mcp.ErrToolUnregistered) {
+		t.Fatalf("expected ErrToolUnregistered for unprefixed name, got %v", err)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+}
+
+func TestClient_StreamableHTTP(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		var request mcp.Message
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if request.Method == "initialize" {
+			w.Header().Set("Mcp-Session-Id", "session-1")
+		} else if r.Header.Get("Mcp-Session-Id") != "session-1" {
+			http.Error(w, "missing session", http.StatusBadRequest)
+			return
+		}
+		response, ok := handleStub(request)
+		if !ok {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		encoded, _ := json.Marshal(response)
+		if request.Method == "tools/call" {
+			// Stream tool results, preceded by an unrelated notification.
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "event: message\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/progress\"}\n\n")
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", encoded)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(encoded)
+	}))
+	defer server.Close()
+
+	transport, err := mcp.NewHTTPTransport(mcp.HTTPConfig{URL: server.URL, HTTPClient: server.Client()})
+	if err != nil {
+		t.Fatalf("new http transport: %v", err)
+	}
+	client, err := mcp.Connect(context.Background(), mcp.Config{Transport: transport})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if transport.SessionID() != "session-1" {
+		t.Fatalf("session id mismatch: got=%q want=%q", transport.SessionID(), "session-1")
+	}
+	exerciseClient(t, client, "")
+	if err := client.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+}
+
+func TestStreamTransport_FailsPendingRequestsWhenServerExits(t *testing.T) {
+	t.Parallel()
+
+	serverReader, clientWriter := io.Pipe()
+	clientReader, serverWriter := io.Pipe()
+	transport := mcp.NewStreamTransport(clientReader, clientWriter)
+	go func() {
+		// Read the request and hang up without answering.
+		_, _ = bufio.NewReader(serverReader).ReadBytes('\n')
+		serverWriter.Close()
+	}()
+
+	request, err := mcp.NewRequest(json.RawMessage("1"), "tools/list", nil)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	_, err = transport.RoundTrip(context.Background(), request)
+	if !errors.Is(err, mcp.ErrTransportClosed) {
+		t.Fatalf("expected ErrTransportClosed, got %v", err)
+	}
+}
+
+func TestStreamTransport_DropsDuplicateResponses(t *testing.T) {
+	t.Parallel()
+
+	serverReader, clientWriter := io.Pipe()
+	clientReader, serverWriter := io.Pipe()
+	transport := mcp.NewStreamTransport(clientReader, clientWriter)
+	t.Cleanup(func() { _ = serverWriter.Close() })
+	go func() {
+		reader := bufio.NewReader(serverReader)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if err != nil {
+				return
+			}
+			var request mcp.Message
+			if err := json.Unmarshal(line, &request); err != nil {
+				return
+			}
+			response, _ := mcp.NewResult(request.ID, map[string]any{})
+			encoded, _ := json.Marshal(response)
+			// Answer every request three times.
+			for range 3 {
+				_, _ = serverWriter.Write(append(encoded, '\n'))
+			}
+		}
+	}()
+
+	for i := 1; i <= 3; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		request, err := mcp.NewRequest(json.RawMessage(strconv.Itoa(i)), "ping", nil)
+		if err != nil {
+			cancel()
+			t.Fatalf("new request: %v", err)
+		}
+		_, err = transport.RoundTrip(ctx, request)
+		cancel()
+		if err != nil {
+			t.Fatalf("round trip %d: %v", i, err)
+		}
+	}
+}
+
+func TestConnect_RejectsUnsupportedProtocolVersion(t *testing.T) {
+	t.Parallel()
+
+	serverReader, clientWriter := io.Pipe()
+	clientReader, serverWriter := io.Pipe()
+	transport := mcp.NewStreamTransport(clientReader, clientWriter)
+	t.Cleanup(func() { _ = serverWriter.Close() })
+	go func() {
+		line, err := bufio.NewReader(serverReader).ReadBytes('\n')
+		if err != nil {
+			return
+		}
+		var request mcp.Message
+		if err := json.Unmarshal(line, &request); err != nil {
+			return
+		}
+		response, _ := mcp.NewResult(request.ID, mcp.InitializeResult{
+			ProtocolVersion: "2024-11-05",
+			ServerInfo:      mcp.Implementation{Name: "old", Version: "0.1.0"},
+		})
+		_ = json.NewEncoder(serverWriter).Encode(response)
+	}()
+
+	_, err := mcp.Connect(context.Background(), mcp.Config{Transport: transport})
+	if !errors.Is(err, mcp.ErrProtocol) {
+		t.Fatalf("expected ErrProtocol, got %v", err)
+	}
+}
@@ -0,0 +1,242 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	sessionIDHeader       = "Mcp-Session-Id"
+	protocolVersionHeader = "MCP-Protocol-Version"
+
+	// DefaultMaxResponseBytes is used when HTTPConfig.MaxResponseBytes is zero.
+	DefaultMaxResponseBytes = 8 << 20
+)
+
+// HTTPConfig connects to a server over the MCP streamable HTTP transport.
+type HTTPConfig struct {
+	// URL is the server's MCP endpoint.
+	URL        string
+	HTTPClient *http.Client
+	// Header is added to every request, e.g. for authorization.
+	Header http.Header
+	// MaxResponseBytes caps one response body. Defaults to DefaultMaxResponseBytes.
+	MaxResponseBytes int64
+}
+
+// HTTPTransport posts each message to the MCP endpoint and accepts either a
+// JSON body or a server-sent event stream in reply. The session ID assigned by
+// the server is sent back on every later request.
+type HTTPTransport struct {
+	url      string
+	client   *http.Client
+	header   http.Header
+	maxBytes int64
+
+	mu        sync.Mutex
+	sessionID string
+}
+
+var _ Transport = (*HTTPTransport)(nil)
+
+func NewHTTPTransport(cfg HTTPConfig) (*HTTPTransport, error) {
+	if strings.TrimSpace(cfg.URL) == "" {
+		return nil, fmt.Errorf("new mcp http transport: url is required")
+	}
+	client := cfg.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	maxBytes := cfg.MaxResponseBytes
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxResponseBytes
+	}
+	return &HTTPTransport{
+		url:      cfg.URL,
+		client:   client,
+		header:   cfg.Header.Clone(),
+		maxBytes: maxBytes,
+	}, nil
+}
+
+// SessionID returns the session identifier assigned by the server, if any.
+func (t *HTTPTransport) SessionID() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sessionID
+}
+
+func (t *HTTPTransport) RoundTrip(ctx context.Context, request Message) (Message, error) {
+	if !request.IsRequest() {
+		return Message{}, fmt.Errorf("%w: field=request reason=missing_id_or_method", ErrProtocol)
+	}
+	response, err := t.post(ctx, request)
+	if err != nil {
+		return Message{}, err
+	}
+	defer response.Body.Close()
+
+	body := io.LimitReader(response.Body, t.maxBytes)
+	mediaType, _, _ := mime.ParseMediaType(response.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/json":
+		var message Message
+		if err := json.NewDecoder(body).Decode(&message); err != nil {
+			return Message{}, fmt.Errorf("%w: decode response: %w", ErrProtocol, err)
+		}
+		if string(message.ID) != string(request.ID) {
+			return Message{}, fmt.Errorf("%w: field=id reason=mismatch got=%s want=%s", ErrProtocol, message.ID, request.ID)
+		}
+		return message, nil
+	case "text/event-stream":
+		return readEventStreamResponse(body, request.ID)
+	default:
+		return Message{}, fmt.Errorf("%w: field=content_type reason=unsupported value=%q", ErrProtocol, mediaType)
+	}
+}
+
+func (t *HTTPTransport) Notify(ctx context.Context, notification Message) error {
+	response, err := t.post(ctx, notification)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, t.maxBytes))
+	return nil
+}
+
+// Close ends the server session when one was assigned.
+func (t *HTTPTransport) Close() error {
+	sessionID := t.SessionID()
+	if sessionID == "" {
+		return nil
+	}
+	request, err := http.NewRequest(http.MethodDelete, t.url, nil)
+	if err != nil {
+		return fmt.Errorf("close mcp session: %w", err)
+	}
+	t.applyHeaders(request, sessionID)
+	response, err := t.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("close mcp session: %w", err)
+	}
+	response.Body.Close()
+	t.mu.Lock()
+	t.sessionID = ""
+	t.mu.Unlock()
+	return nil
+}
+
+func (t *HTTPTransport) post(ctx context.Context, message Message) (*http.Response, error) {
+	encoded, err := json.Marshal(message)
+	if err != nil {
+		return nil, fmt.Errorf("encode mcp message: %w", err)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("build mcp request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json, text/event-stream")
+	t.applyHeaders(request, t.SessionID())
+
+	response, err := t.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("send mcp %s: %w", messageLabel(message), err)
+	}
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		response.Body.Close()
+		return nil, fmt.Errorf(
+			"%w: mcp %s returned http status %d: %s",
+			ErrProtocol,
+			messageLabel(message),
+			response.StatusCode,
+			strings.TrimSpace(string(snippet)),
+		)
+	}
+	if sessionID := response.Header.Get(sessionIDHeader); sessionID != "" {
+		t.mu.Lock()
+		t.sessionID = sessionID
+		t.mu.Unlock()
+	}
+	return response, nil
+}
+
+func (t *HTTPTransport) applyHeaders(request *http.Request, sessionID string) {
+	for key, values := range t.header {
+		for _, value := range values {
+			request.Header.Add(key, value)
+		}
+	}
+	request.Header.Set(protocolVersionHeader, ProtocolVersion)
+	if sessionID != "" {
+		request.Header.Set(sessionIDHeader, sessionID)
+	}
+}
+
+func messageLabel(message Message) string {
+	if message.Method != "" {
+		return message.Method
+	}
+	return "response"
+}
+
+// readEventStreamResponse scans a server-sent event stream until the response
+// to the request identified by id arrives. Server notifications and requests
+// delivered on the stream are skipped.
+func readEventStreamResponse(body io.Reader, id json.RawMessage) (Message, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64<<10), DefaultMaxResponseBytes)
+	var data strings.Builder
+	flush := func() (Message, bool, error) {
+		if data.Len() == 0 {
+			return Message{}, false, nil
+		}
+		payload := data.String()
+		data.Reset()
+		var message Message
+		if err := json.Unmarshal([]byte(payload), &message); err != nil {
+			return Message{}, false, fmt.Errorf("%w: decode event: %w", ErrProtocol, err)
+		}
+		return message, message.IsResponse() && string(message.ID) == string(id), nil
+	}
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			message, matched, err := flush()
+			if err != nil {
+				return Message{}, err
+			}
+			if matched {
+				return message, nil
+			}
+			continue
+		}
+		if value, ok := strings.CutPrefix(line, "data:"); ok {
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(value, " "))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Message{}, fmt.Errorf("%w: read event stream: %w", ErrProtocol, err)
+	}
+	message, matched, err := flush()
+	if err != nil {
+		return Message{}, err
+	}
+	if matched {
+		return message, nil
+	}
+	return Message{}, fmt.Errorf("%w: field=event_stream reason=ended_without_response id=%s", ErrProtocol, id)
+}
@@ -0,0 +1,192 @@
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ProtocolVersion is the MCP revision negotiated during initialization.
+const ProtocolVersion = "2025-06-18"
+
+const jsonRPCVersion = "2.0"
+
+// JSON-RPC 2.0 error codes used by MCP.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+var (
+	ErrMissingTransport = errors.New("mcp transport is required")
+	ErrTransportClosed  = errors.New("mcp transport is closed")
+	ErrProtocol         = errors.New("mcp protocol violation")
+	ErrToolUnregistered = errors.New("tool is not registered")
+)
+
+// Message is one JSON-RPC 2.0 request, notification or response.
+type Message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// IsRequest reports whether m expects a response.
+func (m Message) IsRequest() bool {
+	return m.Method != "" && len(m.ID) > 0
+}
+
+// IsNotification reports whether m is a request without an identifier.
+func (m Message) IsNotification() bool {
+	return m.Method != "" && len(m.ID) == 0
+}
+
+// IsResponse reports whether m answers a request.
+func (m Message) IsResponse() bool {
+	return m.Method == "" && len(m.ID) > 0
+}
+
+// NewRequest builds a request message with JSON-encoded params.
+func NewRequest(id json.RawMessage, method string, params any) (Message, error) {
+	raw, err := marshalParams(params)
+	if err != nil {
+		return Message{}, fmt.Errorf("encode %s params: %w", method, err)
+	}
+	return Message{JSONRPC: jsonRPCVersion, ID: id, Method: method, Params: raw}, nil
+}
+
+// NewNotification builds a notification message with JSON-encoded params.
+func NewNotification(method string, params any) (Message, error) {
+	raw, err := marshalParams(params)
+	if err != nil {
+		return Message{}, fmt.Errorf("encode %s params: %w", method, err)
+	}
+	return Message{JSONRPC: jsonRPCVersion, Method: method, Params: raw}, nil
+}
+
+// NewResult builds a successful response to the request identified by id.
+func NewResult(id json.RawMessage, result any) (Message, error) {
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return Message{}, fmt.Errorf("encode result: %w", err)
+	}
+	return Message{JSONRPC: jsonRPCVersion, ID: id, Result: raw}, nil
+}
+
+// NewErrorResponse builds an error response to the request identified by id.
+func NewErrorResponse(id json.RawMessage, code int, message string) Message {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	return Message{JSONRPC: jsonRPCVersion, ID: id, Error: &RPCError{Code: code, Message: message}}
+}
+
+func marshalParams(params any) (json.RawMessage, error) {
+	if params == nil {
+		return nil, nil
+	}
+	return json.Marshal(params)
+}
+
+// RPCError is a JSON-RPC error object returned by the peer.
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	if e == nil {
+		return "mcp rpc error"
+	}
+	return fmt.Sprintf("mcp rpc error code=%d: %s", e.Code, e.Message)
+}
+
+// Implementation identifies an MCP client or server.
+type Implementation struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// InitializeParams is sent by the client to open a session.
+type InitializeParams struct {
+	ProtocolVersion string         `json:"protocolVersion"`
+	Capabilities    map[string]any `json:"capabilities"`
+	ClientInfo      Implementation `json:"clientInfo"`
+}
+
+// InitializeResult is the server answer to initialize.
+type InitializeResult struct {
+	ProtocolVersion string         `json:"protocolVersion"`
+	Capabilities    map[string]any `json:"capabilities"`
+	ServerInfo      Implementation `json:"serverInfo"`
+	Instructions    string         `json:"instructions,omitempty"`
+}
+
+// Tool is one entry of a tools/list result.
+type Tool struct {
+	Name         string         `json:"name"`
+	Title        string         `json:"title,omitempty"`
+	Description  string         `json:"description,omitempty"`
+	InputSchema  map[string]any `json:"inputSchema"`
+	OutputSchema map[string]any `json:"outputSchema,omitempty"`
+}
+
+// ListToolsParams requests one page of tools.
+type ListToolsParams struct {
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// ListToolsResult is one page of tools.
+type ListToolsResult struct {
+	Tools      []Tool `json:"tools"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// CallToolParams invokes a tool by name.
+type CallToolParams struct {
+	Name      string         `json:"name"`
+	Arguments map[string]any `json:"arguments,omitempty"`
+}
+
+// CallToolResult is the outcome of tools/call. Tool-level failures are
+// reported with IsError rather than as a JSON-RPC error.
+type CallToolResult struct {
+	Content           []Content       `json:"content"`
+	StructuredContent json.RawMessage `json:"structuredContent,omitempty"`
+	IsError           bool            `json:"isError,omitempty"`
+}
+
+// Content is one content block of a tool result.
+type Content struct {
+	Type     string            `json:"type"`
+	Text     string            `json:"text,omitempty"`
+	Data     string            `json:"data,omitempty"`
+	MIMEType string            `json:"mimeType,omitempty"`
+	URI      string            `json:"uri,omitempty"`
+	Name     string            `json:"name,omitempty"`
+	Resource *ResourceContents `json:"resource,omitempty"`
+}
+
+// ResourceContents is the payload of an embedded resource content block.
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MIMEType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"`
+}
+
+// Content block types.
+const (
+	ContentTypeText         = "text"
+	ContentTypeImage        = "image"
+	ContentTypeAudio        = "audio"
+	ContentTypeResource     = "resource"
+	ContentTypeResourceLink = "resource_link"
+)
@@ -0,0 +1,232 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StreamTransport exchanges newline-delimited JSON-RPC messages over a byte
+// stream, as MCP does over a subprocess's stdin and stdout. Requests may be in
+// flight concurrently; responses are matched by ID.
+type StreamTransport struct {
+	writer  io.WriteCloser
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	pending map[string]chan Message
+	readErr error
+	done    chan struct{}
+}
+
+var _ Transport = (*StreamTransport)(nil)
+
+// NewStreamTransport starts reading messages from r and writes messages to w.
+func NewStreamTransport(r io.Reader, w io.WriteCloser) *StreamTransport {
+	t := &StreamTransport{
+		writer:  w,
+		pending: make(map[string]chan Message),
+		done:    make(chan struct{}),
+	}
+	go t.readLoop(r)
+	return t
+}
+
+func (t *StreamTransport) RoundTrip(ctx context.Context, request Message) (Message, error) {
+	if !request.IsRequest() {
+		return Message{}, fmt.Errorf("%w: field=request reason=missing_id_or_method", ErrProtocol)
+	}
+	key := string(request.ID)
+	responses := make(chan Message, 1)
+	t.mu.Lock()
+	if t.readErr != nil {
+		err := t.readErr
+		t.mu.Unlock()
+		return Message{}, err
+	}
+	t.pending[key] = responses
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		delete(t.pending, key)
+		t.mu.Unlock()
+	}()
+
+	if err := t.write(request); err != nil {
+		return Message{}, err
+	}
+	select {
+	case response := <-responses:
+		return response, nil
+	case <-ctx.Done():
+		return Message{}, ctx.Err()
+	case <-t.done:
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		return Message{}, t.readErr
+	}
+}
+
+func (t *StreamTransport) Notify(ctx context.Context, notification Message) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return t.write(notification)
+}
+
+// Close closes the write side of the stream. The peer is expected to exit
+// once its input ends.
+func (t *StreamTransport) Close() error {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	return t.writer.Close()
+}
+
+func (t *StreamTransport) write(message Message) error {
+	encoded, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("encode mcp message: %w", err)
+	}
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	if _, err := t.writer.Write(append(encoded, '\n')); err != nil {
+		return fmt.Errorf("%w: write: %w", ErrTransportClosed, err)
+	}
+	return nil
+}
+
+func (t *StreamTransport) readLoop(r io.Reader) {
+	reader := bufio.NewReader(r)
+	var loopErr error
+	for {
+		line, err := reader.ReadBytes('\n')
+		if trimmed := strings.TrimSpace(string(line)); trimmed != "" {
+			t.dispatch([]byte(trimmed))
+		}
+		if err != nil {
+			loopErr = err
+			break
+		}
+	}
+
+	t.mu.Lock()
+	if errors.Is(loopErr, io.EOF) {
+		t.readErr = ErrTransportClosed
+	} else {
+		t.readErr = fmt.Errorf("%w: read: %w", ErrTransportClosed, loopErr)
+	}
+	t.mu.Unlock()
+	close(t.done)
+}
+
+func (t *StreamTransport) dispatch(line []byte) {
+	var message Message
+	if err := json.Unmarshal(line, &message); err != nil {
+		// Servers may log to stdout by mistake; a line that is not a JSON-RPC
+		// message cannot be matched to a request and is dropped.
+		return
+	}
+	switch {
+	case message.IsResponse():
+		// Only the first response for an ID is delivered; removing the entry
+		// drops duplicates and late answers instead of blocking the read loop.
+		t.mu.Lock()
+		responses, ok := t.pending[string(message.ID)]
+		delete(t.pending, string(message.ID))
+		t.mu.Unlock()
+		if ok {
+			responses <- message
+		}
+	case message.IsRequest():
+		// The client advertises no capabilities, so ping is the only
+		// server-initiated request it answers.
+		reply := NewErrorResponse(message.ID, CodeMethodNotFound, fmt.Sprintf("method %q is not supported", message.Method))
+		if message.Method == "ping" {
+			reply = Message{JSONRPC: jsonRPCVersion, ID: message.ID, Result: json.RawMessage("{}")}
+		}
+		_ = t.write(reply)
+	}
+}
+
+// StdioConfig launches a local MCP server subprocess.
+type StdioConfig struct {
+	Command string
+	Args    []string
+	// Env replaces the subprocess environment when non-nil.
+	Env []string
+	Dir string
+	// Stderr receives the server's diagnostic output. Defaults to discarding it.
+	Stderr io.Writer
+	// ShutdownTimeout bounds how long Close waits for the server to exit after
+	// its input is closed before killing it. Defaults to DefaultShutdownTimeout.
+	ShutdownTimeout time.Duration
+}
+
+// DefaultShutdownTimeout is used when StdioConfig.ShutdownTimeout is zero.
+const DefaultShutdownTimeout = 2 * time.Second
+
+// StdioTransport is a StreamTransport connected to a server subprocess.
+type StdioTransport struct {
+	*StreamTransport
+
+	cmd             *exec.Cmd
+	shutdownTimeout time.Duration
+	closeOnce       sync.Once
+	closeErr        error
+}
+
+// NewStdioTransport starts the server process described by cfg.
+func NewStdioTransport(cfg StdioConfig) (*StdioTransport, error) {
+	if strings.TrimSpace(cfg.Command) == "" {
+		return nil, fmt.Errorf("new mcp stdio transport: command is required")
+	}
+	cmd := exec.Command(cfg.Command, cfg.Args...)
+	cmd.Env = cfg.Env
+	cmd.Dir = cfg.Dir
+	cmd.Stderr = cfg.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("new mcp stdio transport: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("new mcp stdio transport: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("new mcp stdio transport: start %q: %w", cfg.Command, err)
+	}
+	shutdownTimeout := cfg.ShutdownTimeout
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = DefaultShutdownTimeout
+	}
+	return &StdioTransport{
+		StreamTransport: NewStreamTransport(stdout, stdin),
+		cmd:             cmd,
+		shutdownTimeout: shutdownTimeout,
+	}, nil
+}
+
+// Close closes the server's input and waits for it to exit, killing it after
+// the shutdown timeout.
+func (t *StdioTransport) Close() error {
+	t.closeOnce.Do(func() {
+		_ = t.StreamTransport.Close()
+		exited := make(chan error, 1)
+		go func() { exited <- t.cmd.Wait() }()
+		select {
+		case <-exited:
+		case <-time.After(t.shutdownTimeout):
+			_ = t.cmd.Process.Kill()
+			<-exited
+			t.closeErr = fmt.Errorf("mcp server %q did not exit within %s and was killed", t.cmd.Path, t.shutdownTimeout)
+		}
+	})
+	return t.closeErr
+}
@@ -0,0 +1,390 @@
+package openapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Gurpartap/agentframe/agent"
+)
+
+// BodyArgument is the InputSchema property that carries the request body.
+const BodyArgument = "body"
+
+const maxToolNameLength = 64
+
+var httpMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+var invalidToolNameRunes = regexp.MustCompile(`[^A-Za-z0-9-]+`)
+
+// Document is the subset of an OpenAPI 3 document needed to generate tools.
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Servers    []Server            `json:"servers"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Server struct {
+	URL string `json:"url"`
+}
+
+type PathItem struct {
+	Parameters []Parameter `json:"parameters"`
+	Get        *Operation  `json:"get"`
+	Put        *Operation  `json:"put"`
+	Post       *Operation  `json:"post"`
+	Delete     *Operation  `json:"delete"`
+	Options    *Operation  `json:"options"`
+	Head       *Operation  `json:"head"`
+	Patch      *Operation  `json:"patch"`
+	Trace      *Operation  `json:"trace"`
+}
+
+func (p PathItem) operation(method string) *Operation {
+	switch method {
+	case "get":
+		return p.Get
+	case "put":
+		return p.Put
+	case "post":
+		return p.Post
+	case "delete":
+		return p.Delete
+	case "options":
+		return p.Options
+	case "head":
+		return p.Head
+	case "patch":
+		return p.Patch
+	case "trace":
+		return p.Trace
+	default:
+		return nil
+	}
+}
+
+type Operation struct {
+	OperationID string       `json:"operationId"`
+	Summary     string       `json:"summary"`
+	Description string       `json:"description"`
+	Parameters  []Parameter  `json:"parameters"`
+	RequestBody *RequestBody `json:"requestBody"`
+}
+
+type Parameter struct {
+	Ref         string         `json:"$ref"`
+	Name        string         `json:"name"`
+	In          string         `json:"in"`
+	Description string         `json:"description"`
+	Required    bool           `json:"required"`
+	Schema      map[string]any `json:"schema"`
+}
+
+type RequestBody struct {
+	Ref         string               `json:"$ref"`
+	Description string               `json:"description"`
+	Required    bool                 `json:"required"`
+	Content     map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema map[string]any `json:"schema"`
+}
+
+type Components struct {
+	Schemas       map[string]map[string]any `json:"schemas"`
+	Parameters    map[string]Parameter      `json:"parameters"`
+	RequestBodies map[string]RequestBody    `json:"requestBodies"`
+}
+
+// Parse decodes a JSON OpenAPI 3 document.
+func Parse(data []byte) (*Document, error) {
+	var document Document
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("%w: decode: %w", ErrInvalidDocument, err)
+	}
+	if !strings.HasPrefix(document.OpenAPI, "3.") {
+		return nil, fmt.Errorf("%w: field=openapi reason=unsupported_version value=%q", ErrInvalidDocument, document.OpenAPI)
+	}
+	if len(document.Paths) == 0 {
+		return nil, fmt.Errorf("%w: field=paths reason=empty", ErrInvalidDocument)
+	}
+	return &document, nil
+}
+
+// operation is one resolved OpenAPI operation bound to a tool name.
+type operation struct {
+	definition agent.ToolDefinition
+	method     string
+	path       string
+	parameters []Parameter
+	hasBody    bool
+	bodyMIME   string
+	bodyNeeded bool
+}
+
+// errNoJSONRequestBody marks operations whose request body cannot be sent as
+// JSON, such as multipart uploads; they are skipped rather than failing the
+// whole document.
+var errNoJSONRequestBody = errors.New("request body has no JSON media type")
+
+// operations resolves every operation in the document in path and method
+// order, so generated tool lists are deterministic. skipped lists operations
+// left out because their request body is not JSON.
+func (d *Document) operations(prefix string) ([]operation, []string, error) {
+	paths := make([]string, 0, len(d.Paths))
+	for path := range d.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var (
+		out     []operation
+		skipped []string
+	)
+	names := map[string]string{}
+	for _, path := range paths {
+		item := d.Paths[path]
+		for _, method := range httpMethods {
+			op := item.operation(method)
+			if op == nil {
+				continue
+			}
+			resolved, err := d.resolveOperation(prefix, method, path, item.Parameters, op)
+			if errors.Is(err, errNoJSONRequestBody) {
+				skipped = append(skipped, strings.ToUpper(method)+" "+path)
+				continue
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			name := resolved.definition.Name
+			if previous, exists := names[name]; exists {
+				return nil, nil, fmt.Errorf(
+					"%w: field=operationId reason=duplicate_tool_name name=%q operations=%q,%q",
+					ErrInvalidDocument,
+					name,
+					previous,
+					strings.ToUpper(method)+" "+path,
+				)
+			}
+			names[name] = strings.ToUpper(method) + " " + path
+			out = append(out, resolved)
+		}
+	}
+	return out, skipped, nil
+}
+
+func (d *Document) resolveOperation(
+	prefix string,
+	method string,
+	path string,
+	shared []Parameter,
+	op *Operation,
+) (operation, error) {
+	label := strings.ToUpper(method) + " " + path
+	name := op.OperationID
+	if name == "" {
+		name = method + "_" + path
+	}
+	name = strings.Trim(invalidToolNameRunes.ReplaceAllString(prefix+name, "_"), "_")
+	if len(name) > maxToolNameLength {
+		name = name[:maxToolNameLength]
+	}
+
+	// Operation parameters override path-level ones with the same name and location.
+	byKey := map[string]Parameter{}
+	var order []string
+	for _, list := range [][]Parameter{shared, op.Parameters} {
+		for _, raw := range list {
+			parameter, err := d.resolveParameter(raw)
+			if err != nil {
+				return operation{}, fmt.Errorf("%w: operation=%q", err, label)
+			}
+			key := parameter.In + ":" + parameter.Name
+			if _, seen := byKey[key]; !seen {
+				order = append(order, key)
+			}
+			byKey[key] = parameter
+		}
+	}
+
+	properties := map[string]any{}
+	var required []any
+	resolved := operation{method: strings.ToUpper(method), path: path}
+	for _, key := range order {
+		parameter := byKey[key]
+		if parameter.In == "cookie" {
+			continue
+		}
+		if _, taken := properties[parameter.Name]; taken || parameter.Name == BodyArgument {
+			return operation{}, fmt.Errorf(
+				"%w: field=parameters reason=name_conflict name=%q operation=%q",
+				ErrInvalidDocument,
+				parameter.Name,
+				label,
+			)
+		}
+		schema := d.resolveSchema(parameter.Schema, map[string]bool{})
+		if schema == nil {
+			schema = map[string]any{"type": "string"}
+		}
+		if parameter.Description != "" {
+			schema["description"] = parameter.Description
+		}
+		properties[parameter.Name] = schema
+		if parameter.Required || parameter.In == "path" {
+			required = append(required, parameter.Name)
+		}
+		resolved.parameters = append(resolved.parameters, parameter)
+	}
+
+	if op.RequestBody != nil {
+		body, err := d.resolveRequestBody(*op.RequestBody)
+		if err != nil {
+			return operation{}, fmt.Errorf("%w: operation=%q", err, label)
+		}
+		mimeType, media, ok := jsonMediaType(body.Content)
+		if !ok {
+			return operation{}, fmt.Errorf("%w: operation=%q", errNoJSONRequestBody, label)
+		}
+		schema := d.resolveSchema(media.Schema, map[string]bool{})
+		if schema == nil {
+			schema = map[string]any{}
+		}
+		if body.Description != "" {
+			schema["description"] = body.Description
+		}
+		properties[BodyArgument] = schema
+		if body.Required {
+			required = append(required, BodyArgument)
+		}
+		resolved.hasBody = true
+		resolved.bodyMIME = mimeType
+		resolved.bodyNeeded = body.Required
+	}
+
+	inputSchema := map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		inputSchema["required"] = required
+	}
+	description := strings.TrimSpace(op.Summary)
+	if detail := strings.TrimSpace(op.Description); detail != "" && detail != description {
+		description = strings.TrimSpace(description + "\n" + detail)
+	}
+	if description == "" {
+		description = label
+	}
+	resolved.definition = agent.ToolDefinition{
+		Name:        name,
+		Description: description,
+		InputSchema: inputSchema,
+	}
+	return resolved, nil
+}
+
+func (d *Document) resolveParameter(parameter Parameter) (Parameter, error) {
+	if parameter.Ref != "" {
+		name, ok := strings.CutPrefix(parameter.Ref, "#/components/parameters/")
+		if !ok {
+			return Parameter{}, fmt.Errorf("%w: field=$ref reason=unsupported value=%q", ErrInvalidDocument, parameter.Ref)
+		}
+		target, found := d.Components.Parameters[name]
+		if !found || target.Ref != "" {
+			return Parameter{}, fmt.Errorf("%w: field=$ref reason=unresolved value=%q", ErrInvalidDocument, parameter.Ref)
+		}
+		parameter = target
+	}
+	switch parameter.In {
+	case "path", "query", "header", "cookie":
+	default:
+		return Parameter{}, fmt.Errorf(
+			"%w: field=parameters.in reason=unsupported name=%q value=%q",
+			ErrInvalidDocument,
+			parameter.Name,
+			parameter.In,
+		)
+	}
+	if parameter.Name == "" {
+		return Parameter{}, fmt.Errorf("%w: field=parameters.name reason=empty", ErrInvalidDocument)
+	}
+	return parameter, nil
+}
+
+func (d *Document) resolveRequestBody(body RequestBody) (RequestBody, error) {
+	if body.Ref == "" {
+		return body, nil
+	}
+	name, ok := strings.CutPrefix(body.Ref, "#/components/requestBodies/")
+	if !ok {
+		return RequestBody{}, fmt.Errorf("%w: field=$ref reason=unsupported value=%q", ErrInvalidDocument, body.Ref)
+	}
+	target, found := d.Components.RequestBodies[name]
+	if !found || target.Ref != "" {
+		return RequestBody{}, fmt.Errorf("%w: field=$ref reason=unresolved value=%q", ErrInvalidDocument, body.Ref)
+	}
+	return target, nil
+}
+
+// resolveSchema returns a deep copy of schema with local component references
+// inlined. Recursive references are replaced by an unconstrained schema.
+func (d *Document) resolveSchema(schema map[string]any, visiting map[string]bool) map[string]any {
+	if schema == nil {
+		return nil
+	}
+	if ref, ok := schema["$ref"].(string); ok {
+		name, local := strings.CutPrefix(ref, "#/components/schemas/")
+		target, found := d.Components.Schemas[name]
+		if !local || !found || visiting[name] {
+			return map[string]any{}
+		}
+		visiting[name] = true
+		defer delete(visiting, name)
+		return d.resolveSchema(target, visiting)
+	}
+	out := make(map[string]any, len(schema))
+	for key, value := range schema {
+		out[key] = d.resolveSchemaValue(value, visiting)
+	}
+	return out
+}
+
+func (d *Document) resolveSchemaValue(value any, visiting map[string]bool) any {
+	switch typed := value.(type) {
+	case map[string]any:
+		return d.resolveSchema(typed, visiting)
+	case []any:
+		out := make([]any, len(typed))
+		for i := range typed {
+			out[i] = d.resolveSchemaValue(typed[i], visiting)
+		}
+		return out
+	default:
+		return typed
+	}
+}
+
+func jsonMediaType(content map[string]MediaType) (string, MediaType, bool) {
+	if media, ok := content["application/json"]; ok {
+		return "application/json", media, true
+	}
+	keys := make([]string, 0, len(content))
+	for key := range content {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if strings.HasSuffix(key, "+json") {
+			return key, content[key], true
+		}
+	}
+	return "", MediaType{}, false
+}
@@ -0,0 +1,303 @@
+// Package openapi generates tools from an OpenAPI 3 document. Every operation
+// becomes one agent.ToolDefinition whose InputSchema holds the operation's
+// path, query and header parameters plus a "body" property for the JSON
+// request body, and tool calls are executed as HTTP requests. Operations whose
+// request body is not JSON are skipped and reported by Executor.Skipped.
+package openapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Gurpartap/agentframe/agent"
+)
+
+// DefaultMaxResponseBytes is used when Config.MaxResponseBytes is zero.
+const DefaultMaxResponseBytes = 64 << 10
+
+var (
+	ErrMissingDocument      = errors.New("openapi document is required")
+	ErrInvalidDocument      = errors.New("openapi document is invalid")
+	ErrBaseURLMissing       = errors.New("openapi base url is missing")
+	ErrToolUnregistered     = errors.New("tool is not registered")
+	ErrInvalidArguments     = errors.New("invalid openapi tool arguments")
+	ErrAuthenticationFailed = errors.New("openapi request authentication failed")
+)
+
+// Authenticator adds credentials to an outgoing request.
+type Authenticator interface {
+	Authenticate(request *http.Request) error
+}
+
+// AuthenticatorFunc adapts a function to Authenticator.
+type AuthenticatorFunc func(request *http.Request) error
+
+func (f AuthenticatorFunc) Authenticate(request *http.Request) error {
+	return f(request)
+}
+
+// BearerToken sends token in the Authorization header.
+func BearerToken(token string) Authenticator {
+	return AuthenticatorFunc(func(request *http.Request) error {
+		request.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	})
+}
+
+// APIKeyHeader sends key in the named header.
+func APIKeyHeader(header, key string) Authenticator {
+	return AuthenticatorFunc(func(request *http.Request) error {
+		request.Header.Set(header, key)
+		return nil
+	})
+}
+
+// BasicAuth sends HTTP basic credentials.
+func BasicAuth(username, password string) Authenticator {
+	return AuthenticatorFunc(func(request *http.Request) error {
+		request.SetBasicAuth(username, password)
+		return nil
+	})
+}
+
+// Config binds a document to the API it describes.
+type Config struct {
+	Document *Document
+	// BaseURL overrides the document's first server URL. It is required when
+	// the document has no absolute server URL.
+	BaseURL    string
+	HTTPClient *http.Client
+	// Auth is applied to every request. It may be nil.
+	Auth Authenticator
+	// MaxResponseBytes caps the response body kept in a tool result. Longer
+	// bodies are truncated. Defaults to DefaultMaxResponseBytes.
+	MaxResponseBytes int
+	// ToolPrefix is prepended to every generated tool name.
+	ToolPrefix string
+}
+
+// Executor executes generated tools as HTTP requests.
+type Executor struct {
+	baseURL    *url.URL
+	client     *http.Client
+	auth       Authenticator
+	maxBytes   int
+	operations map[string]operation
+	order      []string
+	skipped    []string
+}
+
+func New(cfg Config) (*Executor, error) {
+	if cfg.Document == nil {
+		return nil, fmt.Errorf("new openapi executor: %w", ErrMissingDocument)
+	}
+	baseURL, err := resolveBaseURL(cfg.BaseURL, cfg.Document.Servers)
+	if err != nil {
+		return nil, fmt.Errorf("new openapi executor: %w", err)
+	}
+	operations, skipped, err := cfg.Document.operations(cfg.ToolPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("new openapi executor: %w", err)
+	}
+	maxBytes := cfg.MaxResponseBytes
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxResponseBytes
+	}
+	client := cfg.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	executor := &Executor{
+		baseURL:    baseURL,
+		client:     client,
+		auth:       cfg.Auth,
+		maxBytes:   maxBytes,
+		operations: make(map[string]operation, len(operations)),
+		order:      make([]string, 0, len(operations)),
+		skipped:    skipped,
+	}
+	for _, op := range operations {
+		executor.operations[op.definition.Name] = op
+		executor.order = append(executor.order, op.definition.Name)
+	}
+	return executor, nil
+}
+
+func resolveBaseURL(override string, servers []Server) (*url.URL, error) {
+	raw := strings.TrimSpace(override)
+	if raw == "" && len(servers) > 0 {
+		raw = strings.TrimSpace(servers[0].URL)
+	}
+	if raw == "" {
+		return nil, ErrBaseURLMissing
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("%w: base url must be absolute: %q", ErrBaseURLMissing, raw)
+	}
+	return parsed, nil
+}
+
+// Definitions returns one tool definition per operation in document order.
+func (e *Executor) Definitions() []agent.ToolDefinition {
+	out := make([]agent.ToolDefinition, 0, len(e.order))
+	for _, name := range e.order {
+		out = append(out, agent.CloneToolDefinition(e.operations[name].definition))
+	}
+	return out
+}
+
+// Skipped returns the operations, as "METHOD /path", that generate no tool
+// because their request body has no JSON media type, such as multipart
+// uploads.
+func (e *Executor) Skipped() []string {
+	return append([]string(nil), e.skipped...)
+}
+
+// Execute sends the HTTP request for call. Responses with a 4xx or 5xx status
+// become error results so the model can react to them; transport failures are
+// returned as errors.
+func (e *Executor) Execute(ctx context.Context, call agent.ToolCall) (agent.ToolResult, error) {
+	if ctx == nil {
+		return agent.ToolResult{}, agent.ErrContextNil
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return agent.ToolResult{}, ctxErr
+	}
+	op, ok := e.operations[call.Name]
+	if !ok {
+		return agent.ToolResult{}, fmt.Errorf("%w: %q", ErrToolUnregistered, call.Name)
+	}
+
+	request, err := e.buildRequest(ctx, op, call.Arguments)
+	if err != nil {
+		return agent.ToolResult{}, err
+	}
+	if e.auth != nil {
+		if err := e.auth.Authenticate(request); err != nil {
+			return agent.ToolResult{}, fmt.Errorf("%w: tool=%q: %w", ErrAuthenticationFailed, call.Name, err)
+		}
+	}
+	response, err := e.client.Do(request)
+	if err != nil {
+		return agent.ToolResult{}, fmt.Errorf("call %s %s: %w", op.method, op.path, err)
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, int64(e.maxBytes)+1))
+	if err != nil {
+		return agent.ToolResult{}, fmt.Errorf("read %s %s response: %w", op.method, op.path, err)
+	}
+	truncated := len(body) > e.maxBytes
+	if truncated {
+		body = body[:e.maxBytes]
+	}
+
+	result := agent.ToolResult{CallID: call.ID, Name: call.Name, Content: string(body)}
+	mediaType, _, _ := mime.ParseMediaType(response.Header.Get("Content-Type"))
+	if !truncated && isJSONMediaType(mediaType) && json.Valid(body) {
+		var compacted bytes.Buffer
+		if err := json.Compact(&compacted, body); err == nil && compacted.Len() > 0 {
+			result.Structured = compacted.Bytes()
+		}
+	}
+	if truncated {
+		result.Content += fmt.Sprintf("\n[truncated: response exceeded %d bytes]", e.maxBytes)
+	}
+	if response.StatusCode >= http.StatusBadRequest {
+		result.IsError = true
+		result.FailureReason = agent.ToolFailureReasonExecutorError
+		result.Content = fmt.Sprintf("http status %d %s\n%s", response.StatusCode, http.StatusText(response.StatusCode), result.Content)
+	}
+	return result, nil
+}
+
+func (e *Executor) buildRequest(ctx context.Context, op operation, arguments map[string]any) (*http.Request, error) {
+	path := op.path
+	query := url.Values{}
+	header := http.Header{}
+	for _, parameter := range op.parameters {
+		value, present := arguments[parameter.Name]
+		if !present || value == nil {
+			if parameter.Required || parameter.In == "path" {
+				return nil, fmt.Errorf("%w: field=%s reason=required", ErrInvalidArguments, parameter.Name)
+			}
+			continue
+		}
+		switch parameter.In {
+		case "path":
+			segment := scalarString(value)
+			if segment == "" || segment == "." || segment == ".." {
+				return nil, fmt.Errorf("%w: field=%s reason=invalid_path_segment value=%q", ErrInvalidArguments, parameter.Name, segment)
+			}
+			path = strings.ReplaceAll(path, "{"+parameter.Name+"}", url.PathEscape(segment))
+		case "query":
+			if values, isList := value.([]any); isList {
+				for _, item := range values {
+					query.Add(parameter.Name, scalarString(item))
+				}
+			} else {
+				query.Set(parameter.Name, scalarString(value))
+			}
+		case "header":
+			header.Set(parameter.Name, scalarString(value))
+		}
+	}
+
+	target := e.baseURL.JoinPath(path)
+	target.RawQuery = query.Encode()
+
+	var body io.Reader
+	if op.hasBody {
+		value, present := arguments[BodyArgument]
+		if present && value != nil {
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: field=%s reason=not_json: %w", ErrInvalidArguments, BodyArgument, err)
+			}
+			body = bytes.NewReader(encoded)
+			header.Set("Content-Type", op.bodyMIME)
+		} else if op.bodyNeeded {
+			return nil, fmt.Errorf("%w: field=%s reason=required", ErrInvalidArguments, BodyArgument)
+		}
+	}
+
+	request, err := http.NewRequestWithContext(ctx, op.method, target.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("build %s %s request: %w", op.method, op.path, err)
+	}
+	for key, values := range header {
+		request.Header[key] = values
+	}
+	request.Header.Set("Accept", "application/json, */*;q=0.5")
+	return request, nil
+}
+
+// scalarString formats a decoded JSON scalar without exponent notation for
+// integral numbers.
+func scalarString(value any) string {
+	switch typed := value.(type) {
+	case string:
+		return typed
+	case float64:
+		if typed == float64(int64(typed)) {
+			return fmt.Sprintf("%d", int64(typed))
+		}
+		return fmt.Sprint(typed)
+	default:
+		return fmt.Sprint(typed)
+	}
+}
+
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,252 @@
+package openapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Gurpartap/agentframe/agent"
+	"github.com/Gurpartap/agentframe/tooling/openapi"
+)
+
+const petstore = `{
+  "openapi": "3.0.3",
+  "servers": [{"url": "https://petstore.invalid/v1"}],
+  "paths": {
+    "/pets/{petId}": {
+      "parameters": [{"$ref": "#/components/parameters/PetID"}],
+      "get": {
+        "operationId": "getPet",
+        "summary": "Get a pet",
+        "parameters": [{"name": "X-Trace", "in": "header", "schema": {"type": "string"}}]
+      },
+      "delete": {"summary": "Delete a pet"}
+    },
+    "/pets/{petId}/photo": {
+      "parameters": [{"$ref": "#/components/parameters/PetID"}],
+      "put": {
+        "operationId": "uploadPhoto",
+        "requestBody": {"content": {"multipart/form-data": {"schema": {"type": "object"}}}}
+      }
+    },
+    "/pets": {
+      "get": {
+        "operationId": "listPets",
+        "parameters": [
+          {"name": "tag", "in": "query", "schema": {"type": "array", "items": {"type": "string"}}},
+          {"name": "limit", "in": "query", "required": true, "schema": {"type": "integer"}}
+        ]
+      },
+      "post": {
+        "operationId": "createPet",
+        "requestBody": {
+          "required": true,
+          "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}
+        }
+      }
+    }
+  },
+  "components": {
+    "parameters": {
+      "PetID": {"name": "petId", "in": "path", "required": true, "description": "Pet identifier", "schema": {"type": "string"}}
+    },
+    "schemas": {
+      "Pet": {
+        "type": "object",
+        "required": ["name"],
+        "properties": {
+          "name": {"type": "string"},
+          "parent": {"$ref": "#/components/schemas/Pet"}
+        }
+      }
+    }
+  }
+}`
+
+func newExecutor(t *testing.T, handler http.HandlerFunc, mutate func(*openapi.Config)) *openapi.Executor {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	document, err := openapi.Parse([]byte(petstore))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	cfg := openapi.Config{
+		Document:   document,
+		BaseURL:    server.URL + "/v1",
+		HTTPClient: server.Client(),
+	}
+	if mutate != nil {
+		mutate(&cfg)
+	}
+	executor, err := openapi.New(cfg)
+	if err != nil {
+		t.Fatalf("new executor: %v", err)
+	}
+	return executor
+}
+
+func TestExecutor_GeneratesOneDefinitionPerOperation(t *testing.T) {
+	t.Parallel()
+
+	executor := newExecutor(t, nil, nil)
+	definitions := executor.Definitions()
+	names := make([]string, 0, len(definitions))
+	byName := map[string]agent.ToolDefinition{}
+	for _, definition := range definitions {
+		names = append(names, definition.Name)
+		byName[definition.Name] = definition
+	}
+	if strings.Join(names, ",") != "listPets,createPet,getPet,delete_pets_petId" {
+		t.Fatalf("tool names mismatch: got=%v", names)
+	}
+	if skipped := executor.Skipped(); len(skipped) != 1 || skipped[0] != "PUT /pets/{petId}/photo" {
+		t.Fatalf("skipped operations mismatch: got=%v", skipped)
+	}
+
+	getPet := byName["getPet"].InputSchema
+	properties := getPet["properties"].(map[string]any)
+	if properties["petId"].(map[string]any)["description"] != "Pet identifier" || properties["X-Trace"] == nil {
+		t.Fatalf("getPet properties mismatch: got=%+v", properties)
+	}
+	if required := getPet["required"].([]any); len(required) != 1 || required[0] != "petId" {
+		t.Fatalf("getPet required mismatch: got=%v", required)
+	}
+
+	createPet := byName["createPet"].InputSchema
+	body := createPet["properties"].(map[string]any)[openapi.BodyArgument].(map[string]any)
+	parent := body["properties"].(map[string]any)["parent"].(map[string]any)
+	if body["type"] != "object" || len(parent) != 0 {
+		t.Fatalf("body schema must inline refs and cut recursion: got=%+v", body)
+	}
+	if required := createPet["required"].([]any); len(required) != 1 || required[0] != openapi.BodyArgument {
+		t.Fatalf("createPet required mismatch: got=%v", required)
+	}
+}
+
+func TestExecutor_SendsParametersBodyAndAuth(t *testing.T) {
+	t.Parallel()
+
+	var got struct {
+		method, path, rawQuery, trace, auth, contentType, body string
+	}
+	executor := newExecutor(t, func(w http.ResponseWriter, r *http.Request) {
+		payload, _ := io.ReadAll(r.Body)
+		got.method, got.path, got.rawQuery = r.Method, r.URL.EscapedPath(), r.URL.RawQuery
+		got.trace, got.auth = r.Header.Get("X-Trace"), r.Header.Get("Authorization")
+		got.contentType, got.body = r.Header.Get("Content-Type"), string(payload)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{ "id": "p 1" }`)
+	}, func(cfg *openapi.Config) {
+		cfg.Auth = openapi.BearerToken("secret")
+	})
+
+	result, err := executor.Execute(context.Background(), agent.ToolCall{
+		ID:        "call-1",
+		Name:      "getPet",
+		Arguments: map[string]any{"petId": "p 1/x", "X-Trace": "t-1"},
+	})
+	if err != nil {
+		t.Fatalf("execute getPet: %v", err)
+	}
+	if got.method != http.MethodGet || got.path != "/v1/pets/p%201%2Fx" || got.trace != "t-1" || got.auth != "Bearer secret" {
+		t.Fatalf("getPet request mismatch: %+v", got)
+	}
+	if result.IsError || string(result.Structured) != `{"id":"p 1"}` || result.CallID != "call-1" {
+		t.Fatalf("getPet result mismatch: %+v", result)
+	}
+
+	_, err = executor.Execute(context.Background(), agent.ToolCall{
+		ID:        "call-2",
+		Name:      "listPets",
+		Arguments: map[string]any{"tag": []any{"cat", "dog"}, "limit": float64(10)},
+	})
+	if err != nil {
+		t.Fatalf("execute listPets: %v", err)
+	}
+	if got.rawQuery != "limit=10&tag=cat&tag=dog" {
+		t.Fatalf("query mismatch: got=%q", got.rawQuery)
+	}
+
+	_, err = executor.Execute(context.Background(), agent.ToolCall{
+		ID:        "call-3",
+		Name:      "createPet",
+		Arguments: map[string]any{openapi.BodyArgument: map[string]any{"name": "Rex"}},
+	})
+	if err != nil {
+		t.Fatalf("execute createPet: %v", err)
+	}
+	var sent map[string]any
+	if err := json.Unmarshal([]byte(got.body), &sent); err != nil || sent["name"] != "Rex" || got.contentType != "application/json" {
+		t.Fatalf("body mismatch: body=%q content_type=%q err=%v", got.body, got.contentType, err)
+	}
+}
+
+func TestExecutor_MapsErrorStatusAndCapsResponse(t *testing.T) {
+	t.Parallel()
+
+	executor := newExecutor(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			http.Error(w, "pet is adopted", http.StatusConflict)
+			return
+		}
+		_, _ = io.WriteString(w, strings.Repeat("x", 100))
+	}, func(cfg *openapi.Config) {
+		cfg.MaxResponseBytes = 32
+	})
+
+	conflict, err := executor.Execute(context.Background(), agent.ToolCall{
+		ID:        "call-1",
+		Name:      "delete_pets_petId",
+		Arguments: map[string]any{"petId": "p1"},
+	})
+	if err != nil {
+		t.Fatalf("execute delete: %v", err)
+	}
+	if !conflict.IsError || conflict.FailureReason != agent.ToolFailureReasonExecutorError ||
+		!strings.HasPrefix(conflict.Content, "http status 409 Conflict\npet is adopted") {
+		t.Fatalf("error result mismatch: %+v", conflict)
+	}
+
+	large, err := executor.Execute(context.Background(), agent.ToolCall{
+		ID:        "call-2",
+		Name:      "getPet",
+		Arguments: map[string]any{"petId": "p1"},
+	})
+	if err != nil {
+		t.Fatalf("execute getPet: %v", err)
+	}
+	if !strings.HasPrefix(large.Content, strings.Repeat("x", 32)+"\n[truncated: response exceeded 32 bytes]") {
+		t.Fatalf("truncated content mismatch: %q", large.Content)
+	}
+
+	_, err = executor.Execute(context.Background(), agent.ToolCall{
+		ID:        "call-3",
+		Name:      "getPet",
+		Arguments: map[string]any{"petId": ".."},
+	})
+	if !errors.Is(err, openapi.ErrInvalidArguments) {
+		t.Fatalf("expected ErrInvalidArguments for traversal segment, got %v", err)
+	}
+}
+
+func TestParse_RejectsUnsupportedDocuments(t *testing.T) {
+	t.Parallel()
+
+	if _, err := openapi.Parse([]byte(`{"swagger":"2.0","paths":{"/":{}}}`)); !errors.Is(err, openapi.ErrInvalidDocument) {
+		t.Fatalf("expected ErrInvalidDocument for swagger 2, got %v", err)
+	}
+	document, err := openapi.Parse([]byte(`{"openapi":"3.1.0","servers":[{"url":"/api"}],"paths":{"/a":{"get":{}}}}`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if _, err := openapi.New(openapi.Config{Document: document}); !errors.Is(err, openapi.ErrBaseURLMissing) {
+		t.Fatalf("expected ErrBaseURLMissing for relative server url, got %v", err)
+	}
+}
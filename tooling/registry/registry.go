@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"runtime/debug"
 	"sync"
+	"time"
 
 	"github.com/Gurpartap/agentframe/agent"
 )
@@ -13,45 +15,70 @@ var (
 	ErrToolUnregistered = errors.New("tool is not registered")
 	ErrNilHandler       = errors.New("tool handler is nil")
 	ErrToolNameEmpty    = errors.New("tool name is empty")
+	ErrTimeoutInvalid   = errors.New("tool timeout is invalid")
 )
 
 // Handler executes one tool call using parsed arguments.
 type Handler func(ctx context.Context, arguments map[string]any) (string, error)
 
+// ToolOptions configures execution limits for one registered tool.
+type ToolOptions struct {
+	// Timeout bounds one handler invocation. Zero means the handler is only
+	// bounded by the caller context.
+	Timeout time.Duration
+}
+
+type registration struct {
+	handler Handler
+	options ToolOptions
+}
+
 // Registry stores handlers by tool name and executes tool calls.
+//
+// Handlers run on their own goroutine so that a handler ignoring its context
+// cannot hold the caller past its timeout or cancellation, and panics are
+// recovered into agent.ToolPanicError.
 type Registry struct {
 	mu       sync.RWMutex
-	handlers map[string]Handler
+	handlers map[string]registration
 }
 
 func New(initial map[string]Handler) (*Registry, error) {
-	handlers := make(map[string]Handler, len(initial))
+	handlers := make(map[string]registration, len(initial))
 	for name, handler := range initial {
-		if err := validateRegistration(name, handler); err != nil {
+		if err := validateRegistration(name, handler, ToolOptions{}); err != nil {
 			return nil, err
 		}
-		handlers[name] = handler
+		handlers[name] = registration{handler: handler}
 	}
 	return &Registry{handlers: handlers}, nil
 }
 
 func (r *Registry) Register(name string, handler Handler) error {
-	if err := validateRegistration(name, handler); err != nil {
+	return r.RegisterWithOptions(name, handler, ToolOptions{})
+}
+
+// RegisterWithOptions adds or replaces a handler with per-tool execution limits.
+func (r *Registry) RegisterWithOptions(name string, handler Handler, options ToolOptions) error {
+	if err := validateRegistration(name, handler, options); err != nil {
 		return err
 	}
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.handlers[name] = handler
+	r.handlers[name] = registration{handler: handler, options: options}
 	return nil
 }
 
-func validateRegistration(name string, handler Handler) error {
+func validateRegistration(name string, handler Handler, options ToolOptions) error {
 	if name == "" {
 		return ErrToolNameEmpty
 	}
 	if handler == nil {
 		return ErrNilHandler
 	}
+	if options.Timeout < 0 {
+		return fmt.Errorf("%w: tool=%q timeout=%s", ErrTimeoutInvalid, name, options.Timeout)
+	}
 	return nil
 }
 
@@ -67,16 +94,16 @@ func (r *Registry) Execute(ctx context.Context, call agent.ToolCall) (agent.Tool
 	}
 
 	r.mu.RLock()
-	handler, ok := r.handlers[call.Name]
+	entry, ok := r.handlers[call.Name]
 	r.mu.RUnlock()
 	if !ok {
 		return agent.ToolResult{}, fmt.Errorf("%w: %q", ErrToolUnregistered, call.Name)
 	}
-	if handler == nil {
+	if entry.handler == nil {
 		return agent.ToolResult{}, fmt.Errorf("%w: %q", ErrNilHandler, call.Name)
 	}
 
-	content, err := handler(ctx, call.Arguments)
+	content, err := invoke(ctx, call, entry)
 	if err != nil {
 		return agent.ToolResult{}, err
 	}
@@ -87,3 +114,50 @@ func (r *Registry) Execute(ctx context.Context, call agent.ToolCall) (agent.Tool
 		Content: content,
 	}, nil
 }
+
+type invocationOutcome struct {
+	content string
+	err     error
+}
+
+func invoke(ctx context.Context, call agent.ToolCall, entry registration) (string, error) {
+	handlerCtx, cancel := ctx, context.CancelFunc(func() {})
+	if entry.options.Timeout > 0 {
+		handlerCtx, cancel = context.WithTimeout(ctx, entry.options.Timeout)
+	}
+	defer cancel()
+
+	done := make(chan invocationOutcome, 1)
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				done <- invocationOutcome{err: &agent.ToolPanicError{
+					Tool:  call.Name,
+					Value: recovered,
+					Stack: string(debug.Stack()),
+				}}
+			}
+		}()
+		content, err := entry.handler(handlerCtx, call.Arguments)
+		done <- invocationOutcome{content: content, err: err}
+	}()
+
+	select {
+	case outcome := <-done:
+		if outcome.err != nil && timedOut(ctx, handlerCtx) {
+			return "", &agent.ToolTimeoutError{Tool: call.Name, Timeout: entry.options.Timeout}
+		}
+		return outcome.content, outcome.err
+	case <-handlerCtx.Done():
+		if timedOut(ctx, handlerCtx) {
+			return "", &agent.ToolTimeoutError{Tool: call.Name, Timeout: entry.options.Timeout}
+		}
+		return "", ctx.Err()
+	}
+}
+
+// timedOut reports whether the per-tool deadline, rather than the caller
+// context, ended the invocation.
+func timedOut(parent context.Context, handlerCtx context.Context) bool {
+	return parent.Err() == nil && errors.Is(handlerCtx.Err(), context.DeadlineExceeded)
+}
@@ -3,6 +3,7 @@ package registry_test
 import (
 	"context"
 	"errors"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -67,7 +68,7 @@ func TestRegistryExecute_UnknownToolReturnsError(t *testing.T) {
 	if !strings.Contains(err.Error(), `"missing"`) {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if result != (agent.ToolResult{}) {
+	if !reflect.DeepEqual(result, agent.ToolResult{}) {
 		t.Fatalf("unexpected result: %+v", result)
 	}
 }
@@ -83,7 +84,7 @@ func TestRegistryExecute_EmptyToolNameReturnsError(t *testing.T) {
 	if !errors.Is(err, toolingregistry.ErrToolNameEmpty) {
 		t.Fatalf("expected ErrToolNameEmpty, got %v", err)
 	}
-	if result != (agent.ToolResult{}) {
+	if !reflect.DeepEqual(result, agent.ToolResult{}) {
 		t.Fatalf("unexpected result: %+v", result)
 	}
 }
@@ -245,7 +246,7 @@ func TestRegistryExecute_ContextCanceledFailsFastWithoutHandlerInvocation(t *tes
 	if !errors.Is(err, context.Canceled) {
 		t.Fatalf("expected context.Canceled, got %v", err)
 	}
-	if result != (agent.ToolResult{}) {
+	if !reflect.DeepEqual(result, agent.ToolResult{}) {
 		t.Fatalf("unexpected result: %+v", result)
 	}
 	if called {
@@ -271,7 +272,7 @@ func TestRegistryExecute_ContextDeadlineExceededFailsFastWithoutHandlerInvocatio
 	if !errors.Is(err, context.DeadlineExceeded) {
 		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
 	}
-	if result != (agent.ToolResult{}) {
+	if !reflect.DeepEqual(result, agent.ToolResult{}) {
 		t.Fatalf("unexpected result: %+v", result)
 	}
 	if called {
@@ -294,7 +295,7 @@ func TestRegistryExecute_NilContextFailsFastWithoutHandlerInvocation(t *testing.
 	if !errors.Is(err, agent.ErrContextNil) {
 		t.Fatalf("expected ErrContextNil, got %v", err)
 	}
-	if result != (agent.ToolResult{}) {
+	if !reflect.DeepEqual(result, agent.ToolResult{}) {
 		t.Fatalf("unexpected result: %+v", result)
 	}
 	if called {
@@ -311,3 +312,98 @@ func mustNewRegistry(t *testing.T, initial map[string]toolingregistry.Handler) *
 	}
 	return registry
 }
+
+func TestRegistryExecute_TimesOutHungHandler(t *testing.T) {
+	t.Parallel()
+
+	registry, err := toolingregistry.New(nil)
+	if err != nil {
+		t.Fatalf("new registry: %v", err)
+	}
+	release := make(chan struct{})
+	defer close(release)
+	err = registry.RegisterWithOptions("hang", func(_ context.Context, _ map[string]any) (string, error) {
+		<-release
+		return "late", nil
+	}, toolingregistry.ToolOptions{Timeout: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	_, err = registry.Execute(context.Background(), agent.ToolCall{ID: "call-1", Name: "hang"})
+	var timeoutErr *agent.ToolTimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("expected ToolTimeoutError, got %v", err)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("tool timeout must not be classified as a run deadline: %v", err)
+	}
+	if agent.ToolErrorFailureReason(err) != agent.ToolFailureReasonTimeout {
+		t.Fatalf("failure reason mismatch: got=%s want=%s", agent.ToolErrorFailureReason(err), agent.ToolFailureReasonTimeout)
+	}
+}
+
+func TestRegistryExecute_ReturnsParentCancellation(t *testing.T) {
+	t.Parallel()
+
+	registry, err := toolingregistry.New(nil)
+	if err != nil {
+		t.Fatalf("new registry: %v", err)
+	}
+	release := make(chan struct{})
+	defer close(release)
+	err = registry.RegisterWithOptions("hang", func(_ context.Context, _ map[string]any) (string, error) {
+		<-release
+		return "late", nil
+	}, toolingregistry.ToolOptions{Timeout: time.Minute})
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	_, err = registry.Execute(ctx, agent.ToolCall{ID: "call-1", Name: "hang"})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestRegistryExecute_RecoversHandlerPanic(t *testing.T) {
+	t.Parallel()
+
+	registry, err := toolingregistry.New(map[string]toolingregistry.Handler{
+		"explode": func(_ context.Context, _ map[string]any) (string, error) {
+			panic("boom")
+		},
+	})
+	if err != nil {
+		t.Fatalf("new registry: %v", err)
+	}
+
+	_, err = registry.Execute(context.Background(), agent.ToolCall{ID: "call-1", Name: "explode"})
+	var panicErr *agent.ToolPanicError
+	if !errors.As(err, &panicErr) {
+		t.Fatalf("expected ToolPanicError, got %v", err)
+	}
+	if panicErr.Value != "boom" || !strings.Contains(panicErr.Stack, "goroutine") {
+		t.Fatalf("panic details mismatch: value=%v stack=%q", panicErr.Value, panicErr.Stack)
+	}
+	if agent.ToolErrorFailureReason(err) != agent.ToolFailureReasonExecutorError {
+		t.Fatalf("failure reason mismatch: got=%s want=%s", agent.ToolErrorFailureReason(err), agent.ToolFailureReasonExecutorError)
+	}
+}
+
+func TestRegistryRegisterWithOptions_RejectsNegativeTimeout(t *testing.T) {
+	t.Parallel()
+
+	registry, err := toolingregistry.New(nil)
+	if err != nil {
+		t.Fatalf("new registry: %v", err)
+	}
+	err = registry.RegisterWithOptions("lookup", func(_ context.Context, _ map[string]any) (string, error) {
+		return "", nil
+	}, toolingregistry.ToolOptions{Timeout: -time.Second})
+	if !errors.Is(err, toolingregistry.ErrTimeoutInvalid) {
+		t.Fatalf("expected ErrTimeoutInvalid, got %v", err)
+	}
+}
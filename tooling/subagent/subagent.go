@@ -0,0 +1,354 @@
+// Package subagent exposes an agent.Runner as a tool so that a parent run can
+// delegate a task to a child run executed by another agent.
+package subagent
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Gurpartap/agentframe/agent"
+)
+
+const (
+	// DefaultName is the tool name used when Config.Name is empty.
+	DefaultName = "delegate"
+
+	defaultDescription = "Delegate a self-contained task to a sub-agent and return its final answer. " +
+		"Pass run_id instead of task to resume a sub-agent run that is waiting for approval."
+)
+
+var (
+	ErrMissingRunner          = errors.New("subagent runner is required")
+	ErrMissingRunStore        = errors.New("subagent run store is required")
+	ErrToolUnregistered       = errors.New("tool is not registered")
+	ErrParentRunIDMissing     = errors.New("parent run id is missing from context")
+	ErrInvalidArguments       = errors.New("invalid subagent arguments")
+	ErrChildRunFailed         = errors.New("subagent run did not complete")
+	ErrUnsupportedRequirement = errors.New("subagent requirement cannot be forwarded")
+	ErrReplayMismatch         = errors.New("subagent approval replay mismatch")
+)
+
+// Config defines the delegation tool and the child agent that executes it.
+type Config struct {
+	// Name is the tool name exposed to the parent model. Defaults to DefaultName.
+	Name        string
+	Description string
+
+	// Runner executes child runs. RunStore must be the store backing Runner; it
+	// is read to forward pending child requirements to the parent.
+	Runner   *agent.Runner
+	RunStore agent.RunStore
+
+	// SystemPrompt, MaxSteps and Tools configure every child run.
+	SystemPrompt string
+	MaxSteps     int
+	Tools        []agent.ToolDefinition
+
+	// Next handles tool calls that are not addressed to the delegation tool.
+	Next ToolExecutor
+}
+
+// ToolExecutor resolves and executes tool calls.
+type ToolExecutor interface {
+	Execute(ctx context.Context, call agent.ToolCall) (agent.ToolResult, error)
+}
+
+// Executor runs delegation tool calls as child runs and forwards every other
+// call to the configured Next executor.
+type Executor struct {
+	name         string
+	description  string
+	runner       *agent.Runner
+	store        agent.RunStore
+	systemPrompt string
+	maxSteps     int
+	tools        []agent.ToolDefinition
+	next         ToolExecutor
+}
+
+func New(cfg Config) (*Executor, error) {
+	if cfg.Runner == nil {
+		return nil, fmt.Errorf("new subagent executor: %w", ErrMissingRunner)
+	}
+	if cfg.RunStore == nil {
+		return nil, fmt.Errorf("new subagent executor: %w", ErrMissingRunStore)
+	}
+	name := strings.TrimSpace(cfg.Name)
+	if name == "" {
+		name = DefaultName
+	}
+	description := strings.TrimSpace(cfg.Description)
+	if description == "" {
+		description = defaultDescription
+	}
+	return &Executor{
+		name:         name,
+		description:  description,
+		runner:       cfg.Runner,
+		store:        cfg.RunStore,
+		systemPrompt: cfg.SystemPrompt,
+		maxSteps:     cfg.MaxSteps,
+		tools:        agent.CloneToolDefinitions(cfg.Tools),
+		next:         cfg.Next,
+	}, nil
+}
+
+// Definition returns the tool definition to advertise to the parent model.
+func (e *Executor) Definition() agent.ToolDefinition {
+	return agent.ToolDefinition{
+		Name:        e.name,
+		Description: e.description,
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"task": map[string]any{
+					"type":        "string",
+					"description": "Self-contained instructions for the sub-agent.",
+				},
+				"run_id": map[string]any{
+					"type":        "string",
+					"description": "Identifier of a suspended sub-agent run to resume.",
+				},
+			},
+			"additionalProperties": false,
+		},
+	}
+}
+
+// ChildRunID returns the deterministic child run identifier for a delegation
+// tool call made by parentRunID.
+func ChildRunID(parentRunID agent.RunID, callID string) agent.RunID {
+	return agent.RunID(fmt.Sprintf("%s.%s", parentRunID, callID))
+}
+
+func (e *Executor) Execute(ctx context.Context, call agent.ToolCall) (agent.ToolResult, error) {
+	if ctx == nil {
+		return agent.ToolResult{}, agent.ErrContextNil
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return agent.ToolResult{}, ctxErr
+	}
+	if call.Name != e.name {
+		if e.next == nil {
+			return agent.ToolResult{}, fmt.Errorf("%w: %q", ErrToolUnregistered, call.Name)
+		}
+		return e.next.Execute(ctx, call)
+	}
+
+	parentRunID, ok := agent.RunIDFromContext(ctx)
+	if !ok {
+		return agent.ToolResult{}, ErrParentRunIDMissing
+	}
+	task, resumeRunID, err := parseArguments(call.Arguments)
+	if err != nil {
+		return agent.ToolResult{}, err
+	}
+	childRunID := ChildRunID(parentRunID, call.ID)
+	if resumeRunID != "" {
+		if !strings.HasPrefix(string(resumeRunID), string(parentRunID)+".") {
+			return agent.ToolResult{}, fmt.Errorf(
+				"%w: field=run_id reason=not_a_child_of_parent value=%q parent_run_id=%q",
+				ErrInvalidArguments,
+				resumeRunID,
+				parentRunID,
+			)
+		}
+		childRunID = resumeRunID
+	}
+
+	if override, replaying := agent.ApprovedToolCallReplayOverrideFromContext(ctx); replaying && override.ToolCallID == call.ID {
+		return e.resumeApproved(ctx, call, childRunID, override)
+	}
+	childCtx := agent.WithoutApprovedToolCallReplayOverride(ctx)
+	if resumeRunID != "" {
+		child, err := e.store.Load(childCtx, childRunID)
+		if err != nil {
+			return agent.ToolResult{}, fmt.Errorf("load subagent run %q: %w", childRunID, err)
+		}
+		return e.result(call, child, nil)
+	}
+
+	result, runErr := e.runner.Run(childCtx, agent.RunInput{
+		RunID:        childRunID,
+		SystemPrompt: e.systemPrompt,
+		UserPrompt:   task,
+		MaxSteps:     e.maxSteps,
+		Tools:        agent.CloneToolDefinitions(e.tools),
+	})
+	return e.result(call, result.State, runErr)
+}
+
+// resumeApproved continues a suspended child run after the parent approved the
+// requirement that was forwarded for call.
+func (e *Executor) resumeApproved(
+	ctx context.Context,
+	call agent.ToolCall,
+	childRunID agent.RunID,
+	override agent.ApprovedToolCallReplayOverride,
+) (agent.ToolResult, error) {
+	childCtx := agent.WithoutApprovedToolCallReplayOverride(ctx)
+	child, err := e.store.Load(childCtx, childRunID)
+	if err != nil {
+		return agent.ToolResult{}, fmt.Errorf("load subagent run %q: %w", childRunID, err)
+	}
+	if child.Status != agent.RunStatusSuspended || child.PendingRequirement == nil {
+		return agent.ToolResult{}, fmt.Errorf(
+			"%w: field=child_status reason=not_suspended child_run_id=%q status=%s",
+			ErrReplayMismatch,
+			childRunID,
+			child.Status,
+		)
+	}
+	if fingerprint := approvalFingerprint(call, child.ID, child.PendingRequirement); fingerprint != override.Fingerprint {
+		return agent.ToolResult{}, fmt.Errorf(
+			"%w: field=approved_tool_replay_override reason=mismatch got_fingerprint=%q want_fingerprint=%q",
+			ErrReplayMismatch,
+			override.Fingerprint,
+			fingerprint,
+		)
+	}
+
+	result, runErr := e.runner.Continue(childCtx, childRunID, e.maxSteps, agent.CloneToolDefinitions(e.tools), &agent.Resolution{
+		RequirementID: child.PendingRequirement.ID,
+		Kind:          agent.RequirementKindApproval,
+		Outcome:       agent.ResolutionOutcomeApproved,
+	})
+	if runErr == nil && result.State.Status == agent.RunStatusSuspended {
+		// The parent engine replays an approved call exactly once and cannot
+		// suspend again from inside that replay, so a further child requirement
+		// is reported back to the parent model for an explicit resume call.
+		return agent.ToolResult{
+			CallID: call.ID,
+			Name:   call.Name,
+			Content: fmt.Sprintf(
+				"subagent run %s is waiting for another approval (requirement_id=%q); call %s with run_id=%q to request it",
+				childRunID,
+				result.State.PendingRequirement.ID,
+				e.name,
+				childRunID,
+			),
+		}, nil
+	}
+	return e.result(call, result.State, runErr)
+}
+
+// result maps a child run outcome onto the parent tool call.
+func (e *Executor) result(call agent.ToolCall, child agent.RunState, runErr error) (agent.ToolResult, error) {
+	switch child.Status {
+	case agent.RunStatusCompleted:
+		return agent.ToolResult{
+			CallID:  call.ID,
+			Name:    call.Name,
+			Content: child.Output,
+		}, nil
+	case agent.RunStatusSuspended:
+		if runErr != nil {
+			break
+		}
+		requirement, err := e.forwardRequirement(call, child)
+		if err != nil {
+			return agent.ToolResult{}, err
+		}
+		return agent.ToolResult{}, &agent.SuspendRequestError{
+			Requirement: requirement,
+			Err:         fmt.Errorf("subagent run %s suspended", child.ID),
+		}
+	}
+	if runErr != nil {
+		return agent.ToolResult{}, fmt.Errorf("%w: child_run_id=%q status=%s: %w", ErrChildRunFailed, child.ID, child.Status, runErr)
+	}
+	return agent.ToolResult{}, fmt.Errorf(
+		"%w: child_run_id=%q status=%s error=%q",
+		ErrChildRunFailed,
+		child.ID,
+		child.Status,
+		child.Error,
+	)
+}
+
+// forwardRequirement translates a pending child approval into a parent
+// requirement bound to call through a fingerprint of the child requirement.
+func (e *Executor) forwardRequirement(call agent.ToolCall, child agent.RunState) (*agent.PendingRequirement, error) {
+	pending := child.PendingRequirement
+	if pending == nil {
+		return nil, fmt.Errorf("%w: child_run_id=%q reason=missing_requirement", ErrUnsupportedRequirement, child.ID)
+	}
+	if pending.Kind != agent.RequirementKindApproval {
+		return nil, fmt.Errorf(
+			"%w: child_run_id=%q requirement_id=%q kind=%s",
+			ErrUnsupportedRequirement,
+			child.ID,
+			pending.ID,
+			pending.Kind,
+		)
+	}
+	prompt := fmt.Sprintf("approve subagent run %s", child.ID)
+	if pending.Prompt != "" {
+		prompt += ": " + pending.Prompt
+	}
+	return &agent.PendingRequirement{
+		ID:          fmt.Sprintf("req-subagent-%s", call.ID),
+		Kind:        agent.RequirementKindApproval,
+		Origin:      agent.RequirementOriginTool,
+		ToolCallID:  call.ID,
+		Fingerprint: approvalFingerprint(call, child.ID, pending),
+		Prompt:      prompt,
+	}, nil
+}
+
+func approvalFingerprint(call agent.ToolCall, childRunID agent.RunID, pending *agent.PendingRequirement) string {
+	payload, _ := json.Marshal(struct {
+		ToolName               string `json:"tool_name"`
+		CallID                 string `json:"call_id"`
+		ChildRunID             string `json:"child_run_id"`
+		ChildRequirementID     string `json:"child_requirement_id"`
+		ChildRequirementKind   string `json:"child_requirement_kind"`
+		ChildRequirementOrigin string `json:"child_requirement_origin"`
+		ChildFingerprint       string `json:"child_fingerprint"`
+	}{
+		ToolName:               call.Name,
+		CallID:                 call.ID,
+		ChildRunID:             string(childRunID),
+		ChildRequirementID:     pending.ID,
+		ChildRequirementKind:   string(pending.Kind),
+		ChildRequirementOrigin: string(pending.Origin),
+		ChildFingerprint:       pending.Fingerprint,
+	})
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+func parseArguments(arguments map[string]any) (string, agent.RunID, error) {
+	task, err := optionalString(arguments, "task")
+	if err != nil {
+		return "", "", err
+	}
+	runID, err := optionalString(arguments, "run_id")
+	if err != nil {
+		return "", "", err
+	}
+	switch {
+	case task == "" && runID == "":
+		return "", "", fmt.Errorf("%w: reason=task_or_run_id_required", ErrInvalidArguments)
+	case task != "" && runID != "":
+		return "", "", fmt.Errorf("%w: reason=task_and_run_id_exclusive", ErrInvalidArguments)
+	}
+	return task, agent.RunID(runID), nil
+}
+
+func optionalString(arguments map[string]any, key string) (string, error) {
+	raw, ok := arguments[key]
+	if !ok || raw == nil {
+		return "", nil
+	}
+	value, ok := raw.(string)
+	if !ok {
+		return "", fmt.Errorf("%w: field=%s reason=not_a_string", ErrInvalidArguments, key)
+	}
+	return strings.TrimSpace(value), nil
+}
@@ -0,0 +1,275 @@
+package subagent_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/Gurpartap/agentframe/agent"
+	"github.com/Gurpartap/agentframe/agentreact"
+	eventinginmem "github.com/Gurpartap/agentframe/eventing/inmem"
+	runstoreinmem "github.com/Gurpartap/agentframe/runstore/inmem"
+	toolingregistry "github.com/Gurpartap/agentframe/tooling/registry"
+	"github.com/Gurpartap/agentframe/tooling/subagent"
+)
+
+type scriptedModel struct {
+	mu        sync.Mutex
+	index     int
+	responses []agent.Message
+}
+
+func (m *scriptedModel) Generate(_ context.Context, _ agentreact.ModelRequest) (agent.Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.index >= len(m.responses) {
+		return agent.Message{}, fmt.Errorf("script exhausted at step %d", m.index+1)
+	}
+	msg := agent.CloneMessage(m.responses[m.index])
+	m.index++
+	return msg, nil
+}
+
+type staticIDGenerator struct {
+	runID agent.RunID
+}
+
+func (g staticIDGenerator) NewRunID(context.Context) (agent.RunID, error) {
+	return g.runID, nil
+}
+
+func newRunner(t *testing.T, runID agent.RunID, store agent.RunStore, model agentreact.Model, tools agentreact.ToolExecutor) *agent.Runner {
+	t.Helper()
+
+	events := eventinginmem.New()
+	loop, err := agentreact.New(model, tools, events)
+	if err != nil {
+		t.Fatalf("new loop: %v", err)
+	}
+	runner, err := agent.NewRunner(agent.Dependencies{
+		IDGenerator: staticIDGenerator{runID: runID},
+		RunStore:    store,
+		Engine:      loop,
+		EventSink:   events,
+	})
+	if err != nil {
+		t.Fatalf("new runner: %v", err)
+	}
+	return runner
+}
+
+func noTools(t *testing.T) agentreact.ToolExecutor {
+	t.Helper()
+
+	registry, err := toolingregistry.New(nil)
+	if err != nil {
+		t.Fatalf("new registry: %v", err)
+	}
+	return registry
+}
+
+func delegateCall(task string) agent.Message {
+	return agent.Message{
+		Role: agent.RoleAssistant,
+		ToolCalls: []agent.ToolCall{
+			{ID: "call-1", Name: subagent.DefaultName, Arguments: map[string]any{"task": task}},
+		},
+	}
+}
+
+func TestExecutor_ReturnsChildOutput(t *testing.T) {
+	t.Parallel()
+
+	childStore := runstoreinmem.New()
+	childRunner := newRunner(t, "unused", childStore, &scriptedModel{
+		responses: []agent.Message{{Role: agent.RoleAssistant, Content: "child answer"}},
+	}, noTools(t))
+	delegate, err := subagent.New(subagent.Config{
+		Runner:       childRunner,
+		RunStore:     childStore,
+		SystemPrompt: "You are a researcher.",
+	})
+	if err != nil {
+		t.Fatalf("new executor: %v", err)
+	}
+
+	parentModel := &scriptedModel{
+		responses: []agent.Message{
+			delegateCall("research Go"),
+			{Role: agent.RoleAssistant, Content: "parent answer"},
+		},
+	}
+	parent := newRunner(t, "parent", runstoreinmem.New(), parentModel, delegate)
+	result, err := parent.Run(context.Background(), agent.RunInput{
+		UserPrompt: "delegate please",
+		MaxSteps:   4,
+		Tools:      []agent.ToolDefinition{delegate.Definition()},
+	})
+	if err != nil {
+		t.Fatalf("parent run: %v", err)
+	}
+	if result.State.Status != agent.RunStatusCompleted {
+		t.Fatalf("parent status mismatch: got=%s want=%s", result.State.Status, agent.RunStatusCompleted)
+	}
+
+	toolResult := findToolResult(t, result.State.Messages, "call-1")
+	if toolResult.Content != "child answer" {
+		t.Fatalf("tool result mismatch: got=%+v", toolResult)
+	}
+	child, err := childStore.Load(context.Background(), subagent.ChildRunID("parent", "call-1"))
+	if err != nil {
+		t.Fatalf("load child run: %v", err)
+	}
+	if child.Status != agent.RunStatusCompleted || child.Messages[0].Content != "You are a researcher." {
+		t.Fatalf("child run mismatch: status=%s messages=%+v", child.Status, child.Messages)
+	}
+}
+
+func TestExecutor_BubblesChildApprovalToParent(t *testing.T) {
+	t.Parallel()
+
+	childExecutions := 0
+	childTools, err := toolingregistry.New(map[string]toolingregistry.Handler{
+		"deploy": func(ctx context.Context, _ map[string]any) (string, error) {
+			if _, approved := agent.ApprovedToolCallReplayOverrideFromContext(ctx); !approved {
+				return "", &agent.SuspendRequestError{
+					Requirement: &agent.PendingRequirement{
+						ID:          "req-deploy",
+						Kind:        agent.RequirementKindApproval,
+						Origin:      agent.RequirementOriginTool,
+						ToolCallID:  "child-call-1",
+						Fingerprint: "fp-deploy",
+						Prompt:      "deploy to production",
+					},
+					Err: errors.New("deploy requires approval"),
+				}
+			}
+			childExecutions++
+			return "deployed", nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("new registry: %v", err)
+	}
+	childStore := runstoreinmem.New()
+	childRunner := newRunner(t, "unused", childStore, &scriptedModel{
+		responses: []agent.Message{
+			{
+				Role:      agent.RoleAssistant,
+				ToolCalls: []agent.ToolCall{{ID: "child-call-1", Name: "deploy", Arguments: map[string]any{}}},
+			},
+			{Role: agent.RoleAssistant, Content: "deployment finished"},
+		},
+	}, childTools)
+	delegate, err := subagent.New(subagent.Config{
+		Runner:   childRunner,
+		RunStore: childStore,
+		Tools:    []agent.ToolDefinition{{Name: "deploy"}},
+	})
+	if err != nil {
+		t.Fatalf("new executor: %v", err)
+	}
+
+	parentModel := &scriptedModel{
+		responses: []agent.Message{
+			delegateCall("deploy the service"),
+			{Role: agent.RoleAssistant, Content: "all done"},
+		},
+	}
+	parent := newRunner(t, "parent", runstoreinmem.New(), parentModel, delegate)
+	tools := []agent.ToolDefinition{delegate.Definition()}
+	suspended, err := parent.Run(context.Background(), agent.RunInput{UserPrompt: "ship it", MaxSteps: 4, Tools: tools})
+	if err != nil {
+		t.Fatalf("parent run: %v", err)
+	}
+	if suspended.State.Status != agent.RunStatusSuspended {
+		t.Fatalf("parent status mismatch: got=%s want=%s", suspended.State.Status, agent.RunStatusSuspended)
+	}
+	requirement := suspended.State.PendingRequirement
+	if requirement.ToolCallID != "call-1" || requirement.Kind != agent.RequirementKindApproval || requirement.Fingerprint == "" {
+		t.Fatalf("forwarded requirement mismatch: got=%+v", requirement)
+	}
+	if childExecutions != 0 {
+		t.Fatalf("child tool must not execute before approval: executions=%d", childExecutions)
+	}
+
+	resumed, err := parent.Continue(context.Background(), "parent", 4, tools, &agent.Resolution{
+		RequirementID: requirement.ID,
+		Kind:          agent.RequirementKindApproval,
+		Outcome:       agent.ResolutionOutcomeApproved,
+	})
+	if err != nil {
+		t.Fatalf("parent continue: %v", err)
+	}
+	if resumed.State.Status != agent.RunStatusCompleted || resumed.State.Output != "all done" {
+		t.Fatalf("resumed parent mismatch: status=%s output=%q", resumed.State.Status, resumed.State.Output)
+	}
+	if childExecutions != 1 {
+		t.Fatalf("child tool execution count mismatch: got=%d want=1", childExecutions)
+	}
+	toolResults := 0
+	for _, message := range resumed.State.Messages {
+		if message.Role == agent.RoleTool && message.ToolCallID == "call-1" {
+			toolResults++
+		}
+	}
+	if last := resumed.State.Messages[len(resumed.State.Messages)-2]; last.Content != "deployment finished" {
+		t.Fatalf("replayed tool result mismatch: got=%+v", last)
+	}
+	if toolResults != 2 {
+		t.Fatalf("expected suspended and replayed tool results, got %d", toolResults)
+	}
+}
+
+func TestExecutor_RejectsReplayFingerprintMismatch(t *testing.T) {
+	t.Parallel()
+
+	childStore := runstoreinmem.New()
+	if err := childStore.Save(context.Background(), agent.RunState{
+		ID:     subagent.ChildRunID("parent", "call-1"),
+		Status: agent.RunStatusSuspended,
+		PendingRequirement: &agent.PendingRequirement{
+			ID:     "req-child",
+			Kind:   agent.RequirementKindApproval,
+			Origin: agent.RequirementOriginModel,
+		},
+	}); err != nil {
+		t.Fatalf("seed child run: %v", err)
+	}
+	delegate, err := subagent.New(subagent.Config{
+		Runner:   newRunner(t, "unused", childStore, &scriptedModel{}, noTools(t)),
+		RunStore: childStore,
+	})
+	if err != nil {
+		t.Fatalf("new executor: %v", err)
+	}
+
+	ctx := agent.WithRunID(context.Background(), "parent")
+	ctx = agent.WithApprovedToolCallReplayOverride(ctx, agent.ApprovedToolCallReplayOverride{
+		ToolCallID:  "call-1",
+		Fingerprint: "stale",
+	})
+	_, err = delegate.Execute(ctx, agent.ToolCall{
+		ID:        "call-1",
+		Name:      subagent.DefaultName,
+		Arguments: map[string]any{"task": "anything"},
+	})
+	if !errors.Is(err, subagent.ErrReplayMismatch) {
+		t.Fatalf("expected ErrReplayMismatch, got %v", err)
+	}
+}
+
+func findToolResult(t *testing.T, messages []agent.Message, callID string) agent.Message {
+	t.Helper()
+
+	for _, message := range messages {
+		if message.Role == agent.RoleTool && message.ToolCallID == callID {
+			return message
+		}
+	}
+	t.Fatalf("tool result for %q not found", callID)
+	return agent.Message{}
+}